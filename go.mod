@@ -14,6 +14,7 @@ require (
 	github.com/evanw/esbuild v0.27.3
 	github.com/nyaruka/phonenumbers v1.6.11
 	github.com/rs/xid v1.6.0
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 )
 
 require (
@@ -0,0 +1,54 @@
+// Package ballast works out the ballast a driver must carry under their
+// class's BallastRules.
+package ballast
+
+import (
+	"math"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+)
+
+// Requirement is the ballast one driver must carry at the next event.
+type Requirement struct {
+	WeightKg         float64 `json:"weight_kg,omitempty"`
+	WeightBallastKg  float64 `json:"weight_ballast_kg"`
+	Wins             int     `json:"wins"`
+	Podiums          int     `json:"podiums"` // 2nd and 3rd places
+	SuccessBallastKg float64 `json:"success_ballast_kg"`
+	TotalKg          float64 `json:"total_kg"`
+}
+
+// Required works out a driver's ballast from their weight and their earlier
+// race results. Only classified finishes count towards success ballast;
+// disqualified, DNF and DNS results are ignored. Without a recorded weight no
+// weight ballast is given, since it can't be known.
+func Required(rules dynamo.BallastRules, weightKg float64, results []dynamo.Result) Requirement {
+	req := Requirement{WeightKg: weightKg}
+	if weightKg > 0 && rules.MinDriverWeightKg > weightKg {
+		req.WeightBallastKg = round(rules.MinDriverWeightKg - weightKg)
+	}
+
+	for _, r := range results {
+		if r.Disqualified || r.Status != "" {
+			continue
+		}
+		switch r.Position {
+		case 1:
+			req.Wins++
+		case 2, 3:
+			req.Podiums++
+		}
+	}
+	success := float64(req.Wins)*rules.WinBallastKg + float64(req.Podiums)*rules.PodiumBallastKg
+	if rules.MaxBallastKg > 0 {
+		success = min(success, rules.MaxBallastKg)
+	}
+	req.SuccessBallastKg = round(success)
+	req.TotalKg = round(req.WeightBallastKg + req.SuccessBallastKg)
+	return req
+}
+
+// round keeps results to grams so float noise doesn't show up in the UI.
+func round(kg float64) float64 {
+	return math.Round(kg*1000) / 1000
+}
@@ -0,0 +1,65 @@
+package ballast
+
+import (
+	"testing"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+	"github.com/BrianLeishman/karttrackpark.com/go/standings"
+)
+
+func TestRequired(t *testing.T) {
+	rules := dynamo.BallastRules{MinDriverWeightKg: 80, WinBallastKg: 5, PodiumBallastKg: 2.5, MaxBallastKg: 15}
+	results := []dynamo.Result{
+		{UID: "a", Position: 1},
+		{UID: "a", Position: 3},
+		{UID: "a", Position: 2, Disqualified: true},
+		{UID: "a", Position: 1, Status: "dnf"},
+		{UID: "a", Position: 4},
+	}
+
+	got := Required(rules, 72.4, results)
+	want := Requirement{WeightKg: 72.4, WeightBallastKg: 7.6, Wins: 1, Podiums: 1, SuccessBallastKg: 7.5, TotalKg: 15.1}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestRequiredCapsSuccessBallast(t *testing.T) {
+	rules := dynamo.BallastRules{WinBallastKg: 5, MaxBallastKg: 12}
+	results := []dynamo.Result{{Position: 1}, {Position: 1}, {Position: 1}}
+
+	got := Required(rules, 0, results)
+	if got.Wins != 3 || got.SuccessBallastKg != 12 || got.TotalKg != 12 {
+		t.Errorf("got %+v, want 3 wins capped at 12kg", got)
+	}
+}
+
+func TestRequiredUnknownWeight(t *testing.T) {
+	got := Required(dynamo.BallastRules{MinDriverWeightKg: 80}, 0, nil)
+	if got.WeightBallastKg != 0 || got.TotalKg != 0 {
+		t.Errorf("got %+v, want no ballast without a weight", got)
+	}
+}
+
+func TestRequiredFromScoringSessions(t *testing.T) {
+	// a wins both heats and the final; only the final is the round's result
+	sessions := []dynamo.EventSession{
+		{SessionID: "h1", SessionType: "heat"},
+		{SessionID: "h2", SessionType: "heat"},
+		{SessionID: "f", SessionType: "final"},
+	}
+	bySession := map[string][]dynamo.Result{
+		"h1": {{UID: "a", Position: 1}},
+		"h2": {{UID: "a", Position: 1}},
+		"f":  {{UID: "a", Position: 1}},
+	}
+
+	var results []dynamo.Result
+	for _, es := range standings.ScoringSessions(dynamo.ScoringConfig{}, sessions) {
+		results = append(results, bySession[es.SessionID]...)
+	}
+	got := Required(dynamo.BallastRules{WinBallastKg: 5}, 0, results)
+	if got.Wins != 1 || got.SuccessBallastKg != 5 {
+		t.Errorf("got %+v, want one win for the round", got)
+	}
+}
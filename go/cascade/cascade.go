@@ -0,0 +1,73 @@
+// Package cascade works out how a driver's series registration carries over
+// to their series enrollment and to the series' rounds.
+package cascade
+
+import (
+	"time"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+	"github.com/BrianLeishman/karttrackpark.com/go/pricing"
+)
+
+// Enrollment is the series driver a confirmed series registration enrolls.
+func Enrollment(reg dynamo.Registration) dynamo.SeriesDriver {
+	return dynamo.SeriesDriver{
+		SeriesID:        reg.ParentID,
+		UID:             reg.UID,
+		DriverName:      reg.DriverName,
+		TeamID:          reg.TeamID,
+		TeamName:        reg.TeamName,
+		ViaRegistration: true,
+	}
+}
+
+// KeepEnrollment reports whether a driver stays enrolled after withdrawing
+// their series registration: an admin enrolled them, or they have results
+// the standings still count.
+func KeepEnrollment(sd dynamo.SeriesDriver) bool {
+	return !sd.ViaRegistration || sd.TotalPoints != 0 || sd.WeeklyScores != "" || sd.Position != 0
+}
+
+// Upcoming returns the rounds that haven't started by now. A round without a
+// start time yet counts as upcoming.
+func Upcoming(rounds []dynamo.SeriesEvent, now time.Time) []dynamo.SeriesEvent {
+	var out []dynamo.SeriesEvent
+	for _, se := range rounds {
+		start, err := time.Parse(time.RFC3339, se.StartTime)
+		if se.StartTime != "" && err == nil && !start.After(now) {
+			continue
+		}
+		out = append(out, se)
+	}
+	return out
+}
+
+// RoundRegistration is the registration a confirmed series registration
+// gives its driver for one round. The series' entry fee covers the round, as
+// a season pass would.
+func RoundRegistration(reg dynamo.Registration, eventID string) dynamo.Registration {
+	return dynamo.Registration{
+		ParentType: "event",
+		ParentID:   eventID,
+		TrackID:    reg.TrackID,
+		UID:        reg.UID,
+		Email:      reg.Email,
+		DriverName: reg.DriverName,
+		ClassID:    reg.ClassID,
+		TeamID:     reg.TeamID,
+		TeamName:   reg.TeamName,
+		WeightKg:   reg.WeightKg,
+		KartNumber: reg.KartNumber,
+		Status:     "confirmed",
+		InvitedBy:  reg.InvitedBy,
+		ViaSeries:  reg.ParentID,
+		PriceRule:  pricing.RuleSeasonPass,
+	}
+}
+
+// Cascaded reports whether a round's registration came with the driver's
+// registration for seriesID, so withdrawing from the series withdraws it too.
+// Drivers who registered for the round themselves keep their spot.
+func Cascaded(reg *dynamo.Registration, seriesID string) bool {
+	return reg != nil && reg.ViaSeries == seriesID
+}
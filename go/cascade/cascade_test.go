@@ -0,0 +1,72 @@
+package cascade
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+)
+
+func TestKeepEnrollment(t *testing.T) {
+	tests := []struct {
+		name string
+		sd   dynamo.SeriesDriver
+		want bool
+	}{
+		{"enrolled by an admin", dynamo.SeriesDriver{}, true},
+		{"enrolled by registration, no results", dynamo.SeriesDriver{ViaRegistration: true}, false},
+		{"enrolled by registration, scored", dynamo.SeriesDriver{ViaRegistration: true, TotalPoints: 12, WeeklyScores: "12"}, true},
+		{"enrolled by registration, zero points but placed", dynamo.SeriesDriver{ViaRegistration: true, Position: 9}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := KeepEnrollment(tt.sd); got != tt.want {
+				t.Errorf("KeepEnrollment = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpcoming(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	rounds := []dynamo.SeriesEvent{
+		{EventID: "past", StartTime: "2026-05-01T18:00:00Z"},
+		{EventID: "started", StartTime: "2026-06-01T12:00:00Z"},
+		{EventID: "later", StartTime: "2026-06-01T12:30:00Z"},
+		{EventID: "unscheduled"},
+		{EventID: "offset", StartTime: "2026-06-01T08:00:00-05:00"}, // 13:00 UTC
+	}
+	var got []string
+	for _, se := range Upcoming(rounds, now) {
+		got = append(got, se.EventID)
+	}
+	if want := []string{"later", "unscheduled", "offset"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Upcoming = %v, want %v", got, want)
+	}
+}
+
+func TestRoundRegistration(t *testing.T) {
+	reg := dynamo.Registration{
+		ParentType: "series", ParentID: "s1", TrackID: "t1", UID: "u1", DriverName: "Ada",
+		ClassID: "c1", TeamID: "tm", TeamName: "Team", KartNumber: "7", Status: "confirmed",
+		Paid: true, PaymentID: "pay_1", Answers: map[string]string{"q": "a"},
+	}
+	got := RoundRegistration(reg, "e1")
+	want := dynamo.Registration{
+		ParentType: "event", ParentID: "e1", TrackID: "t1", UID: "u1", DriverName: "Ada",
+		ClassID: "c1", TeamID: "tm", TeamName: "Team", KartNumber: "7", Status: "confirmed",
+		ViaSeries: "s1", PriceRule: "season_pass",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RoundRegistration = %+v, want %+v", got, want)
+	}
+	if !Cascaded(&got, "s1") || Cascaded(&got, "s2") || Cascaded(&dynamo.Registration{}, "s1") || Cascaded(nil, "s1") {
+		t.Error("Cascaded should only match registrations made for that series")
+	}
+
+	sd := Enrollment(reg)
+	if sd.SeriesID != "s1" || sd.UID != "u1" || sd.DriverName != "Ada" || sd.TeamID != "tm" || !sd.ViaRegistration {
+		t.Errorf("Enrollment = %+v", sd)
+	}
+}
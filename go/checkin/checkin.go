@@ -0,0 +1,127 @@
+// Package checkin works out who is expected at an event and whether each
+// driver's credentials let them on track: entry fee paid, the track's
+// current waiver signed and old enough to drive.
+package checkin
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+	"github.com/BrianLeishman/karttrackpark.com/go/pricing"
+	"github.com/BrianLeishman/karttrackpark.com/go/waiver"
+)
+
+// Requirements are what a registration's parent asks of its drivers.
+type Requirements struct {
+	PriceCents int
+	MinAge     int
+}
+
+// Entrant is a driver expected at the event with the state of their
+// credentials and check-in.
+type Entrant struct {
+	UID        string `json:"uid"`
+	DriverName string `json:"driver_name"`
+	ParentType string `json:"parent_type"` // the event itself, or a series it's part of
+	ParentID   string `json:"parent_id"`
+	ClassID    string `json:"class_id,omitempty"`
+
+	Paid         bool     `json:"paid"`
+	WaiverSigned bool     `json:"waiver_signed"`
+	Age          *int     `json:"age,omitempty"` // from the waiver's date of birth
+	Problems     []string `json:"problems,omitempty"`
+
+	ArrivedAt   string `json:"arrived_at,omitempty"`
+	Transponder string `json:"transponder,omitempty"`
+	KartNumber  string `json:"kart_number,omitempty"`
+}
+
+// Expected returns the confirmed registrations for an event: its own and
+// those of the series it belongs to, one per driver. A driver registered for
+// both is listed under the event, unless their event registration came with
+// the series one: then it's listed under the series, whose fee covers it.
+func Expected(eventRegs []dynamo.Registration, seriesRegs ...[]dynamo.Registration) []dynamo.Registration {
+	inSeries := map[string]bool{} // by series ID and UID
+	for _, list := range seriesRegs {
+		for _, reg := range list {
+			if reg.Status == "confirmed" {
+				inSeries[reg.ParentID+"#"+reg.UID] = true
+			}
+		}
+	}
+
+	seen := map[string]bool{}
+	var out []dynamo.Registration
+	for _, list := range append([][]dynamo.Registration{eventRegs}, seriesRegs...) {
+		for _, reg := range list {
+			if reg.Status != "confirmed" || seen[reg.UID] {
+				continue
+			}
+			if reg.ViaSeries != "" && inSeries[reg.ViaSeries+"#"+reg.UID] {
+				continue
+			}
+			seen[reg.UID] = true
+			out = append(out, reg)
+		}
+	}
+	return out
+}
+
+// Verify checks one driver's credentials. waiverVersion is the track's
+// current waiver, 0 when it has none; sig is the driver's signature of it.
+// The driver's age comes from the signature's date of birth, so a minimum
+// age can't be checked for a driver who hasn't given one.
+func Verify(reg dynamo.Registration, req Requirements, waiverVersion int, sig *dynamo.WaiverSignature, now time.Time) Entrant {
+	e := Entrant{
+		UID:        reg.UID,
+		DriverName: reg.DriverName,
+		ParentType: reg.ParentType,
+		ParentID:   reg.ParentID,
+		ClassID:    reg.ClassID,
+		KartNumber: reg.KartNumber, // assigned ahead of the day, e.g. by an import
+		Paid:       pricing.Due(req.PriceCents, reg) == 0 || reg.Paid,
+	}
+	if !e.Paid {
+		e.Problems = append(e.Problems, "entry fee not paid")
+	}
+
+	if sig != nil && sig.Version != waiverVersion {
+		sig = nil
+	}
+	e.WaiverSigned = waiverVersion == 0 || sig != nil
+	if !e.WaiverSigned {
+		e.Problems = append(e.Problems, "waiver not signed")
+	}
+
+	if sig != nil && sig.DateOfBirth != "" {
+		if age, err := waiver.Age(sig.DateOfBirth, now); err == nil {
+			e.Age = &age
+		}
+	}
+	if req.MinAge > 0 {
+		switch {
+		case e.Age == nil:
+			e.Problems = append(e.Problems, fmt.Sprintf("age unknown, must be at least %d", req.MinAge))
+		case *e.Age < req.MinAge:
+			e.Problems = append(e.Problems, fmt.Sprintf("under the minimum age of %d", req.MinAge))
+		}
+	}
+	return e
+}
+
+// Sort orders entrants for the gate: drivers still to arrive first, then
+// by name.
+func Sort(entrants []Entrant) {
+	sort.SliceStable(entrants, func(i, j int) bool {
+		a, b := entrants[i], entrants[j]
+		if (a.ArrivedAt == "") != (b.ArrivedAt == "") {
+			return a.ArrivedAt == ""
+		}
+		if a.DriverName != b.DriverName {
+			return a.DriverName < b.DriverName
+		}
+		return a.UID < b.UID
+	})
+}
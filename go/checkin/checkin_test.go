@@ -0,0 +1,106 @@
+package checkin
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+)
+
+var now = time.Date(2026, 5, 2, 9, 0, 0, 0, time.UTC)
+
+func TestExpected(t *testing.T) {
+	event := []dynamo.Registration{
+		{UID: "a", ParentType: "event", Status: "confirmed"},
+		{UID: "b", ParentType: "event", Status: "pending"},
+	}
+	series := []dynamo.Registration{
+		{UID: "a", ParentType: "series", Status: "confirmed"},
+		{UID: "c", ParentType: "series", Status: "confirmed"},
+		{UID: "d", ParentType: "series", Status: "waitlisted"},
+	}
+
+	got := Expected(event, series)
+	var uids, types []string
+	for _, reg := range got {
+		uids = append(uids, reg.UID)
+		types = append(types, reg.ParentType)
+	}
+	if !reflect.DeepEqual(uids, []string{"a", "c"}) || !reflect.DeepEqual(types, []string{"event", "series"}) {
+		t.Errorf("Expected = %v %v, want [a c] [event series]", uids, types)
+	}
+
+	// A round registration made by the series registration defers to it,
+	// unless the series registration is gone
+	event = []dynamo.Registration{
+		{UID: "a", ParentType: "event", Status: "confirmed", ViaSeries: "s1"},
+		{UID: "b", ParentType: "event", Status: "confirmed", ViaSeries: "s1"},
+	}
+	series = []dynamo.Registration{
+		{UID: "a", ParentType: "series", ParentID: "s1", Status: "confirmed", Paid: true},
+	}
+	got = Expected(event, series)
+	if len(got) != 2 || got[0].UID != "b" || got[0].ParentType != "event" || got[1].UID != "a" || !got[1].Paid {
+		t.Errorf("Expected = %+v, want b under the event, then a under the series", got)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	reg := dynamo.Registration{UID: "u1", DriverName: "Ada", ParentType: "event", ParentID: "e1"}
+	adult := &dynamo.WaiverSignature{UID: "u1", Version: 2, DateOfBirth: "1990-05-02"}
+	teen := &dynamo.WaiverSignature{UID: "u1", Version: 2, DateOfBirth: "2012-05-03"} // turns 14 tomorrow
+
+	tests := []struct {
+		name     string
+		reg      dynamo.Registration
+		req      Requirements
+		version  int
+		sig      *dynamo.WaiverSignature
+		problems []string
+	}{
+		{"free, no waiver, no age limit", reg, Requirements{}, 0, nil, nil},
+		{"unpaid", reg, Requirements{PriceCents: 5000}, 0, nil, []string{"entry fee not paid"}},
+		{"paid", dynamo.Registration{UID: "u1", Paid: true}, Requirements{PriceCents: 5000}, 0, nil, nil},
+		{"free with a season pass", dynamo.Registration{UID: "u1", PriceRule: "season_pass"}, Requirements{PriceCents: 5000}, 0, nil, nil},
+		{"discounted, unpaid", dynamo.Registration{UID: "u1", PriceCents: 1000, PriceRule: "member"}, Requirements{PriceCents: 5000}, 0, nil, []string{"entry fee not paid"}},
+		{"unsigned", reg, Requirements{}, 2, nil, []string{"waiver not signed"}},
+		{"signed an old version", reg, Requirements{}, 3, adult, []string{"waiver not signed"}},
+		{"signed adult over the limit", reg, Requirements{MinAge: 16}, 2, adult, nil},
+		{"one day too young", reg, Requirements{MinAge: 14}, 2, teen, []string{"under the minimum age of 14"}},
+		{"age unknown", reg, Requirements{MinAge: 14}, 0, nil, []string{"age unknown, must be at least 14"}},
+		{"everything wrong", reg, Requirements{PriceCents: 100, MinAge: 16}, 1, nil,
+			[]string{"entry fee not paid", "waiver not signed", "age unknown, must be at least 16"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := Verify(tt.reg, tt.req, tt.version, tt.sig, now)
+			if !reflect.DeepEqual(e.Problems, tt.problems) {
+				t.Errorf("problems = %q, want %q", e.Problems, tt.problems)
+			}
+		})
+	}
+
+	reg.KartNumber = "42"
+	e := Verify(reg, Requirements{}, 2, adult, now)
+	if e.Age == nil || *e.Age != 36 || !e.WaiverSigned || !e.Paid || e.KartNumber != "42" {
+		t.Errorf("entrant = %+v, want a paid, signed 36-year-old in kart 42", e)
+	}
+}
+
+func TestSort(t *testing.T) {
+	entrants := []Entrant{
+		{UID: "1", DriverName: "Cy", ArrivedAt: "2026-05-02T08:00:00Z"},
+		{UID: "2", DriverName: "Bo"},
+		{UID: "3", DriverName: "Al", ArrivedAt: "2026-05-02T08:05:00Z"},
+		{UID: "4", DriverName: "Di"},
+	}
+	Sort(entrants)
+	var got []string
+	for _, e := range entrants {
+		got = append(got, e.UID)
+	}
+	if want := []string{"2", "4", "3", "1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,22 @@
+// Command backfill-series-events adds the event-to-series index keys to
+// series event links created before they were written, so the series an
+// event belongs to can be looked up from the event. It only touches links
+// missing the keys, so it is safe to run more than once.
+//
+// Usage: go run ./go/cmd/backfill-series-events
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+)
+
+func main() {
+	n, err := dynamo.BackfillSeriesEventKeys(context.Background())
+	if err != nil {
+		log.Fatalf("backfill series events: %v (%d updated before the error)", err, n)
+	}
+	log.Printf("backfilled %d series event links", n)
+}
@@ -0,0 +1,328 @@
+// Command timing-bridge reads passings from an AMB/MyLaps decoder speaking the
+// P3 protocol and writes them to a session as laps in real time.
+//
+// Live:   go run ./go/cmd/timing-bridge -session <id> -addr 10.0.0.50:5403
+// Replay: go run ./go/cmd/timing-bridge -session <id> -replay capture.p3 -speed 10
+// Dry:    go run ./go/cmd/timing-bridge -replay capture.p3 -speed 0 -dry-run
+//
+// Transponders resolve to drivers through the session's transponder mappings,
+// falling back to a rental kart's transponder and the session kart assignment.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+	"github.com/BrianLeishman/karttrackpark.com/go/p3"
+	"github.com/BrianLeishman/karttrackpark.com/go/timing"
+)
+
+// reloadInterval limits how often an unknown transponder triggers a reload of
+// the session's mappings.
+const reloadInterval = 10 * time.Second
+
+func main() {
+	sessionID := flag.String("session", "", "session ID to write laps to")
+	addr := flag.String("addr", "", "decoder address (host:port, usually port 5403)")
+	replay := flag.String("replay", "", "read passings from a decoder capture file instead of TCP")
+	speed := flag.Float64("speed", 1, "replay speed multiplier (0 = as fast as possible)")
+	record := flag.String("record", "", "append the raw decoder stream to this file (live mode)")
+	minLap := flag.Duration("min-lap", timing.DefaultMinLap, "ignore crossings closer together than this")
+	maxLap := flag.Duration("max-lap", 5*time.Minute, "restart timing after a gap longer than this (0 = never)")
+	dryRun := flag.Bool("dry-run", false, "log laps without touching the database")
+	flag.Parse()
+
+	if *addr == "" && *replay == "" {
+		log.Fatal("one of -addr or -replay is required")
+	}
+	if *sessionID == "" && !*dryRun {
+		log.Fatal("-session is required unless -dry-run is set")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	b := &bridge{
+		sessionID: *sessionID,
+		dryRun:    *dryRun,
+		tracker:   timing.NewTracker(*minLap),
+	}
+	b.tracker.MaxLap = *maxLap
+	if !b.dryRun {
+		if err := b.load(ctx); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *replay != "" {
+		f, err := os.Open(*replay)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		log.Printf("Replaying %s at %gx", *replay, *speed)
+		if err := b.run(ctx, f, *speed); err != nil && !errors.Is(err, io.EOF) {
+			log.Fatal(err)
+		}
+		log.Printf("Replay finished: %d passings, %d laps", b.passings, b.laps)
+		return
+	}
+
+	for ctx.Err() == nil {
+		if err := b.dial(ctx, *addr, *record); err != nil {
+			log.Printf("Decoder connection error: %v; reconnecting", err)
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+func (b *bridge) dial(ctx context.Context, addr, record string) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+	log.Printf("Connected to decoder at %s", addr)
+
+	var src io.Reader = conn
+	if record != "" {
+		f, err := os.OpenFile(record, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		src = io.TeeReader(conn, f)
+	}
+	return b.run(ctx, src, 0)
+}
+
+type driver struct {
+	uid    string
+	name   string
+	kartID string
+}
+
+type bridge struct {
+	sessionID string
+	dryRun    bool
+	tracker   *timing.Tracker
+
+	session    *dynamo.Session
+	kartClass  string
+	drivers    map[string]driver // transponder -> driver
+	loadedAt   time.Time
+	lapCount   int
+	bestLapMs  int64
+	passings   int
+	laps       int
+	lastReplay time.Time
+}
+
+// load reads the session, its transponder mappings and any laps already
+// recorded so a restarted bridge continues numbering where it left off.
+func (b *bridge) load(ctx context.Context) error {
+	session, err := dynamo.GetSession(ctx, b.sessionID)
+	if err != nil {
+		return err
+	}
+	if session == nil {
+		return errors.New("session not found")
+	}
+	b.session = session
+	if len(session.ClassIDs) == 1 {
+		b.kartClass = session.ClassIDs[0]
+	}
+
+	if err := b.loadDrivers(ctx); err != nil {
+		return err
+	}
+
+	laps, err := dynamo.ListLapsForSession(ctx, b.sessionID)
+	if err != nil {
+		return err
+	}
+	b.lapCount = len(laps)
+	lastNo := map[string]int{}
+	lastAt := map[string]int64{}
+	for _, l := range laps {
+		if b.bestLapMs == 0 || l.LapTimeMs < b.bestLapMs {
+			b.bestLapMs = l.LapTimeMs
+		}
+		lastNo[l.UID] = max(lastNo[l.UID], l.LapNo)
+		lastAt[l.UID] = max(lastAt[l.UID], l.CrossedAtMs)
+	}
+	for uid, n := range lastNo {
+		var at time.Time
+		if lastAt[uid] > 0 {
+			at = time.UnixMilli(lastAt[uid]).UTC()
+		}
+		b.tracker.Resume(uid, n, at)
+	}
+
+	log.Printf("Session %s: %d transponders mapped, %d existing laps", b.sessionID, len(b.drivers), b.lapCount)
+	return nil
+}
+
+func (b *bridge) loadDrivers(ctx context.Context) error {
+	drivers := map[string]driver{}
+
+	// Rental karts: kart transponder -> whoever is assigned that kart
+	assignments, err := dynamo.ListSessionKarts(ctx, b.sessionID)
+	if err != nil {
+		return err
+	}
+	if len(assignments) > 0 {
+		karts, err := dynamo.ListKartsForTrack(ctx, b.session.TrackID)
+		if err != nil {
+			return err
+		}
+		byKart := map[string]dynamo.SessionKart{}
+		for _, a := range assignments {
+			byKart[a.KartID] = a
+		}
+		for _, k := range karts {
+			if a, ok := byKart[k.KartID]; ok && k.Transponder != "" {
+				drivers[k.Transponder] = driver{uid: a.UID, name: a.DriverName, kartID: k.KartID}
+			}
+		}
+	}
+
+	// Explicit mappings win over kart transponders
+	mappings, err := dynamo.ListSessionTransponders(ctx, b.sessionID)
+	if err != nil {
+		return err
+	}
+	for _, m := range mappings {
+		drivers[m.Transponder] = driver{uid: m.UID, name: m.DriverName, kartID: m.KartID}
+	}
+
+	b.drivers = drivers
+	b.loadedAt = time.Now()
+	return nil
+}
+
+func (b *bridge) resolve(ctx context.Context, transponder string) (driver, bool) {
+	if b.dryRun {
+		return driver{uid: "transponder:" + transponder, name: "#" + transponder}, true
+	}
+	if d, ok := b.drivers[transponder]; ok {
+		return d, true
+	}
+	if time.Since(b.loadedAt) < reloadInterval {
+		return driver{}, false
+	}
+	if err := b.loadDrivers(ctx); err != nil {
+		log.Printf("Reload transponders error: %v", err)
+		return driver{}, false
+	}
+	d, ok := b.drivers[transponder]
+	return d, ok
+}
+
+// run reads records until the stream ends. When speed > 0, passings are paced
+// by their decoder timestamps (replay mode).
+func (b *bridge) run(ctx context.Context, src io.Reader, speed float64) error {
+	r := p3.NewReader(src)
+	for ctx.Err() == nil {
+		rec, err := r.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed) {
+				return io.EOF
+			}
+			var netErr net.Error
+			if errors.As(err, &netErr) {
+				return err
+			}
+			log.Printf("  Skipping record: %v", err)
+			continue
+		}
+
+		p, ok := rec.Passing()
+		if !ok {
+			continue
+		}
+		if speed > 0 {
+			b.pace(ctx, p.Time, speed)
+		}
+		b.passing(ctx, p)
+	}
+	return ctx.Err()
+}
+
+func (b *bridge) pace(ctx context.Context, at time.Time, speed float64) {
+	if !b.lastReplay.IsZero() && at.After(b.lastReplay) {
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Duration(float64(at.Sub(b.lastReplay)) / speed)):
+		}
+	}
+	b.lastReplay = at
+}
+
+func (b *bridge) passing(ctx context.Context, p p3.Passing) {
+	b.passings++
+	transponder := strconv.FormatUint(uint64(p.Transponder), 10)
+
+	d, ok := b.resolve(ctx, transponder)
+	if !ok {
+		log.Printf("  Passing from unmapped transponder %s", transponder)
+		return
+	}
+
+	lap, ok := b.tracker.Pass(d.uid, p.Time)
+	if !ok {
+		return
+	}
+	b.laps++
+	log.Printf("  %s lap %d: %s", d.name, lap.LapNo, time.Duration(lap.LapTimeMs)*time.Millisecond)
+	if b.dryRun {
+		return
+	}
+
+	if err := dynamo.PutLap(ctx, dynamo.Lap{
+		SessionID: b.sessionID,
+		LapNo:     lap.LapNo,
+		LapTimeMs: lap.LapTimeMs,
+		UID:       d.uid,
+		LayoutID:  b.session.LayoutID,
+		KartClass: b.kartClass,
+		KartID:    d.kartID,
+		// Decoder timing is the track's official timing
+		Verified:    true,
+		CrossedAtMs: lap.CrossedAt.UnixMilli(),
+		CreatedAt:   lap.CrossedAt.Format(time.RFC3339),
+	}); err != nil {
+		log.Printf("  Put lap error: %v", err)
+		return
+	}
+
+	b.lapCount++
+	fields := map[string]any{"lapCount": b.lapCount}
+	if b.bestLapMs == 0 || lap.LapTimeMs < b.bestLapMs {
+		b.bestLapMs = lap.LapTimeMs
+		fields["bestLapMs"] = lap.LapTimeMs
+		if d.name != "" {
+			fields["bestLapDriverName"] = d.name
+		}
+	}
+	if err := dynamo.UpdateSession(ctx, b.sessionID, fields); err != nil {
+		log.Printf("  Update session error: %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+// Package divisions plans promotion and relegation between the tiered series
+// of a championship.
+package divisions
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+)
+
+// Driver is a driver's standing in a division.
+type Driver struct {
+	UID       string
+	Name      string
+	Position  int  // 0 if unclassified
+	Protected bool // relegation protected
+	Seeded    bool // placed in the division by the organizers
+	// Settling is set for a driver who moved into the division and hasn't
+	// raced a round of it since, so has no standing here yet.
+	Settling bool
+}
+
+// Division is one tier of the championship.
+type Division struct {
+	SeriesID  string
+	Name      string
+	Tier      int // 1 is the top division
+	LastRound int // highest round number scored so far
+	Drivers   []Driver
+}
+
+// Plan is the outcome of a promotion/relegation pass.
+type Plan struct {
+	Moves []dynamo.DivisionMove
+	// Protected lists drivers who would have moved but keep their place:
+	// relegation protected drivers in the drop zone and seeded drivers at
+	// either end of the table.
+	Protected []dynamo.DivisionMove
+	// LastRounds is each division's LastRound, by series ID.
+	LastRounds map[string]int
+}
+
+// RacedSince reports whether any division has scored a round past the last
+// rounds recorded by a previous run. A run with none recorded counts as
+// raced since.
+func RacedSince(divs []Division, lastRounds map[string]int) bool {
+	if lastRounds == nil {
+		return true
+	}
+	for _, d := range divs {
+		if d.LastRound > lastRounds[d.SeriesID] {
+			return true
+		}
+	}
+	return false
+}
+
+// Build moves the top promote classified drivers of each division up one
+// tier and the bottom relegate drivers down one tier. Unclassified drivers
+// count as the bottom of the table and are never promoted. Relegation
+// protected drivers are passed over and the next driver up goes down in
+// their place; seeded drivers are passed over both ways. Settling drivers
+// are left where they are and out of the count.
+func Build(divs []Division, promote, relegate int) (Plan, error) {
+	if promote < 0 || relegate < 0 || promote+relegate == 0 {
+		return Plan{}, fmt.Errorf("promote and relegate must not be negative, and at least one must be set")
+	}
+
+	tiers := append([]Division(nil), divs...)
+	sort.SliceStable(tiers, func(i, j int) bool { return tiers[i].Tier < tiers[j].Tier })
+	if len(tiers) < 2 {
+		return Plan{}, fmt.Errorf("need at least two tiered series")
+	}
+	for i := 1; i < len(tiers); i++ {
+		if tiers[i].Tier == tiers[i-1].Tier {
+			return Plan{}, fmt.Errorf("series %q and %q share tier %d", tiers[i-1].Name, tiers[i].Name, tiers[i].Tier)
+		}
+	}
+
+	plan := Plan{LastRounds: map[string]int{}}
+	for i, div := range tiers {
+		plan.LastRounds[div.SeriesID] = div.LastRound
+		table := standingsOrder(div.Drivers)
+		moving := map[string]bool{}
+
+		if i > 0 {
+			up := tiers[i-1]
+			for _, d := range table {
+				if len(moving) == promote || d.Position == 0 {
+					break
+				}
+				if d.Seeded {
+					plan.Protected = append(plan.Protected, move(d, dynamo.MovePromoted, div, up))
+					continue
+				}
+				moving[d.UID] = true
+				plan.Moves = append(plan.Moves, move(d, dynamo.MovePromoted, div, up))
+			}
+		}
+
+		if i < len(tiers)-1 {
+			down := tiers[i+1]
+			n := 0
+			for j := len(table) - 1; j >= 0 && n < relegate; j-- {
+				d := table[j]
+				if moving[d.UID] {
+					break
+				}
+				if d.Protected || d.Seeded {
+					plan.Protected = append(plan.Protected, move(d, dynamo.MoveRelegated, div, down))
+					continue
+				}
+				n++
+				plan.Moves = append(plan.Moves, move(d, dynamo.MoveRelegated, div, down))
+			}
+		}
+	}
+	return plan, nil
+}
+
+// standingsOrder sorts classified drivers by position, then unclassified
+// drivers by name, leaving out settling drivers.
+func standingsOrder(drivers []Driver) []Driver {
+	var out []Driver
+	for _, d := range drivers {
+		if !d.Settling {
+			out = append(out, d)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if (a.Position > 0) != (b.Position > 0) {
+			return a.Position > 0
+		}
+		if a.Position != b.Position {
+			return a.Position < b.Position
+		}
+		return a.Name < b.Name
+	})
+	return out
+}
+
+func move(d Driver, direction string, from, to Division) dynamo.DivisionMove {
+	return dynamo.DivisionMove{
+		UID:            d.UID,
+		DriverName:     d.Name,
+		Direction:      direction,
+		Position:       d.Position,
+		FromSeriesID:   from.SeriesID,
+		FromSeriesName: from.Name,
+		ToSeriesID:     to.SeriesID,
+		ToSeriesName:   to.Name,
+	}
+}
@@ -0,0 +1,116 @@
+package divisions
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+)
+
+func table(spec string) []Driver {
+	out := make([]Driver, len(spec))
+	for i, c := range spec {
+		out[i] = Driver{UID: string(c), Name: strings.ToUpper(string(c)), Position: i + 1}
+	}
+	return out
+}
+
+func summary(moves []dynamo.DivisionMove) string {
+	parts := make([]string, len(moves))
+	for i, m := range moves {
+		parts[i] = m.UID + ":" + m.FromSeriesID + ">" + m.ToSeriesID
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestBuild(t *testing.T) {
+	pro := Division{SeriesID: "pro", Tier: 1, Drivers: table("abcde")}
+	am := Division{SeriesID: "am", Tier: 2, Drivers: table("fghij")}
+	rookie := Division{SeriesID: "rookie", Tier: 3, Drivers: table("klm")}
+
+	// e is protected, so d goes down instead; m never scored
+	pro.Drivers[4].Protected = true
+	rookie.Drivers[2].Position = 0
+
+	plan, err := Build([]Division{rookie, pro, am}, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "d:pro>am f:am>pro g:am>pro j:am>rookie k:rookie>am l:rookie>am"
+	if got := summary(plan.Moves); got != want {
+		t.Errorf("moves = %s\nwant    %s", got, want)
+	}
+	if got := summary(plan.Protected); got != "e:pro>am" {
+		t.Errorf("protected = %s", got)
+	}
+	if plan.Moves[1].Direction != dynamo.MovePromoted || plan.Moves[0].Position != 4 {
+		t.Errorf("move detail = %+v", plan.Moves[:2])
+	}
+}
+
+func TestBuildSmallDivision(t *testing.T) {
+	// Two drivers can't both go up two and down two
+	pro := Division{SeriesID: "pro", Tier: 1, Drivers: table("abc")}
+	am := Division{SeriesID: "am", Tier: 2, Drivers: table("de")}
+	plan, err := Build([]Division{pro, am}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := summary(plan.Moves); got != "c:pro>am b:pro>am d:am>pro e:am>pro" {
+		t.Errorf("moves = %s", got)
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	one := []Division{{SeriesID: "pro", Tier: 1}}
+	if _, err := Build(one, 1, 1); err == nil {
+		t.Error("expected error for a single division")
+	}
+	same := []Division{{SeriesID: "a", Tier: 1}, {SeriesID: "b", Tier: 1}}
+	if _, err := Build(same, 1, 1); err == nil {
+		t.Error("expected error for shared tier")
+	}
+	two := []Division{{SeriesID: "a", Tier: 1}, {SeriesID: "b", Tier: 2}}
+	if _, err := Build(two, 0, 0); err == nil {
+		t.Error("expected error when nothing moves")
+	}
+}
+
+func TestBuildSeededAndSettling(t *testing.T) {
+	pro := Division{SeriesID: "pro", Tier: 1, LastRound: 4, Drivers: table("abcd")}
+	am := Division{SeriesID: "am", Tier: 2, LastRound: 3, Drivers: table("efgh")}
+	rookie := Division{SeriesID: "rookie", Tier: 3, Drivers: table("ij")}
+
+	// h just came down from pro and hasn't raced am yet, so g goes down
+	// instead; seeded drivers stay put at either end of the table
+	am.Drivers[3].Settling = true
+	am.Drivers[0].Seeded = true
+	pro.Drivers[3].Seeded = true
+
+	plan, err := Build([]Division{pro, am, rookie}, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := summary(plan.Moves); got != "c:pro>am f:am>pro g:am>rookie i:rookie>am" {
+		t.Errorf("moves = %s", got)
+	}
+	if got := summary(plan.Protected); got != "d:pro>am e:am>pro" {
+		t.Errorf("protected = %s", got)
+	}
+	if plan.LastRounds["pro"] != 4 || plan.LastRounds["am"] != 3 {
+		t.Errorf("last rounds = %v", plan.LastRounds)
+	}
+}
+
+func TestRacedSince(t *testing.T) {
+	divs := []Division{{SeriesID: "pro", LastRound: 4}, {SeriesID: "am", LastRound: 3}}
+	if RacedSince(divs, map[string]int{"pro": 4, "am": 3}) {
+		t.Error("no new rounds, want not raced since")
+	}
+	if !RacedSince(divs, map[string]int{"pro": 4, "am": 2}) {
+		t.Error("am scored round 3 since, want raced since")
+	}
+	if !RacedSince(divs, nil) {
+		t.Error("a run with no rounds recorded should not block")
+	}
+}
@@ -20,9 +20,26 @@ type Championship struct {
 	Name           string `dynamodbav:"name" json:"name"`
 	Description    string `dynamodbav:"description,omitempty" json:"description,omitempty"`
 	LogoKey        string `dynamodbav:"logoKey,omitempty" json:"logo_key,omitempty"`
-	GSI1PK         string `dynamodbav:"gsi1pk,omitempty" json:"-"`
-	GSI1SK         string `dynamodbav:"gsi1sk,omitempty" json:"-"`
-	CreatedAt      string `dynamodbav:"createdAt" json:"created_at"`
+
+	ChampionshipScoring `dynamodbav:",omitempty"`
+
+	GSI1PK    string `dynamodbav:"gsi1pk,omitempty" json:"-"`
+	GSI1SK    string `dynamodbav:"gsi1sk,omitempty" json:"-"`
+	CreatedAt string `dynamodbav:"createdAt" json:"created_at"`
+}
+
+// ChampionshipScoring configures the championship-wide tables built from its
+// series standings.
+type ChampionshipScoring struct {
+	// ChampMethod is "sum" to add up series points totals, or "position" to
+	// award ChampPointsScheme by finishing position in each series. Timed
+	// series always score by position.
+	ChampMethod       string `dynamodbav:"champMethod,omitempty" json:"champ_method,omitempty"`
+	ChampPointsScheme []int  `dynamodbav:"champPointsScheme,omitempty" json:"champ_points_scheme,omitempty"`
+	// TeamCountBest and ChassisCountBest limit how many drivers score for a
+	// team or chassis in each series (0 counts everyone).
+	TeamCountBest    int `dynamodbav:"teamCountBest,omitempty" json:"team_count_best,omitempty"`
+	ChassisCountBest int `dynamodbav:"chassisCountBest,omitempty" json:"chassis_count_best,omitempty"`
 }
 
 func CreateChampionship(ctx context.Context, c Championship) (*Championship, error) {
@@ -0,0 +1,164 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// CheckIn is a driver's day-of-event record, stored under EVENT#eid /
+// CHECKIN#uid. It's made the first time the driver's QR code is asked for;
+// ArrivedAt is set when staff check them in at the track.
+type CheckIn struct {
+	PK         string `dynamodbav:"pk" json:"-"`
+	SK         string `dynamodbav:"sk" json:"-"`
+	EventID    string `dynamodbav:"eventId" json:"event_id"`
+	TrackID    string `dynamodbav:"trackId" json:"track_id"`
+	UID        string `dynamodbav:"uid" json:"uid"`
+	DriverName string `dynamodbav:"driverName,omitempty" json:"driver_name,omitempty"`
+	// Code is the secret in the driver's QR code, so a scan proves they hold
+	// the pass rather than just knowing their user ID.
+	Code        string `dynamodbav:"code" json:"-"`
+	ArrivedAt   string `dynamodbav:"arrivedAt,omitempty" json:"arrived_at,omitempty"`
+	CheckedInBy string `dynamodbav:"checkedInBy,omitempty" json:"checked_in_by,omitempty"`
+	// Overridden lists the credential problems staff waved through.
+	Overridden  []string `dynamodbav:"overridden,omitempty" json:"overridden,omitempty"`
+	Transponder string   `dynamodbav:"transponder,omitempty" json:"transponder,omitempty"`
+	KartNumber  string   `dynamodbav:"kartNumber,omitempty" json:"kart_number,omitempty"`
+	CreatedAt   string   `dynamodbav:"createdAt" json:"created_at"`
+}
+
+// GetCheckIn returns a driver's check-in record for an event, or nil if it
+// doesn't exist.
+func GetCheckIn(ctx context.Context, eventID, uid string) (*CheckIn, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(TableName),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: EventPK(eventID)},
+			"sk": &types.AttributeValueMemberS{Value: CheckInSK(uid)},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get check-in: %w", err)
+	}
+	if out.Item == nil {
+		return nil, nil
+	}
+
+	var ci CheckIn
+	if err := attributevalue.UnmarshalMap(out.Item, &ci); err != nil {
+		return nil, fmt.Errorf("unmarshal check-in: %w", err)
+	}
+	return &ci, nil
+}
+
+// EnsureCheckIn returns the driver's check-in record for an event, creating
+// it with a fresh code the first time. The code never changes afterwards, so
+// a printed pass stays valid.
+func EnsureCheckIn(ctx context.Context, ci CheckIn) (*CheckIn, error) {
+	existing, err := GetCheckIn(ctx, ci.EventID, ci.UID)
+	if err != nil || existing != nil {
+		return existing, err
+	}
+
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	ci.Code, err = randomHex(12)
+	if err != nil {
+		return nil, fmt.Errorf("generate check-in code: %w", err)
+	}
+	ci.PK = EventPK(ci.EventID)
+	ci.SK = CheckInSK(ci.UID)
+	ci.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+
+	item, err := attributevalue.MarshalMap(ci)
+	if err != nil {
+		return nil, fmt.Errorf("marshal check-in: %w", err)
+	}
+
+	_, err = c.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(TableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(pk)"),
+	})
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		// Made by a concurrent request; use theirs
+		return GetCheckIn(ctx, ci.EventID, ci.UID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("put check-in: %w", err)
+	}
+	return &ci, nil
+}
+
+func UpdateCheckIn(ctx context.Context, eventID, uid string, fields map[string]any) error {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	c, err := client()
+	if err != nil {
+		return err
+	}
+
+	expr, names, values, err := BuildUpdateExpression(fields)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(TableName),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: EventPK(eventID)},
+			"sk": &types.AttributeValueMemberS{Value: CheckInSK(uid)},
+		},
+		UpdateExpression:          aws.String(expr),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+	if err != nil {
+		return fmt.Errorf("update check-in: %w", err)
+	}
+	return nil
+}
+
+// ListCheckIns returns every check-in record for an event.
+func ListCheckIns(ctx context.Context, eventID string) ([]CheckIn, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(TableName),
+		KeyConditionExpression: aws.String("pk = :pk AND begins_with(sk, :prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":     &types.AttributeValueMemberS{Value: EventPK(eventID)},
+			":prefix": &types.AttributeValueMemberS{Value: "CHECKIN#"},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list check-ins: %w", err)
+	}
+
+	var list []CheckIn
+	if err := attributevalue.UnmarshalListOfMaps(out.Items, &list); err != nil {
+		return nil, fmt.Errorf("unmarshal check-ins: %w", err)
+	}
+	return list, nil
+}
@@ -0,0 +1,53 @@
+package dynamo
+
+import (
+	"context"
+	"testing"
+)
+
+func TestEnsureCheckIn(t *testing.T) {
+	_, cleanup := setup()
+	defer cleanup()
+	ctx := context.Background()
+
+	first, err := EnsureCheckIn(ctx, CheckIn{EventID: "e1", TrackID: "t1", UID: "u1", DriverName: "Ada"})
+	if err != nil {
+		t.Fatalf("EnsureCheckIn: %v", err)
+	}
+	if len(first.Code) != 24 {
+		t.Errorf("code = %q, want 24 hex chars", first.Code)
+	}
+
+	again, err := EnsureCheckIn(ctx, CheckIn{EventID: "e1", TrackID: "t1", UID: "u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Code != first.Code || again.DriverName != "Ada" {
+		t.Errorf("second EnsureCheckIn = %+v, want the first record", again)
+	}
+
+	if err := UpdateCheckIn(ctx, "e1", "u1", map[string]any{"arrivedAt": "2026-05-01T08:00:00Z", "transponder": "1234"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := EnsureCheckIn(ctx, CheckIn{EventID: "e1", TrackID: "t1", UID: "u2"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := EnsureCheckIn(ctx, CheckIn{EventID: "e2", TrackID: "t1", UID: "u1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := ListCheckIns(ctx, "e1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("ListCheckIns = %d records, want 2", len(list))
+	}
+	got, err := GetCheckIn(ctx, "e1", "u1")
+	if err != nil || got == nil || got.ArrivedAt == "" || got.Transponder != "1234" {
+		t.Errorf("GetCheckIn after update = %+v, %v", got, err)
+	}
+	if got, _ := GetCheckIn(ctx, "e1", "nobody"); got != nil {
+		t.Errorf("GetCheckIn of a missing driver = %+v, want nil", got)
+	}
+}
@@ -0,0 +1,254 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rs/xid"
+)
+
+// Equipment is a piece of a driver's own gear (engine, chassis, tires) stored
+// under USER#uid / EQUIP#id. Usage counters accumulate from ingested uploads.
+type Equipment struct {
+	PK          string `dynamodbav:"pk" json:"-"`
+	SK          string `dynamodbav:"sk" json:"-"`
+	EquipmentID string `dynamodbav:"equipmentId" json:"equipment_id"`
+	UID         string `dynamodbav:"uid" json:"uid"`
+	Type        string `dynamodbav:"type" json:"type"` // engine, chassis, tires
+	Name        string `dynamodbav:"name" json:"name"`
+	Serial      string `dynamodbav:"serial,omitempty" json:"serial,omitempty"`
+	Notes       string `dynamodbav:"notes,omitempty" json:"notes,omitempty"`
+	Retired     bool   `dynamodbav:"retired,omitempty" json:"retired,omitempty"`
+
+	RPMThreshold         int     `dynamodbav:"rpmThreshold,omitempty" json:"rpm_threshold,omitempty"`
+	RebuildIntervalHours float64 `dynamodbav:"rebuildIntervalHours,omitempty" json:"rebuild_interval_hours,omitempty"`
+
+	TotalTimeMs    int64  `dynamodbav:"totalTimeMs" json:"total_time_ms"`
+	SinceRebuildMs int64  `dynamodbav:"sinceRebuildMs" json:"since_rebuild_ms"`
+	TimeAboveRPMMs int64  `dynamodbav:"timeAboveRpmMs" json:"time_above_rpm_ms"`
+	MaxRPM         int    `dynamodbav:"maxRpm,omitempty" json:"max_rpm,omitempty"`
+	SessionCount   int    `dynamodbav:"sessionCount" json:"session_count"`
+	LastRebuiltAt  string `dynamodbav:"lastRebuiltAt,omitempty" json:"last_rebuilt_at,omitempty"`
+	ReminderSentAt string `dynamodbav:"reminderSentAt,omitempty" json:"reminder_sent_at,omitempty"`
+	CreatedAt      string `dynamodbav:"createdAt" json:"created_at"`
+}
+
+// CreateEquipment adds a piece of equipment to a user's registry.
+func CreateEquipment(ctx context.Context, e Equipment) (*Equipment, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	e.EquipmentID = xid.New().String()
+	e.PK = UserPK(e.UID)
+	e.SK = EquipmentSK(e.EquipmentID)
+	e.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+
+	item, err := attributevalue.MarshalMap(e)
+	if err != nil {
+		return nil, fmt.Errorf("marshal equipment: %w", err)
+	}
+
+	_, err = c.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(TableName),
+		Item:      item,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create equipment: %w", err)
+	}
+	return &e, nil
+}
+
+// GetEquipment returns a single piece of equipment owned by uid.
+func GetEquipment(ctx context.Context, uid, equipmentID string) (*Equipment, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(TableName),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: UserPK(uid)},
+			"sk": &types.AttributeValueMemberS{Value: EquipmentSK(equipmentID)},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get equipment: %w", err)
+	}
+	if out.Item == nil {
+		return nil, nil
+	}
+
+	var e Equipment
+	if err := attributevalue.UnmarshalMap(out.Item, &e); err != nil {
+		return nil, fmt.Errorf("unmarshal equipment: %w", err)
+	}
+	return &e, nil
+}
+
+// ListEquipmentForUser returns every piece of equipment in a user's registry.
+func ListEquipmentForUser(ctx context.Context, uid string) ([]Equipment, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(TableName),
+		KeyConditionExpression: aws.String("pk = :pk AND begins_with(sk, :prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":     &types.AttributeValueMemberS{Value: UserPK(uid)},
+			":prefix": &types.AttributeValueMemberS{Value: "EQUIP#"},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list equipment: %w", err)
+	}
+
+	var equipment []Equipment
+	if err := attributevalue.UnmarshalListOfMaps(out.Items, &equipment); err != nil {
+		return nil, fmt.Errorf("unmarshal equipment: %w", err)
+	}
+	return equipment, nil
+}
+
+// UpdateEquipment updates mutable fields on a piece of equipment.
+func UpdateEquipment(ctx context.Context, uid, equipmentID string, fields map[string]any) error {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	c, err := client()
+	if err != nil {
+		return err
+	}
+
+	expr, names, values, err := BuildUpdateExpression(fields)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(TableName),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: UserPK(uid)},
+			"sk": &types.AttributeValueMemberS{Value: EquipmentSK(equipmentID)},
+		},
+		UpdateExpression:          aws.String(expr),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+	return err
+}
+
+// ErrUsageApplied is returned when an upload's usage has already been credited
+// to a piece of equipment.
+var ErrUsageApplied = errors.New("usage already applied")
+
+// EquipmentUsage is what one upload adds to a piece of equipment.
+type EquipmentUsage struct {
+	RunMs          int64
+	TimeAboveRPMMs int64
+	MaxRPM         int
+}
+
+// ApplyEquipmentUsage adds an upload's usage to a piece of equipment's
+// counters and records the equipment on the upload's equipmentApplied list,
+// in one transaction, provided it isn't on the list already. The counters are
+// added in place, so uploads ingested at the same time don't overwrite each
+// other. Returns the equipment with its new totals, nil if it doesn't exist,
+// or ErrUsageApplied.
+func ApplyEquipmentUsage(ctx context.Context, uid, equipmentID, uploadID string, usage EquipmentUsage) (*Equipment, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = c.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{Update: &types.Update{
+				TableName: aws.String(TableName),
+				Key: map[string]types.AttributeValue{
+					"pk": &types.AttributeValueMemberS{Value: UserPK(uid)},
+					"sk": &types.AttributeValueMemberS{Value: EquipmentSK(equipmentID)},
+				},
+				ConditionExpression: aws.String("attribute_exists(pk)"),
+				UpdateExpression:    aws.String("ADD totalTimeMs :run, sinceRebuildMs :run, timeAboveRpmMs :above, sessionCount :one"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":run":   &types.AttributeValueMemberN{Value: fmt.Sprint(usage.RunMs)},
+					":above": &types.AttributeValueMemberN{Value: fmt.Sprint(usage.TimeAboveRPMMs)},
+					":one":   &types.AttributeValueMemberN{Value: "1"},
+				},
+			}},
+			{Update: &types.Update{
+				TableName: aws.String(TableName),
+				Key: map[string]types.AttributeValue{
+					"pk": &types.AttributeValueMemberS{Value: UploadPK(uploadID)},
+					"sk": &types.AttributeValueMemberS{Value: ProfileSK},
+				},
+				ConditionExpression: aws.String("NOT contains(equipmentApplied, :id)"),
+				UpdateExpression:    aws.String("SET equipmentApplied = list_append(if_not_exists(equipmentApplied, :none), :ids)"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":id":   &types.AttributeValueMemberS{Value: equipmentID},
+					":none": &types.AttributeValueMemberL{Value: []types.AttributeValue{}},
+					":ids":  &types.AttributeValueMemberL{Value: []types.AttributeValue{&types.AttributeValueMemberS{Value: equipmentID}}},
+				},
+			}},
+		},
+	})
+	failed := cancelled(err)
+	switch {
+	case len(failed) > 0 && failed[0]:
+		return nil, nil
+	case len(failed) > 1 && failed[1]:
+		return nil, ErrUsageApplied
+	case err != nil:
+		return nil, fmt.Errorf("apply equipment usage: %w", err)
+	}
+
+	if usage.MaxRPM > 0 {
+		_, err = c.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+			TableName: aws.String(TableName),
+			Key: map[string]types.AttributeValue{
+				"pk": &types.AttributeValueMemberS{Value: UserPK(uid)},
+				"sk": &types.AttributeValueMemberS{Value: EquipmentSK(equipmentID)},
+			},
+			ConditionExpression: aws.String("attribute_not_exists(maxRpm) OR maxRpm < :rpm"),
+			UpdateExpression:    aws.String("SET maxRpm = :rpm"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":rpm": &types.AttributeValueMemberN{Value: fmt.Sprint(usage.MaxRPM)},
+			},
+		})
+		var ccf *types.ConditionalCheckFailedException
+		if err != nil && !errors.As(err, &ccf) {
+			return nil, fmt.Errorf("update max rpm: %w", err)
+		}
+	}
+
+	return GetEquipment(ctx, uid, equipmentID)
+}
+
+// DeleteEquipment removes a piece of equipment from a user's registry.
+func DeleteEquipment(ctx context.Context, uid, equipmentID string) error {
+	c, err := client()
+	if err != nil {
+		return err
+	}
+
+	_, err = c.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(TableName),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: UserPK(uid)},
+			"sk": &types.AttributeValueMemberS{Value: EquipmentSK(equipmentID)},
+		},
+	})
+	return err
+}
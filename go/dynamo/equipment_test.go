@@ -0,0 +1,52 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestApplyEquipmentUsage(t *testing.T) {
+	_, cleanup := setup()
+	defer cleanup()
+	ctx := context.Background()
+
+	eq, err := CreateEquipment(ctx, Equipment{UID: "u1", Type: "engine", Name: "KA100"})
+	if err != nil {
+		t.Fatalf("CreateEquipment: %v", err)
+	}
+	for _, id := range []string{"up1", "up2"} {
+		if _, err := CreateUpload(ctx, Upload{UploadID: id, UID: "u1"}); err != nil {
+			t.Fatalf("CreateUpload: %v", err)
+		}
+	}
+
+	got, err := ApplyEquipmentUsage(ctx, "u1", eq.EquipmentID, "up1", EquipmentUsage{RunMs: 1000, TimeAboveRPMMs: 200, MaxRPM: 14000})
+	if err != nil {
+		t.Fatalf("ApplyEquipmentUsage: %v", err)
+	}
+	got, err = ApplyEquipmentUsage(ctx, "u1", eq.EquipmentID, "up2", EquipmentUsage{RunMs: 500, TimeAboveRPMMs: 100, MaxRPM: 13000})
+	if err != nil {
+		t.Fatalf("ApplyEquipmentUsage: %v", err)
+	}
+	if got.TotalTimeMs != 1500 || got.SinceRebuildMs != 1500 || got.TimeAboveRPMMs != 300 || got.SessionCount != 2 || got.MaxRPM != 14000 {
+		t.Errorf("equipment = %+v, want both uploads added and the higher peak kept", got)
+	}
+
+	// A retry of the same upload adds nothing
+	if _, err := ApplyEquipmentUsage(ctx, "u1", eq.EquipmentID, "up1", EquipmentUsage{RunMs: 1000}); !errors.Is(err, ErrUsageApplied) {
+		t.Errorf("second apply err = %v, want ErrUsageApplied", err)
+	}
+	if got, _ := GetEquipment(ctx, "u1", eq.EquipmentID); got.TotalTimeMs != 1500 {
+		t.Errorf("totalTimeMs = %d after a retry, want 1500", got.TotalTimeMs)
+	}
+	if up, _ := GetUpload(ctx, "up1"); len(up.EquipmentApplied) != 1 || up.EquipmentApplied[0] != eq.EquipmentID {
+		t.Errorf("equipmentApplied = %v, want [%s]", up.EquipmentApplied, eq.EquipmentID)
+	}
+
+	// Deleted equipment isn't recreated
+	got, err = ApplyEquipmentUsage(ctx, "u1", "gone", "up1", EquipmentUsage{RunMs: 1000})
+	if err != nil || got != nil {
+		t.Errorf("apply to missing equipment = %+v, %v; want nil, nil", got, err)
+	}
+}
@@ -2,22 +2,48 @@ package dynamo
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rs/xid"
 )
 
 type Kart struct {
-	PK        string `dynamodbav:"pk" json:"-"`
-	SK        string `dynamodbav:"sk" json:"-"`
-	KartID    string `dynamodbav:"kartId" json:"kart_id"`
-	TrackID   string `dynamodbav:"trackId" json:"track_id"`
-	Number    string `dynamodbav:"number" json:"number"`
-	Class     string `dynamodbav:"class,omitempty" json:"class,omitempty"`
-	CreatedAt string `dynamodbav:"createdAt" json:"created_at"`
+	PK           string `dynamodbav:"pk" json:"-"`
+	SK           string `dynamodbav:"sk" json:"-"`
+	KartID       string `dynamodbav:"kartId" json:"kart_id"`
+	TrackID      string `dynamodbav:"trackId" json:"track_id"`
+	Number       string `dynamodbav:"number" json:"number"`
+	Class        string `dynamodbav:"class,omitempty" json:"class,omitempty"`
+	Transponder  string `dynamodbav:"transponder,omitempty" json:"transponder,omitempty"`
+	Status       string `dynamodbav:"status,omitempty" json:"status,omitempty"` // active, out_of_service
+	Notes        string `dynamodbav:"notes,omitempty" json:"notes,omitempty"`
+	Slow         bool   `dynamodbav:"slow,omitempty" json:"slow,omitempty"` // avoided by the kart draw
+	SessionCount int    `dynamodbav:"sessionCount,omitempty" json:"session_count,omitempty"`
+	GSI1PK       string `dynamodbav:"gsi1pk,omitempty" json:"-"`
+	GSI1SK       string `dynamodbav:"gsi1sk,omitempty" json:"-"`
+	CreatedAt    string `dynamodbav:"createdAt" json:"created_at"`
+}
+
+// InService reports whether the kart can be assigned to drivers.
+func (k Kart) InService() bool { return k.Status != "out_of_service" }
+
+// SessionKart records which kart a driver ran in a session, stored under
+// SESSION#sid / DRIVERKART#uid.
+type SessionKart struct {
+	PK         string `dynamodbav:"pk" json:"-"`
+	SK         string `dynamodbav:"sk" json:"-"`
+	SessionID  string `dynamodbav:"sessionId" json:"session_id"`
+	UID        string `dynamodbav:"uid" json:"uid"`
+	DriverName string `dynamodbav:"driverName,omitempty" json:"driver_name,omitempty"`
+	KartID     string `dynamodbav:"kartId" json:"kart_id"`
+	KartNumber string `dynamodbav:"kartNumber,omitempty" json:"kart_number,omitempty"`
+	CreatedAt  string `dynamodbav:"createdAt" json:"created_at"`
 }
 
 func PutKart(ctx context.Context, k Kart) error {
@@ -28,6 +54,8 @@ func PutKart(ctx context.Context, k Kart) error {
 
 	k.PK = KartPK(k.KartID)
 	k.SK = ProfileSK
+	k.GSI1PK = TrackPK(k.TrackID)
+	k.GSI1SK = KartPK(k.KartID)
 
 	item, err := attributevalue.MarshalMap(k)
 	if err != nil {
@@ -41,6 +69,22 @@ func PutKart(ctx context.Context, k Kart) error {
 	return err
 }
 
+// CreateKart registers a new kart in a track's fleet.
+func CreateKart(ctx context.Context, k Kart) (*Kart, error) {
+	k.KartID = xid.New().String()
+	if k.Status == "" {
+		k.Status = "active"
+	}
+	k.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+
+	if err := PutKart(ctx, k); err != nil {
+		return nil, fmt.Errorf("create kart: %w", err)
+	}
+	k.PK = KartPK(k.KartID)
+	k.SK = ProfileSK
+	return &k, nil
+}
+
 func GetKart(ctx context.Context, kartID string) (*Kart, error) {
 	c, err := client()
 	if err != nil {
@@ -67,3 +111,204 @@ func GetKart(ctx context.Context, kartID string) (*Kart, error) {
 	}
 	return &k, nil
 }
+
+func UpdateKart(ctx context.Context, kartID string, fields map[string]any) error {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	c, err := client()
+	if err != nil {
+		return err
+	}
+
+	expr, names, values, err := BuildUpdateExpression(fields)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(TableName),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: KartPK(kartID)},
+			"sk": &types.AttributeValueMemberS{Value: ProfileSK},
+		},
+		UpdateExpression:          aws.String(expr),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+	return err
+}
+
+// AddKartSessions adds delta to a kart's session count in place. A count
+// already at zero isn't taken below it, and a deleted kart isn't recreated.
+func AddKartSessions(ctx context.Context, kartID string, delta int) error {
+	c, err := client()
+	if err != nil {
+		return err
+	}
+
+	in := &dynamodb.UpdateItemInput{
+		TableName: aws.String(TableName),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: KartPK(kartID)},
+			"sk": &types.AttributeValueMemberS{Value: ProfileSK},
+		},
+		ConditionExpression: aws.String("attribute_exists(pk)"),
+		UpdateExpression:    aws.String("ADD sessionCount :delta"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":delta": &types.AttributeValueMemberN{Value: fmt.Sprint(delta)},
+		},
+	}
+	if delta < 0 {
+		in.ConditionExpression = aws.String("sessionCount > :floor")
+		in.ExpressionAttributeValues[":floor"] = &types.AttributeValueMemberN{Value: fmt.Sprint(-delta - 1)}
+	}
+
+	_, err = c.UpdateItem(ctx, in)
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return nil
+	}
+	return err
+}
+
+func DeleteKart(ctx context.Context, kartID string) error {
+	c, err := client()
+	if err != nil {
+		return err
+	}
+
+	_, err = c.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(TableName),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: KartPK(kartID)},
+			"sk": &types.AttributeValueMemberS{Value: ProfileSK},
+		},
+	})
+	return err
+}
+
+// ListKartsForTrack returns a track's kart fleet (via GSI1).
+func ListKartsForTrack(ctx context.Context, trackID string) ([]Kart, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(TableName),
+		IndexName:              aws.String("gsi1"),
+		KeyConditionExpression: aws.String("gsi1pk = :pk AND begins_with(gsi1sk, :prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":     &types.AttributeValueMemberS{Value: TrackPK(trackID)},
+			":prefix": &types.AttributeValueMemberS{Value: "KART#"},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list karts for track: %w", err)
+	}
+
+	var karts []Kart
+	if err := attributevalue.UnmarshalListOfMaps(out.Items, &karts); err != nil {
+		return nil, fmt.Errorf("unmarshal karts: %w", err)
+	}
+	return karts, nil
+}
+
+// PutSessionKart creates or replaces a driver's kart assignment for a session.
+func PutSessionKart(ctx context.Context, sk SessionKart) (*SessionKart, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	sk.PK = SessionPK(sk.SessionID)
+	sk.SK = SessionKartSK(sk.UID)
+	sk.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+
+	item, err := attributevalue.MarshalMap(sk)
+	if err != nil {
+		return nil, fmt.Errorf("marshal session kart: %w", err)
+	}
+
+	_, err = c.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(TableName),
+		Item:      item,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("put session kart: %w", err)
+	}
+	return &sk, nil
+}
+
+// GetSessionKart returns the kart a driver ran in a session, or nil.
+func GetSessionKart(ctx context.Context, sessionID, uid string) (*SessionKart, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(TableName),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: SessionPK(sessionID)},
+			"sk": &types.AttributeValueMemberS{Value: SessionKartSK(uid)},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get session kart: %w", err)
+	}
+	if out.Item == nil {
+		return nil, nil
+	}
+
+	var sk SessionKart
+	if err := attributevalue.UnmarshalMap(out.Item, &sk); err != nil {
+		return nil, fmt.Errorf("unmarshal session kart: %w", err)
+	}
+	return &sk, nil
+}
+
+// ListSessionKarts returns every driver's kart assignment for a session.
+func ListSessionKarts(ctx context.Context, sessionID string) ([]SessionKart, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(TableName),
+		KeyConditionExpression: aws.String("pk = :pk AND begins_with(sk, :prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":     &types.AttributeValueMemberS{Value: SessionPK(sessionID)},
+			":prefix": &types.AttributeValueMemberS{Value: "DRIVERKART#"},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list session karts: %w", err)
+	}
+
+	var assignments []SessionKart
+	if err := attributevalue.UnmarshalListOfMaps(out.Items, &assignments); err != nil {
+		return nil, fmt.Errorf("unmarshal session karts: %w", err)
+	}
+	return assignments, nil
+}
+
+// DeleteSessionKart removes a driver's kart assignment for a session.
+func DeleteSessionKart(ctx context.Context, sessionID, uid string) error {
+	c, err := client()
+	if err != nil {
+		return err
+	}
+
+	_, err = c.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(TableName),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: SessionPK(sessionID)},
+			"sk": &types.AttributeValueMemberS{Value: SessionKartSK(uid)},
+		},
+	})
+	return err
+}
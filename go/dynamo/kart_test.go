@@ -0,0 +1,39 @@
+package dynamo
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAddKartSessions(t *testing.T) {
+	_, cleanup := setup()
+	defer cleanup()
+	ctx := context.Background()
+
+	k, err := CreateKart(ctx, Kart{TrackID: "t1", Number: "7"})
+	if err != nil {
+		t.Fatalf("CreateKart: %v", err)
+	}
+	for _, delta := range []int{1, 1, -1, -1, -1} {
+		if err := AddKartSessions(ctx, k.KartID, delta); err != nil {
+			t.Fatalf("AddKartSessions(%d): %v", delta, err)
+		}
+	}
+	if got, _ := GetKart(ctx, k.KartID); got.SessionCount != 0 {
+		t.Errorf("sessionCount = %d, want 0", got.SessionCount)
+	}
+	if err := AddKartSessions(ctx, k.KartID, 1); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := GetKart(ctx, k.KartID); got.SessionCount != 1 {
+		t.Errorf("sessionCount = %d, want 1", got.SessionCount)
+	}
+
+	// A deleted kart isn't recreated
+	if err := AddKartSessions(ctx, "gone", 1); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := GetKart(ctx, "gone"); got != nil {
+		t.Errorf("deleted kart recreated: %+v", got)
+	}
+}
@@ -13,6 +13,7 @@ func KartPK(id string) string           { return "KART#" + id }
 func EventPK(id string) string          { return "EVENT#" + id }
 func ChampionshipPK(id string) string   { return "CHAMPIONSHIP#" + id }
 func FormatPK(id string) string         { return "FORMAT#" + id }
+func TeamPK(id string) string           { return "TEAM#" + id }
 func APIKeyLookupPK(hash string) string { return "APIKEY#" + hash }
 
 // GSI1 keys for global event timeline
@@ -32,6 +33,9 @@ func LapSKPrefixUser(uid string) string  { return "LAP#" + uid + "#" }
 func IsNewFormatLapSK(sk string) bool { return strings.Count(sk, "#") >= 2 }
 func APIKeySK(keyID string) string    { return "APIKEY#" + keyID }
 
+// Equipment sort keys (under USER#uid)
+func EquipmentSK(id string) string { return "EQUIP#" + id }
+
 // Series / Result sort keys
 func SeriesPK(id string) string              { return "SERIES#" + id }
 func SeriesEventSK(eventID string) string    { return "EVENT#" + eventID }
@@ -39,9 +43,58 @@ func SeriesDriverSK(uid string) string       { return "DRIVER#" + uid }
 func EventSessionSK(sessionID string) string { return "SESSION#" + sessionID }
 func ResultSK(uid string) string             { return "RESULT#" + uid }
 
+// Session kart assignment sort keys
+func SessionKartSK(uid string) string { return "DRIVERKART#" + uid }
+
+// Transponder mapping sort keys (under SESSION#sid)
+func TransponderSK(number string) string { return "TRANSPONDER#" + number }
+
+// Penalty sort keys (under SESSION#sid) and GSI1 keys for the per-event log
+func PenaltySK(id string) string { return "PENALTY#" + id }
+func PenaltyGSI1SK(createdAt, id string) string {
+	return "PENALTY#" + createdAt + "#" + id
+}
+
+// Protest sort keys (under SESSION#sid)
+func ProtestSK(id string) string { return "PROTEST#" + id }
+
+// Promotion run sort keys (under CHAMPIONSHIP#cid)
+func PromotionSK(id string) string { return "PROMOTION#" + id }
+
+// Waiver sort keys: documents under TRACK#tid, signatures under USER#uid.
+// GSI1 lists a track's signatures by version.
+func WaiverSK(version int) string { return fmt.Sprintf("WAIVER#%06d", version) }
+func WaiverSigSK(trackID string, version int) string {
+	return fmt.Sprintf("WAIVERSIG#%s#%06d", trackID, version)
+}
+func WaiverSigGSI1PK(trackID string) string { return "WAIVERSIGS#" + trackID }
+func WaiverSigGSI1SK(version int, uid string) string {
+	return fmt.Sprintf("%06d#%s", version, uid)
+}
+
+// Promo code use counters (under the registration parent)
+func PromoUseSK(code string) string { return "PROMOUSE#" + code }
+
+// Track membership sort keys (under TRACK#tid)
+func MembershipSK(uid string) string { return "MEMBERSHIP#" + uid }
+
+// Check-in sort keys (under EVENT#eid)
+func CheckInSK(uid string) string { return "CHECKIN#" + uid }
+
 // Registration sort keys
 func RegSK(uid string) string { return "REG#" + uid }
 
+// Registration transfer sort keys (under the registration's parent), and the
+// GSI1 key the recipient finds them by
+func TransferSK(uid string) string       { return "TRANSFER#" + uid }
+func TransferGSI1PK(email string) string { return "REGTRANSFER#" + strings.ToLower(email) }
+
+// SpotsSK is the counter of spots held under a registration parent.
+const SpotsSK = "SPOTS"
+
+// LapSeqSK is the counter that numbers a session's laps as they're written.
+const LapSeqSK = "LAPSEQ"
+
 // GSI2 keys for user registrations
 func UserRegGSI2PK(uid string) string { return "USERREG#" + uid }
 
@@ -55,6 +108,9 @@ func InviteGSI1PK(email string) string { return "INVITE#" + strings.ToLower(emai
 func UploadPK(id string) string          { return "UPLOAD#" + id }
 func UserUploadGSI1PK(uid string) string { return "USERUPLOAD#" + uid }
 
+// GSI2 keys for per-kart lap history
+func KartLapsGSI2PK(kartID string) string { return "KARTLAPS#" + kartID }
+
 // GSI1 keys for leaderboard
 func LeaderboardGSI1PK(layoutID, class string) string {
 	return "LAYOUT#" + layoutID + "#CLASS#" + class
@@ -3,6 +3,7 @@ package dynamo
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
@@ -24,11 +25,17 @@ type Lap struct {
 	Verified     bool    `dynamodbav:"verified" json:"verified"`
 	S3Key        string  `dynamodbav:"s3Key,omitempty" json:"s3_key,omitempty"`
 	TelemetryKey string  `dynamodbav:"telemetryKey,omitempty" json:"telemetry_key,omitempty"`
+	CrossedAtMs  int64   `dynamodbav:"crossedAtMs,omitempty" json:"crossed_at_ms,omitempty"` // decoder time the lap ended (unix ms)
+	Seq          int64   `dynamodbav:"seq,omitempty" json:"seq,omitempty"`                   // order the lap was written in, within its session
 	GSI1PK       string  `dynamodbav:"gsi1pk,omitempty" json:"-"`
 	GSI1SK       string  `dynamodbav:"gsi1sk,omitempty" json:"-"`
+	GSI2PK       string  `dynamodbav:"gsi2pk,omitempty" json:"-"`
+	GSI2SK       string  `dynamodbav:"gsi2sk,omitempty" json:"-"`
 	CreatedAt    string  `dynamodbav:"createdAt" json:"created_at"`
 }
 
+// PutLap writes a lap. A lap without a Seq is numbered from its session's
+// counter, so laps rewritten with the Seq they were read with keep it.
 func PutLap(ctx context.Context, l Lap) error {
 	c, err := client()
 	if err != nil {
@@ -37,6 +44,11 @@ func PutLap(ctx context.Context, l Lap) error {
 
 	l.PK = SessionPK(l.SessionID)
 	l.SK = LapSK(l.UID, l.LapNo)
+	if l.Seq == 0 {
+		if l.Seq, err = nextLapSeq(ctx, c, l.SessionID); err != nil {
+			return err
+		}
+	}
 
 	// Always populate GSI1 for leaderboard queries when we have a layout
 	if l.LayoutID != "" && l.LapTimeMs > 0 {
@@ -44,6 +56,12 @@ func PutLap(ctx context.Context, l Lap) error {
 		l.GSI1SK = LeaderboardGSI1SK(l.LapTimeMs)
 	}
 
+	// Index laps run in a known kart for per-kart performance stats
+	if l.KartID != "" && l.LapTimeMs > 0 {
+		l.GSI2PK = KartLapsGSI2PK(l.KartID)
+		l.GSI2SK = l.CreatedAt + "#" + l.SK
+	}
+
 	item, err := attributevalue.MarshalMap(l)
 	if err != nil {
 		return fmt.Errorf("marshal lap: %w", err)
@@ -56,6 +74,28 @@ func PutLap(ctx context.Context, l Lap) error {
 	return err
 }
 
+// nextLapSeq takes the next number from a session's lap counter.
+func nextLapSeq(ctx context.Context, c DynamoDBAPI, sessionID string) (int64, error) {
+	out, err := c.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(TableName),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: SessionPK(sessionID)},
+			"sk": &types.AttributeValueMemberS{Value: LapSeqSK},
+		},
+		UpdateExpression:          aws.String("ADD seq :one"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":one": &types.AttributeValueMemberN{Value: "1"}},
+		ReturnValues:              types.ReturnValueUpdatedNew,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("next lap seq: %w", err)
+	}
+	n, ok := out.Attributes["seq"].(*types.AttributeValueMemberN)
+	if !ok {
+		return 0, fmt.Errorf("next lap seq: no seq returned")
+	}
+	return strconv.ParseInt(n.Value, 10, 64)
+}
+
 func GetLap(ctx context.Context, sessionID, uid string, lapNo int) (*Lap, error) {
 	c, err := client()
 	if err != nil {
@@ -237,6 +277,49 @@ func DeleteLapsForUser(ctx context.Context, sessionID, uid string) (int, error)
 	return deleted, nil
 }
 
+// ListLapsForKart returns laps run in a kart (via GSI2), oldest first.
+// If since is non-empty, only laps with createdAt >= since are returned.
+func ListLapsForKart(ctx context.Context, kartID, since string) ([]Lap, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(TableName),
+		IndexName:              aws.String("gsi2"),
+		KeyConditionExpression: aws.String("gsi2pk = :pk"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: KartLapsGSI2PK(kartID)},
+		},
+		ScanIndexForward: aws.Bool(true),
+	}
+	if since != "" {
+		input.KeyConditionExpression = aws.String("gsi2pk = :pk AND gsi2sk >= :since")
+		input.ExpressionAttributeValues[":since"] = &types.AttributeValueMemberS{Value: since}
+	}
+
+	var laps []Lap
+	for {
+		out, err := c.Query(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("list laps for kart: %w", err)
+		}
+
+		var batch []Lap
+		if err := attributevalue.UnmarshalListOfMaps(out.Items, &batch); err != nil {
+			return nil, fmt.Errorf("unmarshal laps: %w", err)
+		}
+		laps = append(laps, batch...)
+
+		if out.LastEvaluatedKey == nil {
+			break
+		}
+		input.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+	return laps, nil
+}
+
 // QueryFastestLaps returns laps from the leaderboard GSI, sorted by time ascending.
 // If since is non-empty, only laps with createdAt >= since are returned.
 // Paginates through results when a filter is applied to ensure we return up to limit items.
@@ -0,0 +1,43 @@
+package dynamo
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPutLapSeq(t *testing.T) {
+	_, cleanup := setup()
+	defer cleanup()
+	ctx := context.Background()
+
+	for _, l := range []Lap{
+		{SessionID: "s1", UID: "a", LapNo: 2, LapTimeMs: 41000},
+		{SessionID: "s1", UID: "b", LapNo: 1, LapTimeMs: 42000},
+		{SessionID: "s2", UID: "a", LapNo: 1, LapTimeMs: 40000},
+	} {
+		if err := PutLap(ctx, l); err != nil {
+			t.Fatalf("PutLap: %v", err)
+		}
+	}
+
+	// Numbered in the order written, per session
+	a, _ := GetLap(ctx, "s1", "a", 2)
+	b, _ := GetLap(ctx, "s1", "b", 1)
+	other, _ := GetLap(ctx, "s2", "a", 1)
+	if a == nil || b == nil || other == nil || a.Seq != 1 || b.Seq != 2 || other.Seq != 1 {
+		t.Fatalf("seqs = %v, %v, %v; want 1, 2 and 1", a, b, other)
+	}
+
+	// A lap written late comes after the others, whatever its lap number
+	PutLap(ctx, Lap{SessionID: "s1", UID: "a", LapNo: 1, LapTimeMs: 43000})
+	if late, _ := GetLap(ctx, "s1", "a", 1); late == nil || late.Seq != 3 {
+		t.Errorf("late lap = %+v, want seq 3", late)
+	}
+
+	// Rewriting a lap keeps its number
+	a.KartID = "k1"
+	PutLap(ctx, *a)
+	if got, _ := GetLap(ctx, "s1", "a", 2); got == nil || got.Seq != 1 || got.KartID != "k1" {
+		t.Errorf("rewritten lap = %+v, want seq 1 in kart k1", got)
+	}
+}
@@ -0,0 +1,127 @@
+package dynamo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Membership makes a driver a member of a track, for member pricing. It's
+// stored under TRACK#tid / MEMBERSHIP#uid, apart from the track's staff, and
+// grants no access to anything.
+type Membership struct {
+	PK         string `dynamodbav:"pk" json:"-"`
+	SK         string `dynamodbav:"sk" json:"-"`
+	TrackID    string `dynamodbav:"trackId" json:"track_id"`
+	UID        string `dynamodbav:"uid" json:"uid"`
+	DriverName string `dynamodbav:"driverName,omitempty" json:"driver_name,omitempty"`
+	ExpiresAt  string `dynamodbav:"expiresAt,omitempty" json:"expires_at,omitempty"` // empty never expires
+	AddedBy    string `dynamodbav:"addedBy" json:"added_by"`
+	CreatedAt  string `dynamodbav:"createdAt" json:"created_at"`
+}
+
+// PutMembership adds a driver to a track's members, or renews them.
+func PutMembership(ctx context.Context, m Membership) (*Membership, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	m.PK = TrackPK(m.TrackID)
+	m.SK = MembershipSK(m.UID)
+	m.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+
+	item, err := attributevalue.MarshalMap(m)
+	if err != nil {
+		return nil, fmt.Errorf("marshal membership: %w", err)
+	}
+
+	_, err = c.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(TableName),
+		Item:      item,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("put membership: %w", err)
+	}
+	return &m, nil
+}
+
+// GetMembership returns a driver's membership of a track, or nil if they
+// aren't a member.
+func GetMembership(ctx context.Context, trackID, uid string) (*Membership, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(TableName),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: TrackPK(trackID)},
+			"sk": &types.AttributeValueMemberS{Value: MembershipSK(uid)},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get membership: %w", err)
+	}
+	if out.Item == nil {
+		return nil, nil
+	}
+
+	var m Membership
+	if err := attributevalue.UnmarshalMap(out.Item, &m); err != nil {
+		return nil, fmt.Errorf("unmarshal membership: %w", err)
+	}
+	return &m, nil
+}
+
+// DeleteMembership removes a driver from a track's members.
+func DeleteMembership(ctx context.Context, trackID, uid string) error {
+	c, err := client()
+	if err != nil {
+		return err
+	}
+
+	_, err = c.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(TableName),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: TrackPK(trackID)},
+			"sk": &types.AttributeValueMemberS{Value: MembershipSK(uid)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("delete membership: %w", err)
+	}
+	return nil
+}
+
+// ListMemberships returns a track's members, expired ones included.
+func ListMemberships(ctx context.Context, trackID string) ([]Membership, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(TableName),
+		KeyConditionExpression: aws.String("pk = :pk AND begins_with(sk, :prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":     &types.AttributeValueMemberS{Value: TrackPK(trackID)},
+			":prefix": &types.AttributeValueMemberS{Value: MembershipSK("")},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list memberships: %w", err)
+	}
+
+	var list []Membership
+	if err := attributevalue.UnmarshalListOfMaps(out.Items, &list); err != nil {
+		return nil, fmt.Errorf("unmarshal memberships: %w", err)
+	}
+	return list, nil
+}
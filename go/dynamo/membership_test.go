@@ -0,0 +1,46 @@
+package dynamo
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMemberships(t *testing.T) {
+	_, cleanup := setup()
+	defer cleanup()
+	ctx := context.Background()
+
+	// The track's owner is staff under the same partition, not a member
+	track, err := CreateTrack(ctx, "owner", Track{Name: "Test Track"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tid := track.TrackID
+
+	if _, err := PutMembership(ctx, Membership{TrackID: tid, UID: "u1", DriverName: "Ada", AddedBy: "owner"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := PutMembership(ctx, Membership{TrackID: tid, UID: "u2", ExpiresAt: "2026-12-31T23:59:59Z", AddedBy: "owner"}); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := ListMemberships(ctx, tid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("ListMemberships = %d, want 2", len(list))
+	}
+
+	m, err := GetMembership(ctx, tid, "u2")
+	if err != nil || m == nil || m.ExpiresAt != "2026-12-31T23:59:59Z" {
+		t.Errorf("GetMembership = %+v, %v", m, err)
+	}
+
+	if err := DeleteMembership(ctx, tid, "u2"); err != nil {
+		t.Fatal(err)
+	}
+	if m, _ := GetMembership(ctx, tid, "u2"); m != nil {
+		t.Errorf("GetMembership after delete = %+v, want nil", m)
+	}
+}
@@ -2,10 +2,14 @@ package dynamo
 
 import (
 	"context"
+	"maps"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
@@ -34,6 +38,9 @@ func (m *mockDB) PutItem(_ context.Context, in *dynamodb.PutItemInput, _ ...func
 	defer m.mu.Unlock()
 	pk := strVal(in.Item["pk"])
 	sk := strVal(in.Item["sk"])
+	if !checkCondition(m.items[itemKey(pk, sk)], in.ConditionExpression, in.ExpressionAttributeNames, in.ExpressionAttributeValues) {
+		return nil, &types.ConditionalCheckFailedException{}
+	}
 	// Deep copy the item
 	cp := make(map[string]types.AttributeValue, len(in.Item))
 	for k, v := range in.Item {
@@ -138,48 +145,88 @@ func (m *mockDB) Query(_ context.Context, in *dynamodb.QueryInput, _ ...func(*dy
 	return &dynamodb.QueryOutput{Items: matched}, nil
 }
 
+func (m *mockDB) Scan(_ context.Context, in *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Only pk-prefix filters with an exact or prefix sk, optionally on items
+	// without gsi1 keys, are supported
+	prefixVal := strVal(in.ExpressionAttributeValues[":prefix"])
+	skVal, hasSK := in.ExpressionAttributeValues[":sk"]
+	skPrefix := strVal(in.ExpressionAttributeValues[":skPrefix"])
+	noGSI1 := in.FilterExpression != nil && strings.Contains(*in.FilterExpression, "attribute_not_exists(gsi1pk)")
+
+	var matched []map[string]types.AttributeValue
+	for _, item := range m.items {
+		if !strings.HasPrefix(strVal(item["pk"]), prefixVal) || !strings.HasPrefix(strVal(item["sk"]), skPrefix) {
+			continue
+		}
+		if hasSK && strVal(item["sk"]) != strVal(skVal) {
+			continue
+		}
+		if noGSI1 && item["gsi1pk"] != nil {
+			continue
+		}
+		matched = append(matched, item)
+	}
+	return &dynamodb.ScanOutput{Items: matched}, nil
+}
+
 func (m *mockDB) UpdateItem(_ context.Context, in *dynamodb.UpdateItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	pk := strVal(in.Key["pk"])
-	sk := strVal(in.Key["sk"])
-	key := itemKey(pk, sk)
+	key := itemKey(strVal(in.Key["pk"]), strVal(in.Key["sk"]))
 	item, ok := m.items[key]
-	if !ok {
-		return &dynamodb.UpdateItemOutput{}, nil
+	if !checkCondition(item, in.ConditionExpression, in.ExpressionAttributeNames, in.ExpressionAttributeValues) {
+		return nil, &types.ConditionalCheckFailedException{}
 	}
-
-	// Parse SET expression to apply updates: "SET #k1 = :v1, #k2 = :v2"
-	if in.UpdateExpression != nil {
-		expr := *in.UpdateExpression
-		expr = strings.TrimPrefix(expr, "SET ")
-		parts := strings.Split(expr, ", ")
-		for _, part := range parts {
-			sides := strings.SplitN(part, " = ", 2)
-			if len(sides) != 2 {
-				continue
-			}
-			nameRef := strings.TrimSpace(sides[0])
-			valRef := strings.TrimSpace(sides[1])
-			attrName := nameRef
-			if in.ExpressionAttributeNames != nil {
-				if resolved, ok := in.ExpressionAttributeNames[nameRef]; ok {
-					attrName = resolved
-				}
-			}
-			if val, ok := in.ExpressionAttributeValues[valRef]; ok {
-				item[attrName] = val
-			}
+	if !ok {
+		// Only counters are created by an update; anything else is left
+		// alone, as the package always writes items whole first
+		if !strings.HasPrefix(aws.ToString(in.UpdateExpression), "ADD ") {
+			return &dynamodb.UpdateItemOutput{}, nil
 		}
+		item = map[string]types.AttributeValue{"pk": in.Key["pk"], "sk": in.Key["sk"]}
+		m.items[key] = item
+	}
+	applySet(item, in.UpdateExpression, in.ExpressionAttributeNames, in.ExpressionAttributeValues)
+	if in.ReturnValues == types.ReturnValueUpdatedNew {
+		return &dynamodb.UpdateItemOutput{Attributes: maps.Clone(item)}, nil
 	}
-
-	m.items[key] = item
 	return &dynamodb.UpdateItemOutput{}, nil
 }
 
 func (m *mockDB) TransactWriteItems(_ context.Context, in *dynamodb.TransactWriteItemsInput, _ ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+
+	// Every condition is checked before anything is written
+	reasons := make([]types.CancellationReason, len(in.TransactItems))
+	failed := false
+	for i, tw := range in.TransactItems {
+		var key map[string]types.AttributeValue
+		var cond *string
+		var names map[string]string
+		var values map[string]types.AttributeValue
+		switch {
+		case tw.Put != nil:
+			key, cond, names, values = tw.Put.Item, tw.Put.ConditionExpression, tw.Put.ExpressionAttributeNames, tw.Put.ExpressionAttributeValues
+		case tw.Update != nil:
+			key, cond, names, values = tw.Update.Key, tw.Update.ConditionExpression, tw.Update.ExpressionAttributeNames, tw.Update.ExpressionAttributeValues
+		case tw.Delete != nil:
+			key, cond, names, values = tw.Delete.Key, tw.Delete.ConditionExpression, tw.Delete.ExpressionAttributeNames, tw.Delete.ExpressionAttributeValues
+		}
+		item := m.items[itemKey(strVal(key["pk"]), strVal(key["sk"]))]
+		code := "None"
+		if !checkCondition(item, cond, names, values) {
+			code, failed = "ConditionalCheckFailed", true
+		}
+		reasons[i] = types.CancellationReason{Code: &code}
+	}
+	if failed {
+		return nil, &types.TransactionCanceledException{CancellationReasons: reasons}
+	}
+
 	for _, tw := range in.TransactItems {
 		if tw.Put != nil {
 			pk := strVal(tw.Put.Item["pk"])
@@ -190,6 +237,11 @@ func (m *mockDB) TransactWriteItems(_ context.Context, in *dynamodb.TransactWrit
 			}
 			m.items[itemKey(pk, sk)] = cp
 		}
+		if tw.Update != nil {
+			if item, ok := m.items[itemKey(strVal(tw.Update.Key["pk"]), strVal(tw.Update.Key["sk"]))]; ok {
+				applySet(item, tw.Update.UpdateExpression, tw.Update.ExpressionAttributeNames, tw.Update.ExpressionAttributeValues)
+			}
+		}
 		if tw.Delete != nil {
 			pk := strVal(tw.Delete.Key["pk"])
 			sk := strVal(tw.Delete.Key["sk"])
@@ -199,6 +251,147 @@ func (m *mockDB) TransactWriteItems(_ context.Context, in *dynamodb.TransactWrit
 	return &dynamodb.TransactWriteItemsOutput{}, nil
 }
 
+func numVal(av types.AttributeValue) int {
+	if n, ok := av.(*types.AttributeValueMemberN); ok {
+		v, _ := strconv.Atoi(n.Value)
+		return v
+	}
+	return 0
+}
+
+func resolveName(ref string, names map[string]string) string {
+	if resolved, ok := names[ref]; ok {
+		return resolved
+	}
+	return ref
+}
+
+// checkCondition evaluates the few condition expressions the package uses:
+// attribute_exists / attribute_not_exists, NOT contains(l, :v), "a = :v" and
+// numeric "a < :v" / "a > :v", joined by OR.
+func checkCondition(item map[string]types.AttributeValue, cond *string, names map[string]string, values map[string]types.AttributeValue) bool {
+	if cond == nil || *cond == "" {
+		return true
+	}
+	if lhs, rhs, ok := strings.Cut(*cond, " OR "); ok {
+		return checkCondition(item, &lhs, names, values) || checkCondition(item, &rhs, names, values)
+	}
+	if *cond == "attribute_not_exists(pk)" {
+		return item == nil
+	}
+	if item == nil {
+		return false
+	}
+	if attr, ok := strings.CutPrefix(*cond, "attribute_exists("); ok {
+		return item[resolveName(strings.TrimSuffix(attr, ")"), names)] != nil
+	}
+	if attr, ok := strings.CutPrefix(*cond, "attribute_not_exists("); ok {
+		return item[resolveName(strings.TrimSuffix(attr, ")"), names)] == nil
+	}
+	if args, ok := strings.CutPrefix(*cond, "NOT contains("); ok {
+		attr, v, _ := strings.Cut(strings.TrimSuffix(args, ")"), ", ")
+		list, _ := item[resolveName(attr, names)].(*types.AttributeValueMemberL)
+		if list == nil {
+			return true
+		}
+		for _, el := range list.Value {
+			if strVal(el) == strVal(values[v]) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, op := range []string{" = ", " < ", " > "} {
+		lhs, rhs, ok := strings.Cut(*cond, op)
+		if !ok {
+			continue
+		}
+		attr := item[resolveName(strings.TrimSpace(lhs), names)]
+		want := values[strings.TrimSpace(rhs)]
+		switch op {
+		case " = ":
+			return strVal(attr) == strVal(want)
+		case " < ":
+			return numVal(attr) < numVal(want)
+		default:
+			return numVal(attr) > numVal(want)
+		}
+	}
+	return true
+}
+
+// splitTop splits s on ", " outside parentheses.
+func splitTop(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '(':
+			depth++
+		case s[i] == ')':
+			depth--
+		case depth == 0 && strings.HasPrefix(s[i:], ", "):
+			parts = append(parts, s[start:i])
+			start = i + 2
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// applySet applies "SET #k1 = :v1, n = n + :one, l = list_append(
+// if_not_exists(l, :empty), :v)" style update expressions, optionally
+// followed by "ADD n :delta, m :delta".
+func applySet(item map[string]types.AttributeValue, expr *string, names map[string]string, values map[string]types.AttributeValue) {
+	if expr == nil {
+		return
+	}
+	set, add, _ := strings.Cut(*expr, "ADD ")
+	for _, part := range splitTop(add) {
+		attr, delta, ok := strings.Cut(strings.TrimSpace(part), " ")
+		if !ok {
+			continue
+		}
+		attrName := resolveName(attr, names)
+		item[attrName] = &types.AttributeValueMemberN{Value: strconv.Itoa(numVal(item[attrName]) + numVal(values[delta]))}
+	}
+	set = strings.TrimSpace(strings.TrimPrefix(set, "SET "))
+	if set == "" {
+		return
+	}
+	for _, part := range splitTop(set) {
+		lhs, rhs, ok := strings.Cut(part, " = ")
+		if !ok {
+			continue
+		}
+		attrName := resolveName(strings.TrimSpace(lhs), names)
+		rhs = strings.TrimSpace(rhs)
+		if args, ok := strings.CutPrefix(rhs, "list_append("); ok {
+			args := splitTop(strings.TrimSuffix(args, ")"))
+			var list []types.AttributeValue
+			if inner, ok := strings.CutPrefix(args[0], "if_not_exists("); ok {
+				attr, fallback, _ := strings.Cut(strings.TrimSuffix(inner, ")"), ", ")
+				if l, ok := item[resolveName(attr, names)].(*types.AttributeValueMemberL); ok {
+					list = l.Value
+				} else if l, ok := values[fallback].(*types.AttributeValueMemberL); ok {
+					list = l.Value
+				}
+			} else if l, ok := item[resolveName(args[0], names)].(*types.AttributeValueMemberL); ok {
+				list = l.Value
+			}
+			if l, ok := values[args[1]].(*types.AttributeValueMemberL); ok {
+				list = append(slices.Clone(list), l.Value...)
+			}
+			item[attrName] = &types.AttributeValueMemberL{Value: list}
+		} else if base, delta, ok := strings.Cut(rhs, " + "); ok {
+			item[attrName] = &types.AttributeValueMemberN{Value: strconv.Itoa(numVal(item[resolveName(base, names)]) + numVal(values[delta]))}
+		} else if base, delta, ok := strings.Cut(rhs, " - "); ok {
+			item[attrName] = &types.AttributeValueMemberN{Value: strconv.Itoa(numVal(item[resolveName(base, names)]) - numVal(values[delta]))}
+		} else if val, ok := values[rhs]; ok {
+			item[attrName] = val
+		}
+	}
+}
+
 // setup creates a fresh mockDB and sets it as the test client. Returns a cleanup function.
 func setup() (*mockDB, func()) {
 	db := newMockDB()
@@ -0,0 +1,177 @@
+package dynamo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rs/xid"
+)
+
+// Penalty types
+const (
+	PenaltyTime     = "time"     // Value is milliseconds added to race time
+	PenaltyPosition = "position" // Value is places dropped
+	PenaltyLaps     = "laps"     // Value is laps deducted
+	PenaltyDSQ      = "dsq"      // disqualified from the session
+	PenaltyPoints   = "points"   // Value is series points deducted
+	PenaltyGrid     = "grid"     // Value is grid places in TargetSessionID
+)
+
+// Penalty is a steward's decision against a driver, stored under
+// SESSION#sid / PENALTY#id. GSI1 lists an event's penalties in the order
+// they were issued.
+type Penalty struct {
+	PK              string `dynamodbav:"pk" json:"-"`
+	SK              string `dynamodbav:"sk" json:"-"`
+	PenaltyID       string `dynamodbav:"penaltyId" json:"penalty_id"`
+	SessionID       string `dynamodbav:"sessionId" json:"session_id"`
+	EventID         string `dynamodbav:"eventId,omitempty" json:"event_id,omitempty"`
+	TrackID         string `dynamodbav:"trackId" json:"track_id"`
+	UID             string `dynamodbav:"uid" json:"uid"`
+	DriverName      string `dynamodbav:"driverName,omitempty" json:"driver_name,omitempty"`
+	Type            string `dynamodbav:"type" json:"type"`
+	Value           int64  `dynamodbav:"value,omitempty" json:"value,omitempty"`
+	TargetSessionID string `dynamodbav:"targetSessionId,omitempty" json:"target_session_id,omitempty"`
+	ProtestID       string `dynamodbav:"protestId,omitempty" json:"protest_id,omitempty"`
+	Reason          string `dynamodbav:"reason" json:"reason"`
+	IssuedBy        string `dynamodbav:"issuedBy" json:"issued_by"`
+	IssuedByName    string `dynamodbav:"issuedByName,omitempty" json:"issued_by_name,omitempty"`
+	GSI1PK          string `dynamodbav:"gsi1pk,omitempty" json:"-"`
+	GSI1SK          string `dynamodbav:"gsi1sk,omitempty" json:"-"`
+	CreatedAt       string `dynamodbav:"createdAt" json:"created_at"`
+}
+
+// CreatePenalty records a new penalty.
+func CreatePenalty(ctx context.Context, p Penalty) (*Penalty, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	p.PenaltyID = xid.New().String()
+	p.PK = SessionPK(p.SessionID)
+	p.SK = PenaltySK(p.PenaltyID)
+	p.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	if p.EventID != "" {
+		p.GSI1PK = EventPK(p.EventID)
+		p.GSI1SK = PenaltyGSI1SK(p.CreatedAt, p.PenaltyID)
+	}
+
+	item, err := attributevalue.MarshalMap(p)
+	if err != nil {
+		return nil, fmt.Errorf("marshal penalty: %w", err)
+	}
+
+	_, err = c.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(TableName),
+		Item:      item,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("put penalty: %w", err)
+	}
+	return &p, nil
+}
+
+// GetPenalty returns a penalty, or nil if it doesn't exist.
+func GetPenalty(ctx context.Context, sessionID, penaltyID string) (*Penalty, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(TableName),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: SessionPK(sessionID)},
+			"sk": &types.AttributeValueMemberS{Value: PenaltySK(penaltyID)},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get penalty: %w", err)
+	}
+	if out.Item == nil {
+		return nil, nil
+	}
+
+	var p Penalty
+	if err := attributevalue.UnmarshalMap(out.Item, &p); err != nil {
+		return nil, fmt.Errorf("unmarshal penalty: %w", err)
+	}
+	return &p, nil
+}
+
+// DeletePenalty removes a penalty (e.g. overturned on appeal).
+func DeletePenalty(ctx context.Context, sessionID, penaltyID string) error {
+	c, err := client()
+	if err != nil {
+		return err
+	}
+
+	_, err = c.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(TableName),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: SessionPK(sessionID)},
+			"sk": &types.AttributeValueMemberS{Value: PenaltySK(penaltyID)},
+		},
+	})
+	return err
+}
+
+// ListPenaltiesForSession returns the penalties issued in a session.
+func ListPenaltiesForSession(ctx context.Context, sessionID string) ([]Penalty, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(TableName),
+		KeyConditionExpression: aws.String("pk = :pk AND begins_with(sk, :prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":     &types.AttributeValueMemberS{Value: SessionPK(sessionID)},
+			":prefix": &types.AttributeValueMemberS{Value: "PENALTY#"},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list session penalties: %w", err)
+	}
+
+	var penalties []Penalty
+	if err := attributevalue.UnmarshalListOfMaps(out.Items, &penalties); err != nil {
+		return nil, fmt.Errorf("unmarshal penalties: %w", err)
+	}
+	return penalties, nil
+}
+
+// ListPenaltiesForEvent returns every penalty issued across an event's
+// sessions, oldest first (via GSI1).
+func ListPenaltiesForEvent(ctx context.Context, eventID string) ([]Penalty, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(TableName),
+		IndexName:              aws.String("gsi1"),
+		KeyConditionExpression: aws.String("gsi1pk = :pk AND begins_with(gsi1sk, :prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":     &types.AttributeValueMemberS{Value: EventPK(eventID)},
+			":prefix": &types.AttributeValueMemberS{Value: "PENALTY#"},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list event penalties: %w", err)
+	}
+
+	var penalties []Penalty
+	if err := attributevalue.UnmarshalListOfMaps(out.Items, &penalties); err != nil {
+		return nil, fmt.Errorf("unmarshal penalties: %w", err)
+	}
+	return penalties, nil
+}
@@ -0,0 +1,55 @@
+package dynamo
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPenalties(t *testing.T) {
+	_, cleanup := setup()
+	defer cleanup()
+	ctx := context.Background()
+
+	p, err := CreatePenalty(ctx, Penalty{
+		SessionID: "sess1",
+		EventID:   "evt1",
+		UID:       "u1",
+		Type:      PenaltyTime,
+		Value:     5000,
+		Reason:    "Track limits",
+		IssuedBy:  "steward",
+	})
+	if err != nil {
+		t.Fatalf("CreatePenalty: %v", err)
+	}
+	if p.PenaltyID == "" || p.CreatedAt == "" {
+		t.Fatalf("expected ID and CreatedAt, got %+v", p)
+	}
+
+	CreatePenalty(ctx, Penalty{SessionID: "sess2", EventID: "evt1", UID: "u2", Type: PenaltyDSQ, Reason: "Underweight"})
+	CreatePenalty(ctx, Penalty{SessionID: "sess3", EventID: "evt2", UID: "u3", Type: PenaltyPoints, Value: 3})
+
+	list, err := ListPenaltiesForSession(ctx, "sess1")
+	if err != nil {
+		t.Fatalf("ListPenaltiesForSession: %v", err)
+	}
+	if len(list) != 1 || list[0].Value != 5000 {
+		t.Fatalf("session penalties = %+v", list)
+	}
+
+	list, err = ListPenaltiesForEvent(ctx, "evt1")
+	if err != nil {
+		t.Fatalf("ListPenaltiesForEvent: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("got %d event penalties, want 2", len(list))
+	}
+
+	if err := DeletePenalty(ctx, "sess1", p.PenaltyID); err != nil {
+		t.Fatalf("DeletePenalty: %v", err)
+	}
+	got, err := GetPenalty(ctx, "sess1", p.PenaltyID)
+	if err != nil || got != nil {
+		t.Fatalf("after delete got %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,145 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrPromoCodeUsedUp is returned when every use of a promo code is taken.
+var ErrPromoCodeUsedUp = errors.New("promo code used up")
+
+// Each promo code's uses are counted on a PROMOUSE item under its parent,
+// next to the registrations that used it, so a limited code can't be
+// redeemed more times than it allows.
+
+func promoUseKey(parentType, parentID, code string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"pk": &types.AttributeValueMemberS{Value: parentPK(parentType, parentID)},
+		"sk": &types.AttributeValueMemberS{Value: PromoUseSK(code)},
+	}
+}
+
+// ensurePromoUses creates a code's counter at zero if it doesn't exist yet.
+func ensurePromoUses(ctx context.Context, c DynamoDBAPI, parentType, parentID, code string) error {
+	item, err := attributevalue.MarshalMap(map[string]any{
+		"pk":        parentPK(parentType, parentID),
+		"sk":        PromoUseSK(code),
+		"code":      code,
+		"uses":      0,
+		"createdAt": time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return fmt.Errorf("marshal promo uses: %w", err)
+	}
+	_, err = c.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(TableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(pk)"),
+	})
+	var ccf *types.ConditionalCheckFailedException
+	if err != nil && !errors.As(err, &ccf) {
+		return fmt.Errorf("put promo uses: %w", err)
+	}
+	return nil
+}
+
+// RedeemPromoCode counts a use of one of a parent's promo codes. With
+// maxUses above 0 it fails with ErrPromoCodeUsedUp once they're all taken.
+func RedeemPromoCode(ctx context.Context, parentType, parentID, code string, maxUses int) error {
+	c, err := client()
+	if err != nil {
+		return err
+	}
+	if err := ensurePromoUses(ctx, c, parentType, parentID, code); err != nil {
+		return err
+	}
+
+	in := &dynamodb.UpdateItemInput{
+		TableName:        aws.String(TableName),
+		Key:              promoUseKey(parentType, parentID, code),
+		UpdateExpression: aws.String("SET uses = uses + :one"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":one": &types.AttributeValueMemberN{Value: "1"},
+		},
+	}
+	if maxUses > 0 {
+		in.ConditionExpression = aws.String("uses < :max")
+		in.ExpressionAttributeValues[":max"] = &types.AttributeValueMemberN{Value: fmt.Sprint(maxUses)}
+	}
+	_, err = c.UpdateItem(ctx, in)
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return ErrPromoCodeUsedUp
+	}
+	if err != nil {
+		return fmt.Errorf("redeem promo code: %w", err)
+	}
+	return nil
+}
+
+// ReleasePromoCode gives back a use of a promo code, when the registration
+// that used it is withdrawn or never saved.
+func ReleasePromoCode(ctx context.Context, parentType, parentID, code string) error {
+	c, err := client()
+	if err != nil {
+		return err
+	}
+
+	_, err = c.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(TableName),
+		Key:                 promoUseKey(parentType, parentID, code),
+		UpdateExpression:    aws.String("SET uses = uses - :one"),
+		ConditionExpression: aws.String("uses > :zero"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":one":  &types.AttributeValueMemberN{Value: "1"},
+			":zero": &types.AttributeValueMemberN{Value: "0"},
+		},
+	})
+	var ccf *types.ConditionalCheckFailedException
+	if err != nil && !errors.As(err, &ccf) {
+		return fmt.Errorf("release promo code: %w", err)
+	}
+	return nil
+}
+
+// PromoCodeUses returns how many times each of a parent's promo codes has
+// been used, by code. Codes nobody has used are missing.
+func PromoCodeUses(ctx context.Context, parentType, parentID string) (map[string]int, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(TableName),
+		KeyConditionExpression: aws.String("pk = :pk AND begins_with(sk, :prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":     &types.AttributeValueMemberS{Value: parentPK(parentType, parentID)},
+			":prefix": &types.AttributeValueMemberS{Value: PromoUseSK("")},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list promo uses: %w", err)
+	}
+
+	var items []struct {
+		SK   string `dynamodbav:"sk"`
+		Uses int    `dynamodbav:"uses"`
+	}
+	if err := attributevalue.UnmarshalListOfMaps(out.Items, &items); err != nil {
+		return nil, fmt.Errorf("unmarshal promo uses: %w", err)
+	}
+	uses := make(map[string]int, len(items))
+	for _, item := range items {
+		uses[strings.TrimPrefix(item.SK, PromoUseSK(""))] = item.Uses
+	}
+	return uses, nil
+}
@@ -0,0 +1,66 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRedeemPromoCode(t *testing.T) {
+	_, cleanup := setup()
+	defer cleanup()
+	ctx := context.Background()
+
+	for i := range 2 {
+		if err := RedeemPromoCode(ctx, "event", "e1", "EARLY", 2); err != nil {
+			t.Fatalf("redeem %d: %v", i+1, err)
+		}
+	}
+	if err := RedeemPromoCode(ctx, "event", "e1", "EARLY", 2); !errors.Is(err, ErrPromoCodeUsedUp) {
+		t.Fatalf("third redeem = %v, want ErrPromoCodeUsedUp", err)
+	}
+
+	// A withdrawn registration frees its use for someone else
+	if err := ReleasePromoCode(ctx, "event", "e1", "EARLY"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RedeemPromoCode(ctx, "event", "e1", "EARLY", 2); err != nil {
+		t.Fatalf("redeem after release: %v", err)
+	}
+
+	// Unlimited codes count uses too; codes are counted per parent
+	for range 3 {
+		if err := RedeemPromoCode(ctx, "event", "e1", "CLUB", 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := RedeemPromoCode(ctx, "event", "e2", "EARLY", 2); err != nil {
+		t.Fatal(err)
+	}
+
+	uses, err := PromoCodeUses(ctx, "event", "e1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uses["EARLY"] != 2 || uses["CLUB"] != 3 || len(uses) != 2 {
+		t.Errorf("PromoCodeUses = %v, want EARLY:2 CLUB:3", uses)
+	}
+}
+
+func TestReleasePromoCode_NeverBelowZero(t *testing.T) {
+	_, cleanup := setup()
+	defer cleanup()
+	ctx := context.Background()
+
+	if err := RedeemPromoCode(ctx, "series", "s1", "X", 1); err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if err := ReleasePromoCode(ctx, "series", "s1", "X"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if uses, _ := PromoCodeUses(ctx, "series", "s1"); uses["X"] != 0 {
+		t.Errorf("uses = %d, want 0", uses["X"])
+	}
+}
@@ -0,0 +1,246 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rs/xid"
+)
+
+// Movement directions
+const (
+	MovePromoted  = "promoted"
+	MoveRelegated = "relegated"
+)
+
+// DivisionMove is one driver moving between two series of a championship.
+type DivisionMove struct {
+	UID            string `dynamodbav:"uid" json:"uid"`
+	DriverName     string `dynamodbav:"driverName,omitempty" json:"driver_name,omitempty"`
+	Direction      string `dynamodbav:"direction" json:"direction"`
+	Position       int    `dynamodbav:"position,omitempty" json:"position,omitempty"` // final position in the series they leave
+	FromSeriesID   string `dynamodbav:"fromSeriesId" json:"from_series_id"`
+	FromSeriesName string `dynamodbav:"fromSeriesName,omitempty" json:"from_series_name,omitempty"`
+	ToSeriesID     string `dynamodbav:"toSeriesId" json:"to_series_id"`
+	ToSeriesName   string `dynamodbav:"toSeriesName,omitempty" json:"to_series_name,omitempty"`
+}
+
+// PromotionRun records a promotion/relegation pass over a championship's
+// divisions, stored under CHAMPIONSHIP#cid / PROMOTION#id. Protected lists
+// drivers who would have moved but kept their place. The run is saved before
+// any driver moves; Pending stays set until every move is applied, and
+// Applied lists the drivers moved so far, so a run that stops part-way can
+// be picked up again.
+type PromotionRun struct {
+	PK             string         `dynamodbav:"pk" json:"-"`
+	SK             string         `dynamodbav:"sk" json:"-"`
+	RunID          string         `dynamodbav:"runId" json:"run_id"`
+	ChampionshipID string         `dynamodbav:"championshipId" json:"championship_id"`
+	Promote        int            `dynamodbav:"promote" json:"promote"`
+	Relegate       int            `dynamodbav:"relegate" json:"relegate"`
+	Moves          []DivisionMove `dynamodbav:"moves" json:"moves"`
+	Protected      []DivisionMove `dynamodbav:"protected,omitempty" json:"protected,omitempty"`
+	LastRounds     map[string]int `dynamodbav:"lastRounds,omitempty" json:"last_rounds,omitempty"` // last round each series had scored, by series ID
+	Applied        []string       `dynamodbav:"applied,omitempty" json:"applied,omitempty"`        // UIDs of the drivers moved so far
+	Pending        bool           `dynamodbav:"pending,omitempty" json:"pending,omitempty"`
+	RunBy          string         `dynamodbav:"runBy" json:"run_by"`
+	CreatedAt      string         `dynamodbav:"createdAt" json:"created_at"`
+}
+
+// CreatePromotionRun stores a promotion/relegation pass.
+func CreatePromotionRun(ctx context.Context, run PromotionRun) (*PromotionRun, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	run.RunID = xid.New().String()
+	run.PK = ChampionshipPK(run.ChampionshipID)
+	run.SK = PromotionSK(run.RunID)
+	run.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+
+	item, err := attributevalue.MarshalMap(run)
+	if err != nil {
+		return nil, fmt.Errorf("marshal promotion run: %w", err)
+	}
+
+	_, err = c.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(TableName),
+		Item:      item,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("put promotion run: %w", err)
+	}
+	return &run, nil
+}
+
+// ListPromotionRuns returns a championship's promotion history, oldest first.
+func ListPromotionRuns(ctx context.Context, championshipID string) ([]PromotionRun, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(TableName),
+		KeyConditionExpression: aws.String("pk = :pk AND begins_with(sk, :prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":     &types.AttributeValueMemberS{Value: ChampionshipPK(championshipID)},
+			":prefix": &types.AttributeValueMemberS{Value: "PROMOTION#"},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list promotion runs: %w", err)
+	}
+
+	var runs []PromotionRun
+	if err := attributevalue.UnmarshalListOfMaps(out.Items, &runs); err != nil {
+		return nil, fmt.Errorf("unmarshal promotion runs: %w", err)
+	}
+	return runs, nil
+}
+
+// ErrMoveApplied is returned when a promotion run has already moved a driver.
+var ErrMoveApplied = errors.New("move already applied")
+
+func seriesDriverKey(seriesID, uid string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"pk": &types.AttributeValueMemberS{Value: SeriesPK(seriesID)},
+		"sk": &types.AttributeValueMemberS{Value: SeriesDriverSK(uid)},
+	}
+}
+
+// ApplyDivisionMove makes one move of a promotion run in a single
+// transaction: the driver is enrolled in the series they move to (or their
+// enrollment there is marked as moved in), their old enrollment is marked as
+// moved out, and the run records the driver as applied. movedAfterRound is
+// the last round the new series had scored. It returns ErrMoveApplied when
+// the run has already moved the driver.
+func ApplyDivisionMove(ctx context.Context, run *PromotionRun, m DivisionMove, movedAfterRound int) error {
+	c, err := client()
+	if err != nil {
+		return err
+	}
+
+	existing, err := GetSeriesDriver(ctx, m.ToSeriesID, m.UID)
+	if err != nil {
+		return err
+	}
+	var to types.TransactWriteItem
+	if existing != nil {
+		expr, names, values, err := BuildUpdateExpression(map[string]any{
+			"movedFrom":       m.FromSeriesID,
+			"movedTo":         nil,
+			"movement":        m.Direction,
+			"movedAfterRound": movedAfterRound,
+		})
+		if err != nil {
+			return err
+		}
+		to.Update = &types.Update{
+			TableName:                 aws.String(TableName),
+			Key:                       seriesDriverKey(m.ToSeriesID, m.UID),
+			UpdateExpression:          aws.String(expr),
+			ConditionExpression:       aws.String("attribute_exists(pk)"),
+			ExpressionAttributeNames:  names,
+			ExpressionAttributeValues: values,
+		}
+	} else {
+		item, err := attributevalue.MarshalMap(SeriesDriver{
+			PK:              SeriesPK(m.ToSeriesID),
+			SK:              SeriesDriverSK(m.UID),
+			SeriesID:        m.ToSeriesID,
+			UID:             m.UID,
+			DriverName:      m.DriverName,
+			MovedFrom:       m.FromSeriesID,
+			Movement:        m.Direction,
+			MovedAfterRound: movedAfterRound,
+			CreatedAt:       time.Now().UTC().Format(time.RFC3339),
+		})
+		if err != nil {
+			return fmt.Errorf("marshal series driver: %w", err)
+		}
+		to.Put = &types.Put{
+			TableName:           aws.String(TableName),
+			Item:                item,
+			ConditionExpression: aws.String("attribute_not_exists(pk)"),
+		}
+	}
+
+	expr, names, values, err := BuildUpdateExpression(map[string]any{
+		"seriesId":   m.FromSeriesID,
+		"uid":        m.UID,
+		"driverName": m.DriverName,
+		"movedTo":    m.ToSeriesID,
+		"movement":   m.Direction,
+	})
+	if err != nil {
+		return err
+	}
+	from := &types.Update{
+		TableName:                 aws.String(TableName),
+		Key:                       seriesDriverKey(m.FromSeriesID, m.UID),
+		UpdateExpression:          aws.String(expr),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	}
+
+	_, err = c.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			to,
+			{Update: from},
+			{Update: &types.Update{
+				TableName: aws.String(TableName),
+				Key: map[string]types.AttributeValue{
+					"pk": &types.AttributeValueMemberS{Value: ChampionshipPK(run.ChampionshipID)},
+					"sk": &types.AttributeValueMemberS{Value: PromotionSK(run.RunID)},
+				},
+				UpdateExpression:         aws.String("SET #ap = list_append(if_not_exists(#ap, :empty), :uids)"),
+				ConditionExpression:      aws.String("NOT contains(#ap, :uid)"),
+				ExpressionAttributeNames: map[string]string{"#ap": "applied"},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":empty": &types.AttributeValueMemberL{Value: []types.AttributeValue{}},
+					":uids":  &types.AttributeValueMemberL{Value: []types.AttributeValue{&types.AttributeValueMemberS{Value: m.UID}}},
+					":uid":   &types.AttributeValueMemberS{Value: m.UID},
+				},
+			}},
+		},
+	})
+	if failed := cancelled(err); len(failed) > 2 && failed[2] {
+		return ErrMoveApplied
+	}
+	if err != nil {
+		return fmt.Errorf("apply division move: %w", err)
+	}
+	run.Applied = append(run.Applied, m.UID)
+	return nil
+}
+
+// CompletePromotionRun clears a run's Pending flag once every move is made.
+func CompletePromotionRun(ctx context.Context, run *PromotionRun) error {
+	c, err := client()
+	if err != nil {
+		return err
+	}
+
+	_, err = c.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(TableName),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: ChampionshipPK(run.ChampionshipID)},
+			"sk": &types.AttributeValueMemberS{Value: PromotionSK(run.RunID)},
+		},
+		UpdateExpression:          aws.String("SET pending = :f"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":f": &types.AttributeValueMemberBOOL{Value: false}},
+	})
+	if err != nil {
+		return fmt.Errorf("complete promotion run: %w", err)
+	}
+	run.Pending = false
+	return nil
+}
@@ -0,0 +1,61 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestApplyDivisionMove(t *testing.T) {
+	_, cleanup := setup()
+	defer cleanup()
+	ctx := context.Background()
+
+	EnrollDriver(ctx, SeriesDriver{SeriesID: "pro", UID: "a", DriverName: "Al"})
+	EnrollDriver(ctx, SeriesDriver{SeriesID: "am", UID: "b", DriverName: "Bo"})
+	EnrollDriver(ctx, SeriesDriver{SeriesID: "pro", UID: "b", DriverName: "Bo", MovedTo: "am"})
+
+	moves := []DivisionMove{
+		{UID: "a", DriverName: "Al", Direction: MoveRelegated, FromSeriesID: "pro", ToSeriesID: "am"},
+		{UID: "b", DriverName: "Bo", Direction: MovePromoted, FromSeriesID: "am", ToSeriesID: "pro"},
+	}
+	run, err := CreatePromotionRun(ctx, PromotionRun{ChampionshipID: "c1", Moves: moves, Pending: true})
+	if err != nil {
+		t.Fatalf("CreatePromotionRun: %v", err)
+	}
+
+	// a is moved, then the run stops
+	if err := ApplyDivisionMove(ctx, run, moves[0], 3); err != nil {
+		t.Fatalf("ApplyDivisionMove(a): %v", err)
+	}
+	if sd, _ := GetSeriesDriver(ctx, "am", "a"); sd == nil || sd.MovedFrom != "pro" || sd.MovedAfterRound != 3 {
+		t.Errorf("a in am = %+v, want moved in from pro after round 3", sd)
+	}
+	if sd, _ := GetSeriesDriver(ctx, "pro", "a"); sd == nil || sd.MovedTo != "am" {
+		t.Errorf("a in pro = %+v, want moved to am", sd)
+	}
+
+	runs, _ := ListPromotionRuns(ctx, "c1")
+	if len(runs) != 1 || !runs[0].Pending || len(runs[0].Applied) != 1 || runs[0].Applied[0] != "a" {
+		t.Fatalf("runs = %+v, want one pending run with a applied", runs)
+	}
+
+	// Picking the run up again skips a and finishes with b, who goes back to
+	// an enrollment they had
+	resumed := runs[0]
+	if err := ApplyDivisionMove(ctx, &resumed, moves[0], 3); !errors.Is(err, ErrMoveApplied) {
+		t.Errorf("second move of a err = %v, want ErrMoveApplied", err)
+	}
+	if err := ApplyDivisionMove(ctx, &resumed, moves[1], 5); err != nil {
+		t.Fatalf("ApplyDivisionMove(b): %v", err)
+	}
+	if sd, _ := GetSeriesDriver(ctx, "pro", "b"); sd == nil || sd.MovedFrom != "am" || sd.MovedTo != "" {
+		t.Errorf("b in pro = %+v, want moved back in from am", sd)
+	}
+	if err := CompletePromotionRun(ctx, &resumed); err != nil {
+		t.Fatalf("CompletePromotionRun: %v", err)
+	}
+	if runs, _ := ListPromotionRuns(ctx, "c1"); len(runs) != 1 || runs[0].Pending || len(runs[0].Applied) != 2 {
+		t.Errorf("runs = %+v, want the run complete with both applied", runs)
+	}
+}
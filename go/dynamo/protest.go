@@ -0,0 +1,181 @@
+package dynamo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rs/xid"
+)
+
+// Protest statuses
+const (
+	ProtestSubmitted   = "submitted"
+	ProtestUnderReview = "under_review"
+	ProtestUpheld      = "upheld"
+	ProtestDismissed   = "dismissed"
+	ProtestWithdrawn   = "withdrawn"
+)
+
+// ProtestEvidence is a file attached to a protest, stored in the raw uploads
+// bucket.
+type ProtestEvidence struct {
+	Key         string `dynamodbav:"key" json:"key"`
+	Filename    string `dynamodbav:"filename" json:"filename"`
+	ContentType string `dynamodbav:"contentType,omitempty" json:"content_type,omitempty"`
+	UploadedBy  string `dynamodbav:"uploadedBy" json:"uploaded_by"`
+	UploadedAt  string `dynamodbav:"uploadedAt" json:"uploaded_at"`
+}
+
+// Protest is a driver's complaint against another driver or a result, stored
+// under SESSION#sid / PROTEST#id.
+type Protest struct {
+	PK          string `dynamodbav:"pk" json:"-"`
+	SK          string `dynamodbav:"sk" json:"-"`
+	ProtestID   string `dynamodbav:"protestId" json:"protest_id"`
+	SessionID   string `dynamodbav:"sessionId" json:"session_id"`
+	EventID     string `dynamodbav:"eventId,omitempty" json:"event_id,omitempty"`
+	TrackID     string `dynamodbav:"trackId" json:"track_id"`
+	FiledBy     string `dynamodbav:"filedBy" json:"filed_by"`
+	FiledByName string `dynamodbav:"filedByName,omitempty" json:"filed_by_name,omitempty"`
+	Kind        string `dynamodbav:"kind" json:"kind"` // driver, result
+	AgainstUID  string `dynamodbav:"againstUid,omitempty" json:"against_uid,omitempty"`
+	AgainstName string `dynamodbav:"againstName,omitempty" json:"against_name,omitempty"`
+	Description string `dynamodbav:"description" json:"description"`
+	LapNo       int    `dynamodbav:"lapNo,omitempty" json:"lap_no,omitempty"`
+	TimeRef     string `dynamodbav:"timeRef,omitempty" json:"time_ref,omitempty"` // e.g. "12:34" into the session
+
+	Evidence []ProtestEvidence `dynamodbav:"evidence,omitempty" json:"evidence,omitempty"`
+
+	Status     string   `dynamodbav:"status" json:"status"`
+	Decision   string   `dynamodbav:"decision,omitempty" json:"decision,omitempty"`
+	DecidedBy  string   `dynamodbav:"decidedBy,omitempty" json:"decided_by,omitempty"`
+	DecidedAt  string   `dynamodbav:"decidedAt,omitempty" json:"decided_at,omitempty"`
+	PenaltyIDs []string `dynamodbav:"penaltyIds,omitempty" json:"penalty_ids,omitempty"`
+
+	CreatedAt string `dynamodbav:"createdAt" json:"created_at"`
+	UpdatedAt string `dynamodbav:"updatedAt,omitempty" json:"updated_at,omitempty"`
+}
+
+// Involves reports whether uid filed the protest or is the driver protested.
+func (p *Protest) Involves(uid string) bool {
+	return uid != "" && (p.FiledBy == uid || p.AgainstUID == uid)
+}
+
+// Decided reports whether officials have closed the protest.
+func (p *Protest) Decided() bool {
+	return p.Status == ProtestUpheld || p.Status == ProtestDismissed || p.Status == ProtestWithdrawn
+}
+
+func CreateProtest(ctx context.Context, p Protest) (*Protest, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	p.ProtestID = xid.New().String()
+	p.PK = SessionPK(p.SessionID)
+	p.SK = ProtestSK(p.ProtestID)
+	p.Status = ProtestSubmitted
+	p.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+
+	item, err := attributevalue.MarshalMap(p)
+	if err != nil {
+		return nil, fmt.Errorf("marshal protest: %w", err)
+	}
+
+	_, err = c.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(TableName),
+		Item:      item,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("put protest: %w", err)
+	}
+	return &p, nil
+}
+
+func GetProtest(ctx context.Context, sessionID, protestID string) (*Protest, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(TableName),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: SessionPK(sessionID)},
+			"sk": &types.AttributeValueMemberS{Value: ProtestSK(protestID)},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get protest: %w", err)
+	}
+	if out.Item == nil {
+		return nil, nil
+	}
+
+	var p Protest
+	if err := attributevalue.UnmarshalMap(out.Item, &p); err != nil {
+		return nil, fmt.Errorf("unmarshal protest: %w", err)
+	}
+	return &p, nil
+}
+
+func UpdateProtest(ctx context.Context, sessionID, protestID string, fields map[string]any) error {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	c, err := client()
+	if err != nil {
+		return err
+	}
+
+	fields["updatedAt"] = time.Now().UTC().Format(time.RFC3339)
+	expr, names, values, err := BuildUpdateExpression(fields)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(TableName),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: SessionPK(sessionID)},
+			"sk": &types.AttributeValueMemberS{Value: ProtestSK(protestID)},
+		},
+		UpdateExpression:          aws.String(expr),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+	return err
+}
+
+// ListProtestsForSession returns every protest filed in a session.
+func ListProtestsForSession(ctx context.Context, sessionID string) ([]Protest, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(TableName),
+		KeyConditionExpression: aws.String("pk = :pk AND begins_with(sk, :prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":     &types.AttributeValueMemberS{Value: SessionPK(sessionID)},
+			":prefix": &types.AttributeValueMemberS{Value: "PROTEST#"},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list protests: %w", err)
+	}
+
+	var protests []Protest
+	if err := attributevalue.UnmarshalListOfMaps(out.Items, &protests); err != nil {
+		return nil, fmt.Errorf("unmarshal protests: %w", err)
+	}
+	return protests, nil
+}
@@ -0,0 +1,55 @@
+package dynamo
+
+import (
+	"context"
+	"testing"
+)
+
+func TestProtests(t *testing.T) {
+	_, cleanup := setup()
+	defer cleanup()
+	ctx := context.Background()
+
+	p, err := CreateProtest(ctx, Protest{
+		SessionID:   "sess1",
+		FiledBy:     "u1",
+		Kind:        "driver",
+		AgainstUID:  "u2",
+		Description: "Blocked on the final lap",
+		LapNo:       12,
+	})
+	if err != nil {
+		t.Fatalf("CreateProtest: %v", err)
+	}
+	if p.Status != ProtestSubmitted {
+		t.Errorf("status = %q, want %q", p.Status, ProtestSubmitted)
+	}
+	if !p.Involves("u1") || !p.Involves("u2") || p.Involves("u3") {
+		t.Error("Involves should match the filer and the accused only")
+	}
+
+	err = UpdateProtest(ctx, "sess1", p.ProtestID, map[string]any{
+		"status":     ProtestUpheld,
+		"penaltyIds": []string{"pen1"},
+	})
+	if err != nil {
+		t.Fatalf("UpdateProtest: %v", err)
+	}
+
+	got, err := GetProtest(ctx, "sess1", p.ProtestID)
+	if err != nil || got == nil {
+		t.Fatalf("GetProtest: %v, %v", got, err)
+	}
+	if !got.Decided() || len(got.PenaltyIDs) != 1 || got.UpdatedAt == "" {
+		t.Errorf("after update = %+v", got)
+	}
+
+	CreateProtest(ctx, Protest{SessionID: "sess1", FiledBy: "u3", Kind: "result", Description: "Wrong lap count"})
+	list, err := ListProtestsForSession(ctx, "sess1")
+	if err != nil {
+		t.Fatalf("ListProtestsForSession: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("got %d protests, want 2", len(list))
+	}
+}
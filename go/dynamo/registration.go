@@ -18,6 +18,67 @@ type RegistrationSettings struct {
 	PriceCents           int    `dynamodbav:"priceCents,omitempty" json:"price_cents,omitempty"`
 	Currency             string `dynamodbav:"currency,omitempty" json:"currency,omitempty"`
 	RegistrationDeadline string `dynamodbav:"registrationDeadline,omitempty" json:"registration_deadline,omitempty"`
+	// WaitlistOfferHours is how long a driver offered a spot off the
+	// waitlist has to accept it; 0 confirms them straight away.
+	WaitlistOfferHours int `dynamodbav:"waitlistOfferHours,omitempty" json:"waitlist_offer_hours,omitempty"`
+	// MinAge is the youngest a driver may be on the day to check in; 0 has
+	// no limit.
+	MinAge int `dynamodbav:"minAge,omitempty" json:"min_age,omitempty"`
+	// Questions are asked on the registration form, in order.
+	Questions []RegistrationQuestion `dynamodbav:"questions,omitempty" json:"questions,omitempty"`
+
+	// EarlyBird prices replace PriceCents until their dates.
+	EarlyBird []PriceTier `dynamodbav:"earlyBird,omitempty" json:"early_bird,omitempty"`
+	// MemberPriceCents is what the track's members pay, when it's lower; 0
+	// has no member price.
+	MemberPriceCents int `dynamodbav:"memberPriceCents,omitempty" json:"member_price_cents,omitempty"`
+	// SeasonPass, on a series, lets its confirmed drivers enter each of its
+	// events free.
+	SeasonPass bool `dynamodbav:"seasonPass,omitempty" json:"season_pass,omitempty"`
+	// PromoCodes are kept off the public API; admins manage them through
+	// their own endpoints.
+	PromoCodes []PromoCode `dynamodbav:"promoCodes,omitempty" json:"-"`
+}
+
+// PriceTier is an early-bird price for registering before Until.
+type PriceTier struct {
+	Until      string `dynamodbav:"until" json:"until"` // RFC 3339
+	PriceCents int    `dynamodbav:"priceCents" json:"price_cents"`
+}
+
+// PromoCode takes a percentage or a fixed amount off the entry fee.
+type PromoCode struct {
+	Code           string `dynamodbav:"code" json:"code"` // upper case
+	PercentOff     int    `dynamodbav:"percentOff,omitempty" json:"percent_off,omitempty"`
+	AmountOffCents int    `dynamodbav:"amountOffCents,omitempty" json:"amount_off_cents,omitempty"`
+	MaxUses        int    `dynamodbav:"maxUses,omitempty" json:"max_uses,omitempty"` // 0 is unlimited
+	ExpiresAt      string `dynamodbav:"expiresAt,omitempty" json:"expires_at,omitempty"`
+}
+
+// Registration question types
+const (
+	QuestionText     = "text"
+	QuestionNumber   = "number"
+	QuestionEmail    = "email"
+	QuestionPhone    = "phone"
+	QuestionDate     = "date"     // YYYY-MM-DD
+	QuestionSelect   = "select"   // one of Options
+	QuestionCheckbox = "checkbox" // e.g. waiver acceptance; required means it must be ticked
+)
+
+// RegistrationQuestion is one field on a registration form. Answers are
+// keyed by ID.
+type RegistrationQuestion struct {
+	ID        string   `dynamodbav:"id" json:"id"`
+	Label     string   `dynamodbav:"label" json:"label"`
+	Type      string   `dynamodbav:"type" json:"type"`
+	Required  bool     `dynamodbav:"required,omitempty" json:"required,omitempty"`
+	Help      string   `dynamodbav:"help,omitempty" json:"help,omitempty"`
+	Options   []string `dynamodbav:"options,omitempty" json:"options,omitempty"`
+	MaxLength int      `dynamodbav:"maxLength,omitempty" json:"max_length,omitempty"`
+	Pattern   string   `dynamodbav:"pattern,omitempty" json:"pattern,omitempty"` // regexp a text answer must match in full
+	Min       *float64 `dynamodbav:"min,omitempty" json:"min,omitempty"`
+	Max       *float64 `dynamodbav:"max,omitempty" json:"max,omitempty"`
 }
 
 type ScoringConfig struct {
@@ -25,21 +86,53 @@ type ScoringConfig struct {
 	PointsScheme []int  `dynamodbav:"pointsScheme,omitempty" json:"points_scheme,omitempty"`
 	DropRounds   int    `dynamodbav:"dropRounds,omitempty" json:"drop_rounds,omitempty"`
 	Tiebreaker   string `dynamodbav:"tiebreaker,omitempty" json:"tiebreaker,omitempty"`
+	// TeamCountBest is how many of a team's drivers score for it each round
+	// (0 counts everyone).
+	TeamCountBest int `dynamodbav:"teamCountBest,omitempty" json:"team_count_best,omitempty"`
+	// ScoringSession is the session type whose results score each round.
+	// Empty scores the round's finals, or its heats and races when it has
+	// no final.
+	ScoringSession string `dynamodbav:"scoringSession,omitempty" json:"scoring_session,omitempty"`
 }
 
 type Registration struct {
-	PK         string `dynamodbav:"pk" json:"-"`
-	SK         string `dynamodbav:"sk" json:"-"`
-	ParentType string `dynamodbav:"parentType" json:"parent_type"`
-	ParentID   string `dynamodbav:"parentId" json:"parent_id"`
-	TrackID    string `dynamodbav:"trackId" json:"track_id"`
-	UID        string `dynamodbav:"uid" json:"uid"`
-	Email      string `dynamodbav:"email,omitempty" json:"email,omitempty"`
-	DriverName string `dynamodbav:"driverName" json:"driver_name"`
-	Status     string `dynamodbav:"status" json:"status"`
-	Paid       bool   `dynamodbav:"paid,omitempty" json:"paid,omitempty"`
-	PriceCents int    `dynamodbav:"priceCents,omitempty" json:"price_cents,omitempty"`
-	InvitedBy  string `dynamodbav:"invitedBy,omitempty" json:"invited_by,omitempty"`
+	PK         string  `dynamodbav:"pk" json:"-"`
+	SK         string  `dynamodbav:"sk" json:"-"`
+	ParentType string  `dynamodbav:"parentType" json:"parent_type"`
+	ParentID   string  `dynamodbav:"parentId" json:"parent_id"`
+	TrackID    string  `dynamodbav:"trackId" json:"track_id"`
+	UID        string  `dynamodbav:"uid" json:"uid"`
+	Email      string  `dynamodbav:"email,omitempty" json:"email,omitempty"`
+	DriverName string  `dynamodbav:"driverName" json:"driver_name"`
+	ClassID    string  `dynamodbav:"classId,omitempty" json:"class_id,omitempty"`
+	TeamID     string  `dynamodbav:"teamId,omitempty" json:"team_id,omitempty"`
+	TeamName   string  `dynamodbav:"teamName,omitempty" json:"team_name,omitempty"`
+	WeightKg   float64 `dynamodbav:"weightKg,omitempty" json:"weight_kg,omitempty"` // driver in race gear
+	KartNumber string  `dynamodbav:"kartNumber,omitempty" json:"kart_number,omitempty"`
+	// Answers to the parent's registration questions, by question ID
+	Answers map[string]string `dynamodbav:"answers,omitempty" json:"answers,omitempty"`
+	Status  string            `dynamodbav:"status" json:"status"`
+	// OfferExpiresAt is when an offered spot goes to the next driver.
+	OfferExpiresAt string `dynamodbav:"offerExpiresAt,omitempty" json:"offer_expires_at,omitempty"`
+	Paid           bool   `dynamodbav:"paid,omitempty" json:"paid,omitempty"`
+	PriceCents     int    `dynamodbav:"priceCents,omitempty" json:"price_cents,omitempty"`
+	InvitedBy      string `dynamodbav:"invitedBy,omitempty" json:"invited_by,omitempty"`
+	// ViaSeries is set on a round's registration made by the driver's
+	// registration for the series it belongs to.
+	ViaSeries string `dynamodbav:"viaSeries,omitempty" json:"via_series,omitempty"`
+	// PriceRule is what set PriceCents when the driver registered: standard,
+	// early_bird, member or season_pass. Registrations without one pay the
+	// parent's price.
+	PriceRule string `dynamodbav:"priceRule,omitempty" json:"price_rule,omitempty"`
+	PromoCode string `dynamodbav:"promoCode,omitempty" json:"promo_code,omitempty"`
+
+	// Set when the entry fee is taken online
+	PaymentProvider string `dynamodbav:"paymentProvider,omitempty" json:"payment_provider,omitempty"`
+	CheckoutID      string `dynamodbav:"checkoutId,omitempty" json:"-"`
+	CheckoutURL     string `dynamodbav:"checkoutUrl,omitempty" json:"checkout_url,omitempty"` // hosted page the driver pays on
+	PaymentID       string `dynamodbav:"paymentId,omitempty" json:"payment_id,omitempty"`
+	RefundID        string `dynamodbav:"refundId,omitempty" json:"refund_id,omitempty"`
+	RefundedAt      string `dynamodbav:"refundedAt,omitempty" json:"refunded_at,omitempty"`
 
 	Standings map[string]any `dynamodbav:"standings,omitempty" json:"standings,omitempty"`
 
@@ -148,27 +241,6 @@ func ListRegistrations(ctx context.Context, parentType, parentID string) ([]Regi
 	return regs, nil
 }
 
-func CountRegistrations(ctx context.Context, parentType, parentID string) (int, error) {
-	c, err := client()
-	if err != nil {
-		return 0, err
-	}
-
-	out, err := c.Query(ctx, &dynamodb.QueryInput{
-		TableName:              aws.String(TableName),
-		KeyConditionExpression: aws.String("pk = :pk AND begins_with(sk, :prefix)"),
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":pk":     &types.AttributeValueMemberS{Value: parentPK(parentType, parentID)},
-			":prefix": &types.AttributeValueMemberS{Value: "REG#"},
-		},
-		Select: types.SelectCount,
-	})
-	if err != nil {
-		return 0, fmt.Errorf("count registrations: %w", err)
-	}
-	return int(out.Count), nil
-}
-
 func UpdateRegistration(ctx context.Context, parentType, parentID, uid string, fields map[string]any) error {
 	if len(fields) == 0 {
 		return nil
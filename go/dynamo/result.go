@@ -23,7 +23,22 @@ type Result struct {
 	KartID       string `dynamodbav:"kartId,omitempty" json:"kart_id,omitempty"`
 	GridPosition int    `dynamodbav:"gridPosition,omitempty" json:"grid_position,omitempty"`
 	Penalties    string `dynamodbav:"penalties,omitempty" json:"penalties,omitempty"`
-	CreatedAt    string `dynamodbav:"createdAt" json:"created_at"`
+	Status       string `dynamodbav:"status,omitempty" json:"status,omitempty"` // dnf, dns
+
+	// As classified before penalties; Position is the result after them.
+	FinishPosition int   `dynamodbav:"finishPosition,omitempty" json:"finish_position,omitempty"`
+	Laps           int   `dynamodbav:"laps,omitempty" json:"laps,omitempty"`
+	TotalMs        int64 `dynamodbav:"totalMs,omitempty" json:"total_ms,omitempty"`
+
+	// Applied penalties, recomputed from the session's penalty items
+	TimePenaltyMs   int64 `dynamodbav:"timePenaltyMs,omitempty" json:"time_penalty_ms,omitempty"`
+	LapsDeducted    int   `dynamodbav:"lapsDeducted,omitempty" json:"laps_deducted,omitempty"`
+	PositionDrop    int   `dynamodbav:"positionDrop,omitempty" json:"position_drop,omitempty"`
+	PointsDeduction int   `dynamodbav:"pointsDeduction,omitempty" json:"points_deduction,omitempty"`
+	GridPenalty     int   `dynamodbav:"gridPenalty,omitempty" json:"grid_penalty,omitempty"`
+	Disqualified    bool  `dynamodbav:"disqualified,omitempty" json:"disqualified,omitempty"`
+
+	CreatedAt string `dynamodbav:"createdAt" json:"created_at"`
 }
 
 // PutResult creates or overwrites a result for a driver in a session.
@@ -2,6 +2,7 @@ package dynamo
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -22,6 +23,11 @@ type Series struct {
 	Description    string `dynamodbav:"description,omitempty" json:"description,omitempty"`
 	Status         string `dynamodbav:"status,omitempty" json:"status,omitempty"`
 	Rules          string `dynamodbav:"rules,omitempty" json:"rules,omitempty"`
+	Tier           int    `dynamodbav:"tier,omitempty" json:"tier,omitempty"`        // division rank within the championship, 1 is the top
+	ClassID        string `dynamodbav:"classId,omitempty" json:"class_id,omitempty"` // kart class, for championship class tables
+	// AutoRegisterEvents registers drivers for every upcoming round once
+	// their series registration is confirmed.
+	AutoRegisterEvents bool `dynamodbav:"autoRegisterEvents,omitempty" json:"auto_register_events,omitempty"`
 
 	RegistrationSettings `dynamodbav:",omitempty"`
 	ScoringConfig        `dynamodbav:",omitempty"`
@@ -39,6 +45,8 @@ type SeriesEvent struct {
 	RoundNumber int    `dynamodbav:"roundNumber" json:"round_number"`
 	EventName   string `dynamodbav:"eventName,omitempty" json:"event_name,omitempty"`
 	StartTime   string `dynamodbav:"startTime,omitempty" json:"start_time,omitempty"`
+	GSI1PK      string `dynamodbav:"gsi1pk,omitempty" json:"-"`
+	GSI1SK      string `dynamodbav:"gsi1sk,omitempty" json:"-"`
 	CreatedAt   string `dynamodbav:"createdAt" json:"created_at"`
 }
 
@@ -50,10 +58,26 @@ type SeriesDriver struct {
 	DriverName          string `dynamodbav:"driverName" json:"driver_name"`
 	Seeded              bool   `dynamodbav:"seeded" json:"seeded"`
 	RelegationProtected bool   `dynamodbav:"relegationProtected" json:"relegation_protected"`
+	TeamID              string `dynamodbav:"teamId,omitempty" json:"team_id,omitempty"`
+	TeamName            string `dynamodbav:"teamName,omitempty" json:"team_name,omitempty"`
+	Chassis             string `dynamodbav:"chassis,omitempty" json:"chassis,omitempty"` // chassis manufacturer
 	TotalPoints         int    `dynamodbav:"totalPoints,omitempty" json:"total_points,omitempty"`
 	WeeklyScores        string `dynamodbav:"weeklyScores,omitempty" json:"weekly_scores,omitempty"`
 	DroppedRound        int    `dynamodbav:"droppedRound,omitempty" json:"dropped_round,omitempty"`
-	CreatedAt           string `dynamodbav:"createdAt" json:"created_at"`
+	DroppedRounds       []int  `dynamodbav:"droppedRounds,omitempty" json:"dropped_rounds,omitempty"`
+	Position            int    `dynamodbav:"position,omitempty" json:"position,omitempty"`
+	Wins                int    `dynamodbav:"wins,omitempty" json:"wins,omitempty"`
+	TotalTimeMs         int64  `dynamodbav:"totalTimeMs,omitempty" json:"total_time_ms,omitempty"`
+	MovedFrom           string `dynamodbav:"movedFrom,omitempty" json:"moved_from,omitempty"` // series the driver was promoted or relegated from
+	MovedTo             string `dynamodbav:"movedTo,omitempty" json:"moved_to,omitempty"`     // series the driver has since moved to
+	Movement            string `dynamodbav:"movement,omitempty" json:"movement,omitempty"`    // promoted, relegated
+	// MovedAfterRound is the last round the series had scored when the
+	// driver moved in; they have no standing here until they race a later one.
+	MovedAfterRound int `dynamodbav:"movedAfterRound,omitempty" json:"moved_after_round,omitempty"`
+	// ViaRegistration is set when confirming the driver's series
+	// registration enrolled them, rather than an admin.
+	ViaRegistration bool   `dynamodbav:"viaRegistration,omitempty" json:"via_registration,omitempty"`
+	CreatedAt       string `dynamodbav:"createdAt" json:"created_at"`
 }
 
 func CreateSeries(ctx context.Context, s Series) (*Series, error) {
@@ -194,6 +218,8 @@ func AddEventToSeries(ctx context.Context, se SeriesEvent) (*SeriesEvent, error)
 
 	se.PK = SeriesPK(se.SeriesID)
 	se.SK = SeriesEventSK(se.EventID)
+	se.GSI1PK = EventPK(se.EventID)
+	se.GSI1SK = SeriesPK(se.SeriesID)
 	se.CreatedAt = time.Now().UTC().Format(time.RFC3339)
 
 	item, err := attributevalue.MarshalMap(se)
@@ -254,6 +280,93 @@ func ListSeriesEvents(ctx context.Context, seriesID string) ([]SeriesEvent, erro
 	return events, nil
 }
 
+// ListSeriesForEvent returns the series links an event belongs to (via GSI1).
+func ListSeriesForEvent(ctx context.Context, eventID string) ([]SeriesEvent, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(TableName),
+		IndexName:              aws.String("gsi1"),
+		KeyConditionExpression: aws.String("gsi1pk = :pk AND begins_with(gsi1sk, :prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":     &types.AttributeValueMemberS{Value: EventPK(eventID)},
+			":prefix": &types.AttributeValueMemberS{Value: "SERIES#"},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list series for event: %w", err)
+	}
+
+	var links []SeriesEvent
+	if err := attributevalue.UnmarshalListOfMaps(out.Items, &links); err != nil {
+		return nil, fmt.Errorf("unmarshal series events: %w", err)
+	}
+	return links, nil
+}
+
+// BackfillSeriesEventKeys adds the EVENT# / SERIES# GSI1 keys that
+// ListSeriesForEvent queries to series event links created before they were
+// written. Links that have them are left alone. Returns how many were updated.
+func BackfillSeriesEventKeys(ctx context.Context) (int, error) {
+	c, err := client()
+	if err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	var start map[string]types.AttributeValue
+	for {
+		out, err := c.Scan(ctx, &dynamodb.ScanInput{
+			TableName:        aws.String(TableName),
+			FilterExpression: aws.String("begins_with(pk, :prefix) AND begins_with(sk, :skPrefix) AND attribute_not_exists(gsi1pk)"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":prefix":   &types.AttributeValueMemberS{Value: "SERIES#"},
+				":skPrefix": &types.AttributeValueMemberS{Value: "EVENT#"},
+			},
+			ExclusiveStartKey: start,
+		})
+		if err != nil {
+			return updated, fmt.Errorf("scan series events: %w", err)
+		}
+
+		var links []SeriesEvent
+		if err := attributevalue.UnmarshalListOfMaps(out.Items, &links); err != nil {
+			return updated, fmt.Errorf("unmarshal series events: %w", err)
+		}
+		for _, se := range links {
+			_, err := c.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+				TableName: aws.String(TableName),
+				Key: map[string]types.AttributeValue{
+					"pk": &types.AttributeValueMemberS{Value: se.PK},
+					"sk": &types.AttributeValueMemberS{Value: se.SK},
+				},
+				ConditionExpression: aws.String("attribute_exists(pk)"),
+				UpdateExpression:    aws.String("SET gsi1pk = :gpk, gsi1sk = :gsk"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":gpk": &types.AttributeValueMemberS{Value: EventPK(se.EventID)},
+					":gsk": &types.AttributeValueMemberS{Value: SeriesPK(se.SeriesID)},
+				},
+			})
+			var ccf *types.ConditionalCheckFailedException
+			if errors.As(err, &ccf) {
+				continue // unlinked since the scan
+			}
+			if err != nil {
+				return updated, fmt.Errorf("backfill series event %s/%s: %w", se.SeriesID, se.EventID, err)
+			}
+			updated++
+		}
+
+		if len(out.LastEvaluatedKey) == 0 {
+			return updated, nil
+		}
+		start = out.LastEvaluatedKey
+	}
+}
+
 // EnrollDriver adds a driver to a series.
 func EnrollDriver(ctx context.Context, sd SeriesDriver) (*SeriesDriver, error) {
 	c, err := client()
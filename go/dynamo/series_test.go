@@ -3,6 +3,8 @@ package dynamo
 import (
 	"context"
 	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
 func TestCreateSeries(t *testing.T) {
@@ -181,6 +183,15 @@ func TestSeriesEvents(t *testing.T) {
 		t.Fatalf("got %d events, want 2", len(list))
 	}
 
+	AddEventToSeries(ctx, SeriesEvent{SeriesID: "other", EventID: "evt1", RoundNumber: 3})
+	links, err := ListSeriesForEvent(ctx, "evt1")
+	if err != nil {
+		t.Fatalf("ListSeriesForEvent: %v", err)
+	}
+	if len(links) != 2 {
+		t.Fatalf("got %d series for evt1, want 2", len(links))
+	}
+
 	// Remove one
 	RemoveEventFromSeries(ctx, "series1", "evt1")
 	list, _ = ListSeriesEvents(ctx, "series1")
@@ -239,3 +250,36 @@ func TestSeriesDrivers(t *testing.T) {
 		t.Fatalf("got %d after delete, want 1", len(list))
 	}
 }
+
+func TestBackfillSeriesEventKeys(t *testing.T) {
+	db, cleanup := setup()
+	defer cleanup()
+	ctx := context.Background()
+
+	// A link saved before the GSI1 keys were written
+	db.items[itemKey(SeriesPK("s1"), SeriesEventSK("e1"))] = map[string]types.AttributeValue{
+		"pk":          &types.AttributeValueMemberS{Value: SeriesPK("s1")},
+		"sk":          &types.AttributeValueMemberS{Value: SeriesEventSK("e1")},
+		"seriesId":    &types.AttributeValueMemberS{Value: "s1"},
+		"eventId":     &types.AttributeValueMemberS{Value: "e1"},
+		"roundNumber": &types.AttributeValueMemberN{Value: "1"},
+	}
+	if _, err := AddEventToSeries(ctx, SeriesEvent{SeriesID: "s1", EventID: "e2", RoundNumber: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if links, _ := ListSeriesForEvent(ctx, "e1"); len(links) != 0 {
+		t.Fatalf("old link found before the backfill: %+v", links)
+	}
+
+	n, err := BackfillSeriesEventKeys(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("BackfillSeriesEventKeys = %d, %v; want 1 link updated", n, err)
+	}
+	links, _ := ListSeriesForEvent(ctx, "e1")
+	if len(links) != 1 || links[0].SeriesID != "s1" || links[0].RoundNumber != 1 {
+		t.Errorf("ListSeriesForEvent after the backfill = %+v, want s1 round 1", links)
+	}
+	if n, _ := BackfillSeriesEventKeys(ctx); n != 0 {
+		t.Errorf("second backfill updated %d links, want 0", n)
+	}
+}
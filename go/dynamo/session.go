@@ -32,6 +32,15 @@ type Session struct {
 	BestLapMs         int64    `dynamodbav:"bestLapMs,omitempty" json:"best_lap_ms,omitempty"`
 	BestLapDriverName string   `dynamodbav:"bestLapDriverName,omitempty" json:"best_lap_driver_name,omitempty"`
 
+	FlagState string `dynamodbav:"flagState,omitempty" json:"flag_state,omitempty"` // green, yellow, red, checkered
+
+	KartDraw     []KartDrawEntry `dynamodbav:"kartDraw,omitempty" json:"kart_draw,omitempty"`
+	KartDrawSeed int64           `dynamodbav:"kartDrawSeed,omitempty" json:"kart_draw_seed,omitempty"`
+
+	Grid     []GridEntry `dynamodbav:"grid,omitempty" json:"grid,omitempty"`
+	GridRule string      `dynamodbav:"gridRule,omitempty" json:"grid_rule,omitempty"`
+	GridSeed int64       `dynamodbav:"gridSeed,omitempty" json:"grid_seed,omitempty"`
+
 	IngestStatus string `dynamodbav:"ingestStatus,omitempty" json:"ingest_status,omitempty"`
 	IngestError  string `dynamodbav:"ingestError,omitempty" json:"ingest_error,omitempty"`
 	RawS3Key     string `dynamodbav:"rawS3Key,omitempty" json:"raw_s3_key,omitempty"`
@@ -43,6 +52,23 @@ type Session struct {
 	CreatedAt string `dynamodbav:"createdAt" json:"created_at"`
 }
 
+// GridEntry is one starting position on a session's generated grid.
+type GridEntry struct {
+	Pos         int    `dynamodbav:"pos" json:"pos"`
+	UID         string `dynamodbav:"uid" json:"uid"`
+	DriverName  string `dynamodbav:"driverName,omitempty" json:"driver_name,omitempty"`
+	Points      int    `dynamodbav:"points,omitempty" json:"points,omitempty"`            // heat points total
+	GridPenalty int    `dynamodbav:"gridPenalty,omitempty" json:"grid_penalty,omitempty"` // places dropped
+}
+
+// KartDrawEntry is one driver's kart from a session's random kart draw.
+type KartDrawEntry struct {
+	UID        string `dynamodbav:"uid" json:"uid"`
+	DriverName string `dynamodbav:"driverName,omitempty" json:"driver_name,omitempty"`
+	KartID     string `dynamodbav:"kartId" json:"kart_id"`
+	KartNumber string `dynamodbav:"kartNumber" json:"kart_number"`
+}
+
 func CreateSession(ctx context.Context, s Session) (*Session, error) {
 	c, err := client()
 	if err != nil {
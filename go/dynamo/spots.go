@@ -0,0 +1,294 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrNoSpot is returned when every one of a parent's MaxSpots is held.
+var ErrNoSpot = errors.New("no spot available")
+
+// ErrRegistrationChanged is returned when a registration's status changed
+// since it was read.
+var ErrRegistrationChanged = errors.New("registration changed")
+
+// HoldsSpot reports whether a registration in this status takes up one of
+// its parent's MaxSpots. Invited and waitlisted drivers don't.
+func HoldsSpot(status string) bool {
+	return status == "confirmed" || status == "pending" || status == "offered"
+}
+
+// Spots held under a parent are counted on a SPOTS item next to its
+// registrations, so taking and freeing a spot is atomic with the
+// registration write. The counter is created from the registrations on
+// first use; from then on every change to a spot-holding status must go
+// through the functions here to keep it exact.
+
+// ensureSpotCount creates a parent's counter if it doesn't exist yet. If two
+// callers race to create it, the second put fails its condition and the
+// first count stands.
+func ensureSpotCount(ctx context.Context, c DynamoDBAPI, parentType, parentID string) error {
+	out, err := c.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(TableName),
+		Key:       spotsKey(parentType, parentID),
+	})
+	if err != nil {
+		return fmt.Errorf("get spot count: %w", err)
+	}
+	if out.Item != nil {
+		return nil
+	}
+
+	regs, err := ListRegistrations(ctx, parentType, parentID)
+	if err != nil {
+		return err
+	}
+	held := 0
+	for _, reg := range regs {
+		if HoldsSpot(reg.Status) {
+			held++
+		}
+	}
+
+	item, err := attributevalue.MarshalMap(map[string]any{
+		"pk":        parentPK(parentType, parentID),
+		"sk":        SpotsSK,
+		"held":      held,
+		"createdAt": time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return fmt.Errorf("marshal spot count: %w", err)
+	}
+	_, err = c.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(TableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(pk)"),
+	})
+	var ccf *types.ConditionalCheckFailedException
+	if err != nil && !errors.As(err, &ccf) {
+		return fmt.Errorf("put spot count: %w", err)
+	}
+	return nil
+}
+
+func spotsKey(parentType, parentID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"pk": &types.AttributeValueMemberS{Value: parentPK(parentType, parentID)},
+		"sk": &types.AttributeValueMemberS{Value: SpotsSK},
+	}
+}
+
+// takeSpot increments the held count unless maxSpots are already held.
+func takeSpot(parentType, parentID string, maxSpots int) *types.Update {
+	return &types.Update{
+		TableName:           aws.String(TableName),
+		Key:                 spotsKey(parentType, parentID),
+		UpdateExpression:    aws.String("SET held = held + :one"),
+		ConditionExpression: aws.String("held < :max"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":one": &types.AttributeValueMemberN{Value: "1"},
+			":max": &types.AttributeValueMemberN{Value: fmt.Sprint(maxSpots)},
+		},
+	}
+}
+
+func releaseSpot(parentType, parentID string) *types.Update {
+	return &types.Update{
+		TableName:           aws.String(TableName),
+		Key:                 spotsKey(parentType, parentID),
+		UpdateExpression:    aws.String("SET held = held - :one"),
+		ConditionExpression: aws.String("held > :zero"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":one":  &types.AttributeValueMemberN{Value: "1"},
+			":zero": &types.AttributeValueMemberN{Value: "0"},
+		},
+	}
+}
+
+// cancelled reports which items of a cancelled transaction failed their
+// condition.
+func cancelled(err error) []bool {
+	var tce *types.TransactionCanceledException
+	if !errors.As(err, &tce) {
+		return nil
+	}
+	failed := make([]bool, len(tce.CancellationReasons))
+	for i, reason := range tce.CancellationReasons {
+		failed[i] = aws.ToString(reason.Code) == "ConditionalCheckFailed"
+	}
+	return failed
+}
+
+// CreateRegistrationInSpot creates a registration in a spot-holding status,
+// taking one of the parent's maxSpots in the same transaction. It returns
+// ErrNoSpot when they are all held.
+func CreateRegistrationInSpot(ctx context.Context, reg Registration, maxSpots int) (*Registration, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureSpotCount(ctx, c, reg.ParentType, reg.ParentID); err != nil {
+		return nil, err
+	}
+
+	pk := parentPK(reg.ParentType, reg.ParentID)
+	reg.PK = pk
+	reg.SK = RegSK(reg.UID)
+	reg.GSI2PK = UserRegGSI2PK(reg.UID)
+	reg.GSI2SK = pk
+	now := time.Now().UTC().Format(time.RFC3339)
+	if reg.RegisteredAt == "" {
+		reg.RegisteredAt = now
+	}
+	reg.CreatedAt = now
+
+	item, err := attributevalue.MarshalMap(reg)
+	if err != nil {
+		return nil, fmt.Errorf("marshal registration: %w", err)
+	}
+
+	_, err = c.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{Update: takeSpot(reg.ParentType, reg.ParentID, maxSpots)},
+			{Put: &types.Put{
+				TableName:           aws.String(TableName),
+				Item:                item,
+				ConditionExpression: aws.String("attribute_not_exists(pk)"),
+			}},
+		},
+	})
+	if failed := cancelled(err); len(failed) > 0 && failed[0] {
+		return nil, ErrNoSpot
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create registration: %w", err)
+	}
+	return &reg, nil
+}
+
+// ChangeRegistrationStatus moves a registration still in fromStatus to the
+// status in fields, along with the other fields. When that changes whether
+// it holds a spot, the spot is taken or freed in the same transaction. It
+// returns ErrNoSpot when a spot is needed and maxSpots (0 for no limit) are
+// all held, and ErrRegistrationChanged when the registration is no longer in
+// fromStatus.
+func ChangeRegistrationStatus(ctx context.Context, parentType, parentID, uid, fromStatus string, maxSpots int, fields map[string]any) error {
+	toStatus, _ := fields["status"].(string)
+	if toStatus == "" {
+		return fmt.Errorf("status is required")
+	}
+
+	c, err := client()
+	if err != nil {
+		return err
+	}
+
+	expr, names, values, err := BuildUpdateExpression(fields)
+	if err != nil {
+		return err
+	}
+	names["#st"] = "status"
+	values[":from"] = &types.AttributeValueMemberS{Value: fromStatus}
+	update := &types.Update{
+		TableName: aws.String(TableName),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: parentPK(parentType, parentID)},
+			"sk": &types.AttributeValueMemberS{Value: RegSK(uid)},
+		},
+		UpdateExpression:          aws.String(expr),
+		ConditionExpression:       aws.String("#st = :from"),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	}
+
+	var spot *types.Update
+	taking := HoldsSpot(toStatus) && !HoldsSpot(fromStatus)
+	switch {
+	case taking:
+		if maxSpots <= 0 {
+			maxSpots = math.MaxInt32
+		}
+		spot = takeSpot(parentType, parentID, maxSpots)
+	case HoldsSpot(fromStatus) && !HoldsSpot(toStatus):
+		spot = releaseSpot(parentType, parentID)
+	}
+	if spot == nil {
+		_, err := c.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+			TableName:                 update.TableName,
+			Key:                       update.Key,
+			UpdateExpression:          update.UpdateExpression,
+			ConditionExpression:       update.ConditionExpression,
+			ExpressionAttributeNames:  update.ExpressionAttributeNames,
+			ExpressionAttributeValues: update.ExpressionAttributeValues,
+		})
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return ErrRegistrationChanged
+		}
+		return err
+	}
+
+	if err := ensureSpotCount(ctx, c, parentType, parentID); err != nil {
+		return err
+	}
+	_, err = c.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{{Update: spot}, {Update: update}},
+	})
+	failed := cancelled(err)
+	switch {
+	case len(failed) > 1 && failed[1]:
+		return ErrRegistrationChanged
+	case len(failed) > 0 && failed[0] && taking:
+		return ErrNoSpot
+	case err != nil:
+		return fmt.Errorf("change registration status: %w", err)
+	}
+	return nil
+}
+
+// WithdrawRegistration deletes a registration, freeing its spot if it held
+// one.
+func WithdrawRegistration(ctx context.Context, reg *Registration) error {
+	if !HoldsSpot(reg.Status) {
+		return DeleteRegistration(ctx, reg.ParentType, reg.ParentID, reg.UID)
+	}
+
+	c, err := client()
+	if err != nil {
+		return err
+	}
+	if err := ensureSpotCount(ctx, c, reg.ParentType, reg.ParentID); err != nil {
+		return err
+	}
+
+	_, err = c.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{Update: releaseSpot(reg.ParentType, reg.ParentID)},
+			{Delete: &types.Delete{
+				TableName: aws.String(TableName),
+				Key: map[string]types.AttributeValue{
+					"pk": &types.AttributeValueMemberS{Value: parentPK(reg.ParentType, reg.ParentID)},
+					"sk": &types.AttributeValueMemberS{Value: RegSK(reg.UID)},
+				},
+				ConditionExpression:       aws.String("#st = :status"),
+				ExpressionAttributeNames:  map[string]string{"#st": "status"},
+				ExpressionAttributeValues: map[string]types.AttributeValue{":status": &types.AttributeValueMemberS{Value: reg.Status}},
+			}},
+		},
+	})
+	if failed := cancelled(err); len(failed) > 1 && failed[1] {
+		return ErrRegistrationChanged
+	}
+	if err != nil {
+		return fmt.Errorf("withdraw registration: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func spotsHeld(t *testing.T, db *mockDB, parentType, parentID string) int {
+	t.Helper()
+	item := db.items[itemKey(parentPK(parentType, parentID), SpotsSK)]
+	if item == nil {
+		t.Fatal("no spot counter")
+	}
+	return numVal(item["held"])
+}
+
+func TestRegistrationSpots(t *testing.T) {
+	db, cleanup := setup()
+	defer cleanup()
+	ctx := context.Background()
+
+	// Registered before the counter existed; it must be counted when the
+	// counter is created
+	if _, err := CreateRegistration(ctx, Registration{ParentType: "event", ParentID: "e1", UID: "u0", Status: "confirmed"}); err != nil {
+		t.Fatalf("CreateRegistration: %v", err)
+	}
+
+	for _, uid := range []string{"u1", "u2"} {
+		if _, err := CreateRegistrationInSpot(ctx, Registration{ParentType: "event", ParentID: "e1", UID: uid, Status: "confirmed"}, 3); err != nil {
+			t.Fatalf("CreateRegistrationInSpot(%s): %v", uid, err)
+		}
+	}
+	if got := spotsHeld(t, db, "event", "e1"); got != 3 {
+		t.Errorf("held = %d, want 3", got)
+	}
+
+	_, err := CreateRegistrationInSpot(ctx, Registration{ParentType: "event", ParentID: "e1", UID: "u3", Status: "confirmed"}, 3)
+	if !errors.Is(err, ErrNoSpot) {
+		t.Fatalf("fourth registration err = %v, want ErrNoSpot", err)
+	}
+	if reg, _ := GetRegistration(ctx, "event", "e1", "u3"); reg != nil {
+		t.Error("registration written without a spot")
+	}
+
+	if _, err := CreateRegistration(ctx, Registration{ParentType: "event", ParentID: "e1", UID: "u3", Status: "waitlisted"}); err != nil {
+		t.Fatalf("CreateRegistration: %v", err)
+	}
+	err = ChangeRegistrationStatus(ctx, "event", "e1", "u3", "waitlisted", 3, map[string]any{"status": "confirmed"})
+	if !errors.Is(err, ErrNoSpot) {
+		t.Fatalf("promote while full err = %v, want ErrNoSpot", err)
+	}
+
+	u1, _ := GetRegistration(ctx, "event", "e1", "u1")
+	if err := WithdrawRegistration(ctx, u1); err != nil {
+		t.Fatalf("WithdrawRegistration: %v", err)
+	}
+	if got := spotsHeld(t, db, "event", "e1"); got != 2 {
+		t.Errorf("held after withdraw = %d, want 2", got)
+	}
+
+	if err := ChangeRegistrationStatus(ctx, "event", "e1", "u3", "waitlisted", 3, map[string]any{"status": "offered"}); err != nil {
+		t.Fatalf("promote: %v", err)
+	}
+	if got := spotsHeld(t, db, "event", "e1"); got != 3 {
+		t.Errorf("held after promote = %d, want 3", got)
+	}
+
+	err = ChangeRegistrationStatus(ctx, "event", "e1", "u3", "waitlisted", 3, map[string]any{"status": "confirmed"})
+	if !errors.Is(err, ErrRegistrationChanged) {
+		t.Errorf("stale status err = %v, want ErrRegistrationChanged", err)
+	}
+
+	if err := ChangeRegistrationStatus(ctx, "event", "e1", "u2", "confirmed", 3, map[string]any{"status": "cancelled"}); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if got := spotsHeld(t, db, "event", "e1"); got != 2 {
+		t.Errorf("held after cancel = %d, want 2", got)
+	}
+}
@@ -0,0 +1,295 @@
+package dynamo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rs/xid"
+)
+
+// Team is a racing team or shop that drivers race for, stored under
+// TEAM#id / PROFILE.
+type Team struct {
+	PK             string `dynamodbav:"pk" json:"-"`
+	SK             string `dynamodbav:"sk" json:"-"`
+	TeamID         string `dynamodbav:"teamId" json:"team_id"`
+	Name           string `dynamodbav:"name" json:"name"`
+	Description    string `dynamodbav:"description,omitempty" json:"description,omitempty"`
+	OwnerUID       string `dynamodbav:"ownerUid" json:"owner_uid"`
+	LogoKey        string `dynamodbav:"logoKey,omitempty" json:"logo_key,omitempty"`
+	PrimaryColor   string `dynamodbav:"primaryColor,omitempty" json:"primary_color,omitempty"`
+	SecondaryColor string `dynamodbav:"secondaryColor,omitempty" json:"secondary_color,omitempty"`
+	CreatedAt      string `dynamodbav:"createdAt" json:"created_at"`
+}
+
+// TeamMember is a driver on a team, stored under TEAM#id / MEMBER#uid. GSI1
+// lists a user's teams.
+type TeamMember struct {
+	PK         string `dynamodbav:"pk" json:"-"`
+	SK         string `dynamodbav:"sk" json:"-"`
+	TeamID     string `dynamodbav:"teamId" json:"team_id"`
+	TeamName   string `dynamodbav:"teamName,omitempty" json:"team_name,omitempty"`
+	UID        string `dynamodbav:"uid" json:"uid"`
+	DriverName string `dynamodbav:"driverName,omitempty" json:"driver_name,omitempty"`
+	Role       string `dynamodbav:"role" json:"role"` // owner, member
+	GSI1PK     string `dynamodbav:"gsi1pk,omitempty" json:"-"`
+	GSI1SK     string `dynamodbav:"gsi1sk,omitempty" json:"-"`
+	CreatedAt  string `dynamodbav:"createdAt" json:"created_at"`
+}
+
+func teamMemberItem(m TeamMember) TeamMember {
+	m.PK = TeamPK(m.TeamID)
+	m.SK = MemberSK(m.UID)
+	m.GSI1PK = UserPK(m.UID)
+	m.GSI1SK = TeamPK(m.TeamID)
+	m.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	return m
+}
+
+// CreateTeam creates a team and adds the creator as its owner in a transaction.
+func CreateTeam(ctx context.Context, ownerName string, t Team) (*Team, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	t.TeamID = xid.New().String()
+	t.PK = TeamPK(t.TeamID)
+	t.SK = ProfileSK
+	t.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+
+	teamItem, err := attributevalue.MarshalMap(t)
+	if err != nil {
+		return nil, fmt.Errorf("marshal team: %w", err)
+	}
+	memberItem, err := attributevalue.MarshalMap(teamMemberItem(TeamMember{
+		TeamID:     t.TeamID,
+		TeamName:   t.Name,
+		UID:        t.OwnerUID,
+		DriverName: ownerName,
+		Role:       "owner",
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("marshal team member: %w", err)
+	}
+
+	_, err = c.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{Put: &types.Put{TableName: aws.String(TableName), Item: teamItem}},
+			{Put: &types.Put{TableName: aws.String(TableName), Item: memberItem}},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create team: %w", err)
+	}
+	return &t, nil
+}
+
+func GetTeam(ctx context.Context, teamID string) (*Team, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(TableName),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: TeamPK(teamID)},
+			"sk": &types.AttributeValueMemberS{Value: ProfileSK},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get team: %w", err)
+	}
+	if out.Item == nil {
+		return nil, nil
+	}
+
+	var t Team
+	if err := attributevalue.UnmarshalMap(out.Item, &t); err != nil {
+		return nil, fmt.Errorf("unmarshal team: %w", err)
+	}
+	return &t, nil
+}
+
+func UpdateTeam(ctx context.Context, teamID string, fields map[string]any) error {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	c, err := client()
+	if err != nil {
+		return err
+	}
+
+	expr, names, values, err := BuildUpdateExpression(fields)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(TableName),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: TeamPK(teamID)},
+			"sk": &types.AttributeValueMemberS{Value: ProfileSK},
+		},
+		UpdateExpression:          aws.String(expr),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+	return err
+}
+
+// DeleteTeam removes a team and its member items.
+func DeleteTeam(ctx context.Context, teamID string) error {
+	members, err := ListTeamMembers(ctx, teamID)
+	if err != nil {
+		return err
+	}
+	for _, m := range members {
+		if err := RemoveTeamMember(ctx, teamID, m.UID); err != nil {
+			return err
+		}
+	}
+
+	c, err := client()
+	if err != nil {
+		return err
+	}
+
+	_, err = c.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(TableName),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: TeamPK(teamID)},
+			"sk": &types.AttributeValueMemberS{Value: ProfileSK},
+		},
+	})
+	return err
+}
+
+// AddTeamMember puts a driver on a team.
+func AddTeamMember(ctx context.Context, m TeamMember) (*TeamMember, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	m = teamMemberItem(m)
+	if m.Role == "" {
+		m.Role = "member"
+	}
+
+	item, err := attributevalue.MarshalMap(m)
+	if err != nil {
+		return nil, fmt.Errorf("marshal team member: %w", err)
+	}
+
+	_, err = c.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(TableName),
+		Item:      item,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("add team member: %w", err)
+	}
+	return &m, nil
+}
+
+func GetTeamMember(ctx context.Context, teamID, uid string) (*TeamMember, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(TableName),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: TeamPK(teamID)},
+			"sk": &types.AttributeValueMemberS{Value: MemberSK(uid)},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get team member: %w", err)
+	}
+	if out.Item == nil {
+		return nil, nil
+	}
+
+	var m TeamMember
+	if err := attributevalue.UnmarshalMap(out.Item, &m); err != nil {
+		return nil, fmt.Errorf("unmarshal team member: %w", err)
+	}
+	return &m, nil
+}
+
+func RemoveTeamMember(ctx context.Context, teamID, uid string) error {
+	c, err := client()
+	if err != nil {
+		return err
+	}
+
+	_, err = c.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(TableName),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: TeamPK(teamID)},
+			"sk": &types.AttributeValueMemberS{Value: MemberSK(uid)},
+		},
+	})
+	return err
+}
+
+func ListTeamMembers(ctx context.Context, teamID string) ([]TeamMember, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(TableName),
+		KeyConditionExpression: aws.String("pk = :pk AND begins_with(sk, :prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":     &types.AttributeValueMemberS{Value: TeamPK(teamID)},
+			":prefix": &types.AttributeValueMemberS{Value: "MEMBER#"},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list team members: %w", err)
+	}
+
+	var members []TeamMember
+	if err := attributevalue.UnmarshalListOfMaps(out.Items, &members); err != nil {
+		return nil, fmt.Errorf("unmarshal team members: %w", err)
+	}
+	return members, nil
+}
+
+// ListTeamsForUser returns the teams a user is on (via GSI1).
+func ListTeamsForUser(ctx context.Context, uid string) ([]TeamMember, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(TableName),
+		IndexName:              aws.String("gsi1"),
+		KeyConditionExpression: aws.String("gsi1pk = :pk AND begins_with(gsi1sk, :prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":     &types.AttributeValueMemberS{Value: UserPK(uid)},
+			":prefix": &types.AttributeValueMemberS{Value: "TEAM#"},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list teams for user: %w", err)
+	}
+
+	var members []TeamMember
+	if err := attributevalue.UnmarshalListOfMaps(out.Items, &members); err != nil {
+		return nil, fmt.Errorf("unmarshal team members: %w", err)
+	}
+	return members, nil
+}
@@ -0,0 +1,45 @@
+package dynamo
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTeams(t *testing.T) {
+	_, cleanup := setup()
+	defer cleanup()
+	ctx := context.Background()
+
+	team, err := CreateTeam(ctx, "Owner Driver", Team{Name: "Apex Karting", OwnerUID: "u1", PrimaryColor: "#ff0000"})
+	if err != nil {
+		t.Fatalf("CreateTeam: %v", err)
+	}
+
+	owner, err := GetTeamMember(ctx, team.TeamID, "u1")
+	if err != nil || owner == nil || owner.Role != "owner" {
+		t.Fatalf("owner member = %+v, %v", owner, err)
+	}
+
+	if _, err := AddTeamMember(ctx, TeamMember{TeamID: team.TeamID, TeamName: team.Name, UID: "u2", DriverName: "Second"}); err != nil {
+		t.Fatalf("AddTeamMember: %v", err)
+	}
+	members, err := ListTeamMembers(ctx, team.TeamID)
+	if err != nil || len(members) != 2 {
+		t.Fatalf("ListTeamMembers = %d, %v", len(members), err)
+	}
+
+	mine, err := ListTeamsForUser(ctx, "u2")
+	if err != nil || len(mine) != 1 || mine[0].TeamName != "Apex Karting" {
+		t.Fatalf("ListTeamsForUser = %+v, %v", mine, err)
+	}
+
+	if err := DeleteTeam(ctx, team.TeamID); err != nil {
+		t.Fatalf("DeleteTeam: %v", err)
+	}
+	if got, _ := GetTeam(ctx, team.TeamID); got != nil {
+		t.Error("team still exists after delete")
+	}
+	if mine, _ := ListTeamsForUser(ctx, "u2"); len(mine) != 0 {
+		t.Errorf("membership still listed after delete: %+v", mine)
+	}
+}
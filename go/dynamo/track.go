@@ -89,7 +89,19 @@ type KartClass struct {
 	Engine      string `dynamodbav:"engine,omitempty" json:"engine,omitempty"`
 	Description string `dynamodbav:"description,omitempty" json:"description,omitempty"`
 	IsDefault   bool   `dynamodbav:"isDefault,omitempty" json:"is_default,omitempty"`
-	CreatedAt   string `dynamodbav:"createdAt" json:"created_at"`
+
+	BallastRules `dynamodbav:",omitempty"`
+
+	CreatedAt string `dynamodbav:"createdAt" json:"created_at"`
+}
+
+// BallastRules balance a class: drivers under the minimum weight carry the
+// difference, and race wins and podiums add success ballast on top.
+type BallastRules struct {
+	MinDriverWeightKg float64 `dynamodbav:"minDriverWeightKg,omitempty" json:"min_driver_weight_kg,omitempty"` // driver in race gear
+	WinBallastKg      float64 `dynamodbav:"winBallastKg,omitempty" json:"win_ballast_kg,omitempty"`            // per race win
+	PodiumBallastKg   float64 `dynamodbav:"podiumBallastKg,omitempty" json:"podium_ballast_kg,omitempty"`      // per 2nd or 3rd place
+	MaxBallastKg      float64 `dynamodbav:"maxBallastKg,omitempty" json:"max_ballast_kg,omitempty"`            // success ballast cap, 0 for none
 }
 
 // CreateTrack creates a track and adds the creator as owner in a transaction.
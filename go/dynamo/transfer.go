@@ -0,0 +1,304 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrAlreadyRegistered is returned when a registration would move to a
+// driver who already has one under the same parent.
+var ErrAlreadyRegistered = errors.New("driver already registered")
+
+// RegistrationTransfer is a driver's proposal to hand their registration to
+// someone else, or, for a session, to swap it with another driver's
+// registration for a session of the same event. It waits under the
+// registration's parent, one per registration, until the recipient accepts.
+type RegistrationTransfer struct {
+	PK         string `dynamodbav:"pk" json:"-"`
+	SK         string `dynamodbav:"sk" json:"-"`
+	ParentType string `dynamodbav:"parentType" json:"parent_type"`
+	ParentID   string `dynamodbav:"parentId" json:"parent_id"`
+	ParentName string `dynamodbav:"parentName,omitempty" json:"parent_name,omitempty"`
+	TrackID    string `dynamodbav:"trackId" json:"track_id"`
+	FromUID    string `dynamodbav:"fromUid" json:"from_uid"`
+	FromName   string `dynamodbav:"fromName,omitempty" json:"from_name,omitempty"`
+	ToEmail    string `dynamodbav:"toEmail" json:"to_email"` // lower case
+	// Set on a swap: the registration that moves the other way
+	SwapSessionID string `dynamodbav:"swapSessionId,omitempty" json:"swap_session_id,omitempty"`
+	SwapUID       string `dynamodbav:"swapUid,omitempty" json:"swap_uid,omitempty"`
+	ProposedBy    string `dynamodbav:"proposedBy" json:"proposed_by"`
+	GSI1PK        string `dynamodbav:"gsi1pk,omitempty" json:"-"`
+	GSI1SK        string `dynamodbav:"gsi1sk,omitempty" json:"-"`
+	TTL           int64  `dynamodbav:"ttl,omitempty" json:"-"`
+	CreatedAt     string `dynamodbav:"createdAt" json:"created_at"`
+}
+
+func transferKey(parentType, parentID, uid string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"pk": &types.AttributeValueMemberS{Value: parentPK(parentType, parentID)},
+		"sk": &types.AttributeValueMemberS{Value: TransferSK(uid)},
+	}
+}
+
+// ProposeTransfer saves a transfer proposal with a 14-day TTL, replacing any
+// earlier one for the same registration.
+func ProposeTransfer(ctx context.Context, t RegistrationTransfer) (*RegistrationTransfer, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	t.ToEmail = strings.ToLower(t.ToEmail)
+	t.PK = parentPK(t.ParentType, t.ParentID)
+	t.SK = TransferSK(t.FromUID)
+	t.GSI1PK = TransferGSI1PK(t.ToEmail)
+	t.GSI1SK = t.PK
+	t.TTL = time.Now().UTC().Add(14 * 24 * time.Hour).Unix()
+	t.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+
+	item, err := attributevalue.MarshalMap(t)
+	if err != nil {
+		return nil, fmt.Errorf("marshal transfer: %w", err)
+	}
+
+	_, err = c.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(TableName),
+		Item:      item,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("propose transfer: %w", err)
+	}
+	return &t, nil
+}
+
+func GetTransfer(ctx context.Context, parentType, parentID, uid string) (*RegistrationTransfer, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(TableName),
+		Key:       transferKey(parentType, parentID, uid),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get transfer: %w", err)
+	}
+	if out.Item == nil {
+		return nil, nil
+	}
+
+	var t RegistrationTransfer
+	if err := attributevalue.UnmarshalMap(out.Item, &t); err != nil {
+		return nil, fmt.Errorf("unmarshal transfer: %w", err)
+	}
+	return &t, nil
+}
+
+// ListTransfersForEmail returns the transfers waiting for an email address
+// to accept them (via GSI1).
+func ListTransfersForEmail(ctx context.Context, email string) ([]RegistrationTransfer, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(TableName),
+		IndexName:              aws.String("gsi1"),
+		KeyConditionExpression: aws.String("gsi1pk = :pk"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: TransferGSI1PK(email)},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list transfers for email: %w", err)
+	}
+
+	var transfers []RegistrationTransfer
+	if err := attributevalue.UnmarshalListOfMaps(out.Items, &transfers); err != nil {
+		return nil, fmt.Errorf("unmarshal transfers: %w", err)
+	}
+	return transfers, nil
+}
+
+func DeleteTransfer(ctx context.Context, parentType, parentID, uid string) error {
+	c, err := client()
+	if err != nil {
+		return err
+	}
+
+	_, err = c.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(TableName),
+		Key:       transferKey(parentType, parentID, uid),
+	})
+	return err
+}
+
+// withdrawn deletes a registration as part of a move, provided it's still in
+// the status it was read in.
+func withdrawn(reg *Registration) *types.Delete {
+	return &types.Delete{
+		TableName: aws.String(TableName),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: parentPK(reg.ParentType, reg.ParentID)},
+			"sk": &types.AttributeValueMemberS{Value: RegSK(reg.UID)},
+		},
+		ConditionExpression:       aws.String("#st = :status"),
+		ExpressionAttributeNames:  map[string]string{"#st": "status"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":status": &types.AttributeValueMemberS{Value: reg.Status}},
+	}
+}
+
+// placed writes a registration as part of a move, provided its driver isn't
+// registered there already. Its registeredAt is kept.
+func placed(reg *Registration) (*types.Put, error) {
+	pk := parentPK(reg.ParentType, reg.ParentID)
+	reg.PK = pk
+	reg.SK = RegSK(reg.UID)
+	reg.GSI2PK = UserRegGSI2PK(reg.UID)
+	reg.GSI2SK = pk
+	reg.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+
+	item, err := attributevalue.MarshalMap(reg)
+	if err != nil {
+		return nil, fmt.Errorf("marshal registration: %w", err)
+	}
+	return &types.Put{
+		TableName:           aws.String(TableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(pk)"),
+	}, nil
+}
+
+// TransferRegistration replaces from with to, its registration handed to
+// another driver under the same parent, and drops from's transfer proposal,
+// all in one transaction. The spot moves with it, so the spot count stays.
+// It returns ErrRegistrationChanged when from's status changed since it was
+// read, and ErrAlreadyRegistered when to's driver already has a
+// registration.
+func TransferRegistration(ctx context.Context, from *Registration, to *Registration) error {
+	c, err := client()
+	if err != nil {
+		return err
+	}
+
+	put, err := placed(to)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{Delete: withdrawn(from)},
+			{Put: put},
+			{Delete: &types.Delete{
+				TableName: aws.String(TableName),
+				Key:       transferKey(from.ParentType, from.ParentID, from.UID),
+			}},
+		},
+	})
+	failed := cancelled(err)
+	switch {
+	case len(failed) > 0 && failed[0]:
+		return ErrRegistrationChanged
+	case len(failed) > 1 && failed[1]:
+		return ErrAlreadyRegistered
+	case err != nil:
+		return fmt.Errorf("transfer registration: %w", err)
+	}
+	return nil
+}
+
+// ClaimInvite replaces an invite held under a placeholder UID (the invited
+// email) with reg, the driver's own registration, in one transaction. With
+// maxSpots above 0 one of them is taken for reg in the same transaction. It
+// returns ErrRegistrationChanged when the invite is no longer invited,
+// ErrAlreadyRegistered when reg's driver already has a registration, and
+// ErrNoSpot when every spot is held.
+func ClaimInvite(ctx context.Context, invite *Registration, reg *Registration, maxSpots int) error {
+	c, err := client()
+	if err != nil {
+		return err
+	}
+
+	put, err := placed(reg)
+	if err != nil {
+		return err
+	}
+	items := []types.TransactWriteItem{{Delete: withdrawn(invite)}, {Put: put}}
+	if maxSpots > 0 {
+		if err := ensureSpotCount(ctx, c, reg.ParentType, reg.ParentID); err != nil {
+			return err
+		}
+		items = append(items, types.TransactWriteItem{Update: takeSpot(reg.ParentType, reg.ParentID, maxSpots)})
+	}
+
+	_, err = c.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+	failed := cancelled(err)
+	switch {
+	case len(failed) > 0 && failed[0]:
+		return ErrRegistrationChanged
+	case len(failed) > 1 && failed[1]:
+		return ErrAlreadyRegistered
+	case len(failed) > 2 && failed[2]:
+		return ErrNoSpot
+	case err != nil:
+		return fmt.Errorf("claim invite: %w", err)
+	}
+	return nil
+}
+
+// SwapRegistrations trades the sessions of two drivers' registrations in one
+// transaction: a and b are withdrawn and movedA and movedB, the same
+// registrations under each other's session, written in their place, and
+// a's transfer proposal is dropped. Both registrations hold a spot, so each
+// session's spot count stays. It returns ErrRegistrationChanged when either
+// status changed since it was read, and ErrAlreadyRegistered when a driver
+// already has a registration for the other session.
+func SwapRegistrations(ctx context.Context, a, b, movedA, movedB *Registration) error {
+	c, err := client()
+	if err != nil {
+		return err
+	}
+
+	putA, err := placed(movedA)
+	if err != nil {
+		return err
+	}
+	putB, err := placed(movedB)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{Delete: withdrawn(a)},
+			{Delete: withdrawn(b)},
+			{Put: putA},
+			{Put: putB},
+			{Delete: &types.Delete{
+				TableName: aws.String(TableName),
+				Key:       transferKey(a.ParentType, a.ParentID, a.UID),
+			}},
+		},
+	})
+	failed := cancelled(err)
+	switch {
+	case len(failed) > 1 && (failed[0] || failed[1]):
+		return ErrRegistrationChanged
+	case len(failed) > 3 && (failed[2] || failed[3]):
+		return ErrAlreadyRegistered
+	case err != nil:
+		return fmt.Errorf("swap registrations: %w", err)
+	}
+	return nil
+}
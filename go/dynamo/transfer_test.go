@@ -0,0 +1,178 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestTransferProposals(t *testing.T) {
+	_, cleanup := setup()
+	defer cleanup()
+	ctx := context.Background()
+
+	if _, err := ProposeTransfer(ctx, RegistrationTransfer{ParentType: "event", ParentID: "e1", FromUID: "a", ToEmail: "Bo@Example.com"}); err != nil {
+		t.Fatalf("ProposeTransfer: %v", err)
+	}
+	// A new proposal replaces the first
+	if _, err := ProposeTransfer(ctx, RegistrationTransfer{ParentType: "event", ParentID: "e1", FromUID: "a", ToEmail: "cy@example.com"}); err != nil {
+		t.Fatalf("ProposeTransfer: %v", err)
+	}
+
+	got, err := GetTransfer(ctx, "event", "e1", "a")
+	if err != nil || got == nil || got.ToEmail != "cy@example.com" {
+		t.Fatalf("GetTransfer = %+v, %v; want the proposal to cy", got, err)
+	}
+	if list, _ := ListTransfersForEmail(ctx, "CY@example.com"); len(list) != 1 || list[0].FromUID != "a" {
+		t.Errorf("ListTransfersForEmail = %+v, want a's proposal", list)
+	}
+	if list, _ := ListTransfersForEmail(ctx, "bo@example.com"); len(list) != 0 {
+		t.Errorf("replaced proposal still listed: %+v", list)
+	}
+
+	if err := DeleteTransfer(ctx, "event", "e1", "a"); err != nil {
+		t.Fatalf("DeleteTransfer: %v", err)
+	}
+	if got, _ := GetTransfer(ctx, "event", "e1", "a"); got != nil {
+		t.Error("transfer still there after delete")
+	}
+}
+
+func TestTransferRegistration(t *testing.T) {
+	db, cleanup := setup()
+	defer cleanup()
+	ctx := context.Background()
+
+	from, err := CreateRegistrationInSpot(ctx, Registration{ParentType: "event", ParentID: "e1", UID: "a", Status: "confirmed", Paid: true, RegisteredAt: "2026-04-01T00:00:00Z"}, 2)
+	if err != nil {
+		t.Fatalf("CreateRegistrationInSpot: %v", err)
+	}
+	if _, err := ProposeTransfer(ctx, RegistrationTransfer{ParentType: "event", ParentID: "e1", FromUID: "a", ToEmail: "bo@example.com"}); err != nil {
+		t.Fatalf("ProposeTransfer: %v", err)
+	}
+
+	to := *from
+	to.UID = "b"
+	if err := TransferRegistration(ctx, from, &to); err != nil {
+		t.Fatalf("TransferRegistration: %v", err)
+	}
+	if reg, _ := GetRegistration(ctx, "event", "e1", "a"); reg != nil {
+		t.Error("old registration kept")
+	}
+	reg, _ := GetRegistration(ctx, "event", "e1", "b")
+	if reg == nil || !reg.Paid || reg.RegisteredAt != "2026-04-01T00:00:00Z" {
+		t.Errorf("new registration = %+v, want b, paid, registered when a did", reg)
+	}
+	if got, _ := GetTransfer(ctx, "event", "e1", "a"); got != nil {
+		t.Error("proposal kept after the transfer")
+	}
+	if got := spotsHeld(t, db, "event", "e1"); got != 1 {
+		t.Errorf("held = %d, want 1", got)
+	}
+	if reg != nil && reg.GSI2PK != UserRegGSI2PK("b") {
+		t.Errorf("gsi2pk = %q, want b's registrations", reg.GSI2PK)
+	}
+
+	// Accepting the same transfer again finds a's registration gone
+	if err := TransferRegistration(ctx, from, &to); !errors.Is(err, ErrRegistrationChanged) {
+		t.Errorf("second transfer err = %v, want ErrRegistrationChanged", err)
+	}
+
+	// The recipient already has a registration of their own
+	c, _ := CreateRegistration(ctx, Registration{ParentType: "event", ParentID: "e1", UID: "c", Status: "confirmed"})
+	onto := *c
+	onto.UID = "b"
+	if err := TransferRegistration(ctx, c, &onto); !errors.Is(err, ErrAlreadyRegistered) {
+		t.Errorf("transfer onto a registered driver err = %v, want ErrAlreadyRegistered", err)
+	}
+	if reg, _ := GetRegistration(ctx, "event", "e1", "c"); reg == nil {
+		t.Error("failed transfer removed the registration")
+	}
+}
+
+func TestSwapRegistrations(t *testing.T) {
+	db, cleanup := setup()
+	defer cleanup()
+	ctx := context.Background()
+
+	a, _ := CreateRegistrationInSpot(ctx, Registration{ParentType: "session", ParentID: "s1", UID: "a", Status: "confirmed", Paid: true}, 5)
+	b, _ := CreateRegistrationInSpot(ctx, Registration{ParentType: "session", ParentID: "s2", UID: "b", Status: "pending"}, 5)
+
+	movedA, movedB := *a, *b
+	movedA.ParentID, movedB.ParentID = "s2", "s1"
+	if err := SwapRegistrations(ctx, a, b, &movedA, &movedB); err != nil {
+		t.Fatalf("SwapRegistrations: %v", err)
+	}
+	if reg, _ := GetRegistration(ctx, "session", "s2", "a"); reg == nil || !reg.Paid {
+		t.Errorf("a in s2 = %+v, want a paid registration", reg)
+	}
+	if reg, _ := GetRegistration(ctx, "session", "s1", "b"); reg == nil || reg.Status != "pending" {
+		t.Errorf("b in s1 = %+v, want a pending registration", reg)
+	}
+	for _, key := range [][2]string{{"s1", "a"}, {"s2", "b"}} {
+		if reg, _ := GetRegistration(ctx, "session", key[0], key[1]); reg != nil {
+			t.Errorf("%s still in %s", key[1], key[0])
+		}
+	}
+	for _, s := range []string{"s1", "s2"} {
+		if got := spotsHeld(t, db, "session", s); got != 1 {
+			t.Errorf("%s held = %d, want 1", s, got)
+		}
+	}
+
+	// A stale read changes nothing
+	if err := SwapRegistrations(ctx, a, b, &movedA, &movedB); !errors.Is(err, ErrRegistrationChanged) {
+		t.Errorf("stale swap err = %v, want ErrRegistrationChanged", err)
+	}
+	if reg, _ := GetRegistration(ctx, "session", "s2", "a"); reg == nil {
+		t.Error("stale swap undid the first one")
+	}
+}
+
+func TestClaimInvite(t *testing.T) {
+	db, cleanup := setup()
+	defer cleanup()
+	ctx := context.Background()
+
+	CreateRegistrationInSpot(ctx, Registration{ParentType: "event", ParentID: "e1", UID: "a", Status: "confirmed"}, 2)
+	invite, _ := CreateRegistration(ctx, Registration{ParentType: "event", ParentID: "e1", UID: "email:bo@example.com", Email: "bo@example.com", Status: "invited"})
+	other, _ := CreateRegistration(ctx, Registration{ParentType: "event", ParentID: "e1", UID: "email:cy@example.com", Email: "cy@example.com", Status: "invited"})
+
+	reg := *invite
+	reg.UID, reg.Status = "b", "confirmed"
+	if err := ClaimInvite(ctx, invite, &reg, 2); err != nil {
+		t.Fatalf("ClaimInvite: %v", err)
+	}
+	if got, _ := GetRegistration(ctx, "event", "e1", "email:bo@example.com"); got != nil {
+		t.Error("placeholder kept")
+	}
+	if got, _ := GetRegistration(ctx, "event", "e1", "b"); got == nil || got.Status != "confirmed" {
+		t.Errorf("b = %+v, want a confirmed registration", got)
+	}
+	if got := spotsHeld(t, db, "event", "e1"); got != 2 {
+		t.Errorf("held = %d, want 2", got)
+	}
+
+	// The invite was claimed already
+	if err := ClaimInvite(ctx, invite, &reg, 2); !errors.Is(err, ErrRegistrationChanged) {
+		t.Errorf("second claim err = %v, want ErrRegistrationChanged", err)
+	}
+
+	// No spot left: nothing changes, so the invite can still be claimed
+	// onto the waitlist
+	claim := *other
+	claim.UID, claim.Status = "c", "confirmed"
+	if err := ClaimInvite(ctx, other, &claim, 2); !errors.Is(err, ErrNoSpot) {
+		t.Fatalf("claim while full err = %v, want ErrNoSpot", err)
+	}
+	if got, _ := GetRegistration(ctx, "event", "e1", "email:cy@example.com"); got == nil {
+		t.Error("failed claim removed the invite")
+	}
+	claim.Status = "waitlisted"
+	if err := ClaimInvite(ctx, other, &claim, 0); err != nil {
+		t.Fatalf("waitlisted claim: %v", err)
+	}
+	if got := spotsHeld(t, db, "event", "e1"); got != 2 {
+		t.Errorf("held after waitlisted claim = %d, want 2", got)
+	}
+}
@@ -0,0 +1,94 @@
+package dynamo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// SessionTransponder maps a transponder number to a driver for one session,
+// stored under SESSION#sid / TRANSPONDER#number.
+type SessionTransponder struct {
+	PK          string `dynamodbav:"pk" json:"-"`
+	SK          string `dynamodbav:"sk" json:"-"`
+	SessionID   string `dynamodbav:"sessionId" json:"session_id"`
+	Transponder string `dynamodbav:"transponder" json:"transponder"`
+	UID         string `dynamodbav:"uid" json:"uid"`
+	DriverName  string `dynamodbav:"driverName,omitempty" json:"driver_name,omitempty"`
+	KartID      string `dynamodbav:"kartId,omitempty" json:"kart_id,omitempty"`
+	CreatedAt   string `dynamodbav:"createdAt" json:"created_at"`
+}
+
+// PutSessionTransponder creates or replaces a transponder mapping.
+func PutSessionTransponder(ctx context.Context, t SessionTransponder) (*SessionTransponder, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	t.PK = SessionPK(t.SessionID)
+	t.SK = TransponderSK(t.Transponder)
+	t.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+
+	item, err := attributevalue.MarshalMap(t)
+	if err != nil {
+		return nil, fmt.Errorf("marshal session transponder: %w", err)
+	}
+
+	_, err = c.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(TableName),
+		Item:      item,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("put session transponder: %w", err)
+	}
+	return &t, nil
+}
+
+// ListSessionTransponders returns every transponder mapping for a session.
+func ListSessionTransponders(ctx context.Context, sessionID string) ([]SessionTransponder, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(TableName),
+		KeyConditionExpression: aws.String("pk = :pk AND begins_with(sk, :prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":     &types.AttributeValueMemberS{Value: SessionPK(sessionID)},
+			":prefix": &types.AttributeValueMemberS{Value: "TRANSPONDER#"},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list session transponders: %w", err)
+	}
+
+	var mappings []SessionTransponder
+	if err := attributevalue.UnmarshalListOfMaps(out.Items, &mappings); err != nil {
+		return nil, fmt.Errorf("unmarshal session transponders: %w", err)
+	}
+	return mappings, nil
+}
+
+// DeleteSessionTransponder removes a transponder mapping.
+func DeleteSessionTransponder(ctx context.Context, sessionID, transponder string) error {
+	c, err := client()
+	if err != nil {
+		return err
+	}
+
+	_, err = c.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(TableName),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: SessionPK(sessionID)},
+			"sk": &types.AttributeValueMemberS{Value: TransponderSK(transponder)},
+		},
+	})
+	return err
+}
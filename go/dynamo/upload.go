@@ -17,6 +17,9 @@ type UploadLap struct {
 	MaxSpeed  float64 `dynamodbav:"maxSpeed,omitempty" json:"max_speed,omitempty"`
 }
 
+// RPMBucketSize is the width of each Upload.RPMHistogram band.
+const RPMBucketSize = 250
+
 type Upload struct {
 	PK          string            `dynamodbav:"pk" json:"-"`
 	SK          string            `dynamodbav:"sk" json:"-"`
@@ -35,9 +38,17 @@ type Upload struct {
 	Laps        []UploadLap       `dynamodbav:"laps,omitempty" json:"laps,omitempty"`
 	SessionTime string            `dynamodbav:"sessionTime,omitempty" json:"session_time,omitempty"`
 	Metadata    map[string]string `dynamodbav:"metadata,omitempty" json:"metadata,omitempty"`
-	GSI1PK      string            `dynamodbav:"gsi1pk,omitempty" json:"-"`
-	GSI1SK      string            `dynamodbav:"gsi1sk,omitempty" json:"-"`
-	CreatedAt   string            `dynamodbav:"createdAt" json:"created_at"`
+
+	// Engine data for equipment tracking. RPMHistogram holds milliseconds spent
+	// in each RPMBucketSize-wide band, indexed from 0 RPM.
+	MaxRPM           int      `dynamodbav:"maxRpm,omitempty" json:"max_rpm,omitempty"`
+	RPMHistogram     []int64  `dynamodbav:"rpmHistogram,omitempty" json:"rpm_histogram,omitempty"`
+	EquipmentIDs     []string `dynamodbav:"equipmentIds,omitempty" json:"equipment_ids,omitempty"`
+	EquipmentApplied []string `dynamodbav:"equipmentApplied,omitempty" json:"equipment_applied,omitempty"`
+
+	GSI1PK    string `dynamodbav:"gsi1pk,omitempty" json:"-"`
+	GSI1SK    string `dynamodbav:"gsi1sk,omitempty" json:"-"`
+	CreatedAt string `dynamodbav:"createdAt" json:"created_at"`
 }
 
 func CreateUpload(ctx context.Context, u Upload) (*Upload, error) {
@@ -0,0 +1,305 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var (
+	// ErrWaiverVersionTaken means another version was published at the same
+	// time; the caller should re-read the current version and retry.
+	ErrWaiverVersionTaken = errors.New("waiver version already published")
+	// ErrWaiverSigned means the driver already signed that waiver version.
+	ErrWaiverSigned = errors.New("waiver already signed")
+)
+
+// Waiver is one published version of a track's liability waiver, stored
+// under TRACK#tid / WAIVER#version. Versions are never edited; publishing
+// new text makes a new version that everyone has to sign again.
+type Waiver struct {
+	PK          string `dynamodbav:"pk" json:"-"`
+	SK          string `dynamodbav:"sk" json:"-"`
+	TrackID     string `dynamodbav:"trackId" json:"track_id"`
+	Version     int    `dynamodbav:"version" json:"version"`
+	Title       string `dynamodbav:"title" json:"title"`
+	Body        string `dynamodbav:"body" json:"body"`
+	PublishedBy string `dynamodbav:"publishedBy" json:"published_by"`
+	CreatedAt   string `dynamodbav:"createdAt" json:"created_at"`
+}
+
+// WaiverSignature records a driver signing a waiver version, stored under
+// USER#uid / WAIVERSIG#tid#version. A minor's waiver is also signed by a
+// parent or guardian.
+type WaiverSignature struct {
+	PK         string `dynamodbav:"pk" json:"-"`
+	SK         string `dynamodbav:"sk" json:"-"`
+	UID        string `dynamodbav:"uid" json:"uid"`
+	TrackID    string `dynamodbav:"trackId" json:"track_id"`
+	Version    int    `dynamodbav:"version" json:"version"`
+	DriverName string `dynamodbav:"driverName,omitempty" json:"driver_name,omitempty"`
+	SignedName string `dynamodbav:"signedName" json:"signed_name"` // typed by the signer
+	SignedAt   string `dynamodbav:"signedAt" json:"signed_at"`
+	IP         string `dynamodbav:"ip,omitempty" json:"ip,omitempty"`
+	UserAgent  string `dynamodbav:"userAgent,omitempty" json:"user_agent,omitempty"`
+
+	DateOfBirth          string `dynamodbav:"dateOfBirth,omitempty" json:"date_of_birth,omitempty"`
+	Minor                bool   `dynamodbav:"minor,omitempty" json:"minor,omitempty"`
+	GuardianName         string `dynamodbav:"guardianName,omitempty" json:"guardian_name,omitempty"`
+	GuardianRelationship string `dynamodbav:"guardianRelationship,omitempty" json:"guardian_relationship,omitempty"`
+	GuardianSignedName   string `dynamodbav:"guardianSignedName,omitempty" json:"guardian_signed_name,omitempty"`
+
+	GSI1PK string `dynamodbav:"gsi1pk,omitempty" json:"-"`
+	GSI1SK string `dynamodbav:"gsi1sk,omitempty" json:"-"`
+}
+
+// PublishWaiver saves w as the version after the track's current one.
+func PublishWaiver(ctx context.Context, w Waiver) (*Waiver, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := GetCurrentWaiver(ctx, w.TrackID)
+	if err != nil {
+		return nil, err
+	}
+	w.Version = 1
+	if current != nil {
+		w.Version = current.Version + 1
+	}
+	w.PK = TrackPK(w.TrackID)
+	w.SK = WaiverSK(w.Version)
+	w.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+
+	item, err := attributevalue.MarshalMap(w)
+	if err != nil {
+		return nil, fmt.Errorf("marshal waiver: %w", err)
+	}
+
+	_, err = c.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(TableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(pk)"),
+	})
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return nil, ErrWaiverVersionTaken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("put waiver: %w", err)
+	}
+	return &w, nil
+}
+
+// GetWaiver returns a waiver version, or nil if it doesn't exist.
+func GetWaiver(ctx context.Context, trackID string, version int) (*Waiver, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(TableName),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: TrackPK(trackID)},
+			"sk": &types.AttributeValueMemberS{Value: WaiverSK(version)},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get waiver: %w", err)
+	}
+	if out.Item == nil {
+		return nil, nil
+	}
+
+	var w Waiver
+	if err := attributevalue.UnmarshalMap(out.Item, &w); err != nil {
+		return nil, fmt.Errorf("unmarshal waiver: %w", err)
+	}
+	return &w, nil
+}
+
+// GetCurrentWaiver returns the track's latest waiver version, or nil if it
+// has never published one.
+func GetCurrentWaiver(ctx context.Context, trackID string) (*Waiver, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(TableName),
+		KeyConditionExpression: aws.String("pk = :pk AND begins_with(sk, :prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":     &types.AttributeValueMemberS{Value: TrackPK(trackID)},
+			":prefix": &types.AttributeValueMemberS{Value: "WAIVER#"},
+		},
+		ScanIndexForward: aws.Bool(false),
+		Limit:            aws.Int32(1),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get current waiver: %w", err)
+	}
+	if len(out.Items) == 0 {
+		return nil, nil
+	}
+
+	var w Waiver
+	if err := attributevalue.UnmarshalMap(out.Items[0], &w); err != nil {
+		return nil, fmt.Errorf("unmarshal waiver: %w", err)
+	}
+	return &w, nil
+}
+
+// ListWaivers returns every version of a track's waiver, oldest first.
+func ListWaivers(ctx context.Context, trackID string) ([]Waiver, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(TableName),
+		KeyConditionExpression: aws.String("pk = :pk AND begins_with(sk, :prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":     &types.AttributeValueMemberS{Value: TrackPK(trackID)},
+			":prefix": &types.AttributeValueMemberS{Value: "WAIVER#"},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list waivers: %w", err)
+	}
+
+	var waivers []Waiver
+	if err := attributevalue.UnmarshalListOfMaps(out.Items, &waivers); err != nil {
+		return nil, fmt.Errorf("unmarshal waivers: %w", err)
+	}
+	return waivers, nil
+}
+
+// SignWaiver records a signature. Signatures are kept as signed, so signing
+// the same version twice gives ErrWaiverSigned.
+func SignWaiver(ctx context.Context, sig WaiverSignature) (*WaiverSignature, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	sig.PK = UserPK(sig.UID)
+	sig.SK = WaiverSigSK(sig.TrackID, sig.Version)
+	sig.GSI1PK = WaiverSigGSI1PK(sig.TrackID)
+	sig.GSI1SK = WaiverSigGSI1SK(sig.Version, sig.UID)
+	if sig.SignedAt == "" {
+		sig.SignedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	item, err := attributevalue.MarshalMap(sig)
+	if err != nil {
+		return nil, fmt.Errorf("marshal waiver signature: %w", err)
+	}
+
+	_, err = c.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(TableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(pk)"),
+	})
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return nil, ErrWaiverSigned
+	}
+	if err != nil {
+		return nil, fmt.Errorf("put waiver signature: %w", err)
+	}
+	return &sig, nil
+}
+
+// GetWaiverSignature returns a driver's signature of a waiver version, or
+// nil if they haven't signed it.
+func GetWaiverSignature(ctx context.Context, uid, trackID string, version int) (*WaiverSignature, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(TableName),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: UserPK(uid)},
+			"sk": &types.AttributeValueMemberS{Value: WaiverSigSK(trackID, version)},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get waiver signature: %w", err)
+	}
+	if out.Item == nil {
+		return nil, nil
+	}
+
+	var sig WaiverSignature
+	if err := attributevalue.UnmarshalMap(out.Item, &sig); err != nil {
+		return nil, fmt.Errorf("unmarshal waiver signature: %w", err)
+	}
+	return &sig, nil
+}
+
+// ListUserWaiverSignatures returns every waiver a driver has signed, across
+// tracks and versions.
+func ListUserWaiverSignatures(ctx context.Context, uid string) ([]WaiverSignature, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(TableName),
+		KeyConditionExpression: aws.String("pk = :pk AND begins_with(sk, :prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":     &types.AttributeValueMemberS{Value: UserPK(uid)},
+			":prefix": &types.AttributeValueMemberS{Value: "WAIVERSIG#"},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list user waiver signatures: %w", err)
+	}
+
+	var sigs []WaiverSignature
+	if err := attributevalue.UnmarshalListOfMaps(out.Items, &sigs); err != nil {
+		return nil, fmt.Errorf("unmarshal waiver signatures: %w", err)
+	}
+	return sigs, nil
+}
+
+// ListWaiverSignatures returns the signatures of one version of a track's
+// waiver (via GSI1).
+func ListWaiverSignatures(ctx context.Context, trackID string, version int) ([]WaiverSignature, error) {
+	c, err := client()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(TableName),
+		IndexName:              aws.String("gsi1"),
+		KeyConditionExpression: aws.String("gsi1pk = :pk AND begins_with(gsi1sk, :prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":     &types.AttributeValueMemberS{Value: WaiverSigGSI1PK(trackID)},
+			":prefix": &types.AttributeValueMemberS{Value: WaiverSigGSI1SK(version, "")},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list waiver signatures: %w", err)
+	}
+
+	var sigs []WaiverSignature
+	if err := attributevalue.UnmarshalListOfMaps(out.Items, &sigs); err != nil {
+		return nil, fmt.Errorf("unmarshal waiver signatures: %w", err)
+	}
+	return sigs, nil
+}
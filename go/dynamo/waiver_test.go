@@ -0,0 +1,72 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestWaiverVersions(t *testing.T) {
+	_, cleanup := setup()
+	defer cleanup()
+	ctx := context.Background()
+
+	if w, err := GetCurrentWaiver(ctx, "t1"); err != nil || w != nil {
+		t.Fatalf("GetCurrentWaiver before publishing = %v, %v", w, err)
+	}
+
+	for i, body := range []string{"first", "second"} {
+		w, err := PublishWaiver(ctx, Waiver{TrackID: "t1", Title: "Release", Body: body})
+		if err != nil {
+			t.Fatalf("PublishWaiver: %v", err)
+		}
+		if w.Version != i+1 {
+			t.Errorf("version = %d, want %d", w.Version, i+1)
+		}
+	}
+
+	cur, err := GetCurrentWaiver(ctx, "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cur == nil || cur.Version != 2 || cur.Body != "second" {
+		t.Errorf("current = %+v, want version 2", cur)
+	}
+	all, err := ListWaivers(ctx, "t1")
+	if err != nil || len(all) != 2 {
+		t.Errorf("ListWaivers = %d, %v; want 2", len(all), err)
+	}
+}
+
+func TestSignWaiver(t *testing.T) {
+	_, cleanup := setup()
+	defer cleanup()
+	ctx := context.Background()
+
+	sig := WaiverSignature{UID: "u1", TrackID: "t1", Version: 2, SignedName: "Ada Driver"}
+	if _, err := SignWaiver(ctx, sig); err != nil {
+		t.Fatalf("SignWaiver: %v", err)
+	}
+	if _, err := SignWaiver(ctx, sig); !errors.Is(err, ErrWaiverSigned) {
+		t.Errorf("second SignWaiver err = %v, want ErrWaiverSigned", err)
+	}
+	if _, err := SignWaiver(ctx, WaiverSignature{UID: "u2", TrackID: "t1", Version: 1, SignedName: "Old Signer"}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := GetWaiverSignature(ctx, "u1", "t1", 2)
+	if err != nil || got == nil || got.SignedName != "Ada Driver" || got.SignedAt == "" {
+		t.Errorf("GetWaiverSignature = %+v, %v", got, err)
+	}
+	if got, _ := GetWaiverSignature(ctx, "u1", "t1", 1); got != nil {
+		t.Errorf("signature of an unsigned version = %+v, want nil", got)
+	}
+
+	sigs, err := ListWaiverSignatures(ctx, "t1", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sigs) != 1 || sigs[0].UID != "u1" {
+		t.Errorf("version 2 signatures = %+v, want only u1", sigs)
+	}
+}
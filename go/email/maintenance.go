@@ -0,0 +1,55 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	htmltpl "html/template"
+	texttpl "text/template"
+)
+
+type MaintenanceData struct {
+	EquipmentName string
+	EquipmentType string
+	HoursUsed     string
+	IntervalHours string
+	Link          string
+}
+
+var maintenanceHTML = htmltpl.Must(htmltpl.New("maintenance").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="UTF-8"></head>
+<body style="font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',Roboto,sans-serif;max-width:600px;margin:0 auto;padding:20px;color:#333">
+  <h2 style="color:#111">Time for a rebuild</h2>
+  <p>Your {{.EquipmentType}} <strong>{{.EquipmentName}}</strong> has run <strong>{{.HoursUsed}} hours</strong> since its last rebuild, reaching its {{.IntervalHours}}-hour interval.</p>
+  <p>
+    <a href="{{.Link}}" style="display:inline-block;padding:12px 24px;background:#0d6efd;color:#fff;text-decoration:none;border-radius:6px;font-weight:600">
+      View Equipment
+    </a>
+  </p>
+  <p style="color:#666;font-size:14px;margin-top:32px">
+    Mark it as rebuilt once the work is done to reset the counter.
+  </p>
+</body>
+</html>`))
+
+var maintenanceText = texttpl.Must(texttpl.New("maintenance").Parse(
+	`Your {{.EquipmentType}} {{.EquipmentName}} has run {{.HoursUsed}} hours since its last rebuild, reaching its {{.IntervalHours}}-hour interval.
+
+View your equipment: {{.Link}}
+`))
+
+func SendMaintenanceReminder(ctx context.Context, to string, data MaintenanceData) error {
+	subject := fmt.Sprintf("%s is due for a rebuild", data.EquipmentName)
+
+	htmlBody, err := renderHTML(maintenanceHTML, data)
+	if err != nil {
+		return fmt.Errorf("render maintenance html: %w", err)
+	}
+
+	textBody, err := renderText(maintenanceText, data)
+	if err != nil {
+		return fmt.Errorf("render maintenance text: %w", err)
+	}
+
+	return Send(ctx, to, subject, htmlBody, textBody)
+}
@@ -0,0 +1,104 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	htmltpl "html/template"
+	texttpl "text/template"
+)
+
+type TransferData struct {
+	FromName   string
+	ToName     string
+	EntityName string
+	TrackName  string
+	Link       string
+	// SwapEntityName is the session FromName gets in return on a swap;
+	// empty on a transfer.
+	SwapEntityName string
+}
+
+var transferOfferHTML = htmltpl.Must(htmltpl.New("transferOffer").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="UTF-8"></head>
+<body style="font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',Roboto,sans-serif;max-width:600px;margin:0 auto;padding:20px;color:#333">
+  {{if .SwapEntityName}}<h2 style="color:#111">Swap sessions?</h2>
+  <p><strong>{{.FromName}}</strong> would like to swap their spot in <strong>{{.EntityName}}</strong> for yours in <strong>{{.SwapEntityName}}</strong> at <strong>{{.TrackName}}</strong>.</p>
+  {{else}}<h2 style="color:#111">A spot is yours if you want it</h2>
+  <p><strong>{{.FromName}}</strong> would like to hand you their registration for <strong>{{.EntityName}}</strong> at <strong>{{.TrackName}}</strong>. Any entry fee they paid comes with it.</p>{{end}}
+  <p>
+    <a href="{{.Link}}" style="display:inline-block;padding:12px 24px;background:#0d6efd;color:#fff;text-decoration:none;border-radius:6px;font-weight:600">
+      {{if .SwapEntityName}}View Swap{{else}}View Transfer{{end}}
+    </a>
+  </p>
+  <p style="color:#666;font-size:14px;margin-top:32px">
+    The offer lapses after 14 days. If you don't have an account yet, you can sign up when you visit.
+  </p>
+</body>
+</html>`))
+
+var transferOfferText = texttpl.Must(texttpl.New("transferOffer").Parse(
+	`{{if .SwapEntityName}}{{.FromName}} would like to swap their spot in {{.EntityName}} for yours in {{.SwapEntityName}} at {{.TrackName}}.{{else}}{{.FromName}} would like to hand you their registration for {{.EntityName}} at {{.TrackName}}. Any entry fee they paid comes with it.{{end}}
+
+Accept on Kart Track Park within 14 days: {{.Link}}
+`))
+
+var transferDoneHTML = htmltpl.Must(htmltpl.New("transferDone").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="UTF-8"></head>
+<body style="font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',Roboto,sans-serif;max-width:600px;margin:0 auto;padding:20px;color:#333">
+  {{if .SwapEntityName}}<h2 style="color:#111">Sessions swapped</h2>
+  <p><strong>{{.FromName}}</strong> is now in <strong>{{.SwapEntityName}}</strong> and <strong>{{.ToName}}</strong> in <strong>{{.EntityName}}</strong> at <strong>{{.TrackName}}</strong>.</p>
+  {{else}}<h2 style="color:#111">Registration transferred</h2>
+  <p>The registration for <strong>{{.EntityName}}</strong> at <strong>{{.TrackName}}</strong> has passed from <strong>{{.FromName}}</strong> to <strong>{{.ToName}}</strong>.</p>{{end}}
+  <p>
+    <a href="{{.Link}}" style="display:inline-block;padding:12px 24px;background:#0d6efd;color:#fff;text-decoration:none;border-radius:6px;font-weight:600">
+      View Registration
+    </a>
+  </p>
+</body>
+</html>`))
+
+var transferDoneText = texttpl.Must(texttpl.New("transferDone").Parse(
+	`{{if .SwapEntityName}}{{.FromName}} is now in {{.SwapEntityName}} and {{.ToName}} in {{.EntityName}} at {{.TrackName}}.{{else}}The registration for {{.EntityName}} at {{.TrackName}} has passed from {{.FromName}} to {{.ToName}}.{{end}}
+
+{{.Link}}
+`))
+
+func SendTransferOffer(ctx context.Context, to string, data TransferData) error {
+	subject := fmt.Sprintf("%s offered you their spot in %s", data.FromName, data.EntityName)
+	if data.SwapEntityName != "" {
+		subject = fmt.Sprintf("%s wants to swap sessions with you", data.FromName)
+	}
+
+	htmlBody, err := renderHTML(transferOfferHTML, data)
+	if err != nil {
+		return fmt.Errorf("render transfer offer html: %w", err)
+	}
+
+	textBody, err := renderText(transferOfferText, data)
+	if err != nil {
+		return fmt.Errorf("render transfer offer text: %w", err)
+	}
+
+	return Send(ctx, to, subject, htmlBody, textBody)
+}
+
+func SendTransferDone(ctx context.Context, to string, data TransferData) error {
+	subject := fmt.Sprintf("Registration for %s transferred", data.EntityName)
+	if data.SwapEntityName != "" {
+		subject = fmt.Sprintf("Sessions swapped: %s and %s", data.EntityName, data.SwapEntityName)
+	}
+
+	htmlBody, err := renderHTML(transferDoneHTML, data)
+	if err != nil {
+		return fmt.Errorf("render transfer html: %w", err)
+	}
+
+	textBody, err := renderText(transferDoneText, data)
+	if err != nil {
+		return fmt.Errorf("render transfer text: %w", err)
+	}
+
+	return Send(ctx, to, subject, htmlBody, textBody)
+}
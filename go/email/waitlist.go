@@ -0,0 +1,68 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	htmltpl "html/template"
+	texttpl "text/template"
+)
+
+type WaitlistData struct {
+	DriverName string
+	EntityName string
+	TrackName  string
+	Link       string
+	// ExpiresAt is when an offered spot lapses, already formatted; empty when
+	// the driver was confirmed straight away.
+	ExpiresAt    string
+	NeedsPayment bool
+	NeedsWaiver  bool // the track's current waiver is still to sign
+}
+
+var waitlistHTML = htmltpl.Must(htmltpl.New("waitlist").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="UTF-8"></head>
+<body style="font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',Roboto,sans-serif;max-width:600px;margin:0 auto;padding:20px;color:#333">
+  <h2 style="color:#111">A spot opened up!</h2>
+  <p>Hi {{.DriverName}}, a spot in <strong>{{.EntityName}}</strong> at <strong>{{.TrackName}}</strong> has opened up and you're next on the waitlist.</p>
+  {{if .ExpiresAt}}<p>The spot is held for you until <strong>{{.ExpiresAt}}</strong>. Accept it before then or it goes to the next driver.</p>
+  {{else if .NeedsPayment}}<p>Pay the entry fee to confirm your spot.</p>
+  {{else if .NeedsWaiver}}<p>Sign the track's waiver to confirm your spot.</p>
+  {{else}}<p>You're confirmed &mdash; see you on track.</p>{{end}}
+  <p>
+    <a href="{{.Link}}" style="display:inline-block;padding:12px 24px;background:#0d6efd;color:#fff;text-decoration:none;border-radius:6px;font-weight:600">
+      {{if .ExpiresAt}}Accept Spot{{else if .NeedsPayment}}Pay Entry Fee{{else if .NeedsWaiver}}Sign Waiver{{else}}View Registration{{end}}
+    </a>
+  </p>
+</body>
+</html>`))
+
+var waitlistText = texttpl.Must(texttpl.New("waitlist").Parse(
+	`Hi {{.DriverName}}, a spot in {{.EntityName}} at {{.TrackName}} has opened up and you're next on the waitlist.
+{{if .ExpiresAt}}
+The spot is held for you until {{.ExpiresAt}}. Accept it before then or it goes to the next driver.
+{{else if .NeedsPayment}}
+Pay the entry fee to confirm your spot.
+{{else if .NeedsWaiver}}
+Sign the track's waiver to confirm your spot.
+{{else}}
+You're confirmed - see you on track.
+{{end}}
+{{.Link}}
+`))
+
+func SendWaitlistOffer(ctx context.Context, to string, data WaitlistData) error {
+	subject := fmt.Sprintf("A spot opened up in %s", data.EntityName)
+
+	htmlBody, err := renderHTML(waitlistHTML, data)
+	if err != nil {
+		return fmt.Errorf("render waitlist html: %w", err)
+	}
+
+	textBody, err := renderText(waitlistText, data)
+	if err != nil {
+		return fmt.Errorf("render waitlist text: %w", err)
+	}
+
+	return Send(ctx, to, subject, htmlBody, textBody)
+}
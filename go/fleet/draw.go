@@ -0,0 +1,119 @@
+package fleet
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sort"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+)
+
+// MaxSeed keeps draw seeds within the range JavaScript numbers represent
+// exactly, so a seed shown in the UI can be replayed.
+const MaxSeed = 1 << 53
+
+// NewSeed returns a random seed for Draw.
+func NewSeed() int64 { return rand.Int64N(MaxSeed) }
+
+// Draw randomly assigns in-service karts to drivers, reproducibly for a given
+// seed. A driver never gets a kart listed in their used set (karts they already
+// ran earlier in the event), and karts flagged slow are only handed out once
+// every other option is exhausted. Returns uid -> kart.
+func Draw(karts []dynamo.Kart, uids []string, used map[string]map[string]bool, seed int64) (map[string]dynamo.Kart, error) {
+	var fast, slow []dynamo.Kart
+	for _, k := range karts {
+		if !k.InService() {
+			continue
+		}
+		if k.Slow {
+			slow = append(slow, k)
+		} else {
+			fast = append(fast, k)
+		}
+	}
+	if len(fast)+len(slow) < len(uids) {
+		return nil, fmt.Errorf("need %d karts, only %d available", len(uids), len(fast)+len(slow))
+	}
+
+	// Sort before shuffling so the result depends only on the seed, not on
+	// the order the karts were loaded in.
+	byID := func(ks []dynamo.Kart) {
+		sort.Slice(ks, func(i, j int) bool { return ks[i].KartID < ks[j].KartID })
+	}
+	byID(fast)
+	byID(slow)
+	drivers := append([]string(nil), uids...)
+	sort.Strings(drivers)
+
+	rng := rand.New(rand.NewPCG(uint64(seed), uint64(seed)))
+	rng.Shuffle(len(fast), func(i, j int) { fast[i], fast[j] = fast[j], fast[i] })
+	rng.Shuffle(len(slow), func(i, j int) { slow[i], slow[j] = slow[j], slow[i] })
+	rng.Shuffle(len(drivers), func(i, j int) { drivers[i], drivers[j] = drivers[j], drivers[i] })
+
+	// Fast karts come first in the pool so the matching only reaches for a
+	// slow kart when a driver can't be placed otherwise.
+	pool := append(fast, slow...)
+	m := &matcher{pool: pool, used: used, owner: make([]int, len(pool))}
+	for i := range m.owner {
+		m.owner[i] = -1
+	}
+	m.drivers = drivers
+
+	// Two passes: first restrict to fast karts, then let the remaining
+	// drivers (and any they displace) reach into the slow ones.
+	for _, limit := range []int{len(fast), len(pool)} {
+		m.limit = limit
+		for d := range drivers {
+			if m.assigned(d) {
+				continue
+			}
+			m.seen = make([]bool, len(pool))
+			m.augment(d)
+		}
+	}
+
+	out := make(map[string]dynamo.Kart, len(drivers))
+	for k, d := range m.owner {
+		if d >= 0 {
+			out[drivers[d]] = pool[k]
+		}
+	}
+	if len(out) < len(drivers) {
+		return nil, fmt.Errorf("not enough karts to draw without repeating a kart for %d driver(s)", len(drivers)-len(out))
+	}
+	return out, nil
+}
+
+// matcher finds a maximum bipartite matching of drivers to karts using
+// augmenting paths, trying karts in pool order.
+type matcher struct {
+	drivers []string
+	pool    []dynamo.Kart
+	used    map[string]map[string]bool
+	owner   []int // pool index -> driver index, -1 if free
+	seen    []bool
+	limit   int
+}
+
+func (m *matcher) assigned(d int) bool {
+	for _, o := range m.owner {
+		if o == d {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *matcher) augment(d int) bool {
+	for k := 0; k < m.limit; k++ {
+		if m.seen[k] || m.used[m.drivers[d]][m.pool[k].KartID] {
+			continue
+		}
+		m.seen[k] = true
+		if m.owner[k] < 0 || m.augment(m.owner[k]) {
+			m.owner[k] = d
+			return true
+		}
+	}
+	return false
+}
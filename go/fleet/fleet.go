@@ -0,0 +1,168 @@
+// Package fleet summarises rental kart performance and spreads kart
+// assignments evenly across a track's fleet.
+package fleet
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+)
+
+// MinLapsForStats is how many clean laps a kart needs before it is compared
+// against the rest of the fleet.
+const MinLapsForStats = 10
+
+// SlowThresholdPct flags a kart whose median lap is this much slower than the
+// fleet median.
+const SlowThresholdPct = 1.5
+
+// outlierPct drops laps this much slower than the kart's best (spins, pit
+// laps, traffic) before computing the distribution.
+const outlierPct = 20.0
+
+// KartStats is a kart's lap-time distribution relative to the fleet.
+type KartStats struct {
+	KartID   string  `json:"kart_id"`
+	Number   string  `json:"number"`
+	Status   string  `json:"status,omitempty"`
+	Laps     int     `json:"laps"`
+	BestMs   int64   `json:"best_ms,omitempty"`
+	P10Ms    int64   `json:"p10_ms,omitempty"`
+	P25Ms    int64   `json:"p25_ms,omitempty"`
+	MedianMs int64   `json:"median_ms,omitempty"`
+	P75Ms    int64   `json:"p75_ms,omitempty"`
+	P90Ms    int64   `json:"p90_ms,omitempty"`
+	DeltaMs  int64   `json:"delta_ms"`
+	DeltaPct float64 `json:"delta_pct"`
+	Slow     bool    `json:"slow"`
+}
+
+// Stats computes per-kart distributions from lap times keyed by kart ID and
+// compares each kart's median against the fleet median. Karts with fewer than
+// MinLapsForStats clean laps are reported but never flagged.
+func Stats(karts []dynamo.Kart, lapTimes map[string][]int64) (int64, []KartStats) {
+	stats := make([]KartStats, 0, len(karts))
+	var medians []int64
+	for _, k := range karts {
+		s := KartStats{KartID: k.KartID, Number: k.Number, Status: k.Status}
+		times := clean(lapTimes[k.KartID])
+		s.Laps = len(times)
+		if len(times) > 0 {
+			s.BestMs = times[0]
+			s.P10Ms = Percentile(times, 10)
+			s.P25Ms = Percentile(times, 25)
+			s.MedianMs = Percentile(times, 50)
+			s.P75Ms = Percentile(times, 75)
+			s.P90Ms = Percentile(times, 90)
+		}
+		if s.Laps >= MinLapsForStats {
+			medians = append(medians, s.MedianMs)
+		}
+		stats = append(stats, s)
+	}
+
+	slices.Sort(medians)
+	fleetMedian := Percentile(medians, 50)
+	if fleetMedian > 0 {
+		for i := range stats {
+			s := &stats[i]
+			if s.Laps < MinLapsForStats {
+				continue
+			}
+			s.DeltaMs = s.MedianMs - fleetMedian
+			s.DeltaPct = float64(s.DeltaMs) / float64(fleetMedian) * 100
+			s.Slow = s.DeltaPct >= SlowThresholdPct
+		}
+	}
+
+	sort.SliceStable(stats, func(i, j int) bool {
+		if stats[i].Laps == 0 || stats[j].Laps == 0 {
+			return stats[i].Laps > stats[j].Laps
+		}
+		return stats[i].MedianMs < stats[j].MedianMs
+	})
+	return fleetMedian, stats
+}
+
+// clean sorts lap times ascending and drops non-positive times and outliers.
+func clean(times []int64) []int64 {
+	sorted := make([]int64, 0, len(times))
+	for _, t := range times {
+		if t > 0 {
+			sorted = append(sorted, t)
+		}
+	}
+	slices.Sort(sorted)
+	if len(sorted) == 0 {
+		return sorted
+	}
+
+	limit := float64(sorted[0]) * (1 + outlierPct/100)
+	n := len(sorted)
+	for n > 0 && float64(sorted[n-1]) > limit {
+		n--
+	}
+	return sorted[:n]
+}
+
+// Percentile returns the nearest-rank percentile of an ascending slice.
+func Percentile(sorted []int64, p int) int64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// Balance assigns an in-service kart to each driver, preferring the karts with
+// the fewest sessions so wear spreads evenly. Karts listed in taken are
+// already in use and skipped. Returns uid -> kart.
+func Balance(karts []dynamo.Kart, uids []string, taken map[string]bool) (map[string]dynamo.Kart, error) {
+	var pool []dynamo.Kart
+	for _, k := range karts {
+		if k.InService() && !taken[k.KartID] {
+			pool = append(pool, k)
+		}
+	}
+	if len(pool) < len(uids) {
+		return nil, fmt.Errorf("need %d karts, only %d available", len(uids), len(pool))
+	}
+
+	sort.SliceStable(pool, func(i, j int) bool {
+		if pool[i].SessionCount != pool[j].SessionCount {
+			return pool[i].SessionCount < pool[j].SessionCount
+		}
+		return compareNumbers(pool[i].Number, pool[j].Number) < 0
+	})
+
+	out := make(map[string]dynamo.Kart, len(uids))
+	for i, uid := range uids {
+		out[uid] = pool[i]
+	}
+	return out, nil
+}
+
+// compareNumbers orders kart numbers: whole numbers numerically, so 2 comes
+// before 10, then any others (like 1A) as text.
+func compareNumbers(a, b string) int {
+	x, errA := strconv.Atoi(a)
+	y, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		if c := cmp.Compare(x, y); c != 0 {
+			return c
+		}
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return cmp.Compare(a, b)
+}
@@ -0,0 +1,161 @@
+package fleet
+
+import (
+	"testing"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+)
+
+func laps(base int64, n int) []int64 {
+	out := make([]int64, n)
+	for i := range out {
+		out[i] = base + int64(i%5)*100
+	}
+	return out
+}
+
+func TestStatsFlagsSlowKart(t *testing.T) {
+	karts := []dynamo.Kart{
+		{KartID: "a", Number: "1"},
+		{KartID: "b", Number: "2"},
+		{KartID: "c", Number: "3"},
+		{KartID: "d", Number: "4"},
+	}
+	times := map[string][]int64{
+		"a": laps(30000, 20),
+		"b": laps(30100, 20),
+		"c": laps(31000, 20), // ~3% slower
+		"d": laps(29000, 3),  // too few laps to judge
+	}
+
+	fleetMedian, stats := Stats(karts, times)
+	if fleetMedian != 30300 {
+		t.Fatalf("fleet median = %d, want 30300", fleetMedian)
+	}
+
+	byID := map[string]KartStats{}
+	for _, s := range stats {
+		byID[s.KartID] = s
+	}
+	if !byID["c"].Slow {
+		t.Errorf("kart c not flagged slow: %+v", byID["c"])
+	}
+	if byID["a"].Slow || byID["b"].Slow {
+		t.Error("fast karts flagged slow")
+	}
+	if byID["d"].Slow || byID["d"].DeltaMs != 0 {
+		t.Errorf("kart d with %d laps should not be compared", byID["d"].Laps)
+	}
+	if stats[0].KartID != "d" && stats[0].KartID != "a" {
+		t.Errorf("stats not sorted by median: first = %s", stats[0].KartID)
+	}
+}
+
+func TestStatsDropsOutliers(t *testing.T) {
+	times := map[string][]int64{"a": append(laps(30000, 10), 45000, 0)}
+	_, stats := Stats([]dynamo.Kart{{KartID: "a"}}, times)
+	if stats[0].Laps != 10 {
+		t.Errorf("laps = %d, want 10 after dropping outliers", stats[0].Laps)
+	}
+	if stats[0].BestMs != 30000 {
+		t.Errorf("best = %d, want 30000", stats[0].BestMs)
+	}
+}
+
+func TestBalance(t *testing.T) {
+	karts := []dynamo.Kart{
+		{KartID: "a", Number: "1", SessionCount: 5},
+		{KartID: "b", Number: "2", SessionCount: 1},
+		{KartID: "c", Number: "3", SessionCount: 0, Status: "out_of_service"},
+		{KartID: "d", Number: "4", SessionCount: 1},
+		{KartID: "e", Number: "5", SessionCount: 0},
+	}
+
+	got, err := Balance(karts, []string{"u1", "u2", "u3"}, map[string]bool{"e": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"u1": "b", "u2": "d", "u3": "a"}
+	for uid, kartID := range want {
+		if got[uid].KartID != kartID {
+			t.Errorf("%s got kart %s, want %s", uid, got[uid].KartID, kartID)
+		}
+	}
+
+	if _, err := Balance(karts, []string{"u1", "u2", "u3", "u4", "u5"}, nil); err == nil {
+		t.Error("expected error when fleet is too small")
+	}
+
+	// Equally used karts go out in number order, 2 before 10
+	even := []dynamo.Kart{{KartID: "k10", Number: "10"}, {KartID: "k2", Number: "2"}, {KartID: "k1a", Number: "1A"}}
+	got, err = Balance(even, []string{"u1", "u2", "u3"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["u1"].Number != "2" || got["u2"].Number != "10" || got["u3"].Number != "1A" {
+		t.Errorf("got %s, %s, %s; want karts 2, 10, 1A", got["u1"].Number, got["u2"].Number, got["u3"].Number)
+	}
+}
+
+func TestDrawAvoidsRepeatsAndSlowKarts(t *testing.T) {
+	karts := []dynamo.Kart{
+		{KartID: "a", Number: "1"},
+		{KartID: "b", Number: "2"},
+		{KartID: "c", Number: "3", Slow: true},
+		{KartID: "d", Number: "4", Status: "out_of_service"},
+	}
+	used := map[string]map[string]bool{
+		"u1": {"a": true},
+	}
+
+	for seed := int64(0); seed < 50; seed++ {
+		got, err := Draw(karts, []string{"u1", "u2"}, used, seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got["u1"].KartID != "b" || got["u2"].KartID != "a" {
+			t.Fatalf("seed %d: got u1=%s u2=%s, want u1=b u2=a", seed, got["u1"].KartID, got["u2"].KartID)
+		}
+	}
+
+	// With three drivers the slow kart has to be used
+	got, err := Draw(karts, []string{"u1", "u2", "u3"}, used, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || got["u1"].KartID == "a" {
+		t.Errorf("bad draw: %+v", got)
+	}
+}
+
+func TestDrawIsReproducible(t *testing.T) {
+	var karts []dynamo.Kart
+	for _, id := range []string{"a", "b", "c", "d", "e", "f"} {
+		karts = append(karts, dynamo.Kart{KartID: id})
+	}
+	uids := []string{"u1", "u2", "u3", "u4"}
+
+	first, err := Draw(karts, uids, nil, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Same seed, different input order
+	reversed := []dynamo.Kart{karts[5], karts[4], karts[3], karts[2], karts[1], karts[0]}
+	second, err := Draw(reversed, []string{"u4", "u3", "u2", "u1"}, nil, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range uids {
+		if first[u].KartID != second[u].KartID {
+			t.Errorf("%s: %s != %s", u, first[u].KartID, second[u].KartID)
+		}
+	}
+}
+
+func TestDrawFailsWhenRepeatsUnavoidable(t *testing.T) {
+	karts := []dynamo.Kart{{KartID: "a"}, {KartID: "b"}}
+	used := map[string]map[string]bool{"u1": {"a": true}, "u2": {"a": true}}
+	if _, err := Draw(karts, []string{"u1", "u2"}, used, 1); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -0,0 +1,296 @@
+// Package grid builds starting grids for an event session from the results of
+// the sessions run before it.
+package grid
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sort"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+)
+
+// Grid rules
+const (
+	RuleQualifying = "qualifying"  // finishing order of the last qualifying session
+	RuleInvertTop  = "invert_top"  // last session's order with the top N reversed
+	RuleHeatPoints = "heat_points" // points summed across every heat
+	RuleRandom     = "random"      // seeded random draw
+)
+
+// ValidRules lists the rules Build understands.
+var ValidRules = map[string]bool{RuleQualifying: true, RuleInvertTop: true, RuleHeatPoints: true, RuleRandom: true}
+
+// Source is an earlier session in the event and its results.
+type Source struct {
+	SessionID   string
+	SessionType string
+	Order       int
+	Results     []dynamo.Result
+}
+
+// Options configures Build.
+type Options struct {
+	Rule         string
+	InvertCount  int   // invert_top: how many front-runners to reverse
+	PointsScheme []int // heat_points: points by finishing position
+	Seed         int64 // random
+	// Entrants (uid -> name) of the target session. When set, drivers from
+	// earlier sessions who aren't entered (e.g. they go to another main) are
+	// left out, and entrants without an earlier result start from the back,
+	// by name. For a random draw they are the whole field.
+	Entrants map[string]string
+	// Drops are grid penalties: uid -> places to move back.
+	Drops map[string]int
+}
+
+// Build orders the grid for a session. Sources must be the sessions before
+// it in the event; they need not be sorted.
+func Build(opts Options, sources []Source) ([]dynamo.GridEntry, error) {
+	sorted := append([]Source(nil), sources...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Order < sorted[j].Order })
+
+	var order []dynamo.GridEntry
+	switch opts.Rule {
+	case RuleQualifying:
+		src := last(sorted, func(s Source) bool { return s.SessionType == "quali" })
+		if src == nil {
+			return nil, fmt.Errorf("no qualifying results before this session")
+		}
+		order = finishingOrder(src)
+	case RuleInvertTop:
+		src := last(sorted, func(Source) bool { return true })
+		if src == nil {
+			return nil, fmt.Errorf("no results before this session")
+		}
+		if opts.InvertCount < 2 {
+			return nil, fmt.Errorf("invert count must be at least 2")
+		}
+		order = finishingOrder(src)
+		n := min(opts.InvertCount, countClassified(src, len(order)))
+		for i, j := 0, n-1; i < j; i, j = i+1, j-1 {
+			order[i], order[j] = order[j], order[i]
+		}
+	case RuleHeatPoints:
+		var heats []Source
+		for _, s := range sorted {
+			if s.SessionType == "heat" && len(s.Results) > 0 {
+				heats = append(heats, s)
+			}
+		}
+		if len(heats) == 0 {
+			return nil, fmt.Errorf("no heat results before this session")
+		}
+		order = heatPoints(heats, opts.PointsScheme)
+	case RuleRandom:
+		order = randomOrder(opts, sorted)
+	default:
+		return nil, fmt.Errorf("unknown grid rule %q", opts.Rule)
+	}
+
+	if len(opts.Entrants) > 0 {
+		kept := order[:0]
+		for _, e := range order {
+			if _, ok := opts.Entrants[e.UID]; ok {
+				kept = append(kept, e)
+			}
+		}
+		order = kept
+	}
+	order = appendMissing(order, opts.Entrants)
+	order = applyDrops(order, opts.Drops)
+	for i := range order {
+		order[i].Pos = i + 1
+		if order[i].DriverName == "" {
+			order[i].DriverName = opts.Entrants[order[i].UID]
+		}
+	}
+	return order, nil
+}
+
+func last(sources []Source, match func(Source) bool) *Source {
+	for i := len(sources) - 1; i >= 0; i-- {
+		if len(sources[i].Results) > 0 && match(sources[i]) {
+			return &sources[i]
+		}
+	}
+	return nil
+}
+
+func finishingOrder(src *Source) []dynamo.GridEntry {
+	results := append([]dynamo.Result(nil), src.Results...)
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Position < results[j].Position })
+	out := make([]dynamo.GridEntry, len(results))
+	for i, r := range results {
+		out[i] = dynamo.GridEntry{UID: r.UID, DriverName: r.DriverName}
+	}
+	return out
+}
+
+// countClassified is how many of the leading results finished; an inversion
+// never pulls a DNF, DNS or disqualified driver to the front.
+func countClassified(src *Source, n int) int {
+	c := 0
+	for _, r := range src.Results {
+		if r.Status == "" && !r.Disqualified {
+			c++
+		}
+	}
+	return min(c, n)
+}
+
+// heatPoints ranks drivers by points summed over the heats, then best single
+// finish, then number of heats run. Without a points scheme the sum of
+// finishing positions is used instead (lowest first), with a missed heat
+// counting as one place behind that heat's last finisher.
+func heatPoints(heats []Source, scheme []int) []dynamo.GridEntry {
+	type tally struct {
+		uid, name string
+		score     int
+		best      int
+		ran       int
+	}
+	byUID := map[string]*tally{}
+	var all []*tally
+	for _, h := range heats {
+		for _, r := range h.Results {
+			t := byUID[r.UID]
+			if t == nil {
+				t = &tally{uid: r.UID}
+				byUID[r.UID] = t
+				all = append(all, t)
+			}
+			if r.DriverName != "" {
+				t.name = r.DriverName
+			}
+		}
+	}
+
+	usePoints := len(scheme) > 0
+	for _, h := range heats {
+		seen := map[string]bool{}
+		for _, r := range h.Results {
+			t := byUID[r.UID]
+			seen[r.UID] = true
+			t.ran++
+			if t.best == 0 || r.Position < t.best {
+				t.best = r.Position
+			}
+			switch {
+			case !usePoints:
+				t.score += r.Position
+			case r.Disqualified || r.Status == "dns":
+			case r.Points != 0:
+				t.score += r.Points
+			case r.Position >= 1 && r.Position <= len(scheme):
+				t.score += scheme[r.Position-1]
+			}
+		}
+		if !usePoints {
+			for _, t := range all {
+				if !seen[t.uid] {
+					t.score += len(h.Results) + 1
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(all, func(i, j int) bool {
+		a, b := all[i], all[j]
+		if a.score != b.score {
+			if usePoints {
+				return a.score > b.score
+			}
+			return a.score < b.score
+		}
+		if a.best != b.best {
+			return a.best < b.best
+		}
+		return a.ran > b.ran
+	})
+	out := make([]dynamo.GridEntry, len(all))
+	for i, t := range all {
+		out[i] = dynamo.GridEntry{UID: t.uid, DriverName: t.name, Points: t.score}
+	}
+	return out
+}
+
+// randomOrder shuffles the session's entrants, or everyone with an earlier
+// result when no entrants are known. Sorting first makes the order depend
+// only on the seed.
+func randomOrder(opts Options, sources []Source) []dynamo.GridEntry {
+	names := map[string]string{}
+	for uid, name := range opts.Entrants {
+		names[uid] = name
+	}
+	if len(names) == 0 {
+		for _, s := range sources {
+			for _, r := range s.Results {
+				names[r.UID] = r.DriverName
+			}
+		}
+	}
+	uids := make([]string, 0, len(names))
+	for uid := range names {
+		uids = append(uids, uid)
+	}
+	sort.Strings(uids)
+
+	rng := rand.New(rand.NewPCG(uint64(opts.Seed), uint64(opts.Seed)))
+	rng.Shuffle(len(uids), func(i, j int) { uids[i], uids[j] = uids[j], uids[i] })
+
+	out := make([]dynamo.GridEntry, len(uids))
+	for i, uid := range uids {
+		out[i] = dynamo.GridEntry{UID: uid, DriverName: names[uid]}
+	}
+	return out
+}
+
+func appendMissing(order []dynamo.GridEntry, entrants map[string]string) []dynamo.GridEntry {
+	placed := map[string]bool{}
+	for _, e := range order {
+		placed[e.UID] = true
+	}
+	var missing []dynamo.GridEntry
+	for uid, name := range entrants {
+		if !placed[uid] {
+			missing = append(missing, dynamo.GridEntry{UID: uid, DriverName: name})
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool {
+		if missing[i].DriverName != missing[j].DriverName {
+			return missing[i].DriverName < missing[j].DriverName
+		}
+		return missing[i].UID < missing[j].UID
+	})
+	return append(order, missing...)
+}
+
+// applyDrops moves penalised drivers back. A driver landing level with an
+// unpenalised one starts behind them.
+func applyDrops(order []dynamo.GridEntry, drops map[string]int) []dynamo.GridEntry {
+	if len(drops) == 0 {
+		return order
+	}
+	type slot struct {
+		at, drop int
+		e        dynamo.GridEntry
+	}
+	slots := make([]slot, len(order))
+	for i, e := range order {
+		d := drops[e.UID]
+		e.GridPenalty = d
+		slots[i] = slot{at: i + d, drop: d, e: e}
+	}
+	sort.SliceStable(slots, func(i, j int) bool {
+		if slots[i].at != slots[j].at {
+			return slots[i].at < slots[j].at
+		}
+		return slots[i].drop < slots[j].drop
+	})
+	out := make([]dynamo.GridEntry, len(slots))
+	for i, s := range slots {
+		out[i] = s.e
+	}
+	return out
+}
@@ -0,0 +1,109 @@
+package grid
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+)
+
+func results(uids ...string) []dynamo.Result {
+	out := make([]dynamo.Result, len(uids))
+	for i, uid := range uids {
+		out[i] = dynamo.Result{UID: uid, DriverName: strings.ToUpper(uid), Position: i + 1}
+	}
+	return out
+}
+
+func uids(grid []dynamo.GridEntry) string {
+	var b strings.Builder
+	for i, e := range grid {
+		if e.Pos != i+1 {
+			return "bad pos"
+		}
+		b.WriteString(e.UID)
+	}
+	return b.String()
+}
+
+func TestBuildQualifying(t *testing.T) {
+	sources := []Source{
+		{SessionID: "q", SessionType: "quali", Order: 2, Results: results("c", "a", "b")},
+		{SessionID: "p", SessionType: "practice", Order: 1, Results: results("a", "b", "c")},
+	}
+	entrants := map[string]string{"a": "A", "c": "C", "z": "Late Entry"}
+
+	got, err := Build(Options{Rule: RuleQualifying, Entrants: entrants}, sources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// b isn't entered in this session
+	if uids(got) != "caz" || got[2].DriverName != "Late Entry" {
+		t.Fatalf("grid = %+v", got)
+	}
+
+	// Grid penalty: c drops two places and starts behind b
+	got, _ = Build(Options{Rule: RuleQualifying, Drops: map[string]int{"c": 2}}, sources)
+	if uids(got) != "abc" || got[2].GridPenalty != 2 {
+		t.Fatalf("with drop = %+v", got)
+	}
+
+	if _, err := Build(Options{Rule: RuleQualifying}, sources[1:]); err == nil {
+		t.Error("expected an error without a qualifying session")
+	}
+}
+
+func TestBuildInvertTop(t *testing.T) {
+	heat := results("a", "b", "c", "d", "e")
+	heat[4].Status = "dnf"
+	sources := []Source{{SessionType: "heat", Order: 1, Results: heat}}
+
+	got, err := Build(Options{Rule: RuleInvertTop, InvertCount: 3}, sources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uids(got) != "cbade" {
+		t.Fatalf("grid = %s", uids(got))
+	}
+
+	// Never inverts past the classified finishers
+	got, _ = Build(Options{Rule: RuleInvertTop, InvertCount: 10}, sources)
+	if uids(got) != "dcbae" {
+		t.Fatalf("grid = %s", uids(got))
+	}
+}
+
+func TestBuildHeatPoints(t *testing.T) {
+	sources := []Source{
+		{SessionType: "heat", Order: 1, Results: results("a", "b", "c")},
+		{SessionType: "heat", Order: 2, Results: results("b", "c", "a")},
+		{SessionType: "quali", Order: 0, Results: results("c", "b", "a")}, // ignored
+	}
+
+	// a: 10+5=15, b: 8+10=18, c: 5+8=13
+	got, err := Build(Options{Rule: RuleHeatPoints, PointsScheme: []int{10, 8, 5}}, sources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uids(got) != "bac" || got[0].Points != 18 {
+		t.Fatalf("grid = %+v", got)
+	}
+
+	// By position sum: a 1+3=4, b 2+1=3, c 3+2=5
+	got, _ = Build(Options{Rule: RuleHeatPoints}, sources)
+	if uids(got) != "bac" {
+		t.Fatalf("grid = %s", uids(got))
+	}
+}
+
+func TestBuildRandom(t *testing.T) {
+	entrants := map[string]string{"a": "A", "b": "B", "c": "C", "d": "D", "e": "E"}
+	first, err := Build(Options{Rule: RuleRandom, Seed: 42, Entrants: entrants}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := Build(Options{Rule: RuleRandom, Seed: 42, Entrants: entrants}, nil)
+	if uids(first) != uids(again) || len(first) != 5 {
+		t.Fatalf("same seed gave %s and %s", uids(first), uids(again))
+	}
+}
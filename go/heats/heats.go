@@ -0,0 +1,149 @@
+// Package heats splits an event's entry list into heat sessions that fit the
+// session capacity.
+package heats
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Split strategies
+const (
+	ByClass     = "class"     // one set of heats per kart class
+	ByStandings = "standings" // championship order, leaders together
+	ByBestLap   = "best_lap"  // best lap order, fastest together
+	Snake       = "snake"     // seeds dealt across heats 1..n, n..1, ...
+)
+
+// ValidStrategies lists the strategies Split understands.
+var ValidStrategies = map[string]bool{ByClass: true, ByStandings: true, ByBestLap: true, Snake: true}
+
+// Driver is an entrant to be placed in a heat.
+type Driver struct {
+	UID     string
+	Name    string
+	ClassID string
+	Seed    int // 1 is the top seed; 0 is unseeded
+}
+
+// Heat is one session's worth of drivers.
+type Heat struct {
+	ClassID string
+	Drivers []Driver
+}
+
+// Seed numbers drivers by championship position, then by best lap for those
+// without one. Anyone with neither is left unseeded.
+func Seed(drivers []Driver, positions map[string]int, bestLaps map[string]int64) {
+	ranked := make([]*Driver, 0, len(drivers))
+	for i := range drivers {
+		drivers[i].Seed = 0
+		if positions[drivers[i].UID] > 0 || bestLaps[drivers[i].UID] > 0 {
+			ranked = append(ranked, &drivers[i])
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		pi, pj := positions[ranked[i].UID], positions[ranked[j].UID]
+		if (pi > 0) != (pj > 0) {
+			return pi > 0
+		}
+		if pi != pj {
+			return pi < pj
+		}
+		return bestLaps[ranked[i].UID] < bestLaps[ranked[j].UID]
+	})
+	for i, d := range ranked {
+		d.Seed = i + 1
+	}
+}
+
+// Split divides drivers into as few heats as fit maxSpots each, keeping heat
+// sizes within one of each other. Drivers should already be seeded.
+func Split(strategy string, drivers []Driver, maxSpots int) ([]Heat, error) {
+	if maxSpots <= 0 {
+		return nil, fmt.Errorf("max spots must be positive")
+	}
+	ordered := seedOrder(drivers)
+
+	switch strategy {
+	case ByStandings, ByBestLap:
+		return chunk("", ordered, maxSpots), nil
+	case Snake:
+		return snake(ordered, maxSpots), nil
+	case ByClass:
+		var classes []string
+		byClass := map[string][]Driver{}
+		for _, d := range ordered {
+			if _, ok := byClass[d.ClassID]; !ok {
+				classes = append(classes, d.ClassID)
+			}
+			byClass[d.ClassID] = append(byClass[d.ClassID], d)
+		}
+		// Unclassed drivers run last
+		sort.SliceStable(classes, func(i, j int) bool {
+			if (classes[i] == "") != (classes[j] == "") {
+				return classes[j] == ""
+			}
+			return classes[i] < classes[j]
+		})
+		var out []Heat
+		for _, c := range classes {
+			out = append(out, chunk(c, byClass[c], maxSpots)...)
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("unknown split strategy %q", strategy)
+	}
+}
+
+// seedOrder sorts seeded drivers first, then the rest by name.
+func seedOrder(drivers []Driver) []Driver {
+	out := append([]Driver(nil), drivers...)
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if (a.Seed > 0) != (b.Seed > 0) {
+			return a.Seed > 0
+		}
+		if a.Seed != b.Seed {
+			return a.Seed < b.Seed
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.UID < b.UID
+	})
+	return out
+}
+
+func heatCount(n, maxSpots int) int {
+	return (n + maxSpots - 1) / maxSpots
+}
+
+// chunk cuts drivers into consecutive runs, the larger runs first.
+func chunk(classID string, drivers []Driver, maxSpots int) []Heat {
+	k := heatCount(len(drivers), maxSpots)
+	out := make([]Heat, k)
+	start := 0
+	for i := range out {
+		size := len(drivers) / k
+		if i < len(drivers)%k {
+			size++
+		}
+		out[i] = Heat{ClassID: classID, Drivers: drivers[start : start+size]}
+		start += size
+	}
+	return out
+}
+
+func snake(drivers []Driver, maxSpots int) []Heat {
+	k := heatCount(len(drivers), maxSpots)
+	out := make([]Heat, k)
+	for i, d := range drivers {
+		h := i % k
+		if (i/k)%2 == 1 {
+			h = k - 1 - h
+		}
+		out[h].Drivers = append(out[h].Drivers, d)
+	}
+	return out
+}
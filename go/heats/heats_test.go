@@ -0,0 +1,101 @@
+package heats
+
+import (
+	"strings"
+	"testing"
+)
+
+func drivers(spec string) []Driver {
+	out := make([]Driver, len(spec))
+	for i, c := range spec {
+		out[i] = Driver{UID: string(c), Name: strings.ToUpper(string(c))}
+	}
+	return out
+}
+
+func layout(heats []Heat) string {
+	parts := make([]string, len(heats))
+	for i, h := range heats {
+		var b strings.Builder
+		for _, d := range h.Drivers {
+			b.WriteString(d.UID)
+		}
+		parts[i] = b.String()
+	}
+	return strings.Join(parts, "|")
+}
+
+func TestSeed(t *testing.T) {
+	ds := drivers("abcde")
+	Seed(ds, map[string]int{"c": 2, "e": 1}, map[string]int64{"a": 51000, "b": 50000, "c": 49000})
+	want := map[string]int{"e": 1, "c": 2, "b": 3, "a": 4, "d": 0}
+	for _, d := range ds {
+		if d.Seed != want[d.UID] {
+			t.Errorf("%s seed = %d, want %d", d.UID, d.Seed, want[d.UID])
+		}
+	}
+}
+
+func TestSplitConsecutive(t *testing.T) {
+	ds := drivers("abcdefg")
+	for i := range ds {
+		ds[i].Seed = i + 1
+	}
+	got, err := Split(ByBestLap, ds, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if layout(got) != "abc|de|fg" {
+		t.Fatalf("heats = %s", layout(got))
+	}
+
+	got, _ = Split(ByStandings, ds, 10)
+	if layout(got) != "abcdefg" {
+		t.Fatalf("single heat = %s", layout(got))
+	}
+}
+
+func TestSplitSnake(t *testing.T) {
+	ds := drivers("abcdefgh")
+	for i := range ds {
+		ds[i].Seed = i + 1
+	}
+	// Unseeded drivers are dealt last, by name
+	ds = append(ds, Driver{UID: "z", Name: "Aaron"})
+
+	got, err := Split(Snake, ds, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if layout(got) != "afg|beh|cdz" {
+		t.Fatalf("heats = %s", layout(got))
+	}
+}
+
+func TestSplitByClass(t *testing.T) {
+	ds := drivers("abcdef")
+	classes := []string{"senior", "junior", "senior", "", "senior", "junior"}
+	for i := range ds {
+		ds[i].ClassID = classes[i]
+	}
+
+	got, err := Split(ByClass, ds, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if layout(got) != "bf|ac|e|d" {
+		t.Fatalf("heats = %s", layout(got))
+	}
+	if got[0].ClassID != "junior" || got[3].ClassID != "" {
+		t.Fatalf("classes = %q, %q", got[0].ClassID, got[3].ClassID)
+	}
+}
+
+func TestSplitErrors(t *testing.T) {
+	if _, err := Split(ByBestLap, drivers("ab"), 0); err == nil {
+		t.Error("expected error for zero max spots")
+	}
+	if _, err := Split("bogus", drivers("ab"), 2); err == nil {
+		t.Error("expected error for unknown strategy")
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/ballast"
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+	"github.com/BrianLeishman/karttrackpark.com/go/standings"
+)
+
+type driverBallast struct {
+	UID        string `json:"uid"`
+	DriverName string `json:"driver_name"`
+	ClassID    string `json:"class_id,omitempty"`
+	ClassName  string `json:"class_name,omitempty"`
+	ballast.Requirement
+}
+
+// nextSeriesEvent returns the series round to work ballast out for: the one
+// given by eventID, else the first round that hasn't started yet. It returns
+// nil when every round is in the past.
+func nextSeriesEvent(links []dynamo.SeriesEvent, eventID string) *dynamo.SeriesEvent {
+	now := time.Now().UTC().Format(time.RFC3339)
+	for i, link := range links {
+		if eventID != "" && link.EventID == eventID {
+			return &links[i]
+		}
+		if eventID == "" && link.StartTime >= now {
+			return &links[i]
+		}
+	}
+	return nil
+}
+
+// priorRaceResults collects each driver's results from the scoring sessions
+// of the rounds before the next one (every round when next is nil), so a
+// round counts towards ballast the way it counts in the standings.
+func priorRaceResults(ctx context.Context, cfg dynamo.ScoringConfig, links []dynamo.SeriesEvent, next *dynamo.SeriesEvent) (map[string][]dynamo.Result, error) {
+	byUID := map[string][]dynamo.Result{}
+	for _, link := range links {
+		if next != nil && link.RoundNumber >= next.RoundNumber {
+			break
+		}
+		sessions, err := dynamo.ListEventSessions(ctx, link.EventID)
+		if err != nil {
+			return nil, err
+		}
+		for _, es := range standings.ScoringSessions(cfg, sessions) {
+			results, err := dynamo.ListResultsForSession(ctx, es.SessionID)
+			if err != nil {
+				return nil, err
+			}
+			for _, res := range results {
+				byUID[res.UID] = append(byUID[res.UID], res)
+			}
+		}
+	}
+	return byUID, nil
+}
+
+// handleGetSeriesBallast tells each confirmed driver in a series the ballast
+// they must carry at the next round (or the round given by ?event_id=), from
+// their class's rules, their recorded weight and their results in the
+// scoring sessions of earlier rounds. A driver's class and weight on the
+// event entry take precedence over their series entry.
+func handleGetSeriesBallast(w http.ResponseWriter, r *http.Request) {
+	series, err := dynamo.GetSeries(r.Context(), r.PathValue("id"))
+	if err != nil {
+		log.Printf("get series error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if series == nil {
+		writeError(w, http.StatusNotFound, "series not found")
+		return
+	}
+
+	links, err := dynamo.ListSeriesEvents(r.Context(), series.SeriesID)
+	if err != nil {
+		log.Printf("list series events error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	sort.SliceStable(links, func(i, j int) bool { return links[i].RoundNumber < links[j].RoundNumber })
+
+	eventID := r.URL.Query().Get("event_id")
+	next := nextSeriesEvent(links, eventID)
+	if eventID != "" && next == nil {
+		writeError(w, http.StatusBadRequest, "event is not part of this series")
+		return
+	}
+
+	results, err := priorRaceResults(r.Context(), series.ScoringConfig, links, next)
+	if err != nil {
+		log.Printf("list series results error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	regs, err := dynamo.ListRegistrations(r.Context(), "series", series.SeriesID)
+	if err != nil {
+		log.Printf("list registrations error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	entries := map[string]dynamo.Registration{}
+	if next != nil {
+		eventRegs, err := dynamo.ListRegistrations(r.Context(), "event", next.EventID)
+		if err != nil {
+			log.Printf("list registrations error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		for _, reg := range eventRegs {
+			if reg.Status == "confirmed" && reg.UID != "" {
+				entries[reg.UID] = reg
+			}
+		}
+	}
+
+	classes := map[string]*dynamo.KartClass{}
+	drivers := []driverBallast{}
+	for _, reg := range regs {
+		if reg.Status != "confirmed" || reg.UID == "" {
+			continue
+		}
+		classID, weight := reg.ClassID, reg.WeightKg
+		if entry, ok := entries[reg.UID]; ok {
+			if entry.ClassID != "" {
+				classID = entry.ClassID
+			}
+			if entry.WeightKg > 0 {
+				weight = entry.WeightKg
+			}
+		}
+		if classID == "" {
+			classID = series.ClassID
+		}
+
+		d := driverBallast{UID: reg.UID, DriverName: reg.DriverName, ClassID: classID}
+		var rules dynamo.BallastRules
+		if classID != "" {
+			class, seen := classes[classID]
+			if !seen {
+				class, err = dynamo.GetKartClass(r.Context(), series.TrackID, classID)
+				if err != nil {
+					log.Printf("get kart class error: %v", err)
+					writeError(w, http.StatusInternalServerError, "internal error")
+					return
+				}
+				classes[classID] = class
+			}
+			if class != nil {
+				d.ClassName = class.Name
+				rules = class.BallastRules
+			}
+		}
+		d.Requirement = ballast.Required(rules, weight, results[reg.UID])
+		drivers = append(drivers, d)
+	}
+	sort.SliceStable(drivers, func(i, j int) bool {
+		if drivers[i].TotalKg != drivers[j].TotalKg {
+			return drivers[i].TotalKg > drivers[j].TotalKg
+		}
+		return drivers[i].DriverName < drivers[j].DriverName
+	})
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"next_event": next,
+		"drivers":    drivers,
+	})
+}
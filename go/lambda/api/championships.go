@@ -115,7 +115,10 @@ func handleUpdateChampionship(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	allowed := map[string]bool{"name": true, "description": true, "logoKey": true}
+	allowed := map[string]bool{
+		"name": true, "description": true, "logoKey": true,
+		"champMethod": true, "champPointsScheme": true, "teamCountBest": true, "chassisCountBest": true,
+	}
 	fields := map[string]any{}
 	for k, v := range req {
 		if allowed[k] {
@@ -0,0 +1,423 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/checkin"
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+	"github.com/skip2/go-qrcode"
+)
+
+// checkInRoles may run the gate on the day. Operators do nothing else yet.
+var checkInRoles = []string{"owner", "admin", "operator"}
+
+// eventCheckIn is everything the gate needs for an event: who is expected,
+// their credentials and who has arrived.
+type eventCheckIn struct {
+	WaiverVersion int               `json:"waiver_version,omitempty"`
+	MinAge        int               `json:"min_age,omitempty"`
+	Expected      int               `json:"expected"`
+	Arrived       int               `json:"arrived"`
+	Problems      int               `json:"problems"` // entrants not yet arrived with a credential problem
+	Entrants      []checkin.Entrant `json:"entrants"`
+
+	records map[string]dynamo.CheckIn
+}
+
+// loadEventCheckIn lists the confirmed drivers for an event, including those
+// registered for a series the event is a round of, and checks each one's
+// credentials against the requirements of what they registered for.
+func loadEventCheckIn(ctx context.Context, eventID string, event *regParentInfo) (*eventCheckIn, error) {
+	eventRegs, err := dynamo.ListRegistrations(ctx, "event", eventID)
+	if err != nil {
+		return nil, err
+	}
+	reqs := map[string]checkin.Requirements{
+		eventID: {PriceCents: event.PriceCents, MinAge: event.MinAge},
+	}
+
+	links, err := dynamo.ListSeriesForEvent(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	var seriesRegs [][]dynamo.Registration
+	for _, link := range links {
+		series, err := resolveSeriesParent(ctx, link.SeriesID)
+		if err != nil {
+			return nil, err
+		}
+		if series == nil {
+			continue
+		}
+		regs, err := dynamo.ListRegistrations(ctx, "series", link.SeriesID)
+		if err != nil {
+			return nil, err
+		}
+		// The event's own age limit applies to everyone driving it
+		reqs[link.SeriesID] = checkin.Requirements{PriceCents: series.PriceCents, MinAge: max(series.MinAge, event.MinAge)}
+		seriesRegs = append(seriesRegs, regs)
+	}
+
+	current, err := dynamo.GetCurrentWaiver(ctx, event.TrackID)
+	if err != nil {
+		return nil, err
+	}
+	sigs := map[string]*dynamo.WaiverSignature{}
+	out := &eventCheckIn{MinAge: event.MinAge, records: map[string]dynamo.CheckIn{}}
+	if current != nil {
+		out.WaiverVersion = current.Version
+		list, err := dynamo.ListWaiverSignatures(ctx, event.TrackID, current.Version)
+		if err != nil {
+			return nil, err
+		}
+		for i := range list {
+			sigs[list[i].UID] = &list[i]
+		}
+	}
+
+	records, err := dynamo.ListCheckIns(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	for _, ci := range records {
+		out.records[ci.UID] = ci
+	}
+
+	now := time.Now().UTC()
+	out.Entrants = []checkin.Entrant{}
+	for _, reg := range checkin.Expected(eventRegs, seriesRegs...) {
+		e := checkin.Verify(reg, reqs[reg.ParentID], out.WaiverVersion, sigs[reg.UID], now)
+		if ci, ok := out.records[reg.UID]; ok {
+			e.ArrivedAt, e.Transponder = ci.ArrivedAt, ci.Transponder
+			if ci.KartNumber != "" {
+				e.KartNumber = ci.KartNumber
+			}
+		}
+		out.Entrants = append(out.Entrants, e)
+		if e.ArrivedAt != "" {
+			out.Arrived++
+		} else if len(e.Problems) > 0 {
+			out.Problems++
+		}
+	}
+	checkin.Sort(out.Entrants)
+	out.Expected = len(out.Entrants)
+	return out, nil
+}
+
+func (c *eventCheckIn) entrant(uid string) *checkin.Entrant {
+	for i := range c.Entrants {
+		if c.Entrants[i].UID == uid {
+			return &c.Entrants[i]
+		}
+	}
+	return nil
+}
+
+// requireCheckInStaff resolves the event in the path and checks the caller
+// may run its check-in.
+func requireCheckInStaff(w http.ResponseWriter, r *http.Request) (string, *regParentInfo, bool) {
+	uid, err := requireAuth(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return "", nil, false
+	}
+
+	event, err := resolveEventParent(r.Context(), r.PathValue("id"))
+	if err != nil {
+		log.Printf("resolve event parent error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return "", nil, false
+	}
+	if event == nil {
+		writeError(w, http.StatusNotFound, "event not found")
+		return "", nil, false
+	}
+
+	if err := requireTrackRole(r, event.TrackID, uid, checkInRoles...); err != nil {
+		writeError(w, http.StatusForbidden, err.Error())
+		return "", nil, false
+	}
+	return uid, event, true
+}
+
+// handleEventCheckIn lists the drivers expected at an event for staff at
+// the gate: their credentials, whether they've arrived and what they were
+// given to drive.
+func handleEventCheckIn(w http.ResponseWriter, r *http.Request) {
+	_, event, ok := requireCheckInStaff(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := loadEventCheckIn(r.Context(), r.PathValue("id"), event)
+	if err != nil {
+		log.Printf("load event check-in error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+// handleCheckInDriver marks a driver as arrived. A credential problem
+// refuses the check-in unless staff override it, which is recorded. A
+// transponder given is mapped to the driver for every session of the event
+// so their laps are timed.
+func handleCheckInDriver(w http.ResponseWriter, r *http.Request) {
+	staffUID, event, ok := requireCheckInStaff(w, r)
+	if !ok {
+		return
+	}
+
+	eventID := r.PathValue("id")
+	driverUID := r.PathValue("uid")
+
+	var req struct {
+		Code        string `json:"code"` // from a scanned pass; optional when checking in from the list
+		Transponder string `json:"transponder"`
+		KartNumber  string `json:"kart_number"`
+		Override    bool   `json:"override"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	req.Transponder = strings.TrimSpace(req.Transponder)
+	req.KartNumber = strings.TrimSpace(req.KartNumber)
+	if req.Transponder != "" {
+		if _, err := strconv.ParseUint(req.Transponder, 10, 32); err != nil {
+			writeError(w, http.StatusBadRequest, "transponder must be a number")
+			return
+		}
+	}
+
+	list, err := loadEventCheckIn(r.Context(), eventID, event)
+	if err != nil {
+		log.Printf("load event check-in error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	entrant := list.entrant(driverUID)
+	if entrant == nil {
+		writeError(w, http.StatusNotFound, "driver is not confirmed for this event")
+		return
+	}
+
+	record, err := dynamo.EnsureCheckIn(r.Context(), dynamo.CheckIn{
+		EventID:    eventID,
+		TrackID:    event.TrackID,
+		UID:        driverUID,
+		DriverName: entrant.DriverName,
+	})
+	if err != nil {
+		log.Printf("ensure check-in error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if req.Code != "" && subtle.ConstantTimeCompare([]byte(req.Code), []byte(record.Code)) != 1 {
+		writeError(w, http.StatusBadRequest, "this pass isn't valid for this driver and event")
+		return
+	}
+
+	if len(entrant.Problems) > 0 && !req.Override {
+		writeJSON(w, http.StatusConflict, map[string]any{
+			"error":    "cannot check in: " + strings.Join(entrant.Problems, "; "),
+			"problems": entrant.Problems,
+		})
+		return
+	}
+
+	for _, other := range list.Entrants {
+		if other.UID == driverUID {
+			continue
+		}
+		if req.Transponder != "" && other.Transponder == req.Transponder {
+			writeError(w, http.StatusConflict, fmt.Sprintf("transponder %s is already assigned to %s", req.Transponder, other.DriverName))
+			return
+		}
+		if req.KartNumber != "" && other.KartNumber == req.KartNumber {
+			writeError(w, http.StatusConflict, fmt.Sprintf("kart %s is already assigned to %s", req.KartNumber, other.DriverName))
+			return
+		}
+	}
+
+	fields := map[string]any{
+		"arrivedAt":   time.Now().UTC().Format(time.RFC3339),
+		"checkedInBy": staffUID,
+		"transponder": nil,
+		"kartNumber":  nil,
+		"overridden":  nil,
+	}
+	if req.Transponder != "" {
+		fields["transponder"] = req.Transponder
+	}
+	if req.KartNumber != "" {
+		fields["kartNumber"] = req.KartNumber
+	}
+	if req.Override && len(entrant.Problems) > 0 {
+		fields["overridden"] = entrant.Problems
+	}
+	if err := dynamo.UpdateCheckIn(r.Context(), eventID, driverUID, fields); err != nil {
+		log.Printf("update check-in error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	if req.Transponder != record.Transponder {
+		assignEventTransponder(r.Context(), eventID, record.Transponder, req.Transponder, driverUID, entrant.DriverName)
+	}
+
+	entrant.ArrivedAt = fields["arrivedAt"].(string)
+	entrant.Transponder = req.Transponder
+	entrant.KartNumber = req.KartNumber
+	writeJSON(w, http.StatusOK, entrant)
+}
+
+// assignEventTransponder moves a driver's transponder mapping in each of
+// the event's sessions from old to number. Errors are logged; the check-in
+// is saved.
+func assignEventTransponder(ctx context.Context, eventID, old, number, uid, driverName string) {
+	sessions, err := dynamo.ListEventSessions(ctx, eventID)
+	if err != nil {
+		log.Printf("list event sessions error: %v", err)
+		return
+	}
+	for _, s := range sessions {
+		if old != "" {
+			if err := dynamo.DeleteSessionTransponder(ctx, s.SessionID, old); err != nil {
+				log.Printf("delete session transponder error: %v", err)
+			}
+		}
+		if number == "" {
+			continue
+		}
+		_, err := dynamo.PutSessionTransponder(ctx, dynamo.SessionTransponder{
+			SessionID:   s.SessionID,
+			Transponder: number,
+			UID:         uid,
+			DriverName:  driverName,
+		})
+		if err != nil {
+			log.Printf("put session transponder error: %v", err)
+		}
+	}
+}
+
+// handleUndoCheckIn marks a driver as not arrived, e.g. after checking in
+// the wrong person. Their transponder and kart stay assigned.
+func handleUndoCheckIn(w http.ResponseWriter, r *http.Request) {
+	if _, _, ok := requireCheckInStaff(w, r); !ok {
+		return
+	}
+
+	eventID := r.PathValue("id")
+	driverUID := r.PathValue("uid")
+
+	record, err := dynamo.GetCheckIn(r.Context(), eventID, driverUID)
+	if err != nil {
+		log.Printf("get check-in error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if record == nil || record.ArrivedAt == "" {
+		writeError(w, http.StatusNotFound, "driver hasn't checked in")
+		return
+	}
+
+	err = dynamo.UpdateCheckIn(r.Context(), eventID, driverUID, map[string]any{
+		"arrivedAt":   nil,
+		"checkedInBy": nil,
+		"overridden":  nil,
+	})
+	if err != nil {
+		log.Printf("update check-in error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// checkInURL is what a driver's pass encodes: the site's check-in page for
+// staff, which finds the driver from it.
+func checkInURL(ci *dynamo.CheckIn) string {
+	q := url.Values{"e": {ci.EventID}, "u": {ci.UID}, "c": {ci.Code}}
+	return siteURL + "/checkin/?" + q.Encode()
+}
+
+// handleCheckInQR returns a confirmed driver's pass for an event as a PNG
+// QR code for staff to scan at the gate. Drivers get their own; staff can
+// reprint anyone's.
+func handleCheckInQR(w http.ResponseWriter, r *http.Request) {
+	uid, err := requireAuth(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	eventID := r.PathValue("id")
+	driverUID := r.PathValue("uid")
+
+	event, err := resolveEventParent(r.Context(), eventID)
+	if err != nil {
+		log.Printf("resolve event parent error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if event == nil {
+		writeError(w, http.StatusNotFound, "event not found")
+		return
+	}
+	if uid != driverUID {
+		if err := requireTrackRole(r, event.TrackID, uid, checkInRoles...); err != nil {
+			writeError(w, http.StatusForbidden, err.Error())
+			return
+		}
+	}
+
+	list, err := loadEventCheckIn(r.Context(), eventID, event)
+	if err != nil {
+		log.Printf("load event check-in error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	entrant := list.entrant(driverUID)
+	if entrant == nil {
+		writeError(w, http.StatusNotFound, "driver is not confirmed for this event")
+		return
+	}
+
+	record, err := dynamo.EnsureCheckIn(r.Context(), dynamo.CheckIn{
+		EventID:    eventID,
+		TrackID:    event.TrackID,
+		UID:        driverUID,
+		DriverName: entrant.DriverName,
+	})
+	if err != nil {
+		log.Printf("ensure check-in error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	png, err := qrcode.Encode(checkInURL(record), qrcode.Medium, 512)
+	if err != nil {
+		log.Printf("encode check-in qr error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(png)
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+	"github.com/BrianLeishman/karttrackpark.com/go/maintenance"
+)
+
+var validEquipmentTypes = map[string]bool{"engine": true, "chassis": true, "tires": true}
+
+func handleCreateEquipment(w http.ResponseWriter, r *http.Request) {
+	uid, err := requireAuth(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req struct {
+		Type                 string  `json:"type"`
+		Name                 string  `json:"name"`
+		Serial               string  `json:"serial"`
+		Notes                string  `json:"notes"`
+		RPMThreshold         int     `json:"rpm_threshold"`
+		RebuildIntervalHours float64 `json:"rebuild_interval_hours"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	if !validEquipmentTypes[req.Type] {
+		writeError(w, http.StatusBadRequest, "type must be engine, chassis, or tires")
+		return
+	}
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	if req.RPMThreshold < 0 || req.RebuildIntervalHours < 0 {
+		writeError(w, http.StatusBadRequest, "rpm_threshold and rebuild_interval_hours must not be negative")
+		return
+	}
+
+	eq, err := dynamo.CreateEquipment(r.Context(), dynamo.Equipment{
+		UID:                  uid,
+		Type:                 req.Type,
+		Name:                 req.Name,
+		Serial:               req.Serial,
+		Notes:                req.Notes,
+		RPMThreshold:         req.RPMThreshold,
+		RebuildIntervalHours: req.RebuildIntervalHours,
+	})
+	if err != nil {
+		log.Printf("create equipment error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, eq)
+}
+
+func handleListEquipment(w http.ResponseWriter, r *http.Request) {
+	uid, err := requireAuth(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	equipment, err := dynamo.ListEquipmentForUser(r.Context(), uid)
+	if err != nil {
+		log.Printf("list equipment error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if equipment == nil {
+		equipment = []dynamo.Equipment{}
+	}
+
+	writeJSON(w, http.StatusOK, equipment)
+}
+
+func handleGetEquipment(w http.ResponseWriter, r *http.Request) {
+	uid, err := requireAuth(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	eq, err := dynamo.GetEquipment(r.Context(), uid, r.PathValue("id"))
+	if err != nil {
+		log.Printf("get equipment error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if eq == nil {
+		writeError(w, http.StatusNotFound, "equipment not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, eq)
+}
+
+func handleUpdateEquipment(w http.ResponseWriter, r *http.Request) {
+	uid, err := requireAuth(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	equipmentID := r.PathValue("id")
+
+	eq, err := dynamo.GetEquipment(r.Context(), uid, equipmentID)
+	if err != nil {
+		log.Printf("get equipment error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if eq == nil {
+		writeError(w, http.StatusNotFound, "equipment not found")
+		return
+	}
+
+	var req map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+
+	allowed := map[string]bool{
+		"name": true, "serial": true, "notes": true, "retired": true,
+		"rpmThreshold": true, "rebuildIntervalHours": true,
+	}
+	fields := map[string]any{}
+	for k, v := range req {
+		if allowed[k] {
+			fields[k] = v
+		}
+	}
+
+	// A new interval may no longer be reached, so allow another reminder
+	if _, ok := fields["rebuildIntervalHours"]; ok {
+		fields["reminderSentAt"] = ""
+	}
+
+	if err := dynamo.UpdateEquipment(r.Context(), uid, equipmentID, fields); err != nil {
+		log.Printf("update equipment error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleRebuildEquipment records a rebuild, resetting the since-rebuild counter
+// and re-arming the reminder.
+func handleRebuildEquipment(w http.ResponseWriter, r *http.Request) {
+	uid, err := requireAuth(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	equipmentID := r.PathValue("id")
+
+	eq, err := dynamo.GetEquipment(r.Context(), uid, equipmentID)
+	if err != nil {
+		log.Printf("get equipment error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if eq == nil {
+		writeError(w, http.StatusNotFound, "equipment not found")
+		return
+	}
+
+	if err := dynamo.UpdateEquipment(r.Context(), uid, equipmentID, map[string]any{
+		"sinceRebuildMs": 0,
+		"lastRebuiltAt":  time.Now().UTC().Format(time.RFC3339),
+		"reminderSentAt": "",
+	}); err != nil {
+		log.Printf("rebuild equipment error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func handleDeleteEquipment(w http.ResponseWriter, r *http.Request) {
+	uid, err := requireAuth(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := dynamo.DeleteEquipment(r.Context(), uid, r.PathValue("id")); err != nil {
+		log.Printf("delete equipment error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleSetUploadEquipment assigns equipment to an upload. If the upload has
+// already been ingested, usage is credited to newly added equipment right away;
+// otherwise the ingest Lambda applies it once parsing completes.
+func handleSetUploadEquipment(w http.ResponseWriter, r *http.Request) {
+	uid, upload, ok := requireOwnUpload(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		EquipmentIDs []string `json:"equipment_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+
+	for _, id := range req.EquipmentIDs {
+		eq, err := dynamo.GetEquipment(r.Context(), uid, id)
+		if err != nil {
+			log.Printf("get equipment error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		if eq == nil {
+			writeError(w, http.StatusBadRequest, "unknown equipment: "+id)
+			return
+		}
+	}
+
+	// Usage already credited stays credited; keep those IDs on the upload
+	ids := slices.Clone(req.EquipmentIDs)
+	for _, id := range upload.EquipmentApplied {
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+
+	if err := dynamo.UpdateUpload(r.Context(), upload.UploadID, map[string]any{
+		"equipmentIds": ids,
+	}); err != nil {
+		log.Printf("update upload equipment error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	upload.EquipmentIDs = ids
+
+	if upload.Status == "complete" || upload.Status == "assigned" {
+		if err := maintenance.ApplyUpload(r.Context(), upload); err != nil {
+			log.Printf("apply equipment usage error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, upload)
+}
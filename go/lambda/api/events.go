@@ -239,7 +239,8 @@ func handleUpdateEvent(w http.ResponseWriter, r *http.Request) {
 	allowed := map[string]bool{
 		"name": true, "description": true, "eventType": true,
 		"startTime": true, "endTime": true,
-		"registrationMode": true, "maxSpots": true, "priceCents": true, "currency": true, "registrationDeadline": true,
+		"registrationMode": true, "maxSpots": true, "priceCents": true, "currency": true, "registrationDeadline": true, "waitlistOfferHours": true, "minAge": true, "questions": true,
+		"earlyBird": true, "memberPriceCents": true,
 		"method": true, "pointsScheme": true, "dropRounds": true, "tiebreaker": true,
 	}
 	fields := map[string]any{}
@@ -254,12 +255,21 @@ func handleUpdateEvent(w http.ResponseWriter, r *http.Request) {
 		fields["gsi1sk"] = st
 	}
 
+	if !setQuestionsField(w, fields) || !setEarlyBirdField(w, fields) {
+		return
+	}
+
 	if err := dynamo.UpdateEvent(r.Context(), eventID, fields); err != nil {
 		log.Printf("update event error: %v", err)
 		writeError(w, http.StatusInternalServerError, "internal error")
 		return
 	}
 
+	// Raising the spot limit takes drivers off the waitlist
+	if _, ok := fields["maxSpots"]; ok {
+		promoteWaitlistFor(r.Context(), "event", eventID)
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+	"github.com/BrianLeishman/karttrackpark.com/go/fleet"
+	"github.com/BrianLeishman/karttrackpark.com/go/grid"
+)
+
+// gridPosition returns a driver's slot on the session's generated grid, or 0.
+func gridPosition(session *dynamo.Session, uid string) int {
+	for _, e := range session.Grid {
+		if e.UID == uid {
+			return e.Pos
+		}
+	}
+	return 0
+}
+
+// handleGenerateGrid builds the starting grid for a session from the results
+// of the sessions before it in the event, applies grid penalties aimed at it
+// and stores the grid on the session.
+func handleGenerateGrid(w http.ResponseWriter, r *http.Request) {
+	session, ok := requireSessionManager(w, r)
+	if !ok {
+		return
+	}
+	if session.EventID == "" {
+		writeError(w, http.StatusBadRequest, "session is not part of an event")
+		return
+	}
+
+	var req struct {
+		Rule        string `json:"rule"`
+		InvertCount int    `json:"invert_count"`
+		Seed        *int64 `json:"seed"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	if !grid.ValidRules[req.Rule] {
+		writeError(w, http.StatusBadRequest, "rule must be qualifying, invert_top, heat_points or random")
+		return
+	}
+
+	event, err := dynamo.GetEvent(r.Context(), session.EventID)
+	if err != nil {
+		log.Printf("get event error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if event == nil {
+		writeError(w, http.StatusNotFound, "event not found")
+		return
+	}
+
+	links, err := dynamo.ListEventSessions(r.Context(), session.EventID)
+	if err != nil {
+		log.Printf("list event sessions error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	order := session.SessionOrder
+	for _, es := range links {
+		if es.SessionID == session.SessionID {
+			order = es.SessionOrder
+		}
+	}
+
+	var sources []grid.Source
+	for _, es := range links {
+		if es.SessionID == session.SessionID || es.SessionOrder >= order {
+			continue
+		}
+		results, err := dynamo.ListResultsForSession(r.Context(), es.SessionID)
+		if err != nil {
+			log.Printf("list results error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		sources = append(sources, grid.Source{
+			SessionID:   es.SessionID,
+			SessionType: es.SessionType,
+			Order:       es.SessionOrder,
+			Results:     results,
+		})
+	}
+
+	entrants, err := sessionEntrants(r.Context(), session.SessionID)
+	if err != nil {
+		log.Printf("session entrants error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	drops := map[string]int{}
+	eventPenalties, err := dynamo.ListPenaltiesForEvent(r.Context(), session.EventID)
+	if err != nil {
+		log.Printf("list event penalties error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	for _, p := range eventPenalties {
+		if p.Type == dynamo.PenaltyGrid && p.TargetSessionID == session.SessionID {
+			drops[p.UID] += int(p.Value)
+		}
+	}
+
+	var seed int64
+	if req.Rule == grid.RuleRandom {
+		seed = fleet.NewSeed()
+		if req.Seed != nil {
+			seed = *req.Seed
+		}
+	}
+
+	entries, err := grid.Build(grid.Options{
+		Rule:         req.Rule,
+		InvertCount:  req.InvertCount,
+		PointsScheme: event.PointsScheme,
+		Seed:         seed,
+		Entrants:     entrants,
+		Drops:        drops,
+	}, sources)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(entries) == 0 {
+		writeError(w, http.StatusBadRequest, "no drivers to put on the grid")
+		return
+	}
+
+	if err := dynamo.UpdateSession(r.Context(), session.SessionID, map[string]any{
+		"grid":     entries,
+		"gridRule": req.Rule,
+		"gridSeed": seed,
+	}); err != nil {
+		log.Printf("update session error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"grid": entries,
+		"rule": req.Rule,
+		"seed": seed,
+	})
+}
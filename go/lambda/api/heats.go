@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+	"github.com/BrianLeishman/karttrackpark.com/go/heats"
+)
+
+// eventBestLaps returns each driver's best lap across the sessions already in
+// an event (practice, qualifying).
+func eventBestLaps(ctx context.Context, eventID string) (map[string]int64, error) {
+	links, err := dynamo.ListEventSessions(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	best := map[string]int64{}
+	for _, es := range links {
+		laps, err := dynamo.ListLapsForSession(ctx, es.SessionID)
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range laps {
+			if l.LapTimeMs > 0 && (best[l.UID] == 0 || l.LapTimeMs < best[l.UID]) {
+				best[l.UID] = l.LapTimeMs
+			}
+		}
+	}
+	return best, nil
+}
+
+// seriesPositions returns the championship position of each driver in the
+// given series, or in the event's first series when seriesID is empty. It
+// returns nil when the event isn't part of a series.
+func seriesPositions(ctx context.Context, eventID, seriesID string) (map[string]int, error) {
+	if seriesID == "" {
+		links, err := dynamo.ListSeriesForEvent(ctx, eventID)
+		if err != nil || len(links) == 0 {
+			return nil, err
+		}
+		seriesID = links[0].SeriesID
+	}
+	drivers, err := dynamo.ListSeriesDrivers(ctx, seriesID)
+	if err != nil {
+		return nil, err
+	}
+	positions := map[string]int{}
+	for _, d := range drivers {
+		if d.Position > 0 {
+			positions[d.UID] = d.Position
+		}
+	}
+	return positions, nil
+}
+
+// handleSplitHeats splits an event's confirmed entry list into heat sessions
+// of at most max_spots drivers, creates the sessions after the event's
+// existing ones and registers each driver in their heat.
+func handleSplitHeats(w http.ResponseWriter, r *http.Request) {
+	uid, err := requireAuth(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	eventID := r.PathValue("id")
+
+	event, err := dynamo.GetEvent(r.Context(), eventID)
+	if err != nil {
+		log.Printf("get event error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if event == nil {
+		writeError(w, http.StatusNotFound, "event not found")
+		return
+	}
+
+	if err := requireTrackRole(r, event.TrackID, uid, "owner", "admin"); err != nil {
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+
+	var req struct {
+		Strategy    string `json:"strategy"`
+		MaxSpots    int    `json:"max_spots"`
+		SeriesID    string `json:"series_id"`
+		SessionName string `json:"session_name"`
+		SessionType string `json:"session_type"`
+		LayoutID    string `json:"layout_id"`
+		StartType   string `json:"start_type"`
+		LapLimit    int    `json:"lap_limit"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	if !heats.ValidStrategies[req.Strategy] {
+		writeError(w, http.StatusBadRequest, "strategy must be class, standings, best_lap or snake")
+		return
+	}
+	if req.MaxSpots <= 0 {
+		writeError(w, http.StatusBadRequest, "max_spots must be positive")
+		return
+	}
+	if req.SessionName == "" {
+		req.SessionName = "Heat"
+	}
+	if req.SessionType == "" {
+		req.SessionType = "heat"
+	}
+
+	regs, err := dynamo.ListRegistrations(r.Context(), "event", eventID)
+	if err != nil {
+		log.Printf("list registrations error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	var drivers []heats.Driver
+	byUID := map[string]dynamo.Registration{}
+	for _, reg := range regs {
+		if reg.Status != "confirmed" {
+			continue
+		}
+		drivers = append(drivers, heats.Driver{UID: reg.UID, Name: reg.DriverName, ClassID: reg.ClassID})
+		byUID[reg.UID] = reg
+	}
+	if len(drivers) == 0 {
+		writeError(w, http.StatusBadRequest, "event has no confirmed registrations")
+		return
+	}
+
+	var positions map[string]int
+	if req.Strategy != heats.ByBestLap {
+		positions, err = seriesPositions(r.Context(), eventID, req.SeriesID)
+		if err != nil {
+			log.Printf("series positions error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		if req.Strategy == heats.ByStandings && positions == nil {
+			writeError(w, http.StatusBadRequest, "event is not part of a series")
+			return
+		}
+	}
+	var bestLaps map[string]int64
+	if req.Strategy != heats.ByStandings {
+		bestLaps, err = eventBestLaps(r.Context(), eventID)
+		if err != nil {
+			log.Printf("event best laps error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+	}
+	heats.Seed(drivers, positions, bestLaps)
+
+	split, err := heats.Split(req.Strategy, drivers, req.MaxSpots)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	existing, err := dynamo.ListEventSessions(r.Context(), eventID)
+	if err != nil {
+		log.Printf("list event sessions error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	order := 0
+	for _, es := range existing {
+		order = max(order, es.SessionOrder)
+	}
+
+	classNames := map[string]string{}
+	type heatOut struct {
+		Session       *dynamo.Session       `json:"session"`
+		Registrations []dynamo.Registration `json:"registrations"`
+	}
+	out := make([]heatOut, 0, len(split))
+	for i, h := range split {
+		order++
+		name := fmt.Sprintf("%s %d", req.SessionName, i+1)
+		var classIDs []string
+		if h.ClassID != "" {
+			classIDs = []string{h.ClassID}
+			if _, ok := classNames[h.ClassID]; !ok {
+				kc, err := dynamo.GetKartClass(r.Context(), event.TrackID, h.ClassID)
+				if err != nil {
+					log.Printf("get kart class error: %v", err)
+				}
+				if kc != nil {
+					classNames[h.ClassID] = kc.Name
+				}
+			}
+			if cn := classNames[h.ClassID]; cn != "" {
+				name += " (" + cn + ")"
+			}
+		}
+
+		session, err := dynamo.CreateSession(r.Context(), dynamo.Session{
+			TrackID:              event.TrackID,
+			UID:                  uid,
+			EventID:              eventID,
+			SessionName:          name,
+			SessionType:          req.SessionType,
+			SessionOrder:         order,
+			LayoutID:             req.LayoutID,
+			StartType:            req.StartType,
+			LapLimit:             req.LapLimit,
+			ClassIDs:             classIDs,
+			RegistrationSettings: dynamo.RegistrationSettings{MaxSpots: req.MaxSpots},
+		})
+		if err != nil {
+			log.Printf("create session error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		if _, err := dynamo.AddSessionToEvent(r.Context(), dynamo.EventSession{
+			EventID:      eventID,
+			SessionID:    session.SessionID,
+			SessionOrder: order,
+			SessionType:  req.SessionType,
+			SessionName:  name,
+			StartType:    req.StartType,
+			LapLimit:     req.LapLimit,
+		}); err != nil {
+			log.Printf("add session to event error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		entry := heatOut{Session: session, Registrations: []dynamo.Registration{}}
+		for _, d := range h.Drivers {
+			src := byUID[d.UID]
+			reg, err := dynamo.CreateRegistration(r.Context(), dynamo.Registration{
+				ParentType: "session",
+				ParentID:   session.SessionID,
+				TrackID:    event.TrackID,
+				UID:        d.UID,
+				Email:      src.Email,
+				DriverName: d.Name,
+				ClassID:    d.ClassID,
+				Status:     "confirmed",
+				InvitedBy:  uid,
+			})
+			if err != nil {
+				log.Printf("create registration error: %v", err)
+				writeError(w, http.StatusInternalServerError, "internal error")
+				return
+			}
+			entry.Registrations = append(entry.Registrations, *reg)
+		}
+		out = append(out, entry)
+	}
+
+	writeJSON(w, http.StatusCreated, out)
+}
@@ -0,0 +1,732 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+	"github.com/BrianLeishman/karttrackpark.com/go/fleet"
+)
+
+var validKartStatuses = map[string]bool{"active": true, "out_of_service": true}
+
+func handleCreateKart(w http.ResponseWriter, r *http.Request) {
+	uid, err := requireAuth(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	trackID := r.PathValue("id")
+
+	if err := requireTrackRole(r, trackID, uid, "owner", "admin"); err != nil {
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+
+	var req struct {
+		Number      string `json:"number"`
+		Class       string `json:"class"`
+		Transponder string `json:"transponder"`
+		Status      string `json:"status"`
+		Notes       string `json:"notes"`
+		Slow        bool   `json:"slow"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	if req.Number == "" {
+		writeError(w, http.StatusBadRequest, "number is required")
+		return
+	}
+	if req.Status != "" && !validKartStatuses[req.Status] {
+		writeError(w, http.StatusBadRequest, "status must be active or out_of_service")
+		return
+	}
+
+	if !requireFreeKartNumber(w, r, trackID, req.Number, "") {
+		return
+	}
+
+	kart, err := dynamo.CreateKart(r.Context(), dynamo.Kart{
+		TrackID:     trackID,
+		Number:      req.Number,
+		Class:       req.Class,
+		Transponder: req.Transponder,
+		Status:      req.Status,
+		Notes:       req.Notes,
+		Slow:        req.Slow,
+	})
+	if err != nil {
+		log.Printf("create kart error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, kart)
+}
+
+func handleListKarts(w http.ResponseWriter, r *http.Request) {
+	trackID := r.PathValue("id")
+
+	karts, err := dynamo.ListKartsForTrack(r.Context(), trackID)
+	if err != nil {
+		log.Printf("list karts error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if karts == nil {
+		karts = []dynamo.Kart{}
+	}
+
+	writeJSON(w, http.StatusOK, karts)
+}
+
+// getTrackKart returns a kart only if it belongs to the given track.
+func getTrackKart(ctx context.Context, trackID, kartID string) (*dynamo.Kart, error) {
+	kart, err := dynamo.GetKart(ctx, kartID)
+	if err != nil || kart == nil || kart.TrackID != trackID {
+		return nil, err
+	}
+	return kart, nil
+}
+
+func handleGetKart(w http.ResponseWriter, r *http.Request) {
+	kart, err := getTrackKart(r.Context(), r.PathValue("id"), r.PathValue("kartId"))
+	if err != nil {
+		log.Printf("get kart error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if kart == nil {
+		writeError(w, http.StatusNotFound, "kart not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, kart)
+}
+
+func handleUpdateKart(w http.ResponseWriter, r *http.Request) {
+	uid, err := requireAuth(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	trackID := r.PathValue("id")
+	kartID := r.PathValue("kartId")
+
+	if err := requireTrackRole(r, trackID, uid, "owner", "admin"); err != nil {
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+
+	kart, err := getTrackKart(r.Context(), trackID, kartID)
+	if err != nil {
+		log.Printf("get kart error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if kart == nil {
+		writeError(w, http.StatusNotFound, "kart not found")
+		return
+	}
+
+	var req map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+
+	allowed := map[string]bool{
+		"number": true, "class": true, "transponder": true,
+		"status": true, "notes": true, "slow": true,
+	}
+	fields := map[string]any{}
+	for k, v := range req {
+		if allowed[k] {
+			fields[k] = v
+		}
+	}
+
+	if status, ok := fields["status"]; ok {
+		if s, isStr := status.(string); !isStr || !validKartStatuses[s] {
+			writeError(w, http.StatusBadRequest, "status must be active or out_of_service")
+			return
+		}
+	}
+	if number, ok := fields["number"]; ok {
+		n, isStr := number.(string)
+		if !isStr || n == "" {
+			writeError(w, http.StatusBadRequest, "number is required")
+			return
+		}
+		if n != kart.Number && !requireFreeKartNumber(w, r, trackID, n, kartID) {
+			return
+		}
+	}
+
+	if err := dynamo.UpdateKart(r.Context(), kartID, fields); err != nil {
+		log.Printf("update kart error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// requireFreeKartNumber checks no other kart at the track has number,
+// ignoring the kart exceptID. On failure the error response has been
+// written.
+func requireFreeKartNumber(w http.ResponseWriter, r *http.Request, trackID, number, exceptID string) bool {
+	existing, err := dynamo.ListKartsForTrack(r.Context(), trackID)
+	if err != nil {
+		log.Printf("list karts error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return false
+	}
+	for _, k := range existing {
+		if k.Number == number && k.KartID != exceptID {
+			writeError(w, http.StatusConflict, "kart number already exists")
+			return false
+		}
+	}
+	return true
+}
+
+func handleDeleteKart(w http.ResponseWriter, r *http.Request) {
+	uid, err := requireAuth(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	trackID := r.PathValue("id")
+	kartID := r.PathValue("kartId")
+
+	if err := requireTrackRole(r, trackID, uid, "owner", "admin"); err != nil {
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+
+	kart, err := getTrackKart(r.Context(), trackID, kartID)
+	if err != nil {
+		log.Printf("get kart error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if kart == nil {
+		writeError(w, http.StatusNotFound, "kart not found")
+		return
+	}
+
+	if err := dynamo.DeleteKart(r.Context(), kartID); err != nil {
+		log.Printf("delete kart error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetKartStats returns each kart's lap-time distribution compared with
+// the fleet median. Optional filters: layout_id, class, since (RFC3339).
+func handleGetKartStats(w http.ResponseWriter, r *http.Request) {
+	trackID := r.PathValue("id")
+	layoutID := r.URL.Query().Get("layout_id")
+	class := r.URL.Query().Get("class")
+	since := r.URL.Query().Get("since")
+
+	karts, err := dynamo.ListKartsForTrack(r.Context(), trackID)
+	if err != nil {
+		log.Printf("list karts error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	var fleetKarts []dynamo.Kart
+	lapTimes := map[string][]int64{}
+	for _, k := range karts {
+		if class != "" && k.Class != class {
+			continue
+		}
+		fleetKarts = append(fleetKarts, k)
+
+		laps, err := dynamo.ListLapsForKart(r.Context(), k.KartID, since)
+		if err != nil {
+			log.Printf("list laps for kart %s error: %v", k.KartID, err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		for _, l := range laps {
+			if layoutID != "" && l.LayoutID != layoutID {
+				continue
+			}
+			lapTimes[k.KartID] = append(lapTimes[k.KartID], l.LapTimeMs)
+		}
+	}
+
+	fleetMedian, stats := fleet.Stats(fleetKarts, lapTimes)
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"fleet_median_ms": fleetMedian,
+		"karts":           stats,
+	})
+}
+
+func handleListSessionKarts(w http.ResponseWriter, r *http.Request) {
+	assignments, err := dynamo.ListSessionKarts(r.Context(), r.PathValue("id"))
+	if err != nil {
+		log.Printf("list session karts error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if assignments == nil {
+		assignments = []dynamo.SessionKart{}
+	}
+
+	writeJSON(w, http.StatusOK, assignments)
+}
+
+// requireSessionManager loads a session and checks the caller can manage its
+// track, writing errors to w if needed.
+func requireSessionManager(w http.ResponseWriter, r *http.Request) (*dynamo.Session, bool) {
+	uid, err := requireAuth(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return nil, false
+	}
+
+	session, err := dynamo.GetSession(r.Context(), r.PathValue("id"))
+	if err != nil {
+		log.Printf("get session error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return nil, false
+	}
+	if session == nil {
+		writeError(w, http.StatusNotFound, "session not found")
+		return nil, false
+	}
+
+	if err := requireTrackRole(r, session.TrackID, uid, "owner", "admin"); err != nil {
+		writeError(w, http.StatusForbidden, err.Error())
+		return nil, false
+	}
+
+	return session, true
+}
+
+// handleSetSessionKart records which kart a driver ran in a session and stamps
+// the kart onto any laps they already have there.
+func handleSetSessionKart(w http.ResponseWriter, r *http.Request) {
+	session, ok := requireSessionManager(w, r)
+	if !ok {
+		return
+	}
+	driverUID := r.PathValue("uid")
+
+	var req struct {
+		KartID     string `json:"kart_id"`
+		DriverName string `json:"driver_name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.KartID == "" {
+		writeError(w, http.StatusBadRequest, "kart_id is required")
+		return
+	}
+
+	kart, err := getTrackKart(r.Context(), session.TrackID, req.KartID)
+	if err != nil {
+		log.Printf("get kart error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if kart == nil {
+		writeError(w, http.StatusBadRequest, "kart not found at this track")
+		return
+	}
+	if !kart.InService() {
+		writeError(w, http.StatusBadRequest, "kart is out of service")
+		return
+	}
+
+	assignments, err := dynamo.ListSessionKarts(r.Context(), session.SessionID)
+	if err != nil {
+		log.Printf("list session karts error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	var previous *dynamo.SessionKart
+	for i, a := range assignments {
+		if a.UID == driverUID {
+			previous = &assignments[i]
+		} else if a.KartID == req.KartID {
+			writeError(w, http.StatusConflict, "kart already assigned to another driver")
+			return
+		}
+	}
+
+	assignment, err := assignSessionKart(r.Context(), session.SessionID, driverUID, req.DriverName, *kart, previous)
+	if err != nil {
+		log.Printf("assign session kart error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, assignment)
+}
+
+func handleDeleteSessionKart(w http.ResponseWriter, r *http.Request) {
+	session, ok := requireSessionManager(w, r)
+	if !ok {
+		return
+	}
+	driverUID := r.PathValue("uid")
+
+	previous, err := dynamo.GetSessionKart(r.Context(), session.SessionID, driverUID)
+	if err != nil {
+		log.Printf("get session kart error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if previous == nil {
+		writeError(w, http.StatusNotFound, "assignment not found")
+		return
+	}
+
+	if err := unassignSessionKart(r.Context(), *previous); err != nil {
+		log.Printf("unassign session kart error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleBalanceSessionKarts assigns karts to every driver in the session who
+// doesn't have one yet, favouring the least-used in-service karts. Drivers
+// default to the session's confirmed registrations.
+func handleBalanceSessionKarts(w http.ResponseWriter, r *http.Request) {
+	session, ok := requireSessionManager(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		UIDs []string `json:"uids"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid body")
+			return
+		}
+	}
+
+	names := map[string]string{}
+	if len(req.UIDs) == 0 {
+		regs, err := dynamo.ListRegistrations(r.Context(), "session", session.SessionID)
+		if err != nil {
+			log.Printf("list registrations error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		for _, reg := range regs {
+			if reg.Status == "confirmed" {
+				req.UIDs = append(req.UIDs, reg.UID)
+				names[reg.UID] = reg.DriverName
+			}
+		}
+	}
+
+	assignments, err := dynamo.ListSessionKarts(r.Context(), session.SessionID)
+	if err != nil {
+		log.Printf("list session karts error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	taken := map[string]bool{}
+	assigned := map[string]bool{}
+	for _, a := range assignments {
+		taken[a.KartID] = true
+		assigned[a.UID] = true
+	}
+
+	var pending []string
+	for _, u := range req.UIDs {
+		if !assigned[u] {
+			pending = append(pending, u)
+		}
+	}
+
+	karts, err := dynamo.ListKartsForTrack(r.Context(), session.TrackID)
+	if err != nil {
+		log.Printf("list karts error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	picks, err := fleet.Balance(karts, pending, taken)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	for _, u := range pending {
+		a, err := assignSessionKart(r.Context(), session.SessionID, u, names[u], picks[u], nil)
+		if err != nil {
+			log.Printf("assign session kart error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		assignments = append(assignments, *a)
+	}
+
+	writeJSON(w, http.StatusOK, assignments)
+}
+
+// handleDrawSessionKarts performs a seeded random kart draw for a session.
+// Drivers default to the session's confirmed registrations, falling back to
+// the event's. Karts are limited to the session's classes; no driver gets a
+// kart they already ran in another session of the same event, and karts
+// flagged slow are only used when unavoidable. Replaces any existing
+// assignments and stores the draw and seed on the session.
+func handleDrawSessionKarts(w http.ResponseWriter, r *http.Request) {
+	session, ok := requireSessionManager(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Seed *int64   `json:"seed"`
+		UIDs []string `json:"uids"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid body")
+			return
+		}
+	}
+	seed := fleet.NewSeed()
+	if req.Seed != nil {
+		if *req.Seed < 0 || *req.Seed >= fleet.MaxSeed {
+			writeError(w, http.StatusBadRequest, "seed out of range")
+			return
+		}
+		seed = *req.Seed
+	}
+
+	names := map[string]string{}
+	if len(req.UIDs) == 0 {
+		parents := [][2]string{{"session", session.SessionID}}
+		if session.EventID != "" {
+			parents = append(parents, [2]string{"event", session.EventID})
+		}
+		for _, p := range parents {
+			regs, err := dynamo.ListRegistrations(r.Context(), p[0], p[1])
+			if err != nil {
+				log.Printf("list registrations error: %v", err)
+				writeError(w, http.StatusInternalServerError, "internal error")
+				return
+			}
+			for _, reg := range regs {
+				if reg.Status == "confirmed" {
+					req.UIDs = append(req.UIDs, reg.UID)
+					names[reg.UID] = reg.DriverName
+				}
+			}
+			if len(req.UIDs) > 0 {
+				break
+			}
+		}
+	}
+	if len(req.UIDs) == 0 {
+		writeError(w, http.StatusBadRequest, "no drivers to draw for")
+		return
+	}
+	slices.Sort(req.UIDs)
+	req.UIDs = slices.Compact(req.UIDs)
+
+	allKarts, err := dynamo.ListKartsForTrack(r.Context(), session.TrackID)
+	if err != nil {
+		log.Printf("list karts error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	var karts []dynamo.Kart
+	for _, k := range allKarts {
+		if len(session.ClassIDs) == 0 || slices.Contains(session.ClassIDs, k.Class) {
+			karts = append(karts, k)
+		}
+	}
+
+	// Karts each driver already ran elsewhere in the event
+	used := map[string]map[string]bool{}
+	if session.EventID != "" {
+		links, err := dynamo.ListEventSessions(r.Context(), session.EventID)
+		if err != nil {
+			log.Printf("list event sessions error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		for _, link := range links {
+			if link.SessionID == session.SessionID {
+				continue
+			}
+			assignments, err := dynamo.ListSessionKarts(r.Context(), link.SessionID)
+			if err != nil {
+				log.Printf("list session karts error: %v", err)
+				writeError(w, http.StatusInternalServerError, "internal error")
+				return
+			}
+			for _, a := range assignments {
+				if used[a.UID] == nil {
+					used[a.UID] = map[string]bool{}
+				}
+				used[a.UID][a.KartID] = true
+			}
+		}
+	}
+
+	picks, err := fleet.Draw(karts, req.UIDs, used, seed)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	existing, err := dynamo.ListSessionKarts(r.Context(), session.SessionID)
+	if err != nil {
+		log.Printf("list session karts error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	previous := map[string]*dynamo.SessionKart{}
+	for i, a := range existing {
+		if _, drawn := picks[a.UID]; drawn {
+			previous[a.UID] = &existing[i]
+			continue
+		}
+		if err := unassignSessionKart(r.Context(), a); err != nil {
+			log.Printf("unassign session kart error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+	}
+
+	draw := make([]dynamo.KartDrawEntry, 0, len(req.UIDs))
+	for _, u := range req.UIDs {
+		a, err := assignSessionKart(r.Context(), session.SessionID, u, names[u], picks[u], previous[u])
+		if err != nil {
+			log.Printf("assign session kart error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		draw = append(draw, dynamo.KartDrawEntry{
+			UID:        u,
+			DriverName: a.DriverName,
+			KartID:     a.KartID,
+			KartNumber: a.KartNumber,
+		})
+	}
+
+	if err := dynamo.UpdateSession(r.Context(), session.SessionID, map[string]any{
+		"kartDraw":     draw,
+		"kartDrawSeed": seed,
+	}); err != nil {
+		log.Printf("update session draw error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"seed": seed,
+		"draw": draw,
+	})
+}
+
+// assignSessionKart writes a driver's kart assignment, moves the session count
+// from the previous kart (if any) to the new one, and stamps existing laps.
+func assignSessionKart(ctx context.Context, sessionID, uid, driverName string, kart dynamo.Kart, previous *dynamo.SessionKart) (*dynamo.SessionKart, error) {
+	if driverName == "" && previous != nil {
+		driverName = previous.DriverName
+	}
+	if driverName == "" {
+		if user, err := dynamo.GetUser(ctx, uid); err == nil && user != nil {
+			driverName = user.Name
+		}
+	}
+
+	assignment, err := dynamo.PutSessionKart(ctx, dynamo.SessionKart{
+		SessionID:  sessionID,
+		UID:        uid,
+		DriverName: driverName,
+		KartID:     kart.KartID,
+		KartNumber: kart.Number,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if previous == nil || previous.KartID != kart.KartID {
+		if previous != nil {
+			if err := dynamo.AddKartSessions(ctx, previous.KartID, -1); err != nil {
+				log.Printf("adjust kart session count error: %v", err)
+			}
+		}
+		if err := dynamo.AddKartSessions(ctx, kart.KartID, 1); err != nil {
+			log.Printf("adjust kart session count error: %v", err)
+		}
+	}
+
+	if err := stampDriverLaps(ctx, sessionID, uid, kart.KartID); err != nil {
+		return nil, err
+	}
+	return assignment, nil
+}
+
+// unassignSessionKart removes a driver's kart assignment, gives the session
+// back to the kart's count and clears the kart from their laps.
+func unassignSessionKart(ctx context.Context, a dynamo.SessionKart) error {
+	if err := dynamo.DeleteSessionKart(ctx, a.SessionID, a.UID); err != nil {
+		return err
+	}
+	if err := dynamo.AddKartSessions(ctx, a.KartID, -1); err != nil {
+		log.Printf("adjust kart session count error: %v", err)
+	}
+	return stampDriverLaps(ctx, a.SessionID, a.UID, "")
+}
+
+// stampDriverLaps rewrites a driver's laps in a session with the given kart so
+// they are indexed for per-kart stats (or removed from it when kartID is empty).
+func stampDriverLaps(ctx context.Context, sessionID, uid, kartID string) error {
+	laps, err := dynamo.ListLapsForSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	for _, l := range laps {
+		if l.UID != uid || l.KartID == kartID {
+			continue
+		}
+		l.KartID = kartID
+		l.GSI2PK, l.GSI2SK = "", ""
+		if err := dynamo.PutLap(ctx, l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sessionKartID returns the kart assigned to a driver for a session, or "".
+func sessionKartID(ctx context.Context, sessionID, uid string) string {
+	assignment, err := dynamo.GetSessionKart(ctx, sessionID, uid)
+	if err != nil {
+		log.Printf("get session kart error: %v", err)
+		return ""
+	}
+	if assignment == nil {
+		return ""
+	}
+	return assignment.KartID
+}
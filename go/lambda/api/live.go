@@ -0,0 +1,363 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+	"github.com/BrianLeishman/karttrackpark.com/go/timing"
+)
+
+var validFlagStates = map[string]bool{"green": true, "yellow": true, "red": true, "checkered": true}
+
+var errSessionNotFound = errors.New("session not found")
+
+const (
+	livePollInterval = time.Second
+	liveHeartbeat    = 15 * time.Second
+	// liveLongPollWait stays under API Gateway's 29s integration timeout.
+	liveLongPollWait = 20 * time.Second
+)
+
+// liveLap is a completed lap as pushed to timing screens.
+type liveLap struct {
+	UID          string `json:"uid"`
+	DriverName   string `json:"driver_name,omitempty"`
+	LapNo        int    `json:"lap_no"`
+	LapTimeMs    int64  `json:"lap_time_ms"`
+	CrossedAtMs  int64  `json:"crossed_at_ms,omitempty"`
+	PersonalBest bool   `json:"personal_best,omitempty"`
+	SessionBest  bool   `json:"session_best,omitempty"`
+}
+
+// liveMessage is a single feed message. A snapshot carries the full board; a
+// lap message carries laps completed since the previous cursor plus the new
+// board; a state message signals a flag change. The cursor is the highest
+// lap Seq seen, so a client can resume with ?since= or Last-Event-ID and a
+// lap written late still reaches it.
+type liveMessage struct {
+	Type      string            `json:"type"` // snapshot, lap, state
+	Cursor    int               `json:"cursor"`
+	FlagState string            `json:"flag_state,omitempty"`
+	LapCount  int               `json:"lap_count"`
+	Laps      []liveLap         `json:"laps,omitempty"`
+	Positions []timing.Position `json:"positions,omitempty"`
+	BestLap   *timing.BestLap   `json:"best_lap,omitempty"`
+}
+
+// liveFeed polls a session's laps and turns them into feed messages. Driver
+// names are cached for the life of the feed.
+type liveFeed struct {
+	sessionID string
+	names     map[string]string
+	namesAt   time.Time
+
+	flagState string
+	race      bool
+	laps      []dynamo.Lap // in completion order
+}
+
+func newLiveFeed(sessionID string) *liveFeed {
+	return &liveFeed{sessionID: sessionID, names: map[string]string{}}
+}
+
+// refresh reloads the session and its laps.
+func (f *liveFeed) refresh(ctx context.Context) error {
+	session, err := dynamo.GetSession(ctx, f.sessionID)
+	if err != nil {
+		return err
+	}
+	if session == nil {
+		return errSessionNotFound
+	}
+	laps, err := dynamo.ListLapsForSession(ctx, f.sessionID)
+	if err != nil {
+		return err
+	}
+	timing.SortLaps(laps)
+
+	f.flagState = session.FlagState
+	f.race = timing.IsRace(session.SessionType)
+	f.laps = laps
+	f.resolveNames(ctx)
+	return nil
+}
+
+// resolveNames fills in driver names from kart and transponder assignments,
+// falling back to user profiles.
+func (f *liveFeed) resolveNames(ctx context.Context) {
+	missing := false
+	for _, l := range f.laps {
+		if _, ok := f.names[l.UID]; !ok {
+			missing = true
+			break
+		}
+	}
+	if !missing {
+		return
+	}
+
+	if time.Since(f.namesAt) > 30*time.Second {
+		f.namesAt = time.Now()
+		if assignments, err := dynamo.ListSessionKarts(ctx, f.sessionID); err == nil {
+			for _, a := range assignments {
+				if a.DriverName != "" {
+					f.names[a.UID] = a.DriverName
+				}
+			}
+		}
+		if mappings, err := dynamo.ListSessionTransponders(ctx, f.sessionID); err == nil {
+			for _, m := range mappings {
+				if m.DriverName != "" {
+					f.names[m.UID] = m.DriverName
+				}
+			}
+		}
+	}
+
+	for _, l := range f.laps {
+		if _, ok := f.names[l.UID]; ok {
+			continue
+		}
+		name := ""
+		if user, err := dynamo.GetUser(ctx, l.UID); err == nil && user != nil {
+			name = user.Name
+		}
+		f.names[l.UID] = name
+	}
+}
+
+func (f *liveFeed) cursor() int {
+	cursor := 0
+	for _, l := range f.laps {
+		cursor = max(cursor, int(l.Seq))
+	}
+	return cursor
+}
+
+func (f *liveFeed) snapshot() liveMessage {
+	positions, best := timing.Board(f.laps, f.race, f.names)
+	if positions == nil {
+		positions = []timing.Position{}
+	}
+	return liveMessage{
+		Type:      "snapshot",
+		Cursor:    f.cursor(),
+		FlagState: f.flagState,
+		LapCount:  len(f.laps),
+		Positions: positions,
+		BestLap:   best,
+	}
+}
+
+// since returns the messages a client at cursor (having seen flag) needs to
+// catch up. A cursor past the latest lap (laps were deleted or replaced)
+// gets a fresh snapshot.
+func (f *liveFeed) since(cursor int, flag string) []liveMessage {
+	if cursor < 0 || cursor > f.cursor() {
+		return []liveMessage{f.snapshot()}
+	}
+
+	var msgs []liveMessage
+	if cursor < f.cursor() {
+		positions, best := timing.Board(f.laps, f.race, f.names)
+		msgs = append(msgs, liveMessage{
+			Type:      "lap",
+			Cursor:    f.cursor(),
+			FlagState: f.flagState,
+			LapCount:  len(f.laps),
+			Laps:      f.newLaps(cursor),
+			Positions: positions,
+			BestLap:   best,
+		})
+	}
+	if flag != f.flagState {
+		msgs = append(msgs, liveMessage{
+			Type:      "state",
+			Cursor:    f.cursor(),
+			FlagState: f.flagState,
+			LapCount:  len(f.laps),
+		})
+	}
+	return msgs
+}
+
+// newLaps returns laps written after cursor in completion order, marking
+// personal and session bests as of the moment each lap was completed.
+func (f *liveFeed) newLaps(cursor int) []liveLap {
+	personal := map[string]int64{}
+	var sessionBest int64
+	var out []liveLap
+	for _, l := range f.laps {
+		pb := personal[l.UID] == 0 || l.LapTimeMs < personal[l.UID]
+		if pb {
+			personal[l.UID] = l.LapTimeMs
+		}
+		sb := sessionBest == 0 || l.LapTimeMs < sessionBest
+		if sb {
+			sessionBest = l.LapTimeMs
+		}
+		if int(l.Seq) <= cursor {
+			continue
+		}
+		out = append(out, liveLap{
+			UID:          l.UID,
+			DriverName:   f.names[l.UID],
+			LapNo:        l.LapNo,
+			LapTimeMs:    l.LapTimeMs,
+			CrossedAtMs:  l.CrossedAtMs,
+			PersonalBest: pb,
+			SessionBest:  sb,
+		})
+	}
+	return out
+}
+
+// handleGetSessionLive serves the live timing feed. Clients that accept
+// text/event-stream get Server-Sent Events when running as a local server.
+// Under Lambda, or for plain requests, it long-polls: without ?since= it
+// returns a snapshot; with ?since=<cursor>[&flag=<state>] it waits up to 20s
+// for new laps or a flag change and returns the catch-up messages.
+func handleGetSessionLive(w http.ResponseWriter, r *http.Request) {
+	feed := newLiveFeed(r.PathValue("id"))
+	if err := feed.refresh(r.Context()); err != nil {
+		if errors.Is(err, errSessionNotFound) {
+			writeError(w, http.StatusNotFound, "session not found")
+			return
+		}
+		log.Printf("live refresh error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	streaming := os.Getenv("AWS_LAMBDA_FUNCTION_NAME") == "" &&
+		strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+	if streaming {
+		streamLive(w, r, feed)
+		return
+	}
+	pollLive(w, r, feed)
+}
+
+func pollLive(w http.ResponseWriter, r *http.Request, feed *liveFeed) {
+	sinceParam := r.URL.Query().Get("since")
+	if sinceParam == "" {
+		writeJSON(w, http.StatusOK, []liveMessage{feed.snapshot()})
+		return
+	}
+	since, err := strconv.Atoi(sinceParam)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "since must be a number")
+		return
+	}
+	flag := r.URL.Query().Get("flag")
+
+	deadline := time.Now().Add(liveLongPollWait)
+	if r.URL.Query().Get("wait") == "0" {
+		deadline = time.Now()
+	}
+
+	for {
+		if msgs := feed.since(since, flag); len(msgs) > 0 || !time.Now().Before(deadline) {
+			if msgs == nil {
+				msgs = []liveMessage{}
+			}
+			writeJSON(w, http.StatusOK, msgs)
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(livePollInterval):
+		}
+		if err := feed.refresh(r.Context()); err != nil {
+			log.Printf("live refresh error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+	}
+}
+
+func streamLive(w http.ResponseWriter, r *http.Request, feed *liveFeed) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		pollLive(w, r, feed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(msg liveMessage) error {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", msg.Cursor, msg.Type, data)
+		return err
+	}
+
+	// Resume from Last-Event-ID when reconnecting, otherwise start with a snapshot
+	var msgs []liveMessage
+	if last, err := strconv.Atoi(r.Header.Get("Last-Event-ID")); err == nil {
+		msgs = feed.since(last, "")
+	} else {
+		msgs = []liveMessage{feed.snapshot()}
+	}
+	for _, m := range msgs {
+		if err := send(m); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	cursor, flag := feed.cursor(), feed.flagState
+	ticker := time.NewTicker(livePollInterval)
+	defer ticker.Stop()
+	lastWrite := time.Now()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := feed.refresh(r.Context()); err != nil {
+			if r.Context().Err() == nil {
+				log.Printf("live refresh error: %v", err)
+			}
+			continue
+		}
+
+		msgs := feed.since(cursor, flag)
+		for _, m := range msgs {
+			if err := send(m); err != nil {
+				return
+			}
+		}
+		if len(msgs) > 0 {
+			cursor, flag = feed.cursor(), feed.flagState
+			lastWrite = time.Now()
+		} else if time.Since(lastWrite) >= liveHeartbeat {
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			lastWrite = time.Now()
+		} else {
+			continue
+		}
+		flusher.Flush()
+	}
+}
@@ -36,6 +36,14 @@ func main() {
 	mux.HandleFunc("PUT /api/tracks/{id}/classes/{classId}", handleUpdateKartClass)
 	mux.HandleFunc("DELETE /api/tracks/{id}/classes/{classId}", handleDeleteKartClass)
 
+	// Karts
+	mux.HandleFunc("POST /api/tracks/{id}/karts", handleCreateKart)
+	mux.HandleFunc("GET /api/tracks/{id}/karts", handleListKarts)
+	mux.HandleFunc("GET /api/tracks/{id}/karts/stats", handleGetKartStats)
+	mux.HandleFunc("GET /api/tracks/{id}/karts/{kartId}", handleGetKart)
+	mux.HandleFunc("PUT /api/tracks/{id}/karts/{kartId}", handleUpdateKart)
+	mux.HandleFunc("DELETE /api/tracks/{id}/karts/{kartId}", handleDeleteKart)
+
 	// Leaderboard
 	mux.HandleFunc("GET /api/tracks/{id}/leaderboard", handleGetLeaderboard)
 
@@ -47,6 +55,11 @@ func main() {
 	// Members
 	mux.HandleFunc("GET /api/tracks/{id}/members", handleListMembers)
 
+	// Memberships (driver members, for member pricing)
+	mux.HandleFunc("GET /api/tracks/{id}/memberships", handleListMemberships)
+	mux.HandleFunc("POST /api/tracks/{id}/memberships", handleAddMembership)
+	mux.HandleFunc("DELETE /api/tracks/{id}/memberships/{uid}", handleRemoveMembership)
+
 	// Invites (user-scoped)
 	mux.HandleFunc("GET /api/invites", handleListMyInvites)
 	mux.HandleFunc("POST /api/invites/{trackId}/accept", handleAcceptInvite)
@@ -72,6 +85,15 @@ func main() {
 	mux.HandleFunc("POST /api/uploads/{id}/assign", handleAssignUpload)
 	mux.HandleFunc("POST /api/uploads/{id}/ingest", handleTriggerIngest)
 	mux.HandleFunc("DELETE /api/uploads/{id}", handleDeleteUpload)
+	mux.HandleFunc("PUT /api/uploads/{id}/equipment", handleSetUploadEquipment)
+
+	// Equipment
+	mux.HandleFunc("POST /api/equipment", handleCreateEquipment)
+	mux.HandleFunc("GET /api/equipment", handleListEquipment)
+	mux.HandleFunc("GET /api/equipment/{id}", handleGetEquipment)
+	mux.HandleFunc("PUT /api/equipment/{id}", handleUpdateEquipment)
+	mux.HandleFunc("POST /api/equipment/{id}/rebuild", handleRebuildEquipment)
+	mux.HandleFunc("DELETE /api/equipment/{id}", handleDeleteEquipment)
 
 	// Events
 	mux.HandleFunc("GET /api/events", handleListEvents)
@@ -86,6 +108,12 @@ func main() {
 	mux.HandleFunc("GET /api/championships/{id}", handleGetChampionship)
 	mux.HandleFunc("PUT /api/championships/{id}", handleUpdateChampionship)
 	mux.HandleFunc("DELETE /api/championships/{id}", handleDeleteChampionship)
+	mux.HandleFunc("GET /api/championships/{id}/standings", handleGetChampionshipStandings)
+
+	// Promotion / relegation
+	mux.HandleFunc("POST /api/championships/{id}/promotions/preview", handlePreviewPromotions)
+	mux.HandleFunc("POST /api/championships/{id}/promotions", handleRunPromotions)
+	mux.HandleFunc("GET /api/championships/{id}/promotions", handleListPromotions)
 
 	// Formats
 	mux.HandleFunc("POST /api/tracks/{id}/formats", handleCreateFormat)
@@ -106,6 +134,12 @@ func main() {
 	mux.HandleFunc("GET /api/series/{id}/events", handleListSeriesEvents)
 	mux.HandleFunc("DELETE /api/series/{id}/events/{eventId}", handleRemoveEventFromSeries)
 
+	// Series Standings
+	mux.HandleFunc("GET /api/series/{id}/standings", handleGetSeriesStandings)
+	mux.HandleFunc("POST /api/series/{id}/standings/recompute", handleRecomputeSeriesStandings)
+	mux.HandleFunc("GET /api/series/{id}/team-standings", handleGetSeriesTeamStandings)
+	mux.HandleFunc("GET /api/series/{id}/ballast", handleGetSeriesBallast)
+
 	// Series Drivers
 	mux.HandleFunc("POST /api/series/{id}/drivers", handleEnrollDriver)
 	mux.HandleFunc("GET /api/series/{id}/drivers", handleListSeriesDrivers)
@@ -115,30 +149,94 @@ func main() {
 	// Registrations (series)
 	mux.HandleFunc("POST /api/series/{id}/registrations", handleCreateSeriesReg)
 	mux.HandleFunc("GET /api/series/{id}/registrations", handleListSeriesRegs)
+	mux.HandleFunc("GET /api/series/{id}/registrations/export", handleExportSeriesRegs)
+	mux.HandleFunc("POST /api/series/{id}/registrations/import", handleImportSeriesRegs)
+	mux.HandleFunc("GET /api/series/{id}/price", handleSeriesPrice)
+	mux.HandleFunc("GET /api/series/{id}/promo-codes", handleSeriesPromoCodes)
+	mux.HandleFunc("PUT /api/series/{id}/promo-codes", handleSeriesPromoCodes)
+	mux.HandleFunc("GET /api/series/{id}/checkin", handleCheckInSeries)
 	mux.HandleFunc("GET /api/series/{id}/registrations/{uid}", handleGetSeriesReg)
 	mux.HandleFunc("PUT /api/series/{id}/registrations/{uid}", handleUpdateSeriesReg)
 	mux.HandleFunc("DELETE /api/series/{id}/registrations/{uid}", handleDeleteSeriesReg)
+	mux.HandleFunc("POST /api/series/{id}/registrations/{uid}/checkout", handleCheckoutSeriesReg)
+	mux.HandleFunc("POST /api/series/{id}/registrations/{uid}/accept", handleAcceptSeriesReg)
+	mux.HandleFunc("GET /api/series/{id}/registrations/{uid}/transfer", handleTransferSeriesReg)
+	mux.HandleFunc("POST /api/series/{id}/registrations/{uid}/transfer", handleTransferSeriesReg)
+	mux.HandleFunc("DELETE /api/series/{id}/registrations/{uid}/transfer", handleTransferSeriesReg)
+	mux.HandleFunc("POST /api/series/{id}/registrations/{uid}/transfer/accept", handleClaimSeriesReg)
 
 	// Registrations (events)
 	mux.HandleFunc("POST /api/events/{id}/registrations", handleCreateEventReg)
 	mux.HandleFunc("GET /api/events/{id}/registrations", handleListEventRegs)
+	mux.HandleFunc("GET /api/events/{id}/registrations/export", handleExportEventRegs)
+	mux.HandleFunc("POST /api/events/{id}/registrations/import", handleImportEventRegs)
+	mux.HandleFunc("GET /api/events/{id}/price", handleEventPrice)
+	mux.HandleFunc("GET /api/events/{id}/promo-codes", handleEventPromoCodes)
+	mux.HandleFunc("PUT /api/events/{id}/promo-codes", handleEventPromoCodes)
+	mux.HandleFunc("GET /api/events/{id}/checkin", handleEventCheckIn)
+	mux.HandleFunc("POST /api/events/{id}/checkin/{uid}", handleCheckInDriver)
+	mux.HandleFunc("DELETE /api/events/{id}/checkin/{uid}", handleUndoCheckIn)
+	mux.HandleFunc("GET /api/events/{id}/checkin/{uid}/qr", handleCheckInQR)
 	mux.HandleFunc("GET /api/events/{id}/registrations/{uid}", handleGetEventReg)
 	mux.HandleFunc("PUT /api/events/{id}/registrations/{uid}", handleUpdateEventReg)
 	mux.HandleFunc("DELETE /api/events/{id}/registrations/{uid}", handleDeleteEventReg)
+	mux.HandleFunc("POST /api/events/{id}/registrations/{uid}/checkout", handleCheckoutEventReg)
+	mux.HandleFunc("POST /api/events/{id}/registrations/{uid}/accept", handleAcceptEventReg)
+	mux.HandleFunc("GET /api/events/{id}/registrations/{uid}/transfer", handleTransferEventReg)
+	mux.HandleFunc("POST /api/events/{id}/registrations/{uid}/transfer", handleTransferEventReg)
+	mux.HandleFunc("DELETE /api/events/{id}/registrations/{uid}/transfer", handleTransferEventReg)
+	mux.HandleFunc("POST /api/events/{id}/registrations/{uid}/transfer/accept", handleClaimEventReg)
 
 	// Registrations (sessions)
 	mux.HandleFunc("POST /api/sessions/{id}/registrations", handleCreateSessionReg)
 	mux.HandleFunc("GET /api/sessions/{id}/registrations", handleListSessionRegs)
+	mux.HandleFunc("GET /api/sessions/{id}/registrations/export", handleExportSessionRegs)
+	mux.HandleFunc("POST /api/sessions/{id}/registrations/import", handleImportSessionRegs)
+	mux.HandleFunc("GET /api/sessions/{id}/price", handleSessionPrice)
+	mux.HandleFunc("GET /api/sessions/{id}/promo-codes", handleSessionPromoCodes)
+	mux.HandleFunc("PUT /api/sessions/{id}/promo-codes", handleSessionPromoCodes)
+	mux.HandleFunc("GET /api/sessions/{id}/checkin", handleCheckInSession)
 	mux.HandleFunc("GET /api/sessions/{id}/registrations/{uid}", handleGetSessionReg)
 	mux.HandleFunc("PUT /api/sessions/{id}/registrations/{uid}", handleUpdateSessionReg)
 	mux.HandleFunc("DELETE /api/sessions/{id}/registrations/{uid}", handleDeleteSessionReg)
+	mux.HandleFunc("POST /api/sessions/{id}/registrations/{uid}/checkout", handleCheckoutSessionReg)
+	mux.HandleFunc("POST /api/sessions/{id}/registrations/{uid}/accept", handleAcceptSessionReg)
+	mux.HandleFunc("GET /api/sessions/{id}/registrations/{uid}/transfer", handleTransferSessionReg)
+	mux.HandleFunc("POST /api/sessions/{id}/registrations/{uid}/transfer", handleTransferSessionReg)
+	mux.HandleFunc("DELETE /api/sessions/{id}/registrations/{uid}/transfer", handleTransferSessionReg)
+	mux.HandleFunc("POST /api/sessions/{id}/registrations/{uid}/transfer/accept", handleClaimSessionReg)
+	mux.HandleFunc("POST /api/sessions/{id}/registrations/{uid}/swap", handleSwapSession)
+
+	// Payments
+	mux.HandleFunc("POST /api/payments/webhook", handlePaymentWebhook)
+	mux.HandleFunc("GET /api/payments/fake/{checkoutId}", handleFakeCheckout)
 
 	// My registrations
 	mux.HandleFunc("GET /api/my/registrations", handleListMyRegistrations)
+	mux.HandleFunc("GET /api/my/transfers", handleListMyTransfers)
+
+	// Waivers
+	mux.HandleFunc("POST /api/tracks/{id}/waivers", handlePublishWaiver)
+	mux.HandleFunc("GET /api/tracks/{id}/waivers", handleListWaivers)
+	mux.HandleFunc("GET /api/tracks/{id}/waivers/{version}", handleGetWaiverVersion)
+	mux.HandleFunc("GET /api/tracks/{id}/waiver", handleGetCurrentWaiver)
+	mux.HandleFunc("POST /api/tracks/{id}/waiver/sign", handleSignWaiver)
+	mux.HandleFunc("GET /api/my/waivers", handleListMyWaivers)
+
+	// Teams
+	mux.HandleFunc("POST /api/teams", handleCreateTeam)
+	mux.HandleFunc("GET /api/teams/{id}", handleGetTeam)
+	mux.HandleFunc("PUT /api/teams/{id}", handleUpdateTeam)
+	mux.HandleFunc("DELETE /api/teams/{id}", handleDeleteTeam)
+	mux.HandleFunc("GET /api/teams/{id}/members", handleListTeamMembers)
+	mux.HandleFunc("POST /api/teams/{id}/members", handleAddTeamMember)
+	mux.HandleFunc("DELETE /api/teams/{id}/members/{uid}", handleRemoveTeamMember)
+	mux.HandleFunc("GET /api/my/teams", handleListMyTeams)
 
 	// Event Sessions
 	mux.HandleFunc("POST /api/events/{id}/sessions", handleCreateEventSession)
 	mux.HandleFunc("GET /api/events/{id}/sessions", handleListEventSessions)
+	mux.HandleFunc("POST /api/events/{id}/heats", handleSplitHeats)
 
 	// Sessions
 	mux.HandleFunc("POST /api/sessions/{id}/reprocess", handleReprocessLaps)
@@ -150,13 +248,44 @@ func main() {
 	mux.HandleFunc("DELETE /api/sessions/{id}/laps/{uid}", handleDeleteDriverLaps)
 	mux.HandleFunc("GET /api/sessions/{id}/laps/{uid}/{lapNo}", handleGetLap)
 	mux.HandleFunc("GET /api/sessions/{id}/sectors", handleGetSectors)
+	mux.HandleFunc("GET /api/sessions/{id}/live", handleGetSessionLive)
 	mux.HandleFunc("GET /api/sessions/{id}/laps/{uid}/{lapNo}/telemetry", handleGetLapTelemetry)
 
+	// Session kart assignments
+	mux.HandleFunc("GET /api/sessions/{id}/karts", handleListSessionKarts)
+	mux.HandleFunc("POST /api/sessions/{id}/karts/balance", handleBalanceSessionKarts)
+	mux.HandleFunc("POST /api/sessions/{id}/karts/draw", handleDrawSessionKarts)
+	mux.HandleFunc("PUT /api/sessions/{id}/karts/{uid}", handleSetSessionKart)
+	mux.HandleFunc("DELETE /api/sessions/{id}/karts/{uid}", handleDeleteSessionKart)
+
+	// Session transponders (live timing)
+	mux.HandleFunc("GET /api/sessions/{id}/transponders", handleListSessionTransponders)
+	mux.HandleFunc("PUT /api/sessions/{id}/transponders/{number}", handleSetSessionTransponder)
+	mux.HandleFunc("DELETE /api/sessions/{id}/transponders/{number}", handleDeleteSessionTransponder)
+
+	// Grid
+	mux.HandleFunc("POST /api/sessions/{id}/grid", handleGenerateGrid)
+
 	// Results
 	mux.HandleFunc("POST /api/sessions/{id}/results", handlePostResult)
 	mux.HandleFunc("GET /api/sessions/{id}/results", handleListResults)
+	mux.HandleFunc("POST /api/sessions/{id}/results/generate", handleGenerateResults)
+	mux.HandleFunc("POST /api/sessions/{id}/results/publish", handlePublishResults)
 	mux.HandleFunc("DELETE /api/sessions/{id}/results/{uid}", handleDeleteResult)
 
+	// Penalties
+	mux.HandleFunc("POST /api/sessions/{id}/penalties", handleCreatePenalty)
+	mux.HandleFunc("GET /api/sessions/{id}/penalties", handleListSessionPenalties)
+	mux.HandleFunc("DELETE /api/sessions/{id}/penalties/{penaltyId}", handleDeletePenalty)
+	mux.HandleFunc("GET /api/events/{id}/penalties", handleListEventPenalties)
+
+	// Protests
+	mux.HandleFunc("POST /api/sessions/{id}/protests", handleCreateProtest)
+	mux.HandleFunc("GET /api/sessions/{id}/protests", handleListProtests)
+	mux.HandleFunc("GET /api/sessions/{id}/protests/{protestId}", handleGetProtest)
+	mux.HandleFunc("PUT /api/sessions/{id}/protests/{protestId}", handleUpdateProtest)
+	mux.HandleFunc("POST /api/sessions/{id}/protests/{protestId}/evidence", handleProtestEvidenceURL)
+
 	handler := cors(mux)
 
 	if os.Getenv("AWS_LAMBDA_FUNCTION_NAME") != "" {
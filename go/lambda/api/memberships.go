@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+)
+
+// handleListMemberships lists a track's members, for admins managing member
+// pricing.
+func handleListMemberships(w http.ResponseWriter, r *http.Request) {
+	uid, err := requireAuth(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	trackID := r.PathValue("id")
+
+	if err := requireTrackRole(r, trackID, uid, "owner", "admin"); err != nil {
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+
+	list, err := dynamo.ListMemberships(r.Context(), trackID)
+	if err != nil {
+		log.Printf("list memberships error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if list == nil {
+		list = []dynamo.Membership{}
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+// handleAddMembership makes a driver a member of the track, found by uid or
+// email, or renews their membership with a new expiry.
+func handleAddMembership(w http.ResponseWriter, r *http.Request) {
+	uid, err := requireAuth(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	trackID := r.PathValue("id")
+
+	if err := requireTrackRole(r, trackID, uid, "owner", "admin"); err != nil {
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+
+	var req struct {
+		UID       string `json:"uid"`
+		Email     string `json:"email"`
+		ExpiresAt string `json:"expires_at"` // optional
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	if req.ExpiresAt != "" {
+		if _, err := time.Parse(time.RFC3339, req.ExpiresAt); err != nil {
+			writeError(w, http.StatusBadRequest, "expires_at must be RFC 3339")
+			return
+		}
+	}
+
+	var user *dynamo.UserProfile
+	switch {
+	case req.UID != "":
+		user, err = dynamo.GetUser(r.Context(), req.UID)
+	case req.Email != "":
+		user, err = dynamo.GetUserByEmail(r.Context(), strings.ToLower(strings.TrimSpace(req.Email)))
+	default:
+		writeError(w, http.StatusBadRequest, "uid or email is required")
+		return
+	}
+	if err != nil {
+		log.Printf("get user error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if user == nil {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	m, err := dynamo.PutMembership(r.Context(), dynamo.Membership{
+		TrackID:    trackID,
+		UID:        strings.TrimPrefix(user.UID, "USER#"),
+		DriverName: user.Name,
+		ExpiresAt:  req.ExpiresAt,
+		AddedBy:    uid,
+	})
+	if err != nil {
+		log.Printf("put membership error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, m)
+}
+
+func handleRemoveMembership(w http.ResponseWriter, r *http.Request) {
+	uid, err := requireAuth(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	trackID := r.PathValue("id")
+
+	if err := requireTrackRole(r, trackID, uid, "owner", "admin"); err != nil {
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+
+	if err := dynamo.DeleteMembership(r.Context(), trackID, r.PathValue("uid")); err != nil {
+		log.Printf("delete membership error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+	"github.com/BrianLeishman/karttrackpark.com/go/payments"
+	"github.com/BrianLeishman/karttrackpark.com/go/pricing"
+)
+
+const siteURL = "https://karttrackpark.com"
+
+// paymentProvider takes entry fees with Stripe when it's configured. Locally
+// a fake provider stands in, with its checkout pages served by
+// handleFakeCheckout. In Lambda without Stripe it is nil, and admins mark
+// registrations paid by hand as before.
+var paymentProvider = sync.OnceValue(func() payments.Provider {
+	if key := os.Getenv("STRIPE_SECRET_KEY"); key != "" {
+		return payments.NewStripe(key, os.Getenv("STRIPE_WEBHOOK_SECRET"))
+	}
+	if os.Getenv("AWS_LAMBDA_FUNCTION_NAME") == "" {
+		return payments.NewFake("http://localhost:25565/api/payments/fake/")
+	}
+	return nil
+})
+
+var regParentResolvers = map[string]func(context.Context, string) (*regParentInfo, error){
+	"series":  resolveSeriesParent,
+	"event":   resolveEventParent,
+	"session": resolveSessionParent,
+}
+
+// checkoutReturnURL is where the driver lands after checkout: the page they
+// registered from when it's on this site, else the home page.
+func checkoutReturnURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Host != "karttrackpark.com" && u.Hostname() != "localhost") {
+		return siteURL
+	}
+	return u.String()
+}
+
+// deadlinePassed reports whether a registration deadline has passed. No
+// deadline never passes.
+func deadlinePassed(deadline string) bool {
+	if deadline == "" {
+		return false
+	}
+	t, err := time.Parse(time.RFC3339, deadline)
+	return err == nil && time.Now().UTC().After(t)
+}
+
+// startCheckout opens a checkout for a registration's entry fee and saves it
+// on the registration, which stays pending until the payment comes in.
+func startCheckout(ctx context.Context, provider payments.Provider, parent *regParentInfo, reg *dynamo.Registration, returnURL string) error {
+	amount := pricing.Due(parent.PriceCents, *reg)
+	currency := parent.Currency
+	if currency == "" {
+		currency = "usd"
+	}
+	customerEmail := reg.Email
+	if user, err := dynamo.GetUser(ctx, reg.UID); err == nil && user != nil && user.Email != "" {
+		customerEmail = user.Email
+	}
+
+	returnURL = checkoutReturnURL(returnURL)
+	co, err := provider.CreateCheckout(ctx, payments.CheckoutRequest{
+		Description:   parent.ParentName + " registration",
+		AmountCents:   amount,
+		Currency:      currency,
+		CustomerEmail: customerEmail,
+		SuccessURL:    returnURL,
+		CancelURL:     returnURL,
+		Metadata: map[string]string{
+			"parent_type": reg.ParentType,
+			"parent_id":   reg.ParentID,
+			"uid":         reg.UID,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	fields := map[string]any{
+		"priceCents":      amount,
+		"paymentProvider": provider.Name(),
+		"checkoutId":      co.ID,
+		"checkoutUrl":     co.URL,
+	}
+	if err := dynamo.UpdateRegistration(ctx, reg.ParentType, reg.ParentID, reg.UID, fields); err != nil {
+		return fmt.Errorf("save checkout: %w", err)
+	}
+	reg.PriceCents = amount
+	reg.PaymentProvider = provider.Name()
+	reg.CheckoutID = co.ID
+	reg.CheckoutURL = co.URL
+	return nil
+}
+
+// completePayment marks the registration a checkout was for as paid. A
+// pending registration is confirmed, unless the parent needs an admin's
+// approval as well. Events for unknown or superseded checkouts are ignored.
+func completePayment(ctx context.Context, evt *payments.Event) error {
+	parentType, parentID, regUID := evt.Metadata["parent_type"], evt.Metadata["parent_id"], evt.Metadata["uid"]
+	resolve := regParentResolvers[parentType]
+	if resolve == nil {
+		log.Printf("payment for unknown registration type %q (checkout %s)", parentType, evt.CheckoutID)
+		return nil
+	}
+
+	reg, err := dynamo.GetRegistration(ctx, parentType, parentID, regUID)
+	if err != nil {
+		return err
+	}
+	if reg == nil || reg.CheckoutID != evt.CheckoutID {
+		log.Printf("payment for missing registration %s/%s/%s (checkout %s)", parentType, parentID, regUID, evt.CheckoutID)
+		return nil
+	}
+	if reg.Paid {
+		return nil
+	}
+
+	fields := map[string]any{
+		"paid":        true,
+		"paymentId":   evt.PaymentID,
+		"checkoutUrl": nil,
+	}
+	if reg.Status == "pending" {
+		parent, err := resolve(ctx, parentID)
+		if err != nil {
+			return err
+		}
+		unsigned, err := needsWaiver(ctx, reg.TrackID, regUID)
+		if err != nil {
+			return err
+		}
+		if parent != nil && parent.RegistrationMode != "approval_required" && !unsigned {
+			fields["status"] = "confirmed"
+			if err := dynamo.ChangeRegistrationStatus(ctx, parentType, parentID, regUID, "pending", parent.MaxSpots, fields); err != nil {
+				return err
+			}
+			seriesRegChanged(ctx, reg, "pending", "confirmed")
+			return nil
+		}
+	}
+	return dynamo.UpdateRegistration(ctx, parentType, parentID, regUID, fields)
+}
+
+// refundRegistration refunds a paid registration that is withdrawn before
+// the registration deadline. After the deadline the fee is kept. The refund
+// is saved on the registration before it is withdrawn, so a withdrawal that
+// fails and is tried again doesn't refund twice.
+func refundRegistration(ctx context.Context, parent *regParentInfo, reg *dynamo.Registration) error {
+	if !reg.Paid || reg.PaymentID == "" || reg.RefundID != "" || deadlinePassed(parent.RegistrationDeadline) {
+		return nil
+	}
+	provider := paymentProvider()
+	if provider == nil || provider.Name() != reg.PaymentProvider {
+		return fmt.Errorf("payment provider %q not configured", reg.PaymentProvider)
+	}
+	refundID, err := provider.Refund(ctx, reg.PaymentID, reg.PriceCents)
+	if err != nil {
+		return err
+	}
+	log.Printf("refunded %s registration %s/%s: %s", reg.ParentType, reg.ParentID, reg.UID, refundID)
+
+	reg.RefundID = refundID
+	reg.RefundedAt = time.Now().UTC().Format(time.RFC3339)
+	return dynamo.UpdateRegistration(ctx, reg.ParentType, reg.ParentID, reg.UID, map[string]any{
+		"refundId":   reg.RefundID,
+		"refundedAt": reg.RefundedAt,
+	})
+}
+
+// makeCheckoutRegHandler opens a new checkout for the caller's own unpaid
+// registration, e.g. when the first one expired or couldn't be created.
+func makeCheckoutRegHandler(parentType string, resolve func(context.Context, string) (*regParentInfo, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uid, err := requireAuth(r)
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		parentID := r.PathValue("id")
+		if r.PathValue("uid") != uid {
+			writeError(w, http.StatusForbidden, "drivers can only pay for their own registration")
+			return
+		}
+
+		parent, err := resolve(r.Context(), parentID)
+		if err != nil {
+			log.Printf("resolve %s parent error: %v", parentType, err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		if parent == nil {
+			writeError(w, http.StatusNotFound, parentType+" not found")
+			return
+		}
+
+		reg, err := dynamo.GetRegistration(r.Context(), parentType, parentID, uid)
+		if err != nil {
+			log.Printf("get registration error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		if reg == nil {
+			writeError(w, http.StatusNotFound, "registration not found")
+			return
+		}
+		if reg.Paid {
+			writeError(w, http.StatusConflict, "registration already paid")
+			return
+		}
+		if reg.Status != "pending" && reg.Status != "confirmed" {
+			writeError(w, http.StatusConflict, "registration is "+reg.Status)
+			return
+		}
+
+		provider := paymentProvider()
+		if provider == nil || pricing.Due(parent.PriceCents, *reg) <= 0 {
+			writeError(w, http.StatusBadRequest, "online payment is not available")
+			return
+		}
+
+		var req struct {
+			ReturnURL string `json:"return_url"`
+		}
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeError(w, http.StatusBadRequest, "invalid body")
+				return
+			}
+		}
+
+		if err := startCheckout(r.Context(), provider, parent, reg, req.ReturnURL); err != nil {
+			log.Printf("start checkout error: %v", err)
+			writeError(w, http.StatusBadGateway, "payment provider unavailable")
+			return
+		}
+
+		writeJSON(w, http.StatusOK, reg)
+	}
+}
+
+// handlePaymentWebhook receives payment notifications from the provider.
+func handlePaymentWebhook(w http.ResponseWriter, r *http.Request) {
+	provider := paymentProvider()
+	if provider == nil {
+		writeError(w, http.StatusNotFound, "payments not configured")
+		return
+	}
+
+	payload, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	evt, err := provider.ParseWebhook(payload, r.Header)
+	if err != nil {
+		log.Printf("payment webhook rejected: %v", err)
+		writeError(w, http.StatusBadRequest, "invalid webhook")
+		return
+	}
+
+	if evt.Type == payments.EventCheckoutCompleted {
+		if err := completePayment(r.Context(), evt); err != nil {
+			log.Printf("complete payment error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleFakeCheckout is the fake provider's checkout page for local
+// development: visiting it pays at once and sends the driver back.
+func handleFakeCheckout(w http.ResponseWriter, r *http.Request) {
+	fake, ok := paymentProvider().(*payments.Fake)
+	if !ok {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	payload, successURL, err := fake.Complete(r.PathValue("checkoutId"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	evt, err := fake.ParseWebhook(payload, nil)
+	if err == nil {
+		err = completePayment(r.Context(), evt)
+	}
+	if err != nil {
+		log.Printf("fake checkout error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	http.Redirect(w, r, successURL, http.StatusSeeOther)
+}
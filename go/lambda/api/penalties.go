@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+	"github.com/BrianLeishman/karttrackpark.com/go/penalties"
+	"github.com/BrianLeishman/karttrackpark.com/go/timing"
+)
+
+var validPenaltyTypes = map[string]bool{
+	dynamo.PenaltyTime: true, dynamo.PenaltyPosition: true, dynamo.PenaltyLaps: true,
+	dynamo.PenaltyDSQ: true, dynamo.PenaltyPoints: true, dynamo.PenaltyGrid: true,
+}
+
+// applySessionPenalties re-applies every penalty in force for a session to
+// its results. Grid penalties issued here count against the session they
+// target, so they're swapped for the ones targeting this session. Sessions
+// that have never had a penalty are left untouched.
+func applySessionPenalties(ctx context.Context, session *dynamo.Session) error {
+	results, err := dynamo.ListResultsForSession(ctx, session.SessionID)
+	if err != nil || len(results) == 0 {
+		return err
+	}
+
+	issued, err := dynamo.ListPenaltiesForSession(ctx, session.SessionID)
+	if err != nil {
+		return err
+	}
+	var inForce []dynamo.Penalty
+	for _, p := range issued {
+		if p.Type != dynamo.PenaltyGrid {
+			inForce = append(inForce, p)
+		}
+	}
+	if session.EventID != "" {
+		eventPenalties, err := dynamo.ListPenaltiesForEvent(ctx, session.EventID)
+		if err != nil {
+			return err
+		}
+		for _, p := range eventPenalties {
+			if p.Type == dynamo.PenaltyGrid && p.TargetSessionID == session.SessionID {
+				inForce = append(inForce, p)
+			}
+		}
+	}
+
+	if len(inForce) == 0 && !hasAppliedPenalties(results) {
+		return nil
+	}
+
+	for _, res := range penalties.Apply(results, inForce, timing.IsRace(session.SessionType)) {
+		if _, err := dynamo.PutResult(ctx, res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hasAppliedPenalties(results []dynamo.Result) bool {
+	for _, r := range results {
+		if r.TimePenaltyMs != 0 || r.LapsDeducted != 0 || r.PositionDrop != 0 ||
+			r.PointsDeduction != 0 || r.GridPenalty != 0 || r.Disqualified {
+			return true
+		}
+	}
+	return false
+}
+
+// nextRaceSession returns the first race-type session after sessionID in the
+// event's running order, or "" if there isn't one.
+func nextRaceSession(ctx context.Context, eventID, sessionID string) (string, error) {
+	sessions, err := dynamo.ListEventSessions(ctx, eventID)
+	if err != nil {
+		return "", err
+	}
+	sort.SliceStable(sessions, func(i, j int) bool { return sessions[i].SessionOrder < sessions[j].SessionOrder })
+
+	after := false
+	for _, es := range sessions {
+		if es.SessionID == sessionID {
+			after = true
+			continue
+		}
+		if after && timing.IsRace(es.SessionType) {
+			return es.SessionID, nil
+		}
+	}
+	return "", nil
+}
+
+// refreshAfterPenalty re-applies penalties to the affected sessions and then
+// the event's series standings. Errors are logged; the penalty itself is
+// already saved.
+func refreshAfterPenalty(ctx context.Context, session *dynamo.Session, p dynamo.Penalty) {
+	if err := applySessionPenalties(ctx, session); err != nil {
+		log.Printf("apply penalties error: %v", err)
+	}
+	if p.Type == dynamo.PenaltyGrid && p.TargetSessionID != "" {
+		target, err := dynamo.GetSession(ctx, p.TargetSessionID)
+		if err != nil {
+			log.Printf("get session error: %v", err)
+		} else if target != nil {
+			if err := applySessionPenalties(ctx, target); err != nil {
+				log.Printf("apply penalties error: %v", err)
+			}
+		}
+	}
+	recomputeStandingsForEvent(ctx, session.EventID)
+}
+
+func handleCreatePenalty(w http.ResponseWriter, r *http.Request) {
+	uid, err := requireAuth(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	session, err := dynamo.GetSession(r.Context(), r.PathValue("id"))
+	if err != nil {
+		log.Printf("get session error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if session == nil {
+		writeError(w, http.StatusNotFound, "session not found")
+		return
+	}
+
+	if err := requireTrackRole(r, session.TrackID, uid, "owner", "admin"); err != nil {
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+
+	var req struct {
+		UID             string `json:"uid"`
+		Type            string `json:"type"`
+		Value           int64  `json:"value"`
+		Reason          string `json:"reason"`
+		TargetSessionID string `json:"target_session_id"`
+		ProtestID       string `json:"protest_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	if req.UID == "" {
+		writeError(w, http.StatusBadRequest, "uid is required")
+		return
+	}
+	if !validPenaltyTypes[req.Type] {
+		writeError(w, http.StatusBadRequest, "type must be time, position, laps, dsq, points or grid")
+		return
+	}
+	if req.Type != dynamo.PenaltyDSQ && req.Value <= 0 {
+		writeError(w, http.StatusBadRequest, "value must be positive")
+		return
+	}
+	if req.Reason == "" {
+		writeError(w, http.StatusBadRequest, "reason is required")
+		return
+	}
+
+	if req.Type == dynamo.PenaltyGrid {
+		if req.TargetSessionID == "" && session.EventID != "" {
+			next, err := nextRaceSession(r.Context(), session.EventID, session.SessionID)
+			if err != nil {
+				log.Printf("next race session error: %v", err)
+				writeError(w, http.StatusInternalServerError, "internal error")
+				return
+			}
+			req.TargetSessionID = next
+		}
+		if req.TargetSessionID == "" {
+			writeError(w, http.StatusBadRequest, "no later race in this event; target_session_id is required")
+			return
+		}
+	} else {
+		req.TargetSessionID = ""
+	}
+
+	if req.ProtestID != "" {
+		protest, err := dynamo.GetProtest(r.Context(), session.SessionID, req.ProtestID)
+		if err != nil {
+			log.Printf("get protest error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		if protest == nil {
+			writeError(w, http.StatusBadRequest, "protest not found in this session")
+			return
+		}
+	}
+
+	driverName := ""
+	if res, err := dynamo.GetResult(r.Context(), session.SessionID, req.UID); err == nil && res != nil {
+		driverName = res.DriverName
+	} else if user, err := dynamo.GetUser(r.Context(), req.UID); err == nil && user != nil {
+		driverName = user.Name
+	}
+	issuedByName := ""
+	if user, err := dynamo.GetUser(r.Context(), uid); err == nil && user != nil {
+		issuedByName = user.Name
+	}
+
+	p, err := dynamo.CreatePenalty(r.Context(), dynamo.Penalty{
+		SessionID:       session.SessionID,
+		EventID:         session.EventID,
+		TrackID:         session.TrackID,
+		UID:             req.UID,
+		DriverName:      driverName,
+		Type:            req.Type,
+		Value:           req.Value,
+		TargetSessionID: req.TargetSessionID,
+		ProtestID:       req.ProtestID,
+		Reason:          req.Reason,
+		IssuedBy:        uid,
+		IssuedByName:    issuedByName,
+	})
+	if err != nil {
+		log.Printf("create penalty error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	if p.ProtestID != "" {
+		if err := linkPenaltyToProtest(r.Context(), session.SessionID, p.ProtestID, p.PenaltyID); err != nil {
+			log.Printf("link penalty to protest error: %v", err)
+		}
+	}
+	refreshAfterPenalty(r.Context(), session, *p)
+
+	writeJSON(w, http.StatusCreated, p)
+}
+
+func handleListSessionPenalties(w http.ResponseWriter, r *http.Request) {
+	list, err := dynamo.ListPenaltiesForSession(r.Context(), r.PathValue("id"))
+	if err != nil {
+		log.Printf("list penalties error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if list == nil {
+		list = []dynamo.Penalty{}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].CreatedAt < list[j].CreatedAt })
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+// handleListEventPenalties is the public penalty log for an event.
+func handleListEventPenalties(w http.ResponseWriter, r *http.Request) {
+	list, err := dynamo.ListPenaltiesForEvent(r.Context(), r.PathValue("id"))
+	if err != nil {
+		log.Printf("list event penalties error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if list == nil {
+		list = []dynamo.Penalty{}
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+// handleDeletePenalty withdraws a penalty and restores the affected results.
+func handleDeletePenalty(w http.ResponseWriter, r *http.Request) {
+	session, ok := requireSessionManager(w, r)
+	if !ok {
+		return
+	}
+
+	p, err := dynamo.GetPenalty(r.Context(), session.SessionID, r.PathValue("penaltyId"))
+	if err != nil {
+		log.Printf("get penalty error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if p == nil {
+		writeError(w, http.StatusNotFound, "penalty not found")
+		return
+	}
+
+	if err := dynamo.DeletePenalty(r.Context(), session.SessionID, p.PenaltyID); err != nil {
+		log.Printf("delete penalty error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	refreshAfterPenalty(r.Context(), session, *p)
+
+	w.WriteHeader(http.StatusNoContent)
+}
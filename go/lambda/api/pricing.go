@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+	"github.com/BrianLeishman/karttrackpark.com/go/pricing"
+)
+
+var regParentUpdaters = map[string]func(context.Context, string, map[string]any) error{
+	"series":  dynamo.UpdateSeries,
+	"event":   dynamo.UpdateEvent,
+	"session": dynamo.UpdateSession,
+}
+
+// pricingRules gathers a registration parent's pricing settings.
+func pricingRules(parent *regParentInfo) dynamo.RegistrationSettings {
+	return dynamo.RegistrationSettings{
+		PriceCents:       parent.PriceCents,
+		EarlyBird:        parent.EarlyBird,
+		MemberPriceCents: parent.MemberPriceCents,
+		PromoCodes:       parent.PromoCodes,
+	}
+}
+
+// holdsSeasonPass reports whether a driver is confirmed for a series that
+// includes the event and lets its drivers enter its events free.
+func holdsSeasonPass(ctx context.Context, eventID, uid string) (bool, error) {
+	links, err := dynamo.ListSeriesForEvent(ctx, eventID)
+	if err != nil {
+		return false, err
+	}
+	for _, link := range links {
+		series, err := dynamo.GetSeries(ctx, link.SeriesID)
+		if err != nil {
+			return false, err
+		}
+		if series == nil || !series.SeasonPass {
+			continue
+		}
+		reg, err := dynamo.GetRegistration(ctx, "series", link.SeriesID, uid)
+		if err != nil {
+			return false, err
+		}
+		if reg != nil && reg.Status == "confirmed" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// quoteRegistration prices a driver registering for a parent now, with an
+// optional promo code. The error is pricing.ErrUnknownCode or
+// pricing.ErrCodeExpired when the code is no good.
+func quoteRegistration(ctx context.Context, parentType, parentID string, parent *regParentInfo, uid, code string) (pricing.Quote, error) {
+	now := time.Now().UTC()
+	buyer := pricing.Buyer{PromoCode: code}
+	if parent.MemberPriceCents > 0 {
+		m, err := dynamo.GetMembership(ctx, parent.TrackID, uid)
+		if err != nil {
+			return pricing.Quote{}, err
+		}
+		buyer.Member = pricing.Member(m, now)
+	}
+	if parentType == "event" {
+		pass, err := holdsSeasonPass(ctx, parentID, uid)
+		if err != nil {
+			return pricing.Quote{}, err
+		}
+		buyer.SeasonPass = pass
+	}
+	return pricing.Price(pricingRules(parent), buyer, now)
+}
+
+// writeQuoteError writes the response for a quote that failed and reports
+// whether there was anything to write.
+func writeQuoteError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, pricing.ErrUnknownCode), errors.Is(err, pricing.ErrCodeExpired):
+		writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		log.Printf("quote registration error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+	}
+	return true
+}
+
+// redeemQuote takes a use of the quote's promo code, if it has one. On
+// failure the error response has been written.
+func redeemQuote(w http.ResponseWriter, r *http.Request, parentType, parentID string, q pricing.Quote) bool {
+	if q.PromoCode == "" {
+		return true
+	}
+	err := dynamo.RedeemPromoCode(r.Context(), parentType, parentID, q.PromoCode, q.MaxUses)
+	if errors.Is(err, dynamo.ErrPromoCodeUsedUp) {
+		writeError(w, http.StatusConflict, "promo code has been used up")
+		return false
+	}
+	if err != nil {
+		log.Printf("redeem promo code error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return false
+	}
+	return true
+}
+
+// releasePromoCode gives back the promo code use of a registration that was
+// withdrawn or never saved. Errors are logged.
+func releasePromoCode(ctx context.Context, parentType, parentID, code string) {
+	if code == "" {
+		return
+	}
+	if err := dynamo.ReleasePromoCode(ctx, parentType, parentID, code); err != nil {
+		log.Printf("release promo code error: %v", err)
+	}
+}
+
+// applyQuote records the price a registration was quoted.
+func applyQuote(reg *dynamo.Registration, q pricing.Quote) {
+	reg.PriceCents = q.PriceCents
+	reg.PriceRule = q.Rule
+	reg.PromoCode = q.PromoCode
+}
+
+// quoteFields are the update fields that record a quote on a registration.
+func quoteFields(q pricing.Quote) map[string]any {
+	fields := map[string]any{
+		"priceCents": q.PriceCents,
+		"priceRule":  q.Rule,
+		"promoCode":  nil,
+	}
+	if q.PromoCode != "" {
+		fields["promoCode"] = q.PromoCode
+	}
+	return fields
+}
+
+// setEarlyBirdField checks the "earlyBird" tiers of a series or event update
+// and stores them in date order. On failure the error response has been
+// written.
+func setEarlyBirdField(w http.ResponseWriter, fields map[string]any) bool {
+	raw, ok := fields["earlyBird"]
+	if !ok {
+		return true
+	}
+	var tiers []dynamo.PriceTier
+	b, _ := json.Marshal(raw)
+	if err := json.Unmarshal(b, &tiers); err != nil {
+		writeError(w, http.StatusBadRequest, "earlyBird must be a list of price tiers")
+		return false
+	}
+	tiers, err := pricing.ValidateTiers(tiers)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+	fields["earlyBird"] = tiers
+	if len(tiers) == 0 {
+		fields["earlyBird"] = nil
+	}
+	return true
+}
+
+// makePriceHandler quotes the caller what they'd pay to register now, so
+// the form can show the price and check a promo code (?code=) first.
+func makePriceHandler(parentType string, resolve func(context.Context, string) (*regParentInfo, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uid, err := requireAuth(r)
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		parentID := r.PathValue("id")
+
+		parent, err := resolve(r.Context(), parentID)
+		if err != nil {
+			log.Printf("resolve %s parent error: %v", parentType, err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		if parent == nil {
+			writeError(w, http.StatusNotFound, parentType+" not found")
+			return
+		}
+
+		quote, err := quoteRegistration(r.Context(), parentType, parentID, parent, uid, r.URL.Query().Get("code"))
+		if writeQuoteError(w, err) {
+			return
+		}
+
+		writeJSON(w, http.StatusOK, struct {
+			pricing.Quote
+			StandardCents int    `json:"standard_price_cents"`
+			Currency      string `json:"currency,omitempty"`
+		}{quote, parent.PriceCents, parent.Currency})
+	}
+}
+
+// promoCodeWithUses is a promo code as admins see it.
+type promoCodeWithUses struct {
+	dynamo.PromoCode
+	Uses int `json:"uses"`
+}
+
+func listPromoCodes(ctx context.Context, parentType, parentID string, codes []dynamo.PromoCode) ([]promoCodeWithUses, error) {
+	uses, err := dynamo.PromoCodeUses(ctx, parentType, parentID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]promoCodeWithUses, 0, len(codes))
+	for _, pc := range codes {
+		out = append(out, promoCodeWithUses{PromoCode: pc, Uses: uses[pc.Code]})
+	}
+	return out, nil
+}
+
+// makePromoCodesHandler lists a parent's promo codes and how often each has
+// been used, for admins. PUT replaces the list; uses are kept for codes that
+// stay.
+func makePromoCodesHandler(parentType string, resolve func(context.Context, string) (*regParentInfo, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uid, err := requireAuth(r)
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		parentID := r.PathValue("id")
+
+		parent, err := resolve(r.Context(), parentID)
+		if err != nil {
+			log.Printf("resolve %s parent error: %v", parentType, err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		if parent == nil {
+			writeError(w, http.StatusNotFound, parentType+" not found")
+			return
+		}
+
+		if err := requireTrackRole(r, parent.TrackID, uid, "owner", "admin"); err != nil {
+			writeError(w, http.StatusForbidden, err.Error())
+			return
+		}
+
+		codes := parent.PromoCodes
+		if r.Method == http.MethodPut {
+			var req []dynamo.PromoCode
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeError(w, http.StatusBadRequest, "invalid body")
+				return
+			}
+			codes, err = pricing.ValidateCodes(req)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			var value any = codes
+			if len(codes) == 0 {
+				value = nil
+			}
+			if err := regParentUpdaters[parentType](r.Context(), parentID, map[string]any{"promoCodes": value}); err != nil {
+				log.Printf("update promo codes error: %v", err)
+				writeError(w, http.StatusInternalServerError, "internal error")
+				return
+			}
+		}
+
+		list, err := listPromoCodes(r.Context(), parentType, parentID, codes)
+		if err != nil {
+			log.Printf("list promo code uses error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		writeJSON(w, http.StatusOK, list)
+	}
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/divisions"
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+)
+
+// loadDivisions builds the current table of every tiered series in a
+// championship. Positions come from freshly computed standings; drivers who
+// have already moved out of a series are left out of it, and drivers who
+// moved in but haven't raced a round of it since are settling.
+func loadDivisions(ctx context.Context, championshipID string) ([]divisions.Division, error) {
+	list, err := dynamo.ListSeriesForChampionship(ctx, championshipID)
+	if err != nil {
+		return nil, err
+	}
+
+	var divs []divisions.Division
+	for i := range list {
+		series := &list[i]
+		if series.Tier <= 0 {
+			continue
+		}
+		table, err := computeSeriesStandings(ctx, series)
+		if err != nil {
+			return nil, err
+		}
+		enrolled, err := dynamo.ListSeriesDrivers(ctx, series.SeriesID)
+		if err != nil {
+			return nil, err
+		}
+
+		div := divisions.Division{SeriesID: series.SeriesID, Name: series.Name, Tier: series.Tier}
+		byUID := map[string]dynamo.SeriesDriver{}
+		for _, d := range enrolled {
+			byUID[d.UID] = d
+		}
+		placed := map[string]bool{}
+		for _, s := range table {
+			d := byUID[s.UID]
+			if d.MovedTo != "" {
+				continue
+			}
+			placed[s.UID] = true
+			raced := false
+			for _, rs := range s.Rounds {
+				div.LastRound = max(div.LastRound, rs.Round)
+				raced = raced || (rs.Played && rs.Round > d.MovedAfterRound)
+			}
+			div.Drivers = append(div.Drivers, divisions.Driver{
+				UID:       s.UID,
+				Name:      s.DriverName,
+				Position:  s.Position,
+				Protected: d.RelegationProtected,
+				Seeded:    d.Seeded,
+				Settling:  d.MovedFrom != "" && !raced,
+			})
+		}
+		for _, d := range enrolled {
+			if d.MovedTo != "" || placed[d.UID] {
+				continue
+			}
+			div.Drivers = append(div.Drivers, divisions.Driver{
+				UID:       d.UID,
+				Name:      d.DriverName,
+				Protected: d.RelegationProtected,
+				Seeded:    d.Seeded,
+				Settling:  d.MovedFrom != "",
+			})
+		}
+		divs = append(divs, div)
+	}
+	return divs, nil
+}
+
+// requireChampionshipAdmin loads the championship in the path and checks the
+// caller can manage its track.
+func requireChampionshipAdmin(w http.ResponseWriter, r *http.Request) (*dynamo.Championship, string, bool) {
+	uid, err := requireAuth(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return nil, "", false
+	}
+
+	champ, err := dynamo.GetChampionship(r.Context(), r.PathValue("id"))
+	if err != nil {
+		log.Printf("get championship error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return nil, "", false
+	}
+	if champ == nil {
+		writeError(w, http.StatusNotFound, "championship not found")
+		return nil, "", false
+	}
+
+	if err := requireTrackRole(r, champ.TrackID, uid, "owner", "admin"); err != nil {
+		writeError(w, http.StatusForbidden, err.Error())
+		return nil, "", false
+	}
+
+	return champ, uid, true
+}
+
+type promotionRequest struct {
+	Promote  int `json:"promote"`
+	Relegate int `json:"relegate"`
+}
+
+// lastPromotionRun returns a championship's latest promotion run, nil if it
+// has none, writing any error response itself.
+func lastPromotionRun(w http.ResponseWriter, r *http.Request, championshipID string) (*dynamo.PromotionRun, bool) {
+	runs, err := dynamo.ListPromotionRuns(r.Context(), championshipID)
+	if err != nil {
+		log.Printf("list promotion runs error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return nil, false
+	}
+	if len(runs) == 0 {
+		return nil, true
+	}
+	return &runs[len(runs)-1], true
+}
+
+// planPromotions decodes the request and plans the moves, writing any error
+// response itself. It refuses while the last run is unfinished or no round
+// has been scored since it.
+func planPromotions(w http.ResponseWriter, r *http.Request, championshipID string, last *dynamo.PromotionRun) (promotionRequest, divisions.Plan, bool) {
+	var req promotionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body")
+		return req, divisions.Plan{}, false
+	}
+
+	divs, err := loadDivisions(r.Context(), championshipID)
+	if err != nil {
+		log.Printf("load divisions error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return req, divisions.Plan{}, false
+	}
+
+	if last != nil && last.Pending {
+		writeError(w, http.StatusConflict, "the last promotion run didn't finish; run promotions again to complete it")
+		return req, divisions.Plan{}, false
+	}
+	// Standings don't change until another round is scored, so running
+	// again straight away would move the next drivers in line
+	if last != nil && !divisions.RacedSince(divs, last.LastRounds) {
+		writeError(w, http.StatusConflict, "no rounds have been scored since the last promotion run")
+		return req, divisions.Plan{}, false
+	}
+
+	plan, err := divisions.Build(divs, req.Promote, req.Relegate)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return req, divisions.Plan{}, false
+	}
+	if plan.Moves == nil {
+		plan.Moves = []dynamo.DivisionMove{}
+	}
+	return req, plan, true
+}
+
+// handlePreviewPromotions shows who would move without changing anything.
+func handlePreviewPromotions(w http.ResponseWriter, r *http.Request) {
+	champ, _, ok := requireChampionshipAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	last, ok := lastPromotionRun(w, r, champ.ChampionshipID)
+	if !ok {
+		return
+	}
+	_, plan, ok := planPromotions(w, r, champ.ChampionshipID, last)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"moves":     plan.Moves,
+		"protected": plan.Protected,
+	})
+}
+
+// applyPromotionRun makes every move of a run not yet applied, then marks
+// the run complete.
+func applyPromotionRun(ctx context.Context, run *dynamo.PromotionRun) error {
+	applied := map[string]bool{}
+	for _, uid := range run.Applied {
+		applied[uid] = true
+	}
+	for _, m := range run.Moves {
+		if applied[m.UID] {
+			continue
+		}
+		err := dynamo.ApplyDivisionMove(ctx, run, m, run.LastRounds[m.ToSeriesID])
+		if err != nil && !errors.Is(err, dynamo.ErrMoveApplied) {
+			return err
+		}
+	}
+	return dynamo.CompletePromotionRun(ctx, run)
+}
+
+// handleRunPromotions moves drivers between divisions. Each driver is
+// enrolled in their new series with a note of where they came from, and their
+// old enrollment is kept (with its points) and marked as moved. The run is
+// saved before anyone moves; if the last run stopped part-way, this finishes
+// it instead of planning a new one.
+func handleRunPromotions(w http.ResponseWriter, r *http.Request) {
+	champ, uid, ok := requireChampionshipAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	run, ok := lastPromotionRun(w, r, champ.ChampionshipID)
+	if !ok {
+		return
+	}
+	if run == nil || !run.Pending {
+		req, plan, ok := planPromotions(w, r, champ.ChampionshipID, run)
+		if !ok {
+			return
+		}
+		var err error
+		run, err = dynamo.CreatePromotionRun(r.Context(), dynamo.PromotionRun{
+			ChampionshipID: champ.ChampionshipID,
+			Promote:        req.Promote,
+			Relegate:       req.Relegate,
+			Moves:          plan.Moves,
+			Protected:      plan.Protected,
+			LastRounds:     plan.LastRounds,
+			Pending:        true,
+			RunBy:          uid,
+		})
+		if err != nil {
+			log.Printf("create promotion run error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+	}
+
+	if err := applyPromotionRun(r.Context(), run); err != nil {
+		log.Printf("apply promotion run error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, run)
+}
+
+func handleListPromotions(w http.ResponseWriter, r *http.Request) {
+	runs, err := dynamo.ListPromotionRuns(r.Context(), r.PathValue("id"))
+	if err != nil {
+		log.Printf("list promotion runs error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if runs == nil {
+		runs = []dynamo.PromotionRun{}
+	}
+
+	writeJSON(w, http.StatusOK, runs)
+}
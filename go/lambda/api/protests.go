@@ -0,0 +1,415 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/rs/xid"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+)
+
+// maxProtestEvidence caps how many files can be attached to one protest.
+const maxProtestEvidence = 10
+
+var evidenceContentTypes = map[string]string{
+	"image/png":       "png",
+	"image/jpeg":      "jpg",
+	"image/webp":      "webp",
+	"video/mp4":       "mp4",
+	"video/quicktime": "mov",
+	"application/pdf": "pdf",
+}
+
+// Statuses officials can move a protest to; drivers may only withdraw.
+var validProtestDecisions = map[string]bool{
+	dynamo.ProtestUnderReview: true, dynamo.ProtestUpheld: true, dynamo.ProtestDismissed: true,
+}
+
+// isSessionEntrant reports whether uid took part in a session: registered,
+// assigned a kart or transponder, or has a result.
+func isSessionEntrant(ctx context.Context, sessionID, uid string) (bool, error) {
+	entrants, err := sessionEntrants(ctx, sessionID)
+	if err != nil {
+		return false, err
+	}
+	if _, ok := entrants[uid]; ok {
+		return true, nil
+	}
+	res, err := dynamo.GetResult(ctx, sessionID, uid)
+	if err != nil {
+		return false, err
+	}
+	return res != nil, nil
+}
+
+// loadProtest resolves the session and protest from the path and checks that
+// the caller is a track official or one of the parties involved.
+func loadProtest(w http.ResponseWriter, r *http.Request) (uid string, session *dynamo.Session, p *dynamo.Protest, official bool, ok bool) {
+	uid, err := requireAuth(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	session, err = dynamo.GetSession(r.Context(), r.PathValue("id"))
+	if err != nil {
+		log.Printf("get session error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if session == nil {
+		writeError(w, http.StatusNotFound, "session not found")
+		return
+	}
+
+	p, err = dynamo.GetProtest(r.Context(), session.SessionID, r.PathValue("protestId"))
+	if err != nil {
+		log.Printf("get protest error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	official = requireTrackRole(r, session.TrackID, uid, "owner", "admin") == nil
+	// Hide protests from uninvolved drivers entirely
+	if p == nil || (!official && !p.Involves(uid)) {
+		writeError(w, http.StatusNotFound, "protest not found")
+		return
+	}
+	return uid, session, p, official, true
+}
+
+func handleCreateProtest(w http.ResponseWriter, r *http.Request) {
+	uid, err := requireAuth(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	session, err := dynamo.GetSession(r.Context(), r.PathValue("id"))
+	if err != nil {
+		log.Printf("get session error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if session == nil {
+		writeError(w, http.StatusNotFound, "session not found")
+		return
+	}
+
+	entrant, err := isSessionEntrant(r.Context(), session.SessionID, uid)
+	if err != nil {
+		log.Printf("session entrant error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if !entrant {
+		writeError(w, http.StatusForbidden, "only drivers in this session can file a protest")
+		return
+	}
+
+	var req struct {
+		Kind        string `json:"kind"`
+		AgainstUID  string `json:"against_uid"`
+		Description string `json:"description"`
+		LapNo       int    `json:"lap_no"`
+		TimeRef     string `json:"time_ref"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	if req.Kind != "driver" && req.Kind != "result" {
+		writeError(w, http.StatusBadRequest, "kind must be driver or result")
+		return
+	}
+	if req.Kind == "driver" && req.AgainstUID == "" {
+		writeError(w, http.StatusBadRequest, "against_uid is required")
+		return
+	}
+	if req.AgainstUID == uid {
+		writeError(w, http.StatusBadRequest, "cannot protest yourself")
+		return
+	}
+	if req.Description == "" {
+		writeError(w, http.StatusBadRequest, "description is required")
+		return
+	}
+	if req.LapNo < 0 {
+		writeError(w, http.StatusBadRequest, "lap_no must not be negative")
+		return
+	}
+
+	filedByName := ""
+	if user, err := dynamo.GetUser(r.Context(), uid); err == nil && user != nil {
+		filedByName = user.Name
+	}
+	againstName := ""
+	if req.AgainstUID != "" {
+		if res, err := dynamo.GetResult(r.Context(), session.SessionID, req.AgainstUID); err == nil && res != nil {
+			againstName = res.DriverName
+		} else if user, err := dynamo.GetUser(r.Context(), req.AgainstUID); err == nil && user != nil {
+			againstName = user.Name
+		}
+	}
+
+	p, err := dynamo.CreateProtest(r.Context(), dynamo.Protest{
+		SessionID:   session.SessionID,
+		EventID:     session.EventID,
+		TrackID:     session.TrackID,
+		FiledBy:     uid,
+		FiledByName: filedByName,
+		Kind:        req.Kind,
+		AgainstUID:  req.AgainstUID,
+		AgainstName: againstName,
+		Description: req.Description,
+		LapNo:       req.LapNo,
+		TimeRef:     req.TimeRef,
+	})
+	if err != nil {
+		log.Printf("create protest error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, p)
+}
+
+// handleListProtests returns every protest to track officials, and to
+// drivers only the protests they filed or were named in.
+func handleListProtests(w http.ResponseWriter, r *http.Request) {
+	uid, err := requireAuth(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	session, err := dynamo.GetSession(r.Context(), r.PathValue("id"))
+	if err != nil {
+		log.Printf("get session error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if session == nil {
+		writeError(w, http.StatusNotFound, "session not found")
+		return
+	}
+
+	all, err := dynamo.ListProtestsForSession(r.Context(), session.SessionID)
+	if err != nil {
+		log.Printf("list protests error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	official := requireTrackRole(r, session.TrackID, uid, "owner", "admin") == nil
+	protests := []dynamo.Protest{}
+	for _, p := range all {
+		if official || p.Involves(uid) {
+			protests = append(protests, p)
+		}
+	}
+	sort.SliceStable(protests, func(i, j int) bool { return protests[i].CreatedAt < protests[j].CreatedAt })
+
+	writeJSON(w, http.StatusOK, protests)
+}
+
+// handleGetProtest returns a protest with short-lived download links for its
+// evidence.
+func handleGetProtest(w http.ResponseWriter, r *http.Request) {
+	_, _, p, _, ok := loadProtest(w, r)
+	if !ok {
+		return
+	}
+
+	urls := map[string]string{}
+	if len(p.Evidence) > 0 {
+		presigner, err := s3Presigner()
+		if err != nil {
+			log.Printf("s3 presigner error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		for _, e := range p.Evidence {
+			presigned, err := presigner.PresignGetObject(r.Context(), &s3.GetObjectInput{
+				Bucket: aws.String(uploadBucket),
+				Key:    aws.String(e.Key),
+			}, s3.WithPresignExpires(15*time.Minute))
+			if err != nil {
+				log.Printf("presign error: %v", err)
+				writeError(w, http.StatusInternalServerError, "internal error")
+				return
+			}
+			urls[e.Key] = presigned.URL
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"protest":       p,
+		"evidence_urls": urls,
+	})
+}
+
+// handleProtestEvidenceURL returns a presigned upload URL for a new evidence
+// file and records it on the protest.
+func handleProtestEvidenceURL(w http.ResponseWriter, r *http.Request) {
+	uid, session, p, official, ok := loadProtest(w, r)
+	if !ok {
+		return
+	}
+	if p.FiledBy != uid && !official {
+		writeError(w, http.StatusForbidden, "only the driver who filed the protest can add evidence")
+		return
+	}
+	if p.Decided() {
+		writeError(w, http.StatusConflict, "protest is closed")
+		return
+	}
+	if len(p.Evidence) >= maxProtestEvidence {
+		writeError(w, http.StatusBadRequest, "too many evidence files")
+		return
+	}
+
+	var req struct {
+		Filename    string `json:"filename"`
+		ContentType string `json:"content_type"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	if req.Filename == "" || req.ContentType == "" {
+		writeError(w, http.StatusBadRequest, "filename and content_type are required")
+		return
+	}
+	ext, ok := evidenceContentTypes[req.ContentType]
+	if !ok {
+		writeError(w, http.StatusBadRequest, "unsupported content type")
+		return
+	}
+
+	presigner, err := s3Presigner()
+	if err != nil {
+		log.Printf("s3 presigner error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	key := "protests/" + p.ProtestID + "/" + xid.New().String() + "." + ext
+
+	presigned, err := presigner.PresignPutObject(r.Context(), &s3.PutObjectInput{
+		Bucket:      aws.String(uploadBucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(req.ContentType),
+	}, s3.WithPresignExpires(15*time.Minute))
+	if err != nil {
+		log.Printf("presign error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	evidence := append(p.Evidence, dynamo.ProtestEvidence{
+		Key:         key,
+		Filename:    req.Filename,
+		ContentType: req.ContentType,
+		UploadedBy:  uid,
+		UploadedAt:  time.Now().UTC().Format(time.RFC3339),
+	})
+	if err := dynamo.UpdateProtest(r.Context(), session.SessionID, p.ProtestID, map[string]any{"evidence": evidence}); err != nil {
+		log.Printf("update protest error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"upload_url": presigned.URL,
+		"key":        key,
+	})
+}
+
+// handleUpdateProtest lets officials triage and decide a protest, linking any
+// penalties issued, and lets the driver who filed it withdraw it.
+func handleUpdateProtest(w http.ResponseWriter, r *http.Request) {
+	uid, session, p, official, ok := loadProtest(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Status     string   `json:"status"`
+		Decision   *string  `json:"decision"`
+		PenaltyIDs []string `json:"penalty_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+
+	fields := map[string]any{}
+	if !official {
+		if p.FiledBy != uid || req.Status != dynamo.ProtestWithdrawn || req.Decision != nil || req.PenaltyIDs != nil {
+			writeError(w, http.StatusForbidden, "drivers can only withdraw their own protests")
+			return
+		}
+		if p.Decided() {
+			writeError(w, http.StatusConflict, "protest is closed")
+			return
+		}
+		fields["status"] = dynamo.ProtestWithdrawn
+	} else {
+		if req.Status != "" {
+			if !validProtestDecisions[req.Status] {
+				writeError(w, http.StatusBadRequest, "status must be under_review, upheld or dismissed")
+				return
+			}
+			fields["status"] = req.Status
+			if req.Status == dynamo.ProtestUpheld || req.Status == dynamo.ProtestDismissed {
+				fields["decidedBy"] = uid
+				fields["decidedAt"] = time.Now().UTC().Format(time.RFC3339)
+			}
+		}
+		if req.Decision != nil {
+			fields["decision"] = *req.Decision
+		}
+		if req.PenaltyIDs != nil {
+			for _, id := range req.PenaltyIDs {
+				pen, err := dynamo.GetPenalty(r.Context(), session.SessionID, id)
+				if err != nil {
+					log.Printf("get penalty error: %v", err)
+					writeError(w, http.StatusInternalServerError, "internal error")
+					return
+				}
+				if pen == nil {
+					writeError(w, http.StatusBadRequest, "penalty "+id+" not found in this session")
+					return
+				}
+			}
+			fields["penaltyIds"] = req.PenaltyIDs
+		}
+	}
+
+	if err := dynamo.UpdateProtest(r.Context(), session.SessionID, p.ProtestID, fields); err != nil {
+		log.Printf("update protest error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// linkPenaltyToProtest records a penalty on the protest it resolves.
+func linkPenaltyToProtest(ctx context.Context, sessionID, protestID, penaltyID string) error {
+	p, err := dynamo.GetProtest(ctx, sessionID, protestID)
+	if err != nil || p == nil {
+		return err
+	}
+	return dynamo.UpdateProtest(ctx, sessionID, protestID, map[string]any{
+		"penaltyIds": append(p.PenaltyIDs, penaltyID),
+	})
+}
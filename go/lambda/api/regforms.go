@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+	"github.com/BrianLeishman/karttrackpark.com/go/regform"
+)
+
+// setQuestionsField checks the "questions" of a series or event update and
+// swaps in the typed form so it's stored under the item's own attribute
+// names. A bad form is reported to the client and false returned.
+func setQuestionsField(w http.ResponseWriter, fields map[string]any) bool {
+	raw, ok := fields["questions"]
+	if !ok {
+		return true
+	}
+	var questions []dynamo.RegistrationQuestion
+	b, _ := json.Marshal(raw)
+	if err := json.Unmarshal(b, &questions); err != nil {
+		writeError(w, http.StatusBadRequest, "questions must be a list of questions")
+		return false
+	}
+	if err := regform.ValidateSchema(questions); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+	fields["questions"] = questions
+	if len(questions) == 0 {
+		fields["questions"] = nil
+	}
+	return true
+}
+
+// answersVisibleTo returns whether the caller may see a registration's
+// answers. They can hold emergency contacts and the like, so only track
+// admins see everyone's; drivers see their own.
+func answersVisibleTo(r *http.Request, trackID string) func(regUID string) bool {
+	uid, err := requireAuth(r)
+	if err != nil {
+		return func(string) bool { return false }
+	}
+	if requireTrackRole(r, trackID, uid, "owner", "admin") == nil {
+		return func(string) bool { return true }
+	}
+	return func(regUID string) bool { return regUID == uid }
+}
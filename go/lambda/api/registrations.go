@@ -3,13 +3,14 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
-	"github.com/BrianLeishman/karttrackpark.com/go/email"
+	"github.com/BrianLeishman/karttrackpark.com/go/regform"
 )
 
 type regParentInfo struct {
@@ -17,7 +18,15 @@ type regParentInfo struct {
 	ParentName           string
 	RegistrationMode     string
 	MaxSpots             int
+	PriceCents           int
+	Currency             string
 	RegistrationDeadline string
+	WaitlistOfferHours   int
+	MinAge               int
+	Questions            []dynamo.RegistrationQuestion
+	EarlyBird            []dynamo.PriceTier
+	MemberPriceCents     int
+	PromoCodes           []dynamo.PromoCode
 }
 
 func resolveSeriesParent(ctx context.Context, id string) (*regParentInfo, error) {
@@ -33,7 +42,15 @@ func resolveSeriesParent(ctx context.Context, id string) (*regParentInfo, error)
 		ParentName:           s.Name,
 		RegistrationMode:     s.RegistrationMode,
 		MaxSpots:             s.MaxSpots,
+		PriceCents:           s.PriceCents,
+		Currency:             s.Currency,
 		RegistrationDeadline: s.RegistrationDeadline,
+		WaitlistOfferHours:   s.WaitlistOfferHours,
+		MinAge:               s.MinAge,
+		Questions:            s.Questions,
+		EarlyBird:            s.EarlyBird,
+		MemberPriceCents:     s.MemberPriceCents,
+		PromoCodes:           s.PromoCodes,
 	}, nil
 }
 
@@ -50,7 +67,15 @@ func resolveEventParent(ctx context.Context, id string) (*regParentInfo, error)
 		ParentName:           e.Name,
 		RegistrationMode:     e.RegistrationMode,
 		MaxSpots:             e.MaxSpots,
+		PriceCents:           e.PriceCents,
+		Currency:             e.Currency,
 		RegistrationDeadline: e.RegistrationDeadline,
+		WaitlistOfferHours:   e.WaitlistOfferHours,
+		MinAge:               e.MinAge,
+		Questions:            e.Questions,
+		EarlyBird:            e.EarlyBird,
+		MemberPriceCents:     e.MemberPriceCents,
+		PromoCodes:           e.PromoCodes,
 	}, nil
 }
 
@@ -67,7 +92,15 @@ func resolveSessionParent(ctx context.Context, id string) (*regParentInfo, error
 		ParentName:           s.SessionName,
 		RegistrationMode:     s.RegistrationMode,
 		MaxSpots:             s.MaxSpots,
+		PriceCents:           s.PriceCents,
+		Currency:             s.Currency,
 		RegistrationDeadline: s.RegistrationDeadline,
+		WaitlistOfferHours:   s.WaitlistOfferHours,
+		MinAge:               s.MinAge,
+		Questions:            s.Questions,
+		EarlyBird:            s.EarlyBird,
+		MemberPriceCents:     s.MemberPriceCents,
+		PromoCodes:           s.PromoCodes,
 	}, nil
 }
 
@@ -93,8 +126,14 @@ func makeCreateRegHandler(parentType string, resolve func(context.Context, strin
 		}
 
 		var req struct {
-			Email      string `json:"email"`
-			DriverName string `json:"driver_name"`
+			Email      string         `json:"email"`
+			DriverName string         `json:"driver_name"`
+			ClassID    string         `json:"class_id"`
+			TeamID     string         `json:"team_id"`
+			WeightKg   float64        `json:"weight_kg"`
+			Answers    map[string]any `json:"answers"`
+			PromoCode  string         `json:"promo_code"`
+			ReturnURL  string         `json:"return_url"` // where to come back to after paying
 		}
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			writeError(w, http.StatusBadRequest, "invalid body")
@@ -152,6 +191,19 @@ func makeCreateRegHandler(parentType string, resolve func(context.Context, strin
 			driverName = inviteEmail
 		}
 
+		team, ok := teamForDriver(w, r, req.TeamID, targetUID)
+		if !ok {
+			return
+		}
+
+		// Drivers answer every required question themselves; an admin
+		// entering someone else may leave them for the driver
+		answers, err := regform.Validate(parent.Questions, req.Answers, targetUID == uid)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
 		status := "confirmed"
 		mode := parent.RegistrationMode
 		if mode == "" {
@@ -160,6 +212,17 @@ func makeCreateRegHandler(parentType string, resolve func(context.Context, strin
 
 		isSelfRegistering := targetUID == uid
 
+		// The price is fixed when the driver registers
+		quote, err := quoteRegistration(r.Context(), parentType, parentID, parent, targetUID, req.PromoCode)
+		if writeQuoteError(w, err) {
+			return
+		}
+
+		// Drivers registering themselves for a priced entry pay online; the
+		// registration stays pending until the payment comes in
+		provider := paymentProvider()
+		payOnline := isSelfRegistering && quote.PriceCents > 0 && provider != nil
+
 		if isAdmin && !isSelfRegistering && mode == "invite_only" {
 			// Admin inviting a driver — create as "invited" so they
 			// still need to self-register (and potentially pay)
@@ -171,6 +234,9 @@ func makeCreateRegHandler(parentType string, resolve func(context.Context, strin
 				return
 			case "open":
 				status = "confirmed"
+				if payOnline {
+					status = "pending"
+				}
 			case "invite_only":
 				// Check if this driver has an existing "invited" registration
 				existing, err := dynamo.GetRegistration(r.Context(), parentType, parentID, targetUID)
@@ -204,44 +270,93 @@ func makeCreateRegHandler(parentType string, resolve func(context.Context, strin
 					return
 				}
 
-				// Upgrade the invited registration to confirmed
-				// If the invite was by email (placeholder UID), delete old + create new with real UID
+				// Upgrade the invited registration to confirmed. An invite
+				// made out to an email (placeholder UID) is swapped for one
+				// under the driver's UID in the same write, so a failure
+				// before then leaves the invite to try again
 				if existing.UID != targetUID {
-					if err := dynamo.DeleteRegistration(r.Context(), parentType, parentID, existing.UID); err != nil {
-						log.Printf("delete placeholder reg error: %v", err)
+					claimed := *existing
+					claimed.UID = targetUID
+					claimed.Status = "confirmed"
+					if payOnline {
+						claimed.Status = "pending"
+					}
+					claimed.Status, err = holdForWaiver(r.Context(), parent.TrackID, targetUID, claimed.Status)
+					if err != nil {
+						log.Printf("check waiver error: %v", err)
 						writeError(w, http.StatusInternalServerError, "internal error")
 						return
 					}
-					existing.UID = targetUID
-					existing.Status = "confirmed"
-					existing.RegisteredAt = time.Now().UTC().Format(time.RFC3339)
+					claimed.RegisteredAt = time.Now().UTC().Format(time.RFC3339)
 					if driverName != "" {
-						existing.DriverName = driverName
+						claimed.DriverName = driverName
 					}
-					confirmed, err := dynamo.CreateRegistration(r.Context(), *existing)
+					claimed.Answers = answers
+					if !redeemQuote(w, r, parentType, parentID, quote) {
+						return
+					}
+					applyQuote(&claimed, quote)
+					confirmed, err := claimInvite(r.Context(), parent, existing, claimed)
 					if err != nil {
-						log.Printf("create confirmed reg error: %v", err)
+						releasePromoCode(r.Context(), parentType, parentID, quote.PromoCode)
+					}
+					switch {
+					case errors.Is(err, dynamo.ErrRegistrationChanged):
+						writeError(w, http.StatusConflict, "invite changed, reload and try again")
+						return
+					case errors.Is(err, dynamo.ErrAlreadyRegistered):
+						writeError(w, http.StatusConflict, "you're already registered")
+						return
+					case err != nil:
+						log.Printf("claim invite error: %v", err)
 						writeError(w, http.StatusInternalServerError, "internal error")
 						return
 					}
+					seriesRegChanged(r.Context(), confirmed, "invited", confirmed.Status)
+					if payOnline && confirmed.Status == "pending" {
+						if err := startCheckout(r.Context(), provider, parent, confirmed, req.ReturnURL); err != nil {
+							log.Printf("start checkout error: %v", err)
+						}
+					}
 					writeJSON(w, http.StatusOK, confirmed)
 					return
 				}
 
 				// Same UID — just update in place
-				fields := map[string]any{
-					"status":       "confirmed",
-					"registeredAt": time.Now().UTC().Format(time.RFC3339),
+				existing.Status = "confirmed"
+				if payOnline {
+					existing.Status = "pending"
 				}
+				existing.Status, err = holdForWaiver(r.Context(), parent.TrackID, targetUID, existing.Status)
+				if err != nil {
+					log.Printf("check waiver error: %v", err)
+					writeError(w, http.StatusInternalServerError, "internal error")
+					return
+				}
+				fields := quoteFields(quote)
+				fields["registeredAt"] = time.Now().UTC().Format(time.RFC3339)
 				if driverName != "" && driverName != existing.DriverName {
 					fields["driverName"] = driverName
 				}
-				if err := dynamo.UpdateRegistration(r.Context(), parentType, parentID, targetUID, fields); err != nil {
+				if len(answers) > 0 {
+					fields["answers"] = answers
+					existing.Answers = answers
+				}
+				if !redeemQuote(w, r, parentType, parentID, quote) {
+					return
+				}
+				if err := admitInvite(r.Context(), parent, existing, fields); err != nil {
+					releasePromoCode(r.Context(), parentType, parentID, quote.PromoCode)
 					log.Printf("confirm invite error: %v", err)
 					writeError(w, http.StatusInternalServerError, "internal error")
 					return
 				}
-				existing.Status = "confirmed"
+				seriesRegChanged(r.Context(), existing, "invited", existing.Status)
+				if payOnline && existing.Status == "pending" {
+					if err := startCheckout(r.Context(), provider, parent, existing, req.ReturnURL); err != nil {
+						log.Printf("start checkout error: %v", err)
+					}
+				}
 				writeJSON(w, http.StatusOK, existing)
 				return
 			case "approval_required":
@@ -258,17 +373,10 @@ func makeCreateRegHandler(parentType string, resolve func(context.Context, strin
 			}
 		}
 
-		// Check capacity (only count confirmed/pending, not invited)
-		if parent.MaxSpots > 0 && status != "invited" {
-			count, err := dynamo.CountRegistrations(r.Context(), parentType, parentID)
-			if err != nil {
-				log.Printf("count registrations error: %v", err)
-				writeError(w, http.StatusInternalServerError, "internal error")
-				return
-			}
-			if count >= parent.MaxSpots {
-				status = "waitlisted"
-			}
+		// Lapsed offers free their spots for the waitlist before anyone new
+		// can take them
+		if parent.MaxSpots > 0 {
+			promoteWaitlist(r.Context(), parentType, parentID, parent)
 		}
 
 		invitedBy := ""
@@ -276,42 +384,56 @@ func makeCreateRegHandler(parentType string, resolve func(context.Context, strin
 			invitedBy = uid
 		}
 
-		reg, err := dynamo.CreateRegistration(r.Context(), dynamo.Registration{
+		// Nobody is confirmed before signing the track's current waiver
+		status, err = holdForWaiver(r.Context(), parent.TrackID, targetUID, status)
+		if err != nil {
+			log.Printf("check waiver error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		newReg := dynamo.Registration{
 			ParentType: parentType,
 			ParentID:   parentID,
 			TrackID:    parent.TrackID,
 			UID:        targetUID,
 			Email:      inviteEmail,
 			DriverName: driverName,
+			ClassID:    req.ClassID,
+			TeamID:     team.TeamID,
+			TeamName:   team.Name,
+			WeightKg:   req.WeightKg,
+			Answers:    answers,
 			Status:     status,
 			InvitedBy:  invitedBy,
-		})
+		}
+		// Invited drivers are quoted when they register themselves
+		if status != "invited" {
+			if !redeemQuote(w, r, parentType, parentID, quote) {
+				return
+			}
+			applyQuote(&newReg, quote)
+		}
+		reg, err := admitRegistration(r.Context(), parent, newReg)
 		if err != nil {
+			releasePromoCode(r.Context(), parentType, parentID, newReg.PromoCode)
 			log.Printf("create registration error: %v", err)
 			writeError(w, http.StatusInternalServerError, "internal error")
 			return
 		}
+		seriesRegChanged(r.Context(), reg, "", reg.Status)
+
+		// A failed checkout leaves the registration pending; the driver can
+		// retry through the checkout endpoint
+		if payOnline && reg.Status == "pending" {
+			if err := startCheckout(r.Context(), provider, parent, reg, req.ReturnURL); err != nil {
+				log.Printf("start checkout error: %v", err)
+			}
+		}
 
 		// Send invite email (best-effort, don't fail the request)
 		if inviteEmail != "" && status == "invited" {
-			inviter, _ := dynamo.GetUser(r.Context(), uid)
-			inviterName := uid
-			if inviter != nil && inviter.Name != "" {
-				inviterName = inviter.Name
-			}
-			track, _ := dynamo.GetTrack(r.Context(), parent.TrackID)
-			trackName := parent.TrackID
-			if track != nil {
-				trackName = track.Name
-			}
-			if err := email.SendInvite(r.Context(), inviteEmail, email.InviteData{
-				InviterName: inviterName,
-				EntityName:  parent.ParentName,
-				TrackName:   trackName,
-				Link:        "https://karttrackpark.com",
-			}); err != nil {
-				log.Printf("send invite email error: %v", err)
-			}
+			sendRegInvite(r.Context(), uid, parent, inviteEmail)
 		}
 
 		writeJSON(w, http.StatusCreated, reg)
@@ -342,6 +464,12 @@ func makeListRegsHandler(parentType string, resolve func(context.Context, string
 		if regs == nil {
 			regs = []dynamo.Registration{}
 		}
+		visible := answersVisibleTo(r, parent.TrackID)
+		for i := range regs {
+			if !visible(regs[i].UID) {
+				regs[i].Answers = nil
+			}
+		}
 
 		writeJSON(w, http.StatusOK, regs)
 	}
@@ -362,6 +490,9 @@ func makeGetRegHandler(parentType string) http.HandlerFunc {
 			writeError(w, http.StatusNotFound, "registration not found")
 			return
 		}
+		if !answersVisibleTo(r, reg.TrackID)(reg.UID) {
+			reg.Answers = nil
+		}
 
 		writeJSON(w, http.StatusOK, reg)
 	}
@@ -389,8 +520,11 @@ func makeUpdateRegHandler(parentType string, resolve func(context.Context, strin
 			return
 		}
 
-		if err := requireTrackRole(r, parent.TrackID, uid, "owner", "admin"); err != nil {
-			writeError(w, http.StatusForbidden, err.Error())
+		// Drivers may record their own weight and answers; everything else
+		// is admin only
+		isAdmin := requireTrackRole(r, parent.TrackID, uid, "owner", "admin") == nil
+		if !isAdmin && regUID != uid {
+			writeError(w, http.StatusForbidden, "forbidden")
 			return
 		}
 
@@ -400,9 +534,12 @@ func makeUpdateRegHandler(parentType string, resolve func(context.Context, strin
 			return
 		}
 
-		allowed := map[string]bool{
-			"status": true, "driverName": true, "paid": true,
-			"priceCents": true, "standings": true,
+		allowed := map[string]bool{"weightKg": true, "answers": true}
+		if isAdmin {
+			allowed = map[string]bool{
+				"status": true, "driverName": true, "classId": true, "weightKg": true, "kartNumber": true,
+				"paid": true, "priceCents": true, "standings": true, "answers": true,
+			}
 		}
 		fields := map[string]any{}
 		for k, v := range req {
@@ -411,12 +548,81 @@ func makeUpdateRegHandler(parentType string, resolve func(context.Context, strin
 			}
 		}
 
-		if err := dynamo.UpdateRegistration(r.Context(), parentType, parentID, regUID, fields); err != nil {
+		if raw, ok := fields["answers"]; ok {
+			given, isMap := raw.(map[string]any)
+			if raw != nil && !isMap {
+				writeError(w, http.StatusBadRequest, "answers must be an object")
+				return
+			}
+			answers, err := regform.Validate(parent.Questions, given, !isAdmin)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			fields["answers"] = answers
+			if len(answers) == 0 {
+				fields["answers"] = nil
+			}
+		}
+
+		status, changesStatus := fields["status"]
+		if !changesStatus {
+			if err := dynamo.UpdateRegistration(r.Context(), parentType, parentID, regUID, fields); err != nil {
+				log.Printf("update registration error: %v", err)
+				writeError(w, http.StatusInternalServerError, "internal error")
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		// A status change takes or frees a spot along with it
+		newStatus, ok := status.(string)
+		if !ok || newStatus == "" {
+			writeError(w, http.StatusBadRequest, "status must be a string")
+			return
+		}
+		if newStatus == "confirmed" {
+			unsigned, err := needsWaiver(r.Context(), parent.TrackID, regUID)
+			if err != nil {
+				log.Printf("check waiver error: %v", err)
+				writeError(w, http.StatusInternalServerError, "internal error")
+				return
+			}
+			if unsigned {
+				writeError(w, http.StatusConflict, "the driver hasn't signed the track's current waiver")
+				return
+			}
+		}
+		reg, err := dynamo.GetRegistration(r.Context(), parentType, parentID, regUID)
+		if err != nil {
+			log.Printf("get registration error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		if reg == nil {
+			writeError(w, http.StatusNotFound, "registration not found")
+			return
+		}
+		err = dynamo.ChangeRegistrationStatus(r.Context(), parentType, parentID, regUID, reg.Status, parent.MaxSpots, fields)
+		switch {
+		case errors.Is(err, dynamo.ErrNoSpot):
+			writeError(w, http.StatusConflict, "every spot is taken; raise max spots first")
+			return
+		case errors.Is(err, dynamo.ErrRegistrationChanged):
+			writeError(w, http.StatusConflict, "registration changed, reload and try again")
+			return
+		case err != nil:
 			log.Printf("update registration error: %v", err)
 			writeError(w, http.StatusInternalServerError, "internal error")
 			return
 		}
 
+		seriesRegChanged(r.Context(), reg, reg.Status, newStatus)
+		if dynamo.HoldsSpot(reg.Status) {
+			promoteWaitlist(r.Context(), parentType, parentID, parent)
+		}
+
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
@@ -451,11 +657,42 @@ func makeDeleteRegHandler(parentType string, resolve func(context.Context, strin
 			}
 		}
 
-		if err := dynamo.DeleteRegistration(r.Context(), parentType, parentID, regUID); err != nil {
+		reg, err := dynamo.GetRegistration(r.Context(), parentType, parentID, regUID)
+		if err != nil {
+			log.Printf("get registration error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		if reg == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if err := refundRegistration(r.Context(), parent, reg); err != nil {
+			log.Printf("refund registration error: %v", err)
+			writeError(w, http.StatusBadGateway, "refund failed; registration kept")
+			return
+		}
+
+		// A refund made here is saved on the registration, so trying again
+		// after a failed withdrawal doesn't refund twice
+		err = dynamo.WithdrawRegistration(r.Context(), reg)
+		switch {
+		case errors.Is(err, dynamo.ErrRegistrationChanged):
+			writeError(w, http.StatusConflict, "registration changed, reload and try again")
+			return
+		case err != nil:
 			log.Printf("delete registration error: %v", err)
 			writeError(w, http.StatusInternalServerError, "internal error")
 			return
 		}
+		seriesRegChanged(r.Context(), reg, reg.Status, "")
+		releasePromoCode(r.Context(), parentType, parentID, reg.PromoCode)
+		if err := dynamo.DeleteTransfer(r.Context(), parentType, parentID, regUID); err != nil {
+			log.Printf("delete transfer error: %v", err)
+		}
+		if dynamo.HoldsSpot(reg.Status) {
+			promoteWaitlist(r.Context(), parentType, parentID, parent)
+		}
 
 		w.WriteHeader(http.StatusNoContent)
 	}
@@ -515,21 +752,47 @@ func handleUserLookup(w http.ResponseWriter, r *http.Request) {
 
 // Concrete handler functions
 var (
-	handleCreateSeriesReg = makeCreateRegHandler("series", resolveSeriesParent)
-	handleListSeriesRegs  = makeListRegsHandler("series", resolveSeriesParent)
-	handleGetSeriesReg    = makeGetRegHandler("series")
-	handleUpdateSeriesReg = makeUpdateRegHandler("series", resolveSeriesParent)
-	handleDeleteSeriesReg = makeDeleteRegHandler("series", resolveSeriesParent)
-
-	handleCreateEventReg = makeCreateRegHandler("event", resolveEventParent)
-	handleListEventRegs  = makeListRegsHandler("event", resolveEventParent)
-	handleGetEventReg    = makeGetRegHandler("event")
-	handleUpdateEventReg = makeUpdateRegHandler("event", resolveEventParent)
-	handleDeleteEventReg = makeDeleteRegHandler("event", resolveEventParent)
-
-	handleCreateSessionReg = makeCreateRegHandler("session", resolveSessionParent)
-	handleListSessionRegs  = makeListRegsHandler("session", resolveSessionParent)
-	handleGetSessionReg    = makeGetRegHandler("session")
-	handleUpdateSessionReg = makeUpdateRegHandler("session", resolveSessionParent)
-	handleDeleteSessionReg = makeDeleteRegHandler("session", resolveSessionParent)
+	handleCreateSeriesReg   = makeCreateRegHandler("series", resolveSeriesParent)
+	handleListSeriesRegs    = makeListRegsHandler("series", resolveSeriesParent)
+	handleGetSeriesReg      = makeGetRegHandler("series")
+	handleUpdateSeriesReg   = makeUpdateRegHandler("series", resolveSeriesParent)
+	handleDeleteSeriesReg   = makeDeleteRegHandler("series", resolveSeriesParent)
+	handleCheckoutSeriesReg = makeCheckoutRegHandler("series", resolveSeriesParent)
+	handleAcceptSeriesReg   = makeAcceptRegHandler("series", resolveSeriesParent)
+	handleExportSeriesRegs  = makeExportRegsHandler("series", resolveSeriesParent)
+	handleImportSeriesRegs  = makeImportRegsHandler("series", resolveSeriesParent)
+	handleSeriesPrice       = makePriceHandler("series", resolveSeriesParent)
+	handleSeriesPromoCodes  = makePromoCodesHandler("series", resolveSeriesParent)
+	handleCheckInSeries     = makeCheckInHandler("series", resolveSeriesParent)
+	handleTransferSeriesReg = makeTransferHandler("series", resolveSeriesParent)
+	handleClaimSeriesReg    = makeClaimRegHandler("series", resolveSeriesParent)
+
+	handleCreateEventReg   = makeCreateRegHandler("event", resolveEventParent)
+	handleListEventRegs    = makeListRegsHandler("event", resolveEventParent)
+	handleGetEventReg      = makeGetRegHandler("event")
+	handleUpdateEventReg   = makeUpdateRegHandler("event", resolveEventParent)
+	handleDeleteEventReg   = makeDeleteRegHandler("event", resolveEventParent)
+	handleCheckoutEventReg = makeCheckoutRegHandler("event", resolveEventParent)
+	handleAcceptEventReg   = makeAcceptRegHandler("event", resolveEventParent)
+	handleExportEventRegs  = makeExportRegsHandler("event", resolveEventParent)
+	handleImportEventRegs  = makeImportRegsHandler("event", resolveEventParent)
+	handleEventPrice       = makePriceHandler("event", resolveEventParent)
+	handleEventPromoCodes  = makePromoCodesHandler("event", resolveEventParent)
+	handleTransferEventReg = makeTransferHandler("event", resolveEventParent)
+	handleClaimEventReg    = makeClaimRegHandler("event", resolveEventParent)
+
+	handleCreateSessionReg   = makeCreateRegHandler("session", resolveSessionParent)
+	handleListSessionRegs    = makeListRegsHandler("session", resolveSessionParent)
+	handleGetSessionReg      = makeGetRegHandler("session")
+	handleUpdateSessionReg   = makeUpdateRegHandler("session", resolveSessionParent)
+	handleDeleteSessionReg   = makeDeleteRegHandler("session", resolveSessionParent)
+	handleCheckoutSessionReg = makeCheckoutRegHandler("session", resolveSessionParent)
+	handleAcceptSessionReg   = makeAcceptRegHandler("session", resolveSessionParent)
+	handleExportSessionRegs  = makeExportRegsHandler("session", resolveSessionParent)
+	handleImportSessionRegs  = makeImportRegsHandler("session", resolveSessionParent)
+	handleSessionPrice       = makePriceHandler("session", resolveSessionParent)
+	handleSessionPromoCodes  = makePromoCodesHandler("session", resolveSessionParent)
+	handleCheckInSession     = makeCheckInHandler("session", resolveSessionParent)
+	handleTransferSessionReg = makeTransferHandler("session", resolveSessionParent)
+	handleClaimSessionReg    = makeClaimRegHandler("session", resolveSessionParent)
 )
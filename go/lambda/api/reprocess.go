@@ -146,6 +146,7 @@ func handleReprocessLaps(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 
+		kartID := sessionKartID(r.Context(), sessionID, ref.ownerUID)
 		seqNo := 0
 		for _, ul := range upload.Laps {
 			if !ref.includedLaps[ul.LapNo] {
@@ -161,6 +162,7 @@ func handleReprocessLaps(w http.ResponseWriter, r *http.Request) {
 				UID:          ref.ownerUID,
 				LayoutID:     session.LayoutID,
 				KartClass:    kartClass,
+				KartID:       kartID,
 				TelemetryKey: telemKey,
 				CreatedAt:    upload.CreatedAt,
 			}); err != nil {
@@ -1,13 +1,17 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"net/http"
 
 	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+	"github.com/BrianLeishman/karttrackpark.com/go/timing"
 )
 
+var validResultStatuses = map[string]bool{timing.StatusDNF: true, timing.StatusDNS: true}
+
 func handlePostResult(w http.ResponseWriter, r *http.Request) {
 	uid, err := requireAuth(r)
 	if err != nil {
@@ -42,11 +46,19 @@ func handlePostResult(w http.ResponseWriter, r *http.Request) {
 		KartID       string `json:"kart_id"`
 		GridPosition int    `json:"grid_position"`
 		Penalties    string `json:"penalties"`
+		Status       string `json:"status"`
+		// The classification before penalties; kept from the driver's
+		// current result when not given
+		FinishPosition int `json:"finish_position"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeError(w, http.StatusBadRequest, "invalid body")
 		return
 	}
+	if req.Status != "" && !validResultStatuses[req.Status] {
+		writeError(w, http.StatusBadRequest, "status must be dnf or dns")
+		return
+	}
 	if req.UID == "" {
 		writeError(w, http.StatusBadRequest, "uid is required")
 		return
@@ -60,6 +72,23 @@ func handlePostResult(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Default to the kart the driver was assigned for this session
+	if req.KartID == "" {
+		req.KartID = sessionKartID(r.Context(), sessionID, req.UID)
+	}
+	if req.GridPosition == 0 {
+		req.GridPosition = gridPosition(session, req.UID)
+	}
+	if req.FinishPosition <= 0 {
+		prev, err := dynamo.GetResult(r.Context(), sessionID, req.UID)
+		if err != nil {
+			log.Printf("get result error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		req.FinishPosition = finishPosition(req.Position, prev)
+	}
+
 	result, err := dynamo.PutResult(r.Context(), dynamo.Result{
 		SessionID:    sessionID,
 		UID:          req.UID,
@@ -70,6 +99,9 @@ func handlePostResult(w http.ResponseWriter, r *http.Request) {
 		KartID:       req.KartID,
 		GridPosition: req.GridPosition,
 		Penalties:    req.Penalties,
+		Status:       req.Status,
+		// The classification before penalties
+		FinishPosition: req.FinishPosition,
 	})
 	if err != nil {
 		log.Printf("put result error: %v", err)
@@ -77,6 +109,11 @@ func handlePostResult(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := applySessionPenalties(r.Context(), session); err != nil {
+		log.Printf("apply penalties error: %v", err)
+	}
+	recomputeStandingsForEvent(r.Context(), session.EventID)
+
 	writeJSON(w, http.StatusCreated, result)
 }
 
@@ -128,5 +165,251 @@ func handleDeleteResult(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	recomputeStandingsForEvent(r.Context(), session.EventID)
+
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// sessionEntrants returns everyone expected to start a session (uid -> name):
+// kart and transponder assignments plus confirmed session registrations.
+func sessionEntrants(ctx context.Context, sessionID string) (map[string]string, error) {
+	entrants := map[string]string{}
+
+	regs, err := dynamo.ListRegistrations(ctx, "session", sessionID)
+	if err != nil {
+		return nil, err
+	}
+	for _, reg := range regs {
+		if reg.Status == "confirmed" && reg.UID != "" {
+			entrants[reg.UID] = reg.DriverName
+		}
+	}
+
+	assignments, err := dynamo.ListSessionKarts(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range assignments {
+		if a.DriverName != "" || entrants[a.UID] == "" {
+			entrants[a.UID] = a.DriverName
+		}
+	}
+
+	mappings, err := dynamo.ListSessionTransponders(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range mappings {
+		if m.DriverName != "" || entrants[m.UID] == "" {
+			entrants[m.UID] = m.DriverName
+		}
+	}
+
+	return entrants, nil
+}
+
+// handleGenerateResults derives provisional results from a session's laps:
+// races by laps completed and elapsed time, other sessions by best lap. Nothing
+// is saved; officials review the draft and send it to the publish endpoint.
+// Grid positions (or the session's generated grid), penalties and points already
+// entered are carried over.
+func handleGenerateResults(w http.ResponseWriter, r *http.Request) {
+	session, ok := requireSessionManager(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		MinLapPct int `json:"min_lap_pct"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid body")
+			return
+		}
+	}
+	if req.MinLapPct < 0 || req.MinLapPct > 100 {
+		writeError(w, http.StatusBadRequest, "min_lap_pct must be between 0 and 100")
+		return
+	}
+
+	laps, err := dynamo.ListLapsForSession(r.Context(), session.SessionID)
+	if err != nil {
+		log.Printf("list laps error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	entrants, err := sessionEntrants(r.Context(), session.SessionID)
+	if err != nil {
+		log.Printf("session entrants error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	existing, err := dynamo.ListResultsForSession(r.Context(), session.SessionID)
+	if err != nil {
+		log.Printf("list results error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	names := map[string]string{}
+	for uid, name := range entrants {
+		names[uid] = name
+	}
+	for _, res := range existing {
+		if names[res.UID] == "" {
+			names[res.UID] = res.DriverName
+		}
+	}
+	for _, l := range laps {
+		if _, ok := names[l.UID]; ok {
+			continue
+		}
+		names[l.UID] = ""
+		if user, err := dynamo.GetUser(r.Context(), l.UID); err == nil && user != nil {
+			names[l.UID] = user.Name
+		}
+	}
+
+	race := timing.IsRace(session.SessionType)
+	board, _ := timing.Board(laps, race, names)
+	classified := timing.Classify(board, race, entrants, req.MinLapPct)
+
+	prev := map[string]dynamo.Result{}
+	for _, res := range existing {
+		prev[res.UID] = res
+	}
+	drafts := make([]dynamo.Result, len(classified))
+	for i, c := range classified {
+		p := prev[c.UID]
+		kartID := c.KartID
+		if kartID == "" {
+			kartID = p.KartID
+		}
+		gridPos := p.GridPosition
+		if gridPos == 0 {
+			gridPos = gridPosition(session, c.UID)
+		}
+		drafts[i] = dynamo.Result{
+			SessionID:    session.SessionID,
+			UID:          c.UID,
+			DriverName:   c.DriverName,
+			Position:     c.Pos,
+			Points:       p.Points,
+			FastestLapMs: c.BestLapMs,
+			KartID:       kartID,
+			GridPosition: gridPos,
+			Penalties:    p.Penalties,
+			Status:       c.Status,
+			Laps:         c.Laps,
+			TotalMs:      c.TotalMs,
+			// Freshly classified, before any penalties
+			FinishPosition: c.Pos,
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"results":        drafts,
+		"classification": classified,
+	})
+}
+
+// finishPosition is the classification before penalties for a result saved
+// at position without one: prev's, so penalties already applied to it aren't
+// applied again, or position itself for a new result.
+func finishPosition(position int, prev *dynamo.Result) int {
+	if prev != nil && prev.FinishPosition > 0 {
+		return prev.FinishPosition
+	}
+	return position
+}
+
+// handlePublishResults replaces a session's results with a reviewed list
+// (typically an edited draft from the generate endpoint), re-applies any
+// penalties and refreshes series standings once.
+func handlePublishResults(w http.ResponseWriter, r *http.Request) {
+	session, ok := requireSessionManager(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Results []dynamo.Result `json:"results"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	seen := map[string]bool{}
+	for _, res := range req.Results {
+		switch {
+		case res.UID == "":
+			writeError(w, http.StatusBadRequest, "uid is required")
+		case res.DriverName == "":
+			writeError(w, http.StatusBadRequest, "driver_name is required")
+		case res.Position <= 0:
+			writeError(w, http.StatusBadRequest, "position must be positive")
+		case res.Status != "" && !validResultStatuses[res.Status]:
+			writeError(w, http.StatusBadRequest, "status must be dnf or dns")
+		case seen[res.UID]:
+			writeError(w, http.StatusBadRequest, "duplicate uid "+res.UID)
+		default:
+			seen[res.UID] = true
+			continue
+		}
+		return
+	}
+
+	existing, err := dynamo.ListResultsForSession(r.Context(), session.SessionID)
+	if err != nil {
+		log.Printf("list results error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	prev := map[string]*dynamo.Result{}
+	for i := range existing {
+		prev[existing[i].UID] = &existing[i]
+	}
+	for _, res := range req.Results {
+		res.SessionID = session.SessionID
+		if res.FinishPosition <= 0 {
+			res.FinishPosition = finishPosition(res.Position, prev[res.UID])
+		}
+		if res.KartID == "" {
+			res.KartID = sessionKartID(r.Context(), session.SessionID, res.UID)
+		}
+		if _, err := dynamo.PutResult(r.Context(), res); err != nil {
+			log.Printf("put result error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+	}
+	for _, res := range existing {
+		if seen[res.UID] {
+			continue
+		}
+		if err := dynamo.DeleteResult(r.Context(), session.SessionID, res.UID); err != nil {
+			log.Printf("delete result error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+	}
+
+	if err := applySessionPenalties(r.Context(), session); err != nil {
+		log.Printf("apply penalties error: %v", err)
+	}
+	recomputeStandingsForEvent(r.Context(), session.EventID)
+
+	published, err := dynamo.ListResultsForSession(r.Context(), session.SessionID)
+	if err != nil {
+		log.Printf("list results error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if published == nil {
+		published = []dynamo.Result{}
+	}
+
+	writeJSON(w, http.StatusOK, published)
+}
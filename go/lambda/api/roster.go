@@ -0,0 +1,364 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+	"github.com/BrianLeishman/karttrackpark.com/go/email"
+	"github.com/BrianLeishman/karttrackpark.com/go/pricing"
+	"github.com/BrianLeishman/karttrackpark.com/go/roster"
+	"github.com/BrianLeishman/karttrackpark.com/go/xlsx"
+)
+
+// sendRegInvite emails a driver who was registered by an admin, asking them
+// to sign in and finish registering. Best-effort: failures are logged.
+func sendRegInvite(ctx context.Context, inviterUID string, parent *regParentInfo, to string) {
+	inviterName := inviterUID
+	if inviter, _ := dynamo.GetUser(ctx, inviterUID); inviter != nil && inviter.Name != "" {
+		inviterName = inviter.Name
+	}
+	trackName := parent.TrackID
+	if track, _ := dynamo.GetTrack(ctx, parent.TrackID); track != nil {
+		trackName = track.Name
+	}
+	if err := email.SendInvite(ctx, to, email.InviteData{
+		InviterName: inviterName,
+		EntityName:  parent.ParentName,
+		TrackName:   trackName,
+		Link:        siteURL,
+	}); err != nil {
+		log.Printf("send invite email error: %v", err)
+	}
+}
+
+// maxRosterBytes caps an uploaded roster; MaxRows drivers fit easily.
+const maxRosterBytes = 1 << 20
+
+// makeImportRegsHandler registers a roster of drivers from a CSV upload, the
+// way an admin entering them one at a time would: drivers are matched to
+// accounts by email, and anyone without an account yet is registered under
+// their email and sent an invite. Bad rows are reported and skipped; the
+// rest are imported.
+func makeImportRegsHandler(parentType string, resolve func(context.Context, string) (*regParentInfo, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uid, err := requireAuth(r)
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		parentID := r.PathValue("id")
+
+		parent, err := resolve(r.Context(), parentID)
+		if err != nil {
+			log.Printf("resolve %s parent error: %v", parentType, err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		if parent == nil {
+			writeError(w, http.StatusNotFound, parentType+" not found")
+			return
+		}
+
+		if err := requireTrackRole(r, parent.TrackID, uid, "owner", "admin"); err != nil {
+			writeError(w, http.StatusForbidden, err.Error())
+			return
+		}
+
+		rows, rowErrs, err := roster.Parse(http.MaxBytesReader(w, r.Body, maxRosterBytes))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		classIDs := map[string]string{} // by ID and lower-case name
+		if len(rows) > 0 {
+			classes, err := dynamo.ListKartClasses(r.Context(), parent.TrackID)
+			if err != nil {
+				log.Printf("list kart classes error: %v", err)
+				writeError(w, http.StatusInternalServerError, "internal error")
+				return
+			}
+			for _, kc := range classes {
+				classIDs[kc.ClassID] = kc.ClassID
+				classIDs[strings.ToLower(kc.Name)] = kc.ClassID
+			}
+		}
+
+		// Lapsed offers free their spots before the roster takes them
+		if parent.MaxSpots > 0 {
+			promoteWaitlist(r.Context(), parentType, parentID, parent)
+		}
+
+		existing, err := dynamo.ListRegistrations(r.Context(), parentType, parentID)
+		if err != nil {
+			log.Printf("list registrations error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		registered := map[string]bool{} // by UID and email
+		karts := map[string]string{}    // kart number -> driver
+		for _, reg := range existing {
+			registered[reg.UID] = true
+			if reg.Email != "" {
+				registered[strings.ToLower(reg.Email)] = true
+			}
+			if reg.KartNumber != "" {
+				karts[reg.KartNumber] = reg.DriverName
+			}
+		}
+
+		status := "confirmed"
+		if parent.RegistrationMode == "invite_only" {
+			// Drivers still register themselves, and pay if there's a fee
+			status = "invited"
+		}
+
+		resp := struct {
+			Imported      int                   `json:"imported"`
+			Invited       int                   `json:"invited"` // emailed to sign in and finish registering
+			Waitlisted    int                   `json:"waitlisted"`
+			Errors        []roster.RowError     `json:"errors"`
+			Registrations []dynamo.Registration `json:"registrations"`
+		}{Errors: rowErrs, Registrations: []dynamo.Registration{}}
+		fail := func(row roster.Row, msg string) {
+			resp.Errors = append(resp.Errors, roster.RowError{Line: row.Line, Email: row.Email, Error: msg})
+		}
+
+		for _, row := range rows {
+			classID := ""
+			if row.Class != "" {
+				if classID = classIDs[row.Class]; classID == "" {
+					classID = classIDs[strings.ToLower(row.Class)]
+				}
+				if classID == "" {
+					fail(row, fmt.Sprintf("unknown class %q", row.Class))
+					continue
+				}
+			}
+			if other, taken := karts[row.KartNumber]; row.KartNumber != "" && taken {
+				fail(row, fmt.Sprintf("kart %s is already assigned to %s", row.KartNumber, other))
+				continue
+			}
+
+			user, err := dynamo.GetUserByEmail(r.Context(), row.Email)
+			if err != nil {
+				log.Printf("lookup user by email error: %v", err)
+				fail(row, "couldn't look up the driver, try again")
+				continue
+			}
+			// Drivers without an account yet are registered under their
+			// email until they sign up
+			targetUID := "email:" + row.Email
+			driverName := row.Name
+			if user != nil {
+				targetUID = strings.TrimPrefix(user.UID, "USER#")
+				if driverName == "" {
+					driverName = user.Name
+				}
+			}
+			if driverName == "" {
+				driverName = row.Email
+			}
+			if registered[targetUID] || registered[row.Email] {
+				fail(row, "already registered")
+				continue
+			}
+
+			// Nobody is confirmed before signing the track's current waiver
+			regStatus, err := holdForWaiver(r.Context(), parent.TrackID, targetUID, status)
+			if err != nil {
+				log.Printf("check waiver error: %v", err)
+				fail(row, "couldn't check the driver's waiver, try again")
+				continue
+			}
+
+			newReg := dynamo.Registration{
+				ParentType: parentType,
+				ParentID:   parentID,
+				TrackID:    parent.TrackID,
+				UID:        targetUID,
+				Email:      row.Email,
+				DriverName: driverName,
+				ClassID:    classID,
+				KartNumber: row.KartNumber,
+				Status:     regStatus,
+				InvitedBy:  uid,
+			}
+			// Invited drivers are quoted when they register themselves
+			if regStatus != "invited" {
+				quote, err := quoteRegistration(r.Context(), parentType, parentID, parent, targetUID, "")
+				if err != nil {
+					log.Printf("quote registration error: %v", err)
+					fail(row, "couldn't price the registration, try again")
+					continue
+				}
+				applyQuote(&newReg, quote)
+			}
+
+			reg, err := admitRegistration(r.Context(), parent, newReg)
+			if err != nil {
+				log.Printf("import registration error: %v", err)
+				fail(row, "couldn't save the registration, try again")
+				continue
+			}
+			seriesRegChanged(r.Context(), reg, "", reg.Status)
+			registered[targetUID], registered[row.Email] = true, true
+			if row.KartNumber != "" {
+				karts[row.KartNumber] = driverName
+			}
+
+			resp.Imported++
+			if reg.Status == "waitlisted" {
+				resp.Waitlisted++
+			}
+			if user == nil || reg.Status == "invited" {
+				sendRegInvite(r.Context(), uid, parent, row.Email)
+				resp.Invited++
+			}
+			resp.Registrations = append(resp.Registrations, *reg)
+		}
+		sort.SliceStable(resp.Errors, func(i, j int) bool { return resp.Errors[i].Line < resp.Errors[j].Line })
+		if resp.Errors == nil {
+			resp.Errors = []roster.RowError{}
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// paymentStatus describes where a registration's entry fee stands.
+func paymentStatus(parent *regParentInfo, reg dynamo.Registration) string {
+	switch {
+	case pricing.Due(parent.PriceCents, reg) == 0:
+		return "free"
+	case reg.Paid:
+		return "paid"
+	case reg.CheckoutID != "":
+		return "checkout started"
+	default:
+		return "unpaid"
+	}
+}
+
+// formatPrice formats an amount in cents as e.g. "45.00 USD".
+func formatPrice(cents int, currency string) string {
+	if currency == "" {
+		currency = "USD"
+	}
+	return fmt.Sprintf("%d.%02d %s", cents/100, cents%100, strings.ToUpper(currency))
+}
+
+// makeExportRegsHandler downloads the roster for admins as CSV, or as an
+// Excel workbook with ?format=xlsx: the import's columns first, so an edited
+// export can be imported elsewhere, then status, payment and one column per
+// registration question.
+func makeExportRegsHandler(parentType string, resolve func(context.Context, string) (*regParentInfo, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uid, err := requireAuth(r)
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		parentID := r.PathValue("id")
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = "csv"
+		}
+		if format != "csv" && format != "xlsx" {
+			writeError(w, http.StatusBadRequest, "format must be csv or xlsx")
+			return
+		}
+
+		parent, err := resolve(r.Context(), parentID)
+		if err != nil {
+			log.Printf("resolve %s parent error: %v", parentType, err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		if parent == nil {
+			writeError(w, http.StatusNotFound, parentType+" not found")
+			return
+		}
+
+		if err := requireTrackRole(r, parent.TrackID, uid, "owner", "admin"); err != nil {
+			writeError(w, http.StatusForbidden, err.Error())
+			return
+		}
+
+		regs, err := dynamo.ListRegistrations(r.Context(), parentType, parentID)
+		if err != nil {
+			log.Printf("list registrations error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		classNames := map[string]string{}
+		if classes, err := dynamo.ListKartClasses(r.Context(), parent.TrackID); err == nil {
+			for _, kc := range classes {
+				classNames[kc.ClassID] = kc.Name
+			}
+		}
+		sort.SliceStable(regs, func(i, j int) bool { return regs[i].RegisteredAt < regs[j].RegisteredAt })
+
+		header := []string{
+			"Driver", "Email", "Class", "Kart Number", "Status", "Team", "Weight (kg)",
+			"Payment", "Price", "Price Rule", "Promo Code", "Payment ID", "Registered At",
+		}
+		for _, q := range parent.Questions {
+			header = append(header, q.Label)
+		}
+		table := [][]string{header}
+		for _, reg := range regs {
+			emailAddr := reg.Email
+			if user, err := dynamo.GetUser(r.Context(), reg.UID); err == nil && user != nil && user.Email != "" {
+				emailAddr = user.Email
+			}
+			weight := ""
+			if reg.WeightKg > 0 {
+				weight = strconv.FormatFloat(reg.WeightKg, 'f', -1, 64)
+			}
+			class := classNames[reg.ClassID]
+			if class == "" {
+				class = reg.ClassID
+			}
+			price := ""
+			if cents := pricing.Due(parent.PriceCents, reg); cents > 0 {
+				price = formatPrice(cents, parent.Currency)
+			}
+			row := []string{
+				reg.DriverName, emailAddr, class, reg.KartNumber, reg.Status, reg.TeamName, weight,
+				paymentStatus(parent, reg), price, reg.PriceRule, reg.PromoCode, reg.PaymentID, reg.RegisteredAt,
+			}
+			for _, q := range parent.Questions {
+				row = append(row, reg.Answers[q.ID])
+			}
+			table = append(table, row)
+		}
+
+		filename := parentType + "-" + parentID + "-registrations." + format
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+		if format == "xlsx" {
+			w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+			if err := xlsx.Write(w, parent.ParentName, table); err != nil {
+				log.Printf("write registrations xlsx error: %v", err)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		cw := csv.NewWriter(w)
+		cw.WriteAll(table)
+		if err := cw.Error(); err != nil {
+			log.Printf("write registrations csv error: %v", err)
+		}
+	}
+}
@@ -38,6 +38,8 @@ func handleCreateSeries(w http.ResponseWriter, r *http.Request) {
 		Description string `json:"description"`
 		Status      string `json:"status"`
 		Rules       string `json:"rules"`
+		Tier        int    `json:"tier"`
+		ClassID     string `json:"class_id"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeError(w, http.StatusBadRequest, "invalid body")
@@ -55,6 +57,8 @@ func handleCreateSeries(w http.ResponseWriter, r *http.Request) {
 		Description:    req.Description,
 		Status:         req.Status,
 		Rules:          req.Rules,
+		Tier:           req.Tier,
+		ClassID:        req.ClassID,
 	})
 	if err != nil {
 		log.Printf("create series error: %v", err)
@@ -130,9 +134,10 @@ func handleUpdateSeries(w http.ResponseWriter, r *http.Request) {
 	}
 
 	allowed := map[string]bool{
-		"name": true, "description": true, "status": true, "rules": true, "championship_id": true,
-		"registrationMode": true, "maxSpots": true, "priceCents": true, "currency": true, "registrationDeadline": true,
-		"method": true, "pointsScheme": true, "dropRounds": true, "tiebreaker": true,
+		"name": true, "description": true, "status": true, "rules": true, "tier": true, "classId": true, "championship_id": true,
+		"registrationMode": true, "maxSpots": true, "priceCents": true, "currency": true, "registrationDeadline": true, "waitlistOfferHours": true, "minAge": true, "questions": true,
+		"earlyBird": true, "memberPriceCents": true, "seasonPass": true, "autoRegisterEvents": true,
+		"method": true, "pointsScheme": true, "dropRounds": true, "tiebreaker": true, "teamCountBest": true, "scoringSession": true,
 	}
 	fields := map[string]any{}
 	for k, v := range req {
@@ -154,12 +159,41 @@ func handleUpdateSeries(w http.ResponseWriter, r *http.Request) {
 		delete(fields, "championship_id")
 	}
 
+	if !setQuestionsField(w, fields) || !setEarlyBirdField(w, fields) {
+		return
+	}
+
 	if err := dynamo.UpdateSeries(r.Context(), seriesID, fields); err != nil {
 		log.Printf("update series error: %v", err)
 		writeError(w, http.StatusInternalServerError, "internal error")
 		return
 	}
 
+	// Raising the spot limit takes drivers off the waitlist
+	if _, ok := fields["maxSpots"]; ok {
+		promoteWaitlistFor(r.Context(), "series", seriesID)
+	}
+
+	// Turning on round registration catches up the drivers already confirmed
+	if on, _ := fields["autoRegisterEvents"].(bool); on && !series.AutoRegisterEvents {
+		series.AutoRegisterEvents = true
+		if rounds, err := dynamo.ListSeriesEvents(r.Context(), seriesID); err != nil {
+			log.Printf("list series events error: %v", err)
+		} else {
+			registerSeriesForRounds(r.Context(), series, rounds)
+		}
+	}
+
+	// Scoring changes rescore every round
+	for _, k := range []string{"method", "pointsScheme", "dropRounds", "tiebreaker", "scoringSession"} {
+		if _, ok := fields[k]; ok {
+			if _, err := recomputeSeriesStandings(r.Context(), seriesID); err != nil {
+				log.Printf("recompute standings error: %v", err)
+			}
+			break
+		}
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -38,6 +38,8 @@ func handleEnrollDriver(w http.ResponseWriter, r *http.Request) {
 		DriverName          string `json:"driver_name"`
 		Seeded              bool   `json:"seeded"`
 		RelegationProtected bool   `json:"relegation_protected"`
+		TeamID              string `json:"team_id"`
+		Chassis             string `json:"chassis"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeError(w, http.StatusBadRequest, "invalid body")
@@ -52,12 +54,20 @@ func handleEnrollDriver(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	team, ok := teamForDriver(w, r, req.TeamID, req.UID)
+	if !ok {
+		return
+	}
+
 	sd, err := dynamo.EnrollDriver(r.Context(), dynamo.SeriesDriver{
 		SeriesID:            seriesID,
 		UID:                 req.UID,
 		DriverName:          req.DriverName,
 		Seeded:              req.Seeded,
 		RelegationProtected: req.RelegationProtected,
+		TeamID:              team.TeamID,
+		TeamName:            team.Name,
+		Chassis:             req.Chassis,
 	})
 	if err != nil {
 		log.Printf("enroll driver error: %v", err)
@@ -117,7 +127,7 @@ func handleUpdateSeriesDriver(w http.ResponseWriter, r *http.Request) {
 	}
 
 	allowed := map[string]bool{
-		"driverName": true, "seeded": true, "relegationProtected": true,
+		"driverName": true, "seeded": true, "relegationProtected": true, "chassis": true,
 		"totalPoints": true, "weeklyScores": true, "droppedRound": true,
 	}
 	fields := map[string]any{}
@@ -127,6 +137,17 @@ func handleUpdateSeriesDriver(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Changing team: check membership and keep the stored name in step
+	if v, ok := req["teamId"]; ok {
+		teamID, _ := v.(string)
+		team, ok := teamForDriver(w, r, teamID, driverUID)
+		if !ok {
+			return
+		}
+		fields["teamId"] = team.TeamID
+		fields["teamName"] = team.Name
+	}
+
 	if err := dynamo.UpdateSeriesDriver(r.Context(), seriesID, driverUID, fields); err != nil {
 		log.Printf("update series driver error: %v", err)
 		writeError(w, http.StatusInternalServerError, "internal error")
@@ -74,6 +74,11 @@ func handleAddEventToSeries(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusInternalServerError, "internal error")
 		return
 	}
+	registerSeriesForRounds(r.Context(), series, []dynamo.SeriesEvent{*se})
+
+	if _, err := recomputeSeriesStandings(r.Context(), seriesID); err != nil {
+		log.Printf("recompute standings error: %v", err)
+	}
 
 	writeJSON(w, http.StatusCreated, se)
 }
@@ -126,5 +131,9 @@ func handleRemoveEventFromSeries(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if _, err := recomputeSeriesStandings(r.Context(), seriesID); err != nil {
+		log.Printf("recompute standings error: %v", err)
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
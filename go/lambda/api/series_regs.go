@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/cascade"
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+)
+
+// seriesRegChanged carries a series registration moving from one status to
+// another over to the rest of the series. Becoming confirmed enrolls the
+// driver and, when the series registers drivers for its rounds, registers
+// them for each upcoming round; leaving confirmed undoes both. to is empty
+// when the registration was withdrawn. Registrations for anything but a
+// series are left alone. Errors are logged, since the registration change
+// is already saved.
+func seriesRegChanged(ctx context.Context, reg *dynamo.Registration, from, to string) {
+	if reg.ParentType != "series" || (from == "confirmed") == (to == "confirmed") {
+		return
+	}
+	if to == "confirmed" {
+		joinSeries(ctx, reg)
+	} else {
+		leaveSeries(ctx, reg)
+	}
+}
+
+// joinSeries enrolls the driver of a confirmed series registration and
+// registers them for the upcoming rounds if the series asks for it.
+func joinSeries(ctx context.Context, reg *dynamo.Registration) {
+	seriesID := reg.ParentID
+
+	sd, err := dynamo.GetSeriesDriver(ctx, seriesID, reg.UID)
+	if err != nil {
+		log.Printf("get series driver error: %v", err)
+	} else if sd == nil {
+		if _, err := dynamo.EnrollDriver(ctx, cascade.Enrollment(*reg)); err != nil {
+			log.Printf("enroll driver error: %v", err)
+		}
+	}
+
+	series, err := dynamo.GetSeries(ctx, seriesID)
+	if err != nil {
+		log.Printf("get series error: %v", err)
+		return
+	}
+	if series == nil || !series.AutoRegisterEvents {
+		return
+	}
+	rounds, err := dynamo.ListSeriesEvents(ctx, seriesID)
+	if err != nil {
+		log.Printf("list series events error: %v", err)
+		return
+	}
+	for _, se := range cascade.Upcoming(rounds, time.Now().UTC()) {
+		registerForRound(ctx, reg, se.EventID)
+	}
+}
+
+// registerForRound registers the driver of a confirmed series registration
+// for one round, unless they already registered for it. A full round
+// waitlists them like anyone else.
+func registerForRound(ctx context.Context, reg *dynamo.Registration, eventID string) {
+	existing, err := dynamo.GetRegistration(ctx, "event", eventID, reg.UID)
+	if err != nil {
+		log.Printf("get registration error: %v", err)
+		return
+	}
+	if existing != nil {
+		return
+	}
+	parent, err := resolveEventParent(ctx, eventID)
+	if err != nil {
+		log.Printf("resolve event parent error: %v", err)
+		return
+	}
+	if parent == nil {
+		return
+	}
+
+	round := cascade.RoundRegistration(*reg, eventID)
+	round.TrackID = parent.TrackID
+	round.Status, err = holdForWaiver(ctx, parent.TrackID, reg.UID, round.Status)
+	if err != nil {
+		log.Printf("check waiver error: %v", err)
+		return
+	}
+	if _, err := admitRegistration(ctx, parent, round); err != nil {
+		log.Printf("register for series round error: %v", err)
+	}
+}
+
+// leaveSeries unenrolls the driver of a series registration that is no
+// longer confirmed, unless an admin enrolled them or they already have
+// results, and withdraws the upcoming round registrations it made.
+func leaveSeries(ctx context.Context, reg *dynamo.Registration) {
+	seriesID := reg.ParentID
+
+	sd, err := dynamo.GetSeriesDriver(ctx, seriesID, reg.UID)
+	if err != nil {
+		log.Printf("get series driver error: %v", err)
+	} else if sd != nil && !cascade.KeepEnrollment(*sd) {
+		if err := dynamo.DeleteSeriesDriver(ctx, seriesID, reg.UID); err != nil {
+			log.Printf("delete series driver error: %v", err)
+		}
+	}
+
+	rounds, err := dynamo.ListSeriesEvents(ctx, seriesID)
+	if err != nil {
+		log.Printf("list series events error: %v", err)
+		return
+	}
+	for _, se := range cascade.Upcoming(rounds, time.Now().UTC()) {
+		round, err := dynamo.GetRegistration(ctx, "event", se.EventID, reg.UID)
+		if err != nil {
+			log.Printf("get registration error: %v", err)
+			continue
+		}
+		if !cascade.Cascaded(round, seriesID) {
+			continue
+		}
+		if err := dynamo.WithdrawRegistration(ctx, round); err != nil {
+			log.Printf("withdraw series round registration error: %v", err)
+			continue
+		}
+		if dynamo.HoldsSpot(round.Status) {
+			promoteWaitlistFor(ctx, "event", se.EventID)
+		}
+	}
+}
+
+// registerSeriesForRounds registers every confirmed series driver for the
+// upcoming ones of rounds, when the series registers drivers for its rounds:
+// for a round just added, or every round when the option is turned on.
+func registerSeriesForRounds(ctx context.Context, series *dynamo.Series, rounds []dynamo.SeriesEvent) {
+	rounds = cascade.Upcoming(rounds, time.Now().UTC())
+	if !series.AutoRegisterEvents || len(rounds) == 0 {
+		return
+	}
+	regs, err := dynamo.ListRegistrations(ctx, "series", series.SeriesID)
+	if err != nil {
+		log.Printf("list registrations error: %v", err)
+		return
+	}
+	for _, reg := range regs {
+		if reg.Status != "confirmed" {
+			continue
+		}
+		for _, se := range rounds {
+			registerForRound(ctx, &reg, se.EventID)
+		}
+	}
+}
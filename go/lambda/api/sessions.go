@@ -174,7 +174,7 @@ func handleUpdateSession(w http.ResponseWriter, r *http.Request) {
 		"sessionName": true, "sessionType": true, "sessionOrder": true,
 		"layoutId": true, "reverse": true, "notes": true,
 		"startType": true, "lapLimit": true,
-		"classIds": true,
+		"classIds": true, "flagState": true,
 	}
 	fields := map[string]any{}
 	for k, v := range req {
@@ -183,6 +183,13 @@ func handleUpdateSession(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if flag, ok := fields["flagState"]; ok {
+		if s, isStr := flag.(string); !isStr || !validFlagStates[s] {
+			writeError(w, http.StatusBadRequest, "flagState must be green, yellow, red, or checkered")
+			return
+		}
+	}
+
 	if err := dynamo.UpdateSession(r.Context(), sessionID, fields); err != nil {
 		log.Printf("update session error: %v", err)
 		writeError(w, http.StatusInternalServerError, "internal error")
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+	"github.com/BrianLeishman/karttrackpark.com/go/standings"
+)
+
+// computeSeriesStandings scores every round of a series from the results of
+// its events' scoring sessions.
+func computeSeriesStandings(ctx context.Context, series *dynamo.Series) ([]standings.Standing, error) {
+	links, err := dynamo.ListSeriesEvents(ctx, series.SeriesID)
+	if err != nil {
+		return nil, err
+	}
+
+	needLaps := standings.IsTimed(series.Method)
+	rounds := make([]standings.Round, 0, len(links))
+	for _, link := range links {
+		round := standings.Round{Number: link.RoundNumber}
+		sessions, err := dynamo.ListEventSessions(ctx, link.EventID)
+		if err != nil {
+			return nil, err
+		}
+		for _, es := range standings.ScoringSessions(series.ScoringConfig, sessions) {
+			results, err := dynamo.ListResultsForSession(ctx, es.SessionID)
+			if err != nil {
+				return nil, err
+			}
+			if len(results) == 0 {
+				continue
+			}
+			round.Results = append(round.Results, results...)
+			if needLaps {
+				laps, err := dynamo.ListLapsForSession(ctx, es.SessionID)
+				if err != nil {
+					return nil, err
+				}
+				round.Laps = append(round.Laps, laps...)
+			}
+		}
+		rounds = append(rounds, round)
+	}
+
+	return standings.Compute(series.ScoringConfig, rounds), nil
+}
+
+// recomputeSeriesStandings recomputes a series table and writes it to the
+// series drivers and series registrations. Drivers with results who are not
+// enrolled yet are enrolled.
+func recomputeSeriesStandings(ctx context.Context, seriesID string) ([]standings.Standing, error) {
+	series, err := dynamo.GetSeries(ctx, seriesID)
+	if err != nil {
+		return nil, err
+	}
+	if series == nil {
+		return nil, fmt.Errorf("series %s not found", seriesID)
+	}
+
+	table, err := computeSeriesStandings(ctx, series)
+	if err != nil {
+		return nil, err
+	}
+
+	drivers, err := dynamo.ListSeriesDrivers(ctx, seriesID)
+	if err != nil {
+		return nil, err
+	}
+	enrolled := map[string]bool{}
+	for _, d := range drivers {
+		enrolled[d.UID] = true
+	}
+
+	timed := standings.IsTimed(series.Method)
+	ranked := map[string]standings.Standing{}
+	for _, s := range table {
+		ranked[s.UID] = s
+		if !enrolled[s.UID] {
+			if _, err := dynamo.EnrollDriver(ctx, dynamo.SeriesDriver{
+				SeriesID:   seriesID,
+				UID:        s.UID,
+				DriverName: s.DriverName,
+			}); err != nil {
+				return nil, err
+			}
+			enrolled[s.UID] = true
+		}
+		if err := dynamo.UpdateSeriesDriver(ctx, seriesID, s.UID, seriesDriverStandingFields(s, timed)); err != nil {
+			return nil, err
+		}
+	}
+
+	// Clear anyone who no longer has a result (e.g. their only result was deleted)
+	for _, d := range drivers {
+		if _, ok := ranked[d.UID]; ok || (d.Position == 0 && d.TotalPoints == 0 && d.TotalTimeMs == 0) {
+			continue
+		}
+		if err := dynamo.UpdateSeriesDriver(ctx, seriesID, d.UID, seriesDriverStandingFields(standings.Standing{}, timed)); err != nil {
+			return nil, err
+		}
+	}
+
+	regs, err := dynamo.ListRegistrations(ctx, "series", seriesID)
+	if err != nil {
+		return nil, err
+	}
+	for _, reg := range regs {
+		s, ok := ranked[reg.UID]
+		if !ok && reg.Standings == nil {
+			continue
+		}
+		var value any
+		if ok {
+			value = registrationStandings(s, timed)
+		}
+		if err := dynamo.UpdateRegistration(ctx, "series", seriesID, reg.UID, map[string]any{"standings": value}); err != nil {
+			return nil, err
+		}
+	}
+
+	return table, nil
+}
+
+func seriesDriverStandingFields(s standings.Standing, timed bool) map[string]any {
+	scores := make([]string, len(s.Rounds))
+	var dropped []int
+	for i, rs := range s.Rounds {
+		scores[i] = "-"
+		if rs.Played {
+			scores[i] = strconv.FormatInt(rs.Score, 10)
+		}
+		if rs.Dropped {
+			dropped = append(dropped, rs.Round)
+		}
+	}
+
+	fields := map[string]any{
+		"position":      s.Position,
+		"wins":          s.Wins,
+		"weeklyScores":  strings.Join(scores, ","),
+		"droppedRounds": dropped,
+		"droppedRound":  0,
+		"totalPoints":   0,
+		"totalTimeMs":   0,
+	}
+	if len(dropped) > 0 {
+		fields["droppedRound"] = dropped[0]
+	}
+	if timed {
+		fields["totalTimeMs"] = s.Total
+	} else {
+		fields["totalPoints"] = s.Total
+	}
+	return fields
+}
+
+func registrationStandings(s standings.Standing, timed bool) map[string]any {
+	m := map[string]any{
+		"position":    s.Position,
+		"wins":        s.Wins,
+		"best_finish": s.BestFinish,
+		"counted":     s.Counted,
+		"rounds":      s.Rounds,
+	}
+	if timed {
+		m["total_time_ms"] = s.Total
+	} else {
+		m["total_points"] = s.Total
+	}
+	return m
+}
+
+// recomputeStandingsForEvent refreshes every series the event is a round of.
+// Failures are logged rather than returned so the triggering write still
+// succeeds; the table can be rebuilt with the recompute endpoint.
+func recomputeStandingsForEvent(ctx context.Context, eventID string) {
+	if eventID == "" {
+		return
+	}
+	links, err := dynamo.ListSeriesForEvent(ctx, eventID)
+	if err != nil {
+		log.Printf("list series for event error: %v", err)
+		return
+	}
+	for _, link := range links {
+		if _, err := recomputeSeriesStandings(ctx, link.SeriesID); err != nil {
+			log.Printf("recompute series %s standings error: %v", link.SeriesID, err)
+		}
+	}
+}
+
+func handleGetSeriesStandings(w http.ResponseWriter, r *http.Request) {
+	series, err := dynamo.GetSeries(r.Context(), r.PathValue("id"))
+	if err != nil {
+		log.Printf("get series error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if series == nil {
+		writeError(w, http.StatusNotFound, "series not found")
+		return
+	}
+
+	table, err := computeSeriesStandings(r.Context(), series)
+	if err != nil {
+		log.Printf("compute standings error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if table == nil {
+		table = []standings.Standing{}
+	}
+
+	writeJSON(w, http.StatusOK, table)
+}
+
+func handleRecomputeSeriesStandings(w http.ResponseWriter, r *http.Request) {
+	uid, err := requireAuth(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	seriesID := r.PathValue("id")
+
+	series, err := dynamo.GetSeries(r.Context(), seriesID)
+	if err != nil {
+		log.Printf("get series error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if series == nil {
+		writeError(w, http.StatusNotFound, "series not found")
+		return
+	}
+
+	if err := requireTrackRole(r, series.TrackID, uid, "owner", "admin"); err != nil {
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+
+	table, err := recomputeSeriesStandings(r.Context(), seriesID)
+	if err != nil {
+		log.Printf("recompute standings error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if table == nil {
+		table = []standings.Standing{}
+	}
+
+	writeJSON(w, http.StatusOK, table)
+}
+
+// handleGetChampionshipStandings aggregates the championship's series
+// standings into class, team and manufacturer tables.
+func handleGetChampionshipStandings(w http.ResponseWriter, r *http.Request) {
+	champ, err := dynamo.GetChampionship(r.Context(), r.PathValue("id"))
+	if err != nil {
+		log.Printf("get championship error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if champ == nil {
+		writeError(w, http.StatusNotFound, "championship not found")
+		return
+	}
+
+	list, err := dynamo.ListSeriesForChampionship(r.Context(), champ.ChampionshipID)
+	if err != nil {
+		log.Printf("list series error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	tables := make([]standings.SeriesTable, 0, len(list))
+	for i := range list {
+		series := &list[i]
+		table, err := computeSeriesStandings(r.Context(), series)
+		if err != nil {
+			log.Printf("compute standings error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		drivers, err := dynamo.ListSeriesDrivers(r.Context(), series.SeriesID)
+		if err != nil {
+			log.Printf("list series drivers error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		st := standings.SeriesTable{
+			SeriesID:  series.SeriesID,
+			ClassID:   series.ClassID,
+			Timed:     standings.IsTimed(series.Method),
+			Standings: table,
+			Teams:     map[string]standings.TeamRef{},
+			Chassis:   map[string]string{},
+		}
+		for _, d := range drivers {
+			st.Teams[d.UID] = standings.TeamRef{ID: d.TeamID, Name: d.TeamName}
+			st.Chassis[d.UID] = d.Chassis
+		}
+		tables = append(tables, st)
+	}
+
+	out := standings.Aggregate(champ.ChampionshipScoring, tables)
+
+	// Class names for display
+	classNames := map[string]string{}
+	for _, c := range out.Classes {
+		if c.ClassID == "" {
+			continue
+		}
+		if kc, err := dynamo.GetKartClass(r.Context(), champ.TrackID, c.ClassID); err == nil && kc != nil {
+			classNames[c.ClassID] = kc.Name
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"classes":       out.Classes,
+		"class_names":   classNames,
+		"teams":         out.Teams,
+		"manufacturers": out.Manufacturers,
+	})
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+	"github.com/BrianLeishman/karttrackpark.com/go/standings"
+)
+
+// teamForDriver resolves an optional team for a registration or series
+// enrollment, checking the driver is on it. An empty teamID yields an empty
+// team. On failure the error response has been written.
+func teamForDriver(w http.ResponseWriter, r *http.Request, teamID, uid string) (*dynamo.Team, bool) {
+	if teamID == "" {
+		return &dynamo.Team{}, true
+	}
+
+	team, err := dynamo.GetTeam(r.Context(), teamID)
+	if err != nil {
+		log.Printf("get team error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return nil, false
+	}
+	if team == nil {
+		writeError(w, http.StatusBadRequest, "team not found")
+		return nil, false
+	}
+
+	member, err := dynamo.GetTeamMember(r.Context(), teamID, uid)
+	if err != nil {
+		log.Printf("get team member error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return nil, false
+	}
+	if member == nil {
+		writeError(w, http.StatusBadRequest, "driver is not on that team")
+		return nil, false
+	}
+
+	return team, true
+}
+
+// requireTeamOwner loads the team in the path and checks the caller owns it.
+func requireTeamOwner(w http.ResponseWriter, r *http.Request) (*dynamo.Team, bool) {
+	uid, err := requireAuth(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return nil, false
+	}
+
+	team, err := dynamo.GetTeam(r.Context(), r.PathValue("id"))
+	if err != nil {
+		log.Printf("get team error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return nil, false
+	}
+	if team == nil {
+		writeError(w, http.StatusNotFound, "team not found")
+		return nil, false
+	}
+
+	if team.OwnerUID != uid {
+		writeError(w, http.StatusForbidden, "only the team owner can do that")
+		return nil, false
+	}
+
+	return team, true
+}
+
+func handleCreateTeam(w http.ResponseWriter, r *http.Request) {
+	uid, err := requireAuth(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req struct {
+		Name           string `json:"name"`
+		Description    string `json:"description"`
+		LogoKey        string `json:"logo_key"`
+		PrimaryColor   string `json:"primary_color"`
+		SecondaryColor string `json:"secondary_color"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	ownerName := ""
+	if user, err := dynamo.GetUser(r.Context(), uid); err == nil && user != nil {
+		ownerName = user.Name
+	}
+
+	team, err := dynamo.CreateTeam(r.Context(), ownerName, dynamo.Team{
+		Name:           req.Name,
+		Description:    req.Description,
+		OwnerUID:       uid,
+		LogoKey:        req.LogoKey,
+		PrimaryColor:   req.PrimaryColor,
+		SecondaryColor: req.SecondaryColor,
+	})
+	if err != nil {
+		log.Printf("create team error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, team)
+}
+
+func handleGetTeam(w http.ResponseWriter, r *http.Request) {
+	team, err := dynamo.GetTeam(r.Context(), r.PathValue("id"))
+	if err != nil {
+		log.Printf("get team error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if team == nil {
+		writeError(w, http.StatusNotFound, "team not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, team)
+}
+
+func handleUpdateTeam(w http.ResponseWriter, r *http.Request) {
+	team, ok := requireTeamOwner(w, r)
+	if !ok {
+		return
+	}
+
+	var req map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+
+	allowed := map[string]bool{
+		"name": true, "description": true, "logoKey": true, "primaryColor": true, "secondaryColor": true,
+	}
+	fields := map[string]any{}
+	for k, v := range req {
+		if allowed[k] {
+			fields[k] = v
+		}
+	}
+
+	if err := dynamo.UpdateTeam(r.Context(), team.TeamID, fields); err != nil {
+		log.Printf("update team error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	// Keep the name shown in "my teams" current
+	if name, ok := fields["name"].(string); ok && name != team.Name {
+		members, err := dynamo.ListTeamMembers(r.Context(), team.TeamID)
+		if err != nil {
+			log.Printf("list team members error: %v", err)
+		}
+		for _, m := range members {
+			m.TeamName = name
+			if _, err := dynamo.AddTeamMember(r.Context(), m); err != nil {
+				log.Printf("update team member error: %v", err)
+			}
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func handleDeleteTeam(w http.ResponseWriter, r *http.Request) {
+	team, ok := requireTeamOwner(w, r)
+	if !ok {
+		return
+	}
+
+	if err := dynamo.DeleteTeam(r.Context(), team.TeamID); err != nil {
+		log.Printf("delete team error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func handleListTeamMembers(w http.ResponseWriter, r *http.Request) {
+	members, err := dynamo.ListTeamMembers(r.Context(), r.PathValue("id"))
+	if err != nil {
+		log.Printf("list team members error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if members == nil {
+		members = []dynamo.TeamMember{}
+	}
+
+	writeJSON(w, http.StatusOK, members)
+}
+
+// handleAddTeamMember lets the owner add a driver by uid or account email.
+func handleAddTeamMember(w http.ResponseWriter, r *http.Request) {
+	team, ok := requireTeamOwner(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		UID   string `json:"uid"`
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+
+	var user *dynamo.UserProfile
+	var err error
+	switch {
+	case req.UID != "":
+		user, err = dynamo.GetUser(r.Context(), req.UID)
+	case req.Email != "":
+		user, err = dynamo.GetUserByEmail(r.Context(), strings.ToLower(strings.TrimSpace(req.Email)))
+	default:
+		writeError(w, http.StatusBadRequest, "uid or email is required")
+		return
+	}
+	if err != nil {
+		log.Printf("get user error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if user == nil {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	memberUID := strings.TrimPrefix(user.UID, "USER#")
+	if memberUID == team.OwnerUID {
+		writeError(w, http.StatusBadRequest, "the owner is already on the team")
+		return
+	}
+
+	m, err := dynamo.AddTeamMember(r.Context(), dynamo.TeamMember{
+		TeamID:     team.TeamID,
+		TeamName:   team.Name,
+		UID:        memberUID,
+		DriverName: user.Name,
+	})
+	if err != nil {
+		log.Printf("add team member error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, m)
+}
+
+// handleRemoveTeamMember removes a driver; the owner can remove anyone else
+// and members can leave on their own.
+func handleRemoveTeamMember(w http.ResponseWriter, r *http.Request) {
+	uid, err := requireAuth(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	team, err := dynamo.GetTeam(r.Context(), r.PathValue("id"))
+	if err != nil {
+		log.Printf("get team error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if team == nil {
+		writeError(w, http.StatusNotFound, "team not found")
+		return
+	}
+
+	memberUID := r.PathValue("uid")
+	if memberUID == team.OwnerUID {
+		writeError(w, http.StatusBadRequest, "the owner can't leave the team; delete it instead")
+		return
+	}
+	if uid != memberUID && uid != team.OwnerUID {
+		writeError(w, http.StatusForbidden, "only the team owner can do that")
+		return
+	}
+
+	if err := dynamo.RemoveTeamMember(r.Context(), team.TeamID, memberUID); err != nil {
+		log.Printf("remove team member error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func handleListMyTeams(w http.ResponseWriter, r *http.Request) {
+	uid, err := requireAuth(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	teams, err := dynamo.ListTeamsForUser(r.Context(), uid)
+	if err != nil {
+		log.Printf("list teams for user error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if teams == nil {
+		teams = []dynamo.TeamMember{}
+	}
+
+	writeJSON(w, http.StatusOK, teams)
+}
+
+// handleGetSeriesTeamStandings scores each team by its best drivers in every
+// round of the series. A driver's team comes from their series enrollment.
+func handleGetSeriesTeamStandings(w http.ResponseWriter, r *http.Request) {
+	series, err := dynamo.GetSeries(r.Context(), r.PathValue("id"))
+	if err != nil {
+		log.Printf("get series error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if series == nil {
+		writeError(w, http.StatusNotFound, "series not found")
+		return
+	}
+
+	table, err := computeSeriesStandings(r.Context(), series)
+	if err != nil {
+		log.Printf("compute standings error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	drivers, err := dynamo.ListSeriesDrivers(r.Context(), series.SeriesID)
+	if err != nil {
+		log.Printf("list series drivers error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	teams := map[string]standings.TeamRef{}
+	for _, d := range drivers {
+		teams[d.UID] = standings.TeamRef{ID: d.TeamID, Name: d.TeamName}
+	}
+
+	out := standings.ComputeTeams(series.ScoringConfig, table, teams)
+	if out == nil {
+		out = []standings.TeamStanding{}
+	}
+
+	writeJSON(w, http.StatusOK, out)
+}
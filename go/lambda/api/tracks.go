@@ -520,6 +520,7 @@ func handleCreateKartClass(w http.ResponseWriter, r *http.Request) {
 		Engine      string `json:"engine"`
 		Description string `json:"description"`
 		IsDefault   bool   `json:"is_default"`
+		dynamo.BallastRules
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeError(w, http.StatusBadRequest, "invalid body")
@@ -551,12 +552,13 @@ func handleCreateKartClass(w http.ResponseWriter, r *http.Request) {
 	}
 
 	kc, err := dynamo.CreateKartClass(r.Context(), dynamo.KartClass{
-		TrackID:     trackID,
-		Name:        req.Name,
-		Chassis:     req.Chassis,
-		Engine:      req.Engine,
-		Description: req.Description,
-		IsDefault:   req.IsDefault,
+		TrackID:      trackID,
+		Name:         req.Name,
+		Chassis:      req.Chassis,
+		Engine:       req.Engine,
+		Description:  req.Description,
+		IsDefault:    req.IsDefault,
+		BallastRules: req.BallastRules,
 	})
 	if err != nil {
 		log.Printf("create kart class error: %v", err)
@@ -619,7 +621,10 @@ func handleUpdateKartClass(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	allowed := map[string]bool{"name": true, "chassis": true, "engine": true, "description": true, "isDefault": true}
+	allowed := map[string]bool{
+		"name": true, "chassis": true, "engine": true, "description": true, "isDefault": true,
+		"minDriverWeightKg": true, "winBallastKg": true, "podiumBallastKg": true, "maxBallastKg": true,
+	}
 	fields := map[string]any{}
 	for k, v := range req {
 		if allowed[k] {
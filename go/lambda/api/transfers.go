@@ -0,0 +1,520 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+	"github.com/BrianLeishman/karttrackpark.com/go/email"
+	"github.com/BrianLeishman/karttrackpark.com/go/transfer"
+)
+
+// regContact is the address a registration's driver is emailed at: their
+// account's, else the one they were invited at.
+func regContact(ctx context.Context, reg *dynamo.Registration) string {
+	if user, err := dynamo.GetUser(ctx, reg.UID); err == nil && user != nil && user.Email != "" {
+		return user.Email
+	}
+	return reg.Email
+}
+
+func lookupTrackName(ctx context.Context, trackID string) string {
+	if track, err := dynamo.GetTrack(ctx, trackID); err == nil && track != nil {
+		return track.Name
+	}
+	return trackID
+}
+
+// notifyTransfer emails the drivers on both sides of a transfer or swap, and
+// the track's owners and admins, that it went through. Errors are logged,
+// since the registrations have already moved.
+func notifyTransfer(ctx context.Context, trackID string, data email.TransferData, drivers ...string) {
+	to := drivers
+	members, err := dynamo.ListTrackMembers(ctx, trackID)
+	if err != nil {
+		log.Printf("list track members error: %v", err)
+	}
+	for _, m := range members {
+		if m.Role != "owner" && m.Role != "admin" {
+			continue
+		}
+		if user, err := dynamo.GetUser(ctx, m.UID); err == nil && user != nil {
+			to = append(to, user.Email)
+		}
+	}
+
+	sent := map[string]bool{"": true}
+	for _, addr := range to {
+		if sent[strings.ToLower(addr)] {
+			continue
+		}
+		sent[strings.ToLower(addr)] = true
+		if err := email.SendTransferDone(ctx, addr, data); err != nil {
+			log.Printf("send transfer email error: %v", err)
+		}
+	}
+}
+
+// offerTransfer saves a transfer proposal and emails its recipient. On
+// failure the error response has been written.
+func offerTransfer(w http.ResponseWriter, r *http.Request, t dynamo.RegistrationTransfer, swapName string) (*dynamo.RegistrationTransfer, bool) {
+	saved, err := dynamo.ProposeTransfer(r.Context(), t)
+	if err != nil {
+		log.Printf("propose transfer error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return nil, false
+	}
+
+	err = email.SendTransferOffer(r.Context(), saved.ToEmail, email.TransferData{
+		FromName:       t.FromName,
+		EntityName:     t.ParentName,
+		TrackName:      lookupTrackName(r.Context(), t.TrackID),
+		Link:           siteURL,
+		SwapEntityName: swapName,
+	})
+	if err != nil {
+		log.Printf("send transfer offer email error: %v", err)
+	}
+	return saved, true
+}
+
+// makeTransferHandler lets a driver, or an admin for them, offer their
+// registration to someone else by email (POST), see the offer (GET) or
+// take it back (DELETE). The recipient accepts it with the handler from
+// makeClaimRegHandler.
+func makeTransferHandler(parentType string, resolve func(context.Context, string) (*regParentInfo, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uid, err := requireAuth(r)
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		parentID := r.PathValue("id")
+		regUID := r.PathValue("uid")
+
+		parent, err := resolve(r.Context(), parentID)
+		if err != nil {
+			log.Printf("resolve %s parent error: %v", parentType, err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		if parent == nil {
+			writeError(w, http.StatusNotFound, parentType+" not found")
+			return
+		}
+
+		// Allow the driver themselves or admin
+		if regUID != uid {
+			if err := requireTrackRole(r, parent.TrackID, uid, "owner", "admin"); err != nil {
+				writeError(w, http.StatusForbidden, err.Error())
+				return
+			}
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			t, err := dynamo.GetTransfer(r.Context(), parentType, parentID, regUID)
+			if err != nil {
+				log.Printf("get transfer error: %v", err)
+				writeError(w, http.StatusInternalServerError, "internal error")
+				return
+			}
+			if t == nil {
+				writeError(w, http.StatusNotFound, "no transfer offered")
+				return
+			}
+			writeJSON(w, http.StatusOK, t)
+			return
+		case http.MethodDelete:
+			if err := dynamo.DeleteTransfer(r.Context(), parentType, parentID, regUID); err != nil {
+				log.Printf("delete transfer error: %v", err)
+				writeError(w, http.StatusInternalServerError, "internal error")
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		var req struct {
+			Email string `json:"email"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid body")
+			return
+		}
+		toEmail := strings.ToLower(strings.TrimSpace(req.Email))
+		if toEmail == "" {
+			writeError(w, http.StatusBadRequest, "email is required")
+			return
+		}
+
+		reg, err := dynamo.GetRegistration(r.Context(), parentType, parentID, regUID)
+		if err != nil {
+			log.Printf("get registration error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		if reg == nil {
+			writeError(w, http.StatusNotFound, "registration not found")
+			return
+		}
+		if !transfer.Transferable(reg.Status) {
+			writeError(w, http.StatusConflict, transfer.ErrNotTransferable.Error())
+			return
+		}
+		if strings.EqualFold(toEmail, regContact(r.Context(), reg)) {
+			writeError(w, http.StatusBadRequest, transfer.ErrSameDriver.Error())
+			return
+		}
+
+		recipient, err := dynamo.GetUserByEmail(r.Context(), toEmail)
+		if err != nil {
+			log.Printf("lookup user by email error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		if recipient != nil {
+			existing, err := dynamo.GetRegistration(r.Context(), parentType, parentID, strings.TrimPrefix(recipient.UID, "USER#"))
+			if err != nil {
+				log.Printf("get registration error: %v", err)
+				writeError(w, http.StatusInternalServerError, "internal error")
+				return
+			}
+			if existing != nil {
+				writeError(w, http.StatusConflict, "driver already registered")
+				return
+			}
+		}
+
+		t, ok := offerTransfer(w, r, dynamo.RegistrationTransfer{
+			ParentType: parentType,
+			ParentID:   parentID,
+			ParentName: parent.ParentName,
+			TrackID:    parent.TrackID,
+			FromUID:    reg.UID,
+			FromName:   reg.DriverName,
+			ToEmail:    toEmail,
+			ProposedBy: uid,
+		}, "")
+		if !ok {
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, t)
+	}
+}
+
+// makeClaimRegHandler moves a registration offered for transfer to the
+// caller, whose account email the offer was sent to, or completes a
+// session swap offered to them. Whether it was paid carries over; a spot
+// that was confirmed waits on the new driver's waiver if they haven't
+// signed it.
+func makeClaimRegHandler(parentType string, resolve func(context.Context, string) (*regParentInfo, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uid, err := requireAuth(r)
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		parentID := r.PathValue("id")
+		regUID := r.PathValue("uid")
+
+		parent, err := resolve(r.Context(), parentID)
+		if err != nil {
+			log.Printf("resolve %s parent error: %v", parentType, err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		if parent == nil {
+			writeError(w, http.StatusNotFound, parentType+" not found")
+			return
+		}
+
+		user, err := dynamo.GetUser(r.Context(), uid)
+		if err != nil {
+			log.Printf("get user error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		if user == nil {
+			writeError(w, http.StatusBadRequest, "user profile not found")
+			return
+		}
+
+		t, err := dynamo.GetTransfer(r.Context(), parentType, parentID, regUID)
+		if err != nil {
+			log.Printf("get transfer error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		if t == nil {
+			writeError(w, http.StatusNotFound, "transfer not found")
+			return
+		}
+		if (t.SwapUID != "" && t.SwapUID != uid) || (t.SwapUID == "" && !strings.EqualFold(t.ToEmail, user.Email)) {
+			writeError(w, http.StatusForbidden, "this transfer was offered to someone else")
+			return
+		}
+
+		reg, err := dynamo.GetRegistration(r.Context(), parentType, parentID, regUID)
+		if err != nil {
+			log.Printf("get registration error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		if reg == nil {
+			writeError(w, http.StatusNotFound, "registration not found")
+			return
+		}
+
+		if t.SwapUID != "" {
+			other, err := dynamo.GetRegistration(r.Context(), "session", t.SwapSessionID, t.SwapUID)
+			if err != nil {
+				log.Printf("get registration error: %v", err)
+				writeError(w, http.StatusInternalServerError, "internal error")
+				return
+			}
+			if other == nil {
+				writeError(w, http.StatusNotFound, "registration not found")
+				return
+			}
+			sessions, ok := checkSwap(w, r, reg, other)
+			if !ok {
+				return
+			}
+			_, movedOther, ok := swapSessions(w, r, reg, other, sessions)
+			if !ok {
+				return
+			}
+			writeJSON(w, http.StatusOK, movedOther)
+			return
+		}
+
+		if !transfer.Transferable(reg.Status) {
+			writeError(w, http.StatusConflict, transfer.ErrNotTransferable.Error())
+			return
+		}
+		driverName := user.Name
+		if driverName == "" {
+			driverName = user.Email
+		}
+		moved := transfer.Moved(*reg, uid, driverName, user.Email)
+		moved.Status, err = holdForWaiver(r.Context(), parent.TrackID, uid, moved.Status)
+		if err != nil {
+			log.Printf("check waiver error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		err = dynamo.TransferRegistration(r.Context(), reg, &moved)
+		switch {
+		case errors.Is(err, dynamo.ErrRegistrationChanged):
+			writeError(w, http.StatusConflict, "registration changed since the transfer was offered")
+			return
+		case errors.Is(err, dynamo.ErrAlreadyRegistered):
+			writeError(w, http.StatusConflict, "you're already registered")
+			return
+		case err != nil:
+			log.Printf("transfer registration error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		seriesRegChanged(r.Context(), reg, reg.Status, "")
+		seriesRegChanged(r.Context(), &moved, "", moved.Status)
+
+		notifyTransfer(r.Context(), parent.TrackID, email.TransferData{
+			FromName:   reg.DriverName,
+			ToName:     moved.DriverName,
+			EntityName: parent.ParentName,
+			TrackName:  lookupTrackName(r.Context(), parent.TrackID),
+			Link:       siteURL,
+		}, regContact(r.Context(), reg), user.Email)
+
+		writeJSON(w, http.StatusOK, moved)
+	}
+}
+
+// checkSwap loads the sessions of two registrations and checks they can be
+// swapped. On failure the error response has been written.
+func checkSwap(w http.ResponseWriter, r *http.Request, a, b *dynamo.Registration) ([2]*dynamo.Session, bool) {
+	var sessions [2]*dynamo.Session
+	for i, reg := range []*dynamo.Registration{a, b} {
+		s, err := dynamo.GetSession(r.Context(), reg.ParentID)
+		if err != nil {
+			log.Printf("get session error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return sessions, false
+		}
+		if s == nil {
+			writeError(w, http.StatusNotFound, "session not found")
+			return sessions, false
+		}
+		sessions[i] = s
+	}
+
+	err := transfer.CheckSwap(*a, *b, sessions[0].EventID, sessions[1].EventID)
+	if errors.Is(err, transfer.ErrNotTransferable) {
+		writeError(w, http.StatusConflict, err.Error())
+		return sessions, false
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return sessions, false
+	}
+	return sessions, true
+}
+
+// swapSessions trades the sessions of two registrations checked by
+// checkSwap and emails both drivers and the track's admins. On failure the
+// error response has been written.
+func swapSessions(w http.ResponseWriter, r *http.Request, a, b *dynamo.Registration, sessions [2]*dynamo.Session) (*dynamo.Registration, *dynamo.Registration, bool) {
+	movedA, movedB := transfer.Swapped(*a, *b)
+	err := dynamo.SwapRegistrations(r.Context(), a, b, &movedA, &movedB)
+	switch {
+	case errors.Is(err, dynamo.ErrRegistrationChanged):
+		writeError(w, http.StatusConflict, "registration changed since the swap was offered")
+		return nil, nil, false
+	case errors.Is(err, dynamo.ErrAlreadyRegistered):
+		writeError(w, http.StatusConflict, "driver already registered for the other session")
+		return nil, nil, false
+	case err != nil:
+		log.Printf("swap registrations error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return nil, nil, false
+	}
+
+	notifyTransfer(r.Context(), sessions[0].TrackID, email.TransferData{
+		FromName:       a.DriverName,
+		ToName:         b.DriverName,
+		EntityName:     sessions[0].SessionName,
+		TrackName:      lookupTrackName(r.Context(), sessions[0].TrackID),
+		Link:           siteURL,
+		SwapEntityName: sessions[1].SessionName,
+	}, regContact(r.Context(), a), regContact(r.Context(), b))
+	return &movedA, &movedB, true
+}
+
+// handleSwapSession swaps a driver's session registration for another
+// driver's in a different session of the same event. Admins swap them at
+// once; a driver offers the swap to the other driver, who accepts it like a
+// transfer.
+func handleSwapSession(w http.ResponseWriter, r *http.Request) {
+	uid, err := requireAuth(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	sessionID := r.PathValue("id")
+	regUID := r.PathValue("uid")
+
+	var req struct {
+		SessionID string `json:"session_id"`
+		UID       string `json:"uid"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	if req.SessionID == "" || req.UID == "" {
+		writeError(w, http.StatusBadRequest, "session_id and uid are required")
+		return
+	}
+
+	var regs [2]*dynamo.Registration
+	for i, key := range [][2]string{{sessionID, regUID}, {req.SessionID, req.UID}} {
+		reg, err := dynamo.GetRegistration(r.Context(), "session", key[0], key[1])
+		if err != nil {
+			log.Printf("get registration error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		if reg == nil {
+			writeError(w, http.StatusNotFound, "registration not found")
+			return
+		}
+		regs[i] = reg
+	}
+
+	sessions, ok := checkSwap(w, r, regs[0], regs[1])
+	if !ok {
+		return
+	}
+
+	isAdmin := requireTrackRole(r, sessions[0].TrackID, uid, "owner", "admin") == nil
+	if !isAdmin && regUID != uid {
+		writeError(w, http.StatusForbidden, "drivers can only swap their own registration")
+		return
+	}
+
+	if isAdmin {
+		movedA, movedB, ok := swapSessions(w, r, regs[0], regs[1], sessions)
+		if !ok {
+			return
+		}
+		writeJSON(w, http.StatusOK, []*dynamo.Registration{movedA, movedB})
+		return
+	}
+
+	toEmail := regContact(r.Context(), regs[1])
+	if toEmail == "" {
+		writeError(w, http.StatusBadRequest, "the other driver has no email to offer the swap to")
+		return
+	}
+	t, ok := offerTransfer(w, r, dynamo.RegistrationTransfer{
+		ParentType:    "session",
+		ParentID:      sessionID,
+		ParentName:    sessions[0].SessionName,
+		TrackID:       sessions[0].TrackID,
+		FromUID:       regUID,
+		FromName:      regs[0].DriverName,
+		ToEmail:       toEmail,
+		SwapSessionID: req.SessionID,
+		SwapUID:       req.UID,
+		ProposedBy:    uid,
+	}, sessions[1].SessionName)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, t)
+}
+
+// handleListMyTransfers lists the transfers and swaps offered to the
+// caller's email.
+func handleListMyTransfers(w http.ResponseWriter, r *http.Request) {
+	uid, err := requireAuth(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	user, err := dynamo.GetUser(r.Context(), uid)
+	if err != nil {
+		log.Printf("get user error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if user == nil {
+		writeJSON(w, http.StatusOK, []dynamo.RegistrationTransfer{})
+		return
+	}
+
+	transfers, err := dynamo.ListTransfersForEmail(r.Context(), user.Email)
+	if err != nil {
+		log.Printf("list transfers error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if transfers == nil {
+		transfers = []dynamo.RegistrationTransfer{}
+	}
+
+	writeJSON(w, http.StatusOK, transfers)
+}
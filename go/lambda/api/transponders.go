@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+)
+
+func handleListSessionTransponders(w http.ResponseWriter, r *http.Request) {
+	session, ok := requireSessionManager(w, r)
+	if !ok {
+		return
+	}
+
+	mappings, err := dynamo.ListSessionTransponders(r.Context(), session.SessionID)
+	if err != nil {
+		log.Printf("list session transponders error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if mappings == nil {
+		mappings = []dynamo.SessionTransponder{}
+	}
+
+	writeJSON(w, http.StatusOK, mappings)
+}
+
+// handleSetSessionTransponder maps a transponder to a driver for a session.
+// Rental karts with a transponder on file don't need a mapping; the timing
+// bridge resolves them through the session's kart assignments.
+func handleSetSessionTransponder(w http.ResponseWriter, r *http.Request) {
+	session, ok := requireSessionManager(w, r)
+	if !ok {
+		return
+	}
+
+	number := r.PathValue("number")
+	if _, err := strconv.ParseUint(number, 10, 32); err != nil {
+		writeError(w, http.StatusBadRequest, "transponder must be a number")
+		return
+	}
+
+	var req struct {
+		UID        string `json:"uid"`
+		DriverName string `json:"driver_name"`
+		KartID     string `json:"kart_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.UID == "" {
+		writeError(w, http.StatusBadRequest, "uid is required")
+		return
+	}
+
+	if req.DriverName == "" {
+		if user, err := dynamo.GetUser(r.Context(), req.UID); err == nil && user != nil {
+			req.DriverName = user.Name
+		}
+	}
+
+	mapping, err := dynamo.PutSessionTransponder(r.Context(), dynamo.SessionTransponder{
+		SessionID:   session.SessionID,
+		Transponder: number,
+		UID:         req.UID,
+		DriverName:  req.DriverName,
+		KartID:      req.KartID,
+	})
+	if err != nil {
+		log.Printf("put session transponder error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, mapping)
+}
+
+func handleDeleteSessionTransponder(w http.ResponseWriter, r *http.Request) {
+	session, ok := requireSessionManager(w, r)
+	if !ok {
+		return
+	}
+
+	if err := dynamo.DeleteSessionTransponder(r.Context(), session.SessionID, r.PathValue("number")); err != nil {
+		log.Printf("delete session transponder error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
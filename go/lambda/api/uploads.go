@@ -24,9 +24,10 @@ func handleCreateUpload(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		TrackID  string `json:"track_id"`
-		EventID  string `json:"event_id"`
-		Filename string `json:"filename"`
+		TrackID      string   `json:"track_id"`
+		EventID      string   `json:"event_id"`
+		Filename     string   `json:"filename"`
+		EquipmentIDs []string `json:"equipment_ids"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeError(w, http.StatusBadRequest, "invalid body")
@@ -58,13 +59,14 @@ func handleCreateUpload(w http.ResponseWriter, r *http.Request) {
 	}
 
 	upload, err := dynamo.CreateUpload(r.Context(), dynamo.Upload{
-		UploadID: uploadID,
-		UID:      uid,
-		TrackID:  req.TrackID,
-		EventID:  req.EventID,
-		Filename: req.Filename,
-		S3Key:    s3Key,
-		Status:   "uploading",
+		UploadID:     uploadID,
+		UID:          uid,
+		TrackID:      req.TrackID,
+		EventID:      req.EventID,
+		Filename:     req.Filename,
+		S3Key:        s3Key,
+		Status:       "uploading",
+		EquipmentIDs: req.EquipmentIDs,
 	})
 	if err != nil {
 		log.Printf("create upload error: %v", err)
@@ -178,6 +180,7 @@ func handleAssignUpload(w http.ResponseWriter, r *http.Request) {
 	if len(session.ClassIDs) == 1 {
 		kartClass = session.ClassIDs[0]
 	}
+	kartID := sessionKartID(r.Context(), req.SessionID, uid)
 
 	// Write new lap items from upload (sequential numbering)
 	seqNo := 0
@@ -195,6 +198,7 @@ func handleAssignUpload(w http.ResponseWriter, r *http.Request) {
 			UID:          uid,
 			LayoutID:     session.LayoutID,
 			KartClass:    kartClass,
+			KartID:       kartID,
 			TelemetryKey: telemKey,
 			CreatedAt:    upload.CreatedAt,
 		}); err != nil {
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+	"github.com/BrianLeishman/karttrackpark.com/go/email"
+	"github.com/BrianLeishman/karttrackpark.com/go/pricing"
+	"github.com/BrianLeishman/karttrackpark.com/go/waitlist"
+)
+
+// admitRegistration creates a registration. One that would hold a spot
+// takes it atomically, and joins the waitlist instead when none is free.
+func admitRegistration(ctx context.Context, parent *regParentInfo, reg dynamo.Registration) (*dynamo.Registration, error) {
+	if parent.MaxSpots <= 0 || !dynamo.HoldsSpot(reg.Status) {
+		return dynamo.CreateRegistration(ctx, reg)
+	}
+	created, err := dynamo.CreateRegistrationInSpot(ctx, reg, parent.MaxSpots)
+	if errors.Is(err, dynamo.ErrNoSpot) {
+		reg.Status = "waitlisted"
+		return dynamo.CreateRegistration(ctx, reg)
+	}
+	return created, err
+}
+
+// admitInvite moves an invited registration to reg.Status along with fields,
+// taking a spot for it or waitlisting it when none is free. reg.Status is
+// updated to whatever it ends up as.
+func admitInvite(ctx context.Context, parent *regParentInfo, reg *dynamo.Registration, fields map[string]any) error {
+	fields["status"] = reg.Status
+	if parent.MaxSpots <= 0 || !dynamo.HoldsSpot(reg.Status) {
+		return dynamo.UpdateRegistration(ctx, reg.ParentType, reg.ParentID, reg.UID, fields)
+	}
+	err := dynamo.ChangeRegistrationStatus(ctx, reg.ParentType, reg.ParentID, reg.UID, "invited", parent.MaxSpots, fields)
+	if errors.Is(err, dynamo.ErrNoSpot) {
+		reg.Status = "waitlisted"
+		fields["status"] = reg.Status
+		return dynamo.UpdateRegistration(ctx, reg.ParentType, reg.ParentID, reg.UID, fields)
+	}
+	return err
+}
+
+// claimInvite replaces an invite made out to an email address with reg, the
+// driver's own registration, taking a spot for it or waitlisting it when
+// none is free.
+func claimInvite(ctx context.Context, parent *regParentInfo, invite *dynamo.Registration, reg dynamo.Registration) (*dynamo.Registration, error) {
+	if parent.MaxSpots <= 0 || !dynamo.HoldsSpot(reg.Status) {
+		return &reg, dynamo.ClaimInvite(ctx, invite, &reg, 0)
+	}
+	err := dynamo.ClaimInvite(ctx, invite, &reg, parent.MaxSpots)
+	if errors.Is(err, dynamo.ErrNoSpot) {
+		reg.Status = "waitlisted"
+		err = dynamo.ClaimInvite(ctx, invite, &reg, 0)
+	}
+	return &reg, err
+}
+
+// promoteWaitlist lets lapsed spot offers go, then fills free spots from
+// the waitlist, first registered first. With WaitlistOfferHours set a driver
+// is offered the spot for that long; otherwise they're confirmed straight
+// away, or left pending when the entry fee is paid online. Promoted drivers
+// are emailed. Errors are logged, since whatever freed the spot is already
+// saved.
+func promoteWaitlist(ctx context.Context, parentType, parentID string, parent *regParentInfo) {
+	regs, err := dynamo.ListRegistrations(ctx, parentType, parentID)
+	if err != nil {
+		log.Printf("list registrations error: %v", err)
+		return
+	}
+
+	now := time.Now().UTC()
+	for _, reg := range waitlist.Expired(regs, now) {
+		err := dynamo.ChangeRegistrationStatus(ctx, parentType, parentID, reg.UID, "offered", parent.MaxSpots, map[string]any{
+			"status":         "expired",
+			"offerExpiresAt": nil,
+		})
+		if err != nil {
+			log.Printf("expire waitlist offer error: %v", err)
+			return
+		}
+		for i := range regs {
+			if regs[i].UID == reg.UID {
+				regs[i].Status = "expired"
+			}
+		}
+	}
+
+	queue := waitlist.Queue(regs)
+	queue = queue[:min(len(queue), waitlist.Open(regs, parent.MaxSpots))]
+	if len(queue) == 0 {
+		return
+	}
+
+	trackName := parent.TrackID
+	if track, err := dynamo.GetTrack(ctx, parent.TrackID); err == nil && track != nil {
+		trackName = track.Name
+	}
+	provider := paymentProvider()
+
+	for _, reg := range queue {
+		fields := map[string]any{"status": "confirmed"}
+		data := email.WaitlistData{
+			DriverName: reg.DriverName,
+			EntityName: parent.ParentName,
+			TrackName:  trackName,
+			Link:       siteURL,
+		}
+		switch {
+		case parent.WaitlistOfferHours > 0:
+			expires := now.Add(time.Duration(parent.WaitlistOfferHours) * time.Hour)
+			fields["status"] = "offered"
+			fields["offerExpiresAt"] = expires.Format(time.RFC3339)
+			data.ExpiresAt = expires.Format("Mon Jan 2, 3:04 PM MST")
+		case provider != nil && pricing.Due(parent.PriceCents, reg) > 0:
+			fields["status"] = "pending"
+			data.NeedsPayment = true
+		default:
+			// Confirmed once they sign the waiver, if they haven't
+			if unsigned, err := needsWaiver(ctx, parent.TrackID, reg.UID); err != nil || unsigned {
+				fields["status"] = "pending"
+				data.NeedsWaiver = true
+			}
+		}
+
+		err := dynamo.ChangeRegistrationStatus(ctx, parentType, parentID, reg.UID, "waitlisted", parent.MaxSpots, fields)
+		if errors.Is(err, dynamo.ErrNoSpot) {
+			return
+		}
+		if err != nil {
+			log.Printf("promote waitlist error: %v", err)
+			return
+		}
+		seriesRegChanged(ctx, &reg, "waitlisted", fields["status"].(string))
+
+		to := reg.Email
+		if user, err := dynamo.GetUser(ctx, reg.UID); err == nil && user != nil && user.Email != "" {
+			to = user.Email
+		}
+		if to == "" {
+			continue
+		}
+		if err := email.SendWaitlistOffer(ctx, to, data); err != nil {
+			log.Printf("send waitlist email error: %v", err)
+		}
+	}
+}
+
+// promoteWaitlistFor re-resolves a parent whose settings just changed and
+// promotes its waitlist.
+func promoteWaitlistFor(ctx context.Context, parentType, parentID string) {
+	parent, err := regParentResolvers[parentType](ctx, parentID)
+	if err != nil {
+		log.Printf("resolve %s parent error: %v", parentType, err)
+		return
+	}
+	if parent != nil {
+		promoteWaitlist(ctx, parentType, parentID, parent)
+	}
+}
+
+// makeAcceptRegHandler accepts the spot a driver was offered off the
+// waitlist. A priced entry paid online then goes pending with a checkout.
+func makeAcceptRegHandler(parentType string, resolve func(context.Context, string) (*regParentInfo, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uid, err := requireAuth(r)
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		parentID := r.PathValue("id")
+		if r.PathValue("uid") != uid {
+			writeError(w, http.StatusForbidden, "drivers can only accept their own spot")
+			return
+		}
+
+		var req struct {
+			ReturnURL string `json:"return_url"` // where to come back to after paying
+		}
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeError(w, http.StatusBadRequest, "invalid body")
+				return
+			}
+		}
+
+		parent, err := resolve(r.Context(), parentID)
+		if err != nil {
+			log.Printf("resolve %s parent error: %v", parentType, err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		if parent == nil {
+			writeError(w, http.StatusNotFound, parentType+" not found")
+			return
+		}
+
+		reg, err := dynamo.GetRegistration(r.Context(), parentType, parentID, uid)
+		if err != nil {
+			log.Printf("get registration error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		if reg == nil {
+			writeError(w, http.StatusNotFound, "registration not found")
+			return
+		}
+		if reg.Status != "offered" {
+			writeError(w, http.StatusConflict, "no spot on offer")
+			return
+		}
+		if len(waitlist.Expired([]dynamo.Registration{*reg}, time.Now().UTC())) > 0 {
+			promoteWaitlist(r.Context(), parentType, parentID, parent)
+			writeError(w, http.StatusGone, "the offer has expired")
+			return
+		}
+
+		provider := paymentProvider()
+		payOnline := pricing.Due(parent.PriceCents, *reg) > 0 && provider != nil && !reg.Paid
+		reg.Status = "confirmed"
+		if payOnline {
+			reg.Status = "pending"
+		}
+		reg.Status, err = holdForWaiver(r.Context(), parent.TrackID, uid, reg.Status)
+		if err != nil {
+			log.Printf("check waiver error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		fields := map[string]any{
+			"status":         reg.Status,
+			"offerExpiresAt": nil,
+		}
+		err = dynamo.ChangeRegistrationStatus(r.Context(), parentType, parentID, uid, "offered", parent.MaxSpots, fields)
+		if errors.Is(err, dynamo.ErrRegistrationChanged) {
+			writeError(w, http.StatusConflict, "no spot on offer")
+			return
+		}
+		if err != nil {
+			log.Printf("accept offer error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		reg.OfferExpiresAt = ""
+		seriesRegChanged(r.Context(), reg, "offered", reg.Status)
+
+		if payOnline {
+			if err := startCheckout(r.Context(), provider, parent, reg, req.ReturnURL); err != nil {
+				log.Printf("start checkout error: %v", err)
+			}
+		}
+
+		writeJSON(w, http.StatusOK, reg)
+	}
+}
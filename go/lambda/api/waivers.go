@@ -0,0 +1,384 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+	"github.com/BrianLeishman/karttrackpark.com/go/pricing"
+	"github.com/BrianLeishman/karttrackpark.com/go/waiver"
+)
+
+// needsWaiver reports whether a driver has yet to sign the track's current
+// waiver. Tracks without a waiver need nothing signed.
+func needsWaiver(ctx context.Context, trackID, uid string) (bool, error) {
+	current, err := dynamo.GetCurrentWaiver(ctx, trackID)
+	if err != nil || current == nil {
+		return false, err
+	}
+	sig, err := dynamo.GetWaiverSignature(ctx, uid, trackID, current.Version)
+	if err != nil {
+		return false, err
+	}
+	return sig == nil, nil
+}
+
+// holdForWaiver keeps a registration that would be confirmed pending while
+// its driver hasn't signed the track's current waiver. Signing confirms it.
+func holdForWaiver(ctx context.Context, trackID, uid, status string) (string, error) {
+	if status != "confirmed" {
+		return status, nil
+	}
+	unsigned, err := needsWaiver(ctx, trackID, uid)
+	if err != nil {
+		return "", err
+	}
+	if unsigned {
+		return "pending", nil
+	}
+	return status, nil
+}
+
+// confirmAfterWaiver confirms a driver's pending registrations at a track
+// once they've signed its waiver, unless they're also waiting on an admin's
+// approval or an online payment. Errors are logged; the signature is saved.
+func confirmAfterWaiver(ctx context.Context, uid, trackID string) {
+	regs, err := dynamo.ListUserRegistrations(ctx, uid, "")
+	if err != nil {
+		log.Printf("list user registrations error: %v", err)
+		return
+	}
+	provider := paymentProvider()
+	for _, reg := range regs {
+		if reg.TrackID != trackID || reg.Status != "pending" {
+			continue
+		}
+		parent, err := regParentResolvers[reg.ParentType](ctx, reg.ParentID)
+		if err != nil {
+			log.Printf("resolve %s parent error: %v", reg.ParentType, err)
+			continue
+		}
+		if parent == nil || parent.RegistrationMode == "approval_required" {
+			continue
+		}
+		if pricing.Due(parent.PriceCents, reg) > 0 && provider != nil && !reg.Paid {
+			continue
+		}
+		err = dynamo.ChangeRegistrationStatus(ctx, reg.ParentType, reg.ParentID, uid, "pending", parent.MaxSpots, map[string]any{"status": "confirmed"})
+		if err != nil {
+			log.Printf("confirm registration after waiver error: %v", err)
+			continue
+		}
+		seriesRegChanged(ctx, &reg, "pending", "confirmed")
+	}
+}
+
+// clientIP is the address a request came from. In Lambda the proxy sets
+// RemoteAddr to the caller's IP; locally it carries a port.
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// handlePublishWaiver publishes new waiver text for a track as its next
+// version. Drivers have to sign it again before their registrations are
+// confirmed.
+func handlePublishWaiver(w http.ResponseWriter, r *http.Request) {
+	uid, err := requireAuth(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	trackID := r.PathValue("id")
+	if err := requireTrackRole(r, trackID, uid, "owner", "admin"); err != nil {
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+
+	var req struct {
+		Title string `json:"title"`
+		Body  string `json:"body"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	req.Body = strings.TrimSpace(req.Body)
+	if req.Body == "" {
+		writeError(w, http.StatusBadRequest, "body is required")
+		return
+	}
+	req.Title = strings.TrimSpace(req.Title)
+	if req.Title == "" {
+		req.Title = "Liability Waiver"
+	}
+
+	wv, err := dynamo.PublishWaiver(r.Context(), dynamo.Waiver{
+		TrackID:     trackID,
+		Title:       req.Title,
+		Body:        req.Body,
+		PublishedBy: uid,
+	})
+	if errors.Is(err, dynamo.ErrWaiverVersionTaken) {
+		writeError(w, http.StatusConflict, "another version was just published, reload and try again")
+		return
+	}
+	if err != nil {
+		log.Printf("publish waiver error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, wv)
+}
+
+func handleListWaivers(w http.ResponseWriter, r *http.Request) {
+	list, err := dynamo.ListWaivers(r.Context(), r.PathValue("id"))
+	if err != nil {
+		log.Printf("list waivers error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if list == nil {
+		list = []dynamo.Waiver{}
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+func handleGetWaiverVersion(w http.ResponseWriter, r *http.Request) {
+	version, err := strconv.Atoi(r.PathValue("version"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid version")
+		return
+	}
+
+	wv, err := dynamo.GetWaiver(r.Context(), r.PathValue("id"), version)
+	if err != nil {
+		log.Printf("get waiver error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if wv == nil {
+		writeError(w, http.StatusNotFound, "waiver not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, wv)
+}
+
+// handleGetCurrentWaiver returns the waiver drivers sign now, along with
+// the caller's signature of it when they're logged in and have signed.
+func handleGetCurrentWaiver(w http.ResponseWriter, r *http.Request) {
+	trackID := r.PathValue("id")
+
+	wv, err := dynamo.GetCurrentWaiver(r.Context(), trackID)
+	if err != nil {
+		log.Printf("get current waiver error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if wv == nil {
+		writeError(w, http.StatusNotFound, "this track has no waiver")
+		return
+	}
+
+	resp := struct {
+		*dynamo.Waiver
+		Signature *dynamo.WaiverSignature `json:"signature,omitempty"`
+	}{Waiver: wv}
+	if uid, err := requireAuth(r); err == nil {
+		resp.Signature, err = dynamo.GetWaiverSignature(r.Context(), uid, trackID, wv.Version)
+		if err != nil {
+			log.Printf("get waiver signature error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleSignWaiver signs the track's current waiver for the caller. A minor
+// signs along with a parent or guardian.
+func handleSignWaiver(w http.ResponseWriter, r *http.Request) {
+	uid, err := requireAuth(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	trackID := r.PathValue("id")
+
+	var req struct {
+		Version              int    `json:"version"`
+		SignedName           string `json:"signed_name"`
+		DateOfBirth          string `json:"date_of_birth"`
+		Minor                bool   `json:"minor"`
+		GuardianName         string `json:"guardian_name"`
+		GuardianRelationship string `json:"guardian_relationship"`
+		GuardianSignedName   string `json:"guardian_signed_name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+
+	current, err := dynamo.GetCurrentWaiver(r.Context(), trackID)
+	if err != nil {
+		log.Printf("get current waiver error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if current == nil {
+		writeError(w, http.StatusNotFound, "this track has no waiver")
+		return
+	}
+	// The driver must have read the version they're signing
+	if req.Version != current.Version {
+		writeError(w, http.StatusConflict, "the waiver has been updated, read the new version and sign again")
+		return
+	}
+
+	sig := dynamo.WaiverSignature{
+		UID:                  uid,
+		TrackID:              trackID,
+		Version:              current.Version,
+		SignedName:           req.SignedName,
+		IP:                   clientIP(r),
+		UserAgent:            r.UserAgent(),
+		DateOfBirth:          req.DateOfBirth,
+		Minor:                req.Minor,
+		GuardianName:         req.GuardianName,
+		GuardianRelationship: req.GuardianRelationship,
+		GuardianSignedName:   req.GuardianSignedName,
+	}
+	if err := waiver.Check(&sig, time.Now().UTC()); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if user, err := dynamo.GetUser(r.Context(), uid); err == nil && user != nil {
+		sig.DriverName = user.Name
+	}
+
+	signed, err := dynamo.SignWaiver(r.Context(), sig)
+	if errors.Is(err, dynamo.ErrWaiverSigned) {
+		writeError(w, http.StatusConflict, "you have already signed this waiver")
+		return
+	}
+	if err != nil {
+		log.Printf("sign waiver error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	confirmAfterWaiver(r.Context(), uid, trackID)
+
+	writeJSON(w, http.StatusCreated, signed)
+}
+
+func handleListMyWaivers(w http.ResponseWriter, r *http.Request) {
+	uid, err := requireAuth(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	sigs, err := dynamo.ListUserWaiverSignatures(r.Context(), uid)
+	if err != nil {
+		log.Printf("list waiver signatures error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if sigs == nil {
+		sigs = []dynamo.WaiverSignature{}
+	}
+
+	writeJSON(w, http.StatusOK, sigs)
+}
+
+// makeCheckInHandler lists the drivers expected at a series or session for
+// staff at the gate, showing who still has to sign the track's current
+// waiver. Events have their own check-in; see handleEventCheckIn.
+func makeCheckInHandler(parentType string, resolve func(context.Context, string) (*regParentInfo, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uid, err := requireAuth(r)
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		parentID := r.PathValue("id")
+
+		parent, err := resolve(r.Context(), parentID)
+		if err != nil {
+			log.Printf("resolve %s parent error: %v", parentType, err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		if parent == nil {
+			writeError(w, http.StatusNotFound, parentType+" not found")
+			return
+		}
+
+		if err := requireTrackRole(r, parent.TrackID, uid, checkInRoles...); err != nil {
+			writeError(w, http.StatusForbidden, err.Error())
+			return
+		}
+
+		regs, err := dynamo.ListRegistrations(r.Context(), parentType, parentID)
+		if err != nil {
+			log.Printf("list registrations error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		current, err := dynamo.GetCurrentWaiver(r.Context(), parent.TrackID)
+		if err != nil {
+			log.Printf("get current waiver error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		var resp struct {
+			WaiverVersion int              `json:"waiver_version,omitempty"`
+			Unsigned      int              `json:"unsigned"`
+			Entrants      []waiver.Entrant `json:"entrants"`
+		}
+		if current == nil {
+			// Nothing to sign: everyone is clear
+			resp.Entrants = waiver.CheckIn(regs, nil)
+			for i := range resp.Entrants {
+				resp.Entrants[i].Signed = true
+			}
+			writeJSON(w, http.StatusOK, resp)
+			return
+		}
+
+		sigs, err := dynamo.ListWaiverSignatures(r.Context(), parent.TrackID, current.Version)
+		if err != nil {
+			log.Printf("list waiver signatures error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		resp.WaiverVersion = current.Version
+		resp.Entrants = waiver.CheckIn(regs, sigs)
+		for _, e := range resp.Entrants {
+			if !e.Signed {
+				resp.Unsigned++
+			}
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 
 	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+	"github.com/BrianLeishman/karttrackpark.com/go/maintenance"
 	"github.com/BrianLeishman/karttrackpark.com/go/xrk"
 )
 
@@ -523,5 +524,30 @@ func doIngest(ctx context.Context, bkt, key string, upload *dynamo.Upload) error
 	if sessionTime != "" {
 		fields["sessionTime"] = sessionTime
 	}
-	return dynamo.UpdateUpload(ctx, upload.UploadID, fields)
+
+	// Engine usage over the whole session (not just full laps) for equipment tracking
+	for _, sc := range sensors {
+		if !strings.EqualFold(sc.name, "RPM") {
+			continue
+		}
+		maxRPM, hist := maintenance.RPMHistogram(sc.data)
+		if len(hist) > 0 {
+			fields["maxRpm"] = maxRPM
+			fields["rpmHistogram"] = hist
+			upload.MaxRPM = maxRPM
+			upload.RPMHistogram = hist
+			log.Printf("  Engine: %d rpm max, %dms running", maxRPM, maintenance.RunTimeMs(hist))
+		}
+		break
+	}
+
+	if err := dynamo.UpdateUpload(ctx, upload.UploadID, fields); err != nil {
+		return err
+	}
+
+	upload.TotalTimeMs = totalTimeMs
+	if err := maintenance.ApplyUpload(ctx, upload); err != nil {
+		log.Printf("  Apply equipment usage error: %v", err)
+	}
+	return nil
 }
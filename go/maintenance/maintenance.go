@@ -0,0 +1,171 @@
+// Package maintenance accumulates engine hours and RPM usage from ingested
+// uploads onto a driver's equipment and sends rebuild reminders.
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+	"github.com/BrianLeishman/karttrackpark.com/go/email"
+	"github.com/BrianLeishman/karttrackpark.com/go/xrk"
+)
+
+// maxSampleGapMs caps how long a single RPM sample is assumed to hold.
+// Longer gaps mean the logger dropped out, so that time isn't counted.
+const maxSampleGapMs = 1000
+
+// RPMHistogram buckets an RPM channel into dynamo.RPMBucketSize-wide bands,
+// weighting each sample by the time until the next one. Returns the peak RPM
+// and the milliseconds spent in each band.
+func RPMHistogram(samples []xrk.TVPair) (int, []int64) {
+	if len(samples) < 2 {
+		return 0, nil
+	}
+
+	sorted := make([]xrk.TVPair, len(samples))
+	copy(sorted, samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].TimeMs < sorted[j].TimeMs })
+
+	var maxRPM int
+	var hist []int64
+	for i := 0; i < len(sorted)-1; i++ {
+		rpm := int(sorted[i].Value)
+		if rpm < 0 {
+			rpm = 0
+		}
+		if rpm > maxRPM {
+			maxRPM = rpm
+		}
+
+		dt := int64(sorted[i+1].TimeMs - sorted[i].TimeMs)
+		if dt <= 0 || dt > maxSampleGapMs {
+			continue
+		}
+
+		bucket := rpm / dynamo.RPMBucketSize
+		for len(hist) <= bucket {
+			hist = append(hist, 0)
+		}
+		hist[bucket] += dt
+	}
+	if last := int(sorted[len(sorted)-1].Value); last > maxRPM {
+		maxRPM = last
+	}
+	return maxRPM, hist
+}
+
+// RunTimeMs returns the time the engine was turning, i.e. everything above the
+// lowest band of the histogram.
+func RunTimeMs(hist []int64) int64 {
+	var total int64
+	for i := 1; i < len(hist); i++ {
+		total += hist[i]
+	}
+	return total
+}
+
+// TimeAboveRPM returns the time spent in bands at or above threshold. The
+// threshold is rounded up to the next band boundary.
+func TimeAboveRPM(hist []int64, threshold int) int64 {
+	if threshold <= 0 {
+		return RunTimeMs(hist)
+	}
+	start := (threshold + dynamo.RPMBucketSize - 1) / dynamo.RPMBucketSize
+	var total int64
+	for i := start; i < len(hist); i++ {
+		total += hist[i]
+	}
+	return total
+}
+
+// ApplyUpload adds an upload's usage to each assigned piece of equipment that
+// hasn't already been credited. Each piece is credited, and recorded on the
+// upload, in one write, so a re-ingest, a re-assignment or a retry after an
+// error partway through doesn't double count.
+func ApplyUpload(ctx context.Context, u *dynamo.Upload) error {
+	runMs := RunTimeMs(u.RPMHistogram)
+	if runMs == 0 {
+		runMs = u.TotalTimeMs
+	}
+
+	for _, id := range u.EquipmentIDs {
+		if slices.Contains(u.EquipmentApplied, id) {
+			continue
+		}
+
+		eq, err := dynamo.GetEquipment(ctx, u.UID, id)
+		if err != nil {
+			return fmt.Errorf("get equipment %s: %w", id, err)
+		}
+		if eq == nil {
+			log.Printf("  Equipment %s not found for user %s, skipping", id, u.UID)
+			continue
+		}
+
+		usage := dynamo.EquipmentUsage{RunMs: runMs}
+		if eq.Type == "engine" {
+			usage.TimeAboveRPMMs = TimeAboveRPM(u.RPMHistogram, eq.RPMThreshold)
+			usage.MaxRPM = u.MaxRPM
+		}
+		eq, err = dynamo.ApplyEquipmentUsage(ctx, u.UID, id, u.UploadID, usage)
+		if errors.Is(err, dynamo.ErrUsageApplied) {
+			u.EquipmentApplied = append(u.EquipmentApplied, id)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("update equipment %s: %w", id, err)
+		}
+		if eq == nil {
+			log.Printf("  Equipment %s not found for user %s, skipping", id, u.UID)
+			continue
+		}
+		u.EquipmentApplied = append(u.EquipmentApplied, id)
+		log.Printf("  Applied %dms to equipment %s", runMs, id)
+
+		if eq.ReminderSentAt == "" && dueForRebuild(eq) {
+			if err := sendReminder(ctx, eq); err != nil {
+				log.Printf("  Send rebuild reminder for %s error: %v", id, err)
+				continue
+			}
+			if err := dynamo.UpdateEquipment(ctx, u.UID, id, map[string]any{
+				"reminderSentAt": time.Now().UTC().Format(time.RFC3339),
+			}); err != nil {
+				log.Printf("  Record rebuild reminder for %s error: %v", id, err)
+			}
+		}
+	}
+	return nil
+}
+
+func dueForRebuild(eq *dynamo.Equipment) bool {
+	if eq.RebuildIntervalHours <= 0 {
+		return false
+	}
+	return float64(eq.SinceRebuildMs) >= eq.RebuildIntervalHours*float64(time.Hour/time.Millisecond)
+}
+
+func sendReminder(ctx context.Context, eq *dynamo.Equipment) error {
+	user, err := dynamo.GetUser(ctx, eq.UID)
+	if err != nil {
+		return err
+	}
+	if user == nil || user.Email == "" {
+		return fmt.Errorf("no email on file")
+	}
+
+	hours := float64(eq.SinceRebuildMs) / float64(time.Hour/time.Millisecond)
+	return email.SendMaintenanceReminder(ctx, user.Email, email.MaintenanceData{
+		EquipmentName: eq.Name,
+		EquipmentType: eq.Type,
+		HoursUsed:     strconv.FormatFloat(hours, 'f', 1, 64),
+		IntervalHours: strconv.FormatFloat(eq.RebuildIntervalHours, 'f', -1, 64),
+		Link:          "https://karttrackpark.com",
+	})
+}
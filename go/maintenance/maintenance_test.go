@@ -0,0 +1,61 @@
+package maintenance
+
+import (
+	"testing"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/xrk"
+)
+
+func TestRPMHistogram(t *testing.T) {
+	samples := []xrk.TVPair{
+		{TimeMs: 0, Value: 0},
+		{TimeMs: 100, Value: 9000},
+		{TimeMs: 300, Value: 14100},
+		{TimeMs: 400, Value: 12000},
+		{TimeMs: 5000, Value: 12000}, // logger gap — not counted
+		{TimeMs: 5100, Value: 0},
+	}
+
+	maxRPM, hist := RPMHistogram(samples)
+	if maxRPM != 14100 {
+		t.Errorf("maxRPM = %d, want 14100", maxRPM)
+	}
+	if got := RunTimeMs(hist); got != 400 {
+		t.Errorf("RunTimeMs = %d, want 400", got)
+	}
+	if got := hist[0]; got != 100 {
+		t.Errorf("hist[0] = %d, want 100", got)
+	}
+}
+
+func TestRPMHistogram_TooFewSamples(t *testing.T) {
+	maxRPM, hist := RPMHistogram([]xrk.TVPair{{TimeMs: 0, Value: 5000}})
+	if maxRPM != 0 || hist != nil {
+		t.Errorf("got (%d, %v), want (0, nil)", maxRPM, hist)
+	}
+}
+
+func TestTimeAboveRPM(t *testing.T) {
+	_, hist := RPMHistogram([]xrk.TVPair{
+		{TimeMs: 0, Value: 9000},
+		{TimeMs: 200, Value: 13000},
+		{TimeMs: 500, Value: 14000},
+		{TimeMs: 600, Value: 0},
+	})
+
+	tests := []struct {
+		threshold int
+		want      int64
+	}{
+		{0, 600},
+		{12000, 400},
+		{13000, 400},
+		{13100, 100},
+		{20000, 0},
+	}
+	for _, tt := range tests {
+		if got := TimeAboveRPM(hist, tt.threshold); got != tt.want {
+			t.Errorf("TimeAboveRPM(%d) = %d, want %d", tt.threshold, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+// Package p3 decodes the AMB/MyLaps P3 binary protocol spoken by transponder
+// decoders on TCP port 5403.
+//
+// A record on the wire is:
+//
+//	SOR(0x8E) version(1) length(2) crc(2) flags(2) tor(2) fields... EOR(0x8F)
+//
+// Multi-byte values are little endian. Each field is a type byte, a length
+// byte and the value. Bytes 0x8A–0x8F inside a record are escaped as 0x8D
+// followed by the byte plus 0x20. The CRC is CRC-16/CCITT over the unescaped
+// record with the CRC field zeroed.
+package p3
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	SOR = 0x8E // start of record
+	EOR = 0x8F // end of record
+	ESC = 0x8D // escape
+)
+
+// Record types
+const (
+	TORPassing = 0x0001
+	TORStatus  = 0x0002
+)
+
+// PASSING field types
+const (
+	FieldPassingNumber = 0x01
+	FieldTransponder   = 0x03
+	FieldRTCTime       = 0x04
+	FieldStrength      = 0x05
+	FieldHits          = 0x06
+	FieldFlags         = 0x08
+	FieldDecoderID     = 0x81
+)
+
+// headerLen is SOR through TOR.
+const headerLen = 10
+
+// maxRecordLen guards against runaway reads when EOR is lost.
+const maxRecordLen = 1024
+
+var ErrBadCRC = errors.New("p3: bad crc")
+
+// Record is a single decoded P3 record.
+type Record struct {
+	Version byte
+	Flags   uint16
+	TOR     uint16
+	Fields  map[byte][]byte
+}
+
+// Passing is a transponder crossing the timing loop.
+type Passing struct {
+	PassingNumber uint32
+	Transponder   uint32
+	Time          time.Time // decoder RTC, microsecond resolution
+	Strength      uint16
+	Hits          uint16
+	DecoderID     uint32
+}
+
+// Passing extracts a passing from the record. ok is false for other record
+// types or passings missing a transponder or time.
+func (r *Record) Passing() (Passing, bool) {
+	if r.TOR != TORPassing {
+		return Passing{}, false
+	}
+	tr, ok1 := r.uint(FieldTransponder)
+	us, ok2 := r.uint(FieldRTCTime)
+	if !ok1 || !ok2 {
+		return Passing{}, false
+	}
+	p := Passing{
+		Transponder: uint32(tr),
+		Time:        time.UnixMicro(int64(us)).UTC(),
+	}
+	if v, ok := r.uint(FieldPassingNumber); ok {
+		p.PassingNumber = uint32(v)
+	}
+	if v, ok := r.uint(FieldStrength); ok {
+		p.Strength = uint16(v)
+	}
+	if v, ok := r.uint(FieldHits); ok {
+		p.Hits = uint16(v)
+	}
+	if v, ok := r.uint(FieldDecoderID); ok {
+		p.DecoderID = uint32(v)
+	}
+	return p, true
+}
+
+// uint reads a little-endian unsigned field of 1–8 bytes.
+func (r *Record) uint(tof byte) (uint64, bool) {
+	b, ok := r.Fields[tof]
+	if !ok || len(b) == 0 || len(b) > 8 {
+		return 0, false
+	}
+	var v uint64
+	for i := len(b) - 1; i >= 0; i-- {
+		v = v<<8 | uint64(b[i])
+	}
+	return v, true
+}
+
+// Unescape reverses P3 byte stuffing on a framed record (SOR..EOR inclusive).
+func Unescape(frame []byte) []byte {
+	out := make([]byte, 0, len(frame))
+	for i := 0; i < len(frame); i++ {
+		if frame[i] == ESC && i+1 < len(frame) {
+			i++
+			out = append(out, frame[i]-0x20)
+			continue
+		}
+		out = append(out, frame[i])
+	}
+	return out
+}
+
+// Escape applies P3 byte stuffing to everything between SOR and EOR.
+func Escape(raw []byte) []byte {
+	out := make([]byte, 0, len(raw)+8)
+	for i, b := range raw {
+		if i > 0 && i < len(raw)-1 && b >= 0x8A && b <= 0x8F {
+			out = append(out, ESC, b+0x20)
+			continue
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+// CRC16 computes CRC-16/CCITT (poly 0x1021, init 0xFFFF).
+func CRC16(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// Decode parses an unescaped record (SOR..EOR inclusive) and verifies its CRC.
+func Decode(raw []byte) (*Record, error) {
+	if len(raw) < headerLen+1 || raw[0] != SOR || raw[len(raw)-1] != EOR {
+		return nil, fmt.Errorf("p3: malformed record")
+	}
+	length := int(binary.LittleEndian.Uint16(raw[2:]))
+	if length != len(raw) {
+		return nil, fmt.Errorf("p3: length %d, got %d bytes", length, len(raw))
+	}
+
+	want := binary.LittleEndian.Uint16(raw[4:])
+	check := make([]byte, len(raw))
+	copy(check, raw)
+	check[4], check[5] = 0, 0
+	if CRC16(check) != want {
+		return nil, ErrBadCRC
+	}
+
+	rec := &Record{
+		Version: raw[1],
+		Flags:   binary.LittleEndian.Uint16(raw[6:]),
+		TOR:     binary.LittleEndian.Uint16(raw[8:]),
+		Fields:  make(map[byte][]byte),
+	}
+	body := raw[headerLen : len(raw)-1]
+	for len(body) >= 2 {
+		tof, n := body[0], int(body[1])
+		if 2+n > len(body) {
+			return nil, fmt.Errorf("p3: field 0x%02x overruns record", tof)
+		}
+		rec.Fields[tof] = body[2 : 2+n]
+		body = body[2+n:]
+	}
+	return rec, nil
+}
+
+// Encode builds an escaped wire record. Used for replay files and tests.
+func Encode(rec Record, order []byte) []byte {
+	raw := []byte{SOR, rec.Version, 0, 0, 0, 0, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint16(raw[6:], rec.Flags)
+	binary.LittleEndian.PutUint16(raw[8:], rec.TOR)
+	for _, tof := range order {
+		v := rec.Fields[tof]
+		raw = append(raw, tof, byte(len(v)))
+		raw = append(raw, v...)
+	}
+	raw = append(raw, EOR)
+	binary.LittleEndian.PutUint16(raw[2:], uint16(len(raw)))
+	binary.LittleEndian.PutUint16(raw[4:], CRC16(raw))
+	return Escape(raw)
+}
+
+// EncodePassing builds a wire PASSING record.
+func EncodePassing(p Passing) []byte {
+	le := func(v uint64, n int) []byte {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = byte(v >> (8 * i))
+		}
+		return b
+	}
+	rec := Record{
+		TOR: TORPassing,
+		Fields: map[byte][]byte{
+			FieldPassingNumber: le(uint64(p.PassingNumber), 4),
+			FieldTransponder:   le(uint64(p.Transponder), 4),
+			FieldRTCTime:       le(uint64(p.Time.UnixMicro()), 8),
+			FieldStrength:      le(uint64(p.Strength), 2),
+			FieldHits:          le(uint64(p.Hits), 2),
+			FieldDecoderID:     le(uint64(p.DecoderID), 4),
+		},
+	}
+	return Encode(rec, []byte{FieldPassingNumber, FieldTransponder, FieldRTCTime, FieldStrength, FieldHits, FieldDecoderID})
+}
+
+// Reader reads records from a decoder stream or replay file, resynchronising
+// on the next SOR after noise or a corrupt record.
+type Reader struct {
+	br *bufio.Reader
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{br: bufio.NewReader(r)}
+}
+
+// Next returns the next record. Records that fail to decode are returned as
+// errors so callers can log and continue; io.EOF marks the end of the stream.
+func (r *Reader) Next() (*Record, error) {
+	for {
+		b, err := r.br.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if b == SOR {
+			break
+		}
+	}
+
+	frame := []byte{SOR}
+	for {
+		b, err := r.br.ReadByte()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		if b == SOR {
+			// Lost EOR; start over from this SOR
+			frame = frame[:1]
+			continue
+		}
+		frame = append(frame, b)
+		if b == EOR {
+			break
+		}
+		if len(frame) > maxRecordLen {
+			return nil, fmt.Errorf("p3: record exceeds %d bytes", maxRecordLen)
+		}
+	}
+	return Decode(Unescape(frame))
+}
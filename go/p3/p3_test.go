@@ -0,0 +1,94 @@
+package p3
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestCRC16(t *testing.T) {
+	// CRC-16/CCITT-FALSE check value
+	if got := CRC16([]byte("123456789")); got != 0x29B1 {
+		t.Errorf("crc = %04x, want 29b1", got)
+	}
+}
+
+func TestPassingRoundTrip(t *testing.T) {
+	want := Passing{
+		PassingNumber: 42,
+		Transponder:   0x008E8F8D, // forces escaping
+		Time:          time.Date(2026, 5, 1, 14, 3, 7, 123456000, time.UTC),
+		Strength:      120,
+		Hits:          33,
+		DecoderID:     0x0ABC,
+	}
+
+	wire := EncodePassing(want)
+	if bytes.Count(wire, []byte{SOR}) != 1 || bytes.Count(wire, []byte{EOR}) != 1 {
+		t.Fatalf("framing bytes not escaped: % x", wire)
+	}
+
+	rec, err := NewReader(bytes.NewReader(wire)).Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := rec.Passing()
+	if !ok {
+		t.Fatal("not a passing")
+	}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestReaderResyncsAfterNoise(t *testing.T) {
+	base := time.Date(2026, 5, 1, 14, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	buf.Write([]byte{0x00, 0x12, EOR})
+	buf.Write(EncodePassing(Passing{Transponder: 1, Time: base}))
+
+	bad := EncodePassing(Passing{Transponder: 2, Time: base})
+	bad[len(bad)-2] ^= 0xFF // corrupt a field byte
+	buf.Write(bad)
+
+	buf.Write(EncodePassing(Passing{Transponder: 3, Time: base.Add(time.Second)}))
+
+	r := NewReader(&buf)
+	var transponders []uint32
+	var badCRC int
+	for {
+		rec, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if errors.Is(err, ErrBadCRC) {
+			badCRC++
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, _ := rec.Passing()
+		transponders = append(transponders, p.Transponder)
+	}
+
+	if badCRC != 1 {
+		t.Errorf("bad crc count = %d, want 1", badCRC)
+	}
+	if len(transponders) != 2 || transponders[0] != 1 || transponders[1] != 3 {
+		t.Errorf("transponders = %v, want [1 3]", transponders)
+	}
+}
+
+func TestNonPassingRecord(t *testing.T) {
+	wire := Encode(Record{TOR: TORStatus, Fields: map[byte][]byte{0x01: {0x10}}}, []byte{0x01})
+	rec, err := NewReader(bytes.NewReader(wire)).Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := rec.Passing(); ok {
+		t.Error("status record decoded as passing")
+	}
+}
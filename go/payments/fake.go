@@ -0,0 +1,116 @@
+package payments
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/rs/xid"
+)
+
+// Fake is an in-memory provider for local development and tests. Its
+// checkout URL is CheckoutBaseURL plus the checkout ID; whatever serves that
+// URL completes the payment with Complete. Its webhooks are unsigned, so it
+// must never be used in production.
+type Fake struct {
+	CheckoutBaseURL string
+
+	mu        sync.Mutex
+	checkouts map[string]fakeCheckout
+	refunds   map[string]int // payment ID -> cents refunded
+}
+
+type fakeCheckout struct {
+	req       CheckoutRequest
+	paymentID string
+}
+
+// NewFake returns a Fake whose checkout pages live under checkoutBaseURL.
+func NewFake(checkoutBaseURL string) *Fake {
+	return &Fake{
+		CheckoutBaseURL: checkoutBaseURL,
+		checkouts:       map[string]fakeCheckout{},
+		refunds:         map[string]int{},
+	}
+}
+
+func (f *Fake) Name() string { return "fake" }
+
+func (f *Fake) CreateCheckout(_ context.Context, req CheckoutRequest) (*Checkout, error) {
+	if req.AmountCents <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+	id := "fake_cs_" + xid.New().String()
+
+	f.mu.Lock()
+	f.checkouts[id] = fakeCheckout{req: req}
+	f.mu.Unlock()
+
+	return &Checkout{ID: id, URL: f.CheckoutBaseURL + id}, nil
+}
+
+// Complete pays for a checkout and returns the webhook body the provider
+// would send, along with where to send the customer afterwards.
+func (f *Fake) Complete(checkoutID string) (payload []byte, successURL string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	co, ok := f.checkouts[checkoutID]
+	if !ok {
+		return nil, "", fmt.Errorf("unknown checkout %q", checkoutID)
+	}
+	if co.paymentID == "" {
+		co.paymentID = "fake_pi_" + xid.New().String()
+		f.checkouts[checkoutID] = co
+	}
+
+	payload, err = json.Marshal(Event{
+		Type:        EventCheckoutCompleted,
+		CheckoutID:  checkoutID,
+		PaymentID:   co.paymentID,
+		AmountCents: co.req.AmountCents,
+		Metadata:    co.req.Metadata,
+	})
+	return payload, co.req.SuccessURL, err
+}
+
+func (f *Fake) ParseWebhook(payload []byte, _ http.Header) (*Event, error) {
+	var evt Event
+	if err := json.Unmarshal(payload, &evt); err != nil {
+		return nil, fmt.Errorf("decode webhook: %w", err)
+	}
+	return &evt, nil
+}
+
+func (f *Fake) Refund(_ context.Context, paymentID string, amountCents int) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var paid int
+	found := false
+	for _, co := range f.checkouts {
+		if co.paymentID == paymentID {
+			paid, found = co.req.AmountCents, true
+		}
+	}
+	if !found {
+		return "", fmt.Errorf("unknown payment %q", paymentID)
+	}
+	if amountCents == 0 {
+		amountCents = paid - f.refunds[paymentID]
+	}
+	if f.refunds[paymentID]+amountCents > paid {
+		return "", fmt.Errorf("refund exceeds amount paid")
+	}
+	f.refunds[paymentID] += amountCents
+	return "fake_re_" + xid.New().String(), nil
+}
+
+// Refunded returns the total refunded against a payment.
+func (f *Fake) Refunded(paymentID string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.refunds[paymentID]
+}
@@ -0,0 +1,51 @@
+package payments
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestFake(t *testing.T) {
+	f := NewFake("http://localhost/pay/")
+	ctx := context.Background()
+
+	co, err := f.CreateCheckout(ctx, CheckoutRequest{
+		AmountCents: 2500, SuccessURL: "http://localhost/done",
+		Metadata: map[string]string{"uid": "u1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(co.URL, "http://localhost/pay/") {
+		t.Errorf("url = %s", co.URL)
+	}
+
+	payload, success, err := f.Complete(co.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if success != "http://localhost/done" {
+		t.Errorf("success url = %s", success)
+	}
+	evt, err := f.ParseWebhook(payload, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if evt.Type != EventCheckoutCompleted || evt.CheckoutID != co.ID || evt.Metadata["uid"] != "u1" {
+		t.Errorf("got %+v", evt)
+	}
+
+	if _, err := f.Refund(ctx, evt.PaymentID, 1000); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Refund(ctx, evt.PaymentID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.Refunded(evt.PaymentID); got != 2500 {
+		t.Errorf("refunded %d, want 2500", got)
+	}
+	if _, err := f.Refund(ctx, evt.PaymentID, 1); err == nil {
+		t.Error("refunded more than was paid")
+	}
+}
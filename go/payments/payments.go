@@ -0,0 +1,51 @@
+// Package payments takes registration fees through a hosted checkout page
+// run by a payment provider, and refunds them.
+package payments
+
+import (
+	"context"
+	"net/http"
+)
+
+// EventCheckoutCompleted is sent once a checkout has been paid for.
+const EventCheckoutCompleted = "checkout.completed"
+
+// CheckoutRequest describes a single payment.
+type CheckoutRequest struct {
+	Description   string
+	AmountCents   int
+	Currency      string // ISO 4217, e.g. "usd"
+	CustomerEmail string
+	SuccessURL    string
+	CancelURL     string
+	// Metadata is returned with the webhook event, to tie the payment back
+	// to what was bought.
+	Metadata map[string]string
+}
+
+// Checkout is a hosted payment page the customer is sent to.
+type Checkout struct {
+	ID  string
+	URL string
+}
+
+// Event is a webhook notification from the provider. Types other than
+// EventCheckoutCompleted can be ignored.
+type Event struct {
+	Type        string
+	CheckoutID  string
+	PaymentID   string // what Refund takes
+	AmountCents int
+	Metadata    map[string]string
+}
+
+// Provider is a payment provider.
+type Provider interface {
+	Name() string
+	CreateCheckout(ctx context.Context, req CheckoutRequest) (*Checkout, error)
+	// ParseWebhook verifies and decodes a webhook request body.
+	ParseWebhook(payload []byte, header http.Header) (*Event, error)
+	// Refund returns amountCents of a payment (0 refunds all of it) and
+	// gives the refund's ID.
+	Refund(ctx context.Context, paymentID string, amountCents int) (string, error)
+}
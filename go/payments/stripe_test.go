@@ -0,0 +1,122 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func sign(payload []byte, secret string, at time.Time) string {
+	ts := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + "."))
+	mac.Write(payload)
+	return fmt.Sprintf("t=%s,v1=%s", ts, hex.EncodeToString(mac.Sum(nil)))
+}
+
+func TestStripeCreateCheckout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/checkout/sessions" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if user, _, _ := r.BasicAuth(); user != "sk_test" {
+			t.Errorf("api key = %q", user)
+		}
+		r.ParseForm()
+		if got := r.PostForm.Get("line_items[0][price_data][unit_amount]"); got != "2500" {
+			t.Errorf("unit_amount = %q", got)
+		}
+		if got := r.PostForm.Get("metadata[uid]"); got != "u1" {
+			t.Errorf("metadata uid = %q", got)
+		}
+		w.Write([]byte(`{"id":"cs_1","url":"https://checkout.stripe.com/c/cs_1"}`))
+	}))
+	defer srv.Close()
+
+	s := &Stripe{SecretKey: "sk_test", BaseURL: srv.URL}
+	co, err := s.CreateCheckout(context.Background(), CheckoutRequest{
+		Description: "Round 1", AmountCents: 2500, Currency: "USD",
+		Metadata: map[string]string{"uid": "u1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if co.ID != "cs_1" || co.URL != "https://checkout.stripe.com/c/cs_1" {
+		t.Errorf("got %+v", co)
+	}
+}
+
+func TestStripeRefundIdempotent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Idempotency-Key"); got != "refund-pi_1" {
+			t.Errorf("Idempotency-Key = %q, want refund-pi_1", got)
+		}
+		w.Write([]byte(`{"id":"re_1"}`))
+	}))
+	defer srv.Close()
+
+	s := &Stripe{SecretKey: "sk_test", BaseURL: srv.URL}
+	if id, err := s.Refund(context.Background(), "pi_1", 2500); err != nil || id != "re_1" {
+		t.Errorf("Refund = %q, %v", id, err)
+	}
+}
+
+func TestStripeError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"message":"No such payment_intent"}}`))
+	}))
+	defer srv.Close()
+
+	s := &Stripe{SecretKey: "sk_test", BaseURL: srv.URL}
+	if _, err := s.Refund(context.Background(), "pi_x", 0); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestStripeParseWebhook(t *testing.T) {
+	s := NewStripe("sk_test", "whsec_test")
+	payload := []byte(`{"type":"checkout.session.completed","data":{"object":{"id":"cs_1","payment_intent":"pi_1","payment_status":"paid","amount_total":2500,"metadata":{"uid":"u1"}}}}`)
+
+	header := http.Header{}
+	header.Set("Stripe-Signature", sign(payload, "whsec_test", time.Now()))
+	evt, err := s.ParseWebhook(payload, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if evt.Type != EventCheckoutCompleted || evt.PaymentID != "pi_1" || evt.Metadata["uid"] != "u1" {
+		t.Errorf("got %+v", evt)
+	}
+
+	header.Set("Stripe-Signature", sign(payload, "wrong", time.Now()))
+	if _, err := s.ParseWebhook(payload, header); err == nil {
+		t.Error("accepted a bad signature")
+	}
+
+	header.Set("Stripe-Signature", sign(payload, "whsec_test", time.Now().Add(-time.Hour)))
+	if _, err := s.ParseWebhook(payload, header); err == nil {
+		t.Error("accepted a stale signature")
+	}
+}
+
+func TestStripeParseWebhookUnpaid(t *testing.T) {
+	s := NewStripe("sk_test", "whsec_test")
+	payload := []byte(`{"type":"checkout.session.completed","data":{"object":{"id":"cs_1","payment_status":"unpaid"}}}`)
+
+	header := http.Header{}
+	header.Set("Stripe-Signature", sign(payload, "whsec_test", time.Now()))
+	evt, err := s.ParseWebhook(payload, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if evt.Type == EventCheckoutCompleted {
+		t.Error("unpaid checkout reported as completed")
+	}
+}
@@ -0,0 +1,132 @@
+// Package penalties applies steward decisions to a session's results.
+package penalties
+
+import (
+	"sort"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+)
+
+// Apply recomputes results from their pre-penalty classification and the
+// penalties in force. Results are returned in their new finishing order with
+// Position and the applied penalty totals set; the stored classification
+// (FinishPosition, Laps, TotalMs, Status) is never changed, so removing a
+// penalty and re-applying restores the original order.
+//
+// In a race, lap deductions and time penalties re-sort the finishers when
+// every finisher has a total time; otherwise the classified order stands.
+// Position drops then move a driver down that many places among the
+// finishers. DNF and DNS results follow the finishers, and disqualified
+// drivers are placed last. Grid penalties are only totalled, for the grid of
+// the session they target.
+func Apply(results []dynamo.Result, penalties []dynamo.Penalty, race bool) []dynamo.Result {
+	byUID := map[string]int{}
+	out := make([]dynamo.Result, len(results))
+	for i, r := range results {
+		if r.FinishPosition <= 0 {
+			r.FinishPosition = r.Position
+		}
+		r.TimePenaltyMs = 0
+		r.LapsDeducted = 0
+		r.PositionDrop = 0
+		r.PointsDeduction = 0
+		r.GridPenalty = 0
+		r.Disqualified = false
+		out[i] = r
+		byUID[r.UID] = i
+	}
+
+	for _, p := range penalties {
+		i, ok := byUID[p.UID]
+		if !ok {
+			continue
+		}
+		r := &out[i]
+		switch p.Type {
+		case dynamo.PenaltyTime:
+			r.TimePenaltyMs += p.Value
+		case dynamo.PenaltyLaps:
+			r.LapsDeducted += int(p.Value)
+		case dynamo.PenaltyPosition:
+			r.PositionDrop += int(p.Value)
+		case dynamo.PenaltyPoints:
+			r.PointsDeduction += int(p.Value)
+		case dynamo.PenaltyGrid:
+			r.GridPenalty += int(p.Value)
+		case dynamo.PenaltyDSQ:
+			r.Disqualified = true
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].FinishPosition < out[j].FinishPosition })
+
+	var finished, dnf, dns, dsq []dynamo.Result
+	for _, r := range out {
+		switch {
+		case r.Disqualified:
+			dsq = append(dsq, r)
+		case r.Status == "dns":
+			dns = append(dns, r)
+		case r.Status == "dnf":
+			dnf = append(dnf, r)
+		default:
+			finished = append(finished, r)
+		}
+	}
+
+	if race && timed(finished) {
+		sort.SliceStable(finished, func(i, j int) bool {
+			a, b := finished[i], finished[j]
+			la, lb := a.Laps-a.LapsDeducted, b.Laps-b.LapsDeducted
+			if la != lb {
+				return la > lb
+			}
+			return a.TotalMs+a.TimePenaltyMs < b.TotalMs+b.TimePenaltyMs
+		})
+	}
+	finished = dropPlaces(finished)
+
+	sorted := make([]dynamo.Result, 0, len(out))
+	sorted = append(sorted, finished...)
+	sorted = append(sorted, dnf...)
+	sorted = append(sorted, dns...)
+	sorted = append(sorted, dsq...)
+	for i := range sorted {
+		sorted[i].Position = i + 1
+	}
+	return sorted
+}
+
+// timed reports whether every result has a total race time to re-sort by.
+func timed(results []dynamo.Result) bool {
+	for _, r := range results {
+		if r.TotalMs <= 0 || r.Laps <= 0 {
+			return false
+		}
+	}
+	return len(results) > 0
+}
+
+// dropPlaces moves each driver down PositionDrop places. When a dropped
+// driver lands level with another, the unpenalised driver stays ahead.
+func dropPlaces(results []dynamo.Result) []dynamo.Result {
+	type slot struct {
+		at int
+		r  dynamo.Result
+	}
+	slots := make([]slot, len(results))
+	for i, r := range results {
+		slots[i] = slot{at: i + r.PositionDrop, r: r}
+	}
+	sort.SliceStable(slots, func(i, j int) bool {
+		if slots[i].at != slots[j].at {
+			return slots[i].at < slots[j].at
+		}
+		return slots[i].r.PositionDrop < slots[j].r.PositionDrop
+	})
+	out := make([]dynamo.Result, len(slots))
+	for i, s := range slots {
+		out[i] = s.r
+	}
+	return out
+}
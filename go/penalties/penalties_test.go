@@ -0,0 +1,89 @@
+package penalties
+
+import (
+	"testing"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+)
+
+func order(results []dynamo.Result) string {
+	s := ""
+	for _, r := range results {
+		s += r.UID
+	}
+	return s
+}
+
+func raceResults() []dynamo.Result {
+	return []dynamo.Result{
+		{UID: "a", Position: 1, Laps: 10, TotalMs: 400000},
+		{UID: "b", Position: 2, Laps: 10, TotalMs: 402000},
+		{UID: "c", Position: 3, Laps: 10, TotalMs: 410000},
+		{UID: "d", Position: 4, Laps: 9, TotalMs: 395000},
+		{UID: "e", Position: 5, Laps: 3, TotalMs: 120000, Status: "dnf"},
+	}
+}
+
+func TestApplyTimeAndLaps(t *testing.T) {
+	pens := []dynamo.Penalty{
+		{UID: "a", Type: dynamo.PenaltyTime, Value: 5000},
+		{UID: "a", Type: dynamo.PenaltyTime, Value: 5000},
+		{UID: "b", Type: dynamo.PenaltyLaps, Value: 1},
+	}
+
+	got := Apply(raceResults(), pens, true)
+	// a ties c on 410000 and keeps the place it finished in; b drops to
+	// 9 laps, behind d who was quicker over them.
+	if order(got) != "acdbe" {
+		t.Fatalf("order = %s", order(got))
+	}
+	if got[0].TimePenaltyMs != 10000 || got[3].LapsDeducted != 1 {
+		t.Errorf("penalty totals not recorded: %+v", got[:3])
+	}
+	for i, r := range got {
+		if r.Position != i+1 {
+			t.Errorf("%s position = %d, want %d", r.UID, r.Position, i+1)
+		}
+	}
+	if got[0].FinishPosition != 1 || got[3].FinishPosition != 2 {
+		t.Errorf("finish positions should be kept: %+v", got)
+	}
+}
+
+func TestApplyDropsAndDSQ(t *testing.T) {
+	pens := []dynamo.Penalty{
+		{UID: "a", Type: dynamo.PenaltyPosition, Value: 2},
+		{UID: "c", Type: dynamo.PenaltyDSQ},
+		{UID: "d", Type: dynamo.PenaltyPoints, Value: 5},
+		{UID: "zz", Type: dynamo.PenaltyTime, Value: 1000}, // no result; ignored
+	}
+
+	got := Apply(raceResults(), pens, true)
+	// Finishers a b d; a dropped two places lands behind d; e DNF; c DSQ last.
+	if order(got) != "bdaec" {
+		t.Fatalf("order = %s", order(got))
+	}
+	if !got[4].Disqualified || got[1].PointsDeduction != 5 {
+		t.Errorf("got %+v", got)
+	}
+
+	// Re-applying with no penalties restores the classification.
+	got = Apply(got, nil, true)
+	if order(got) != "abcde" || got[4].Disqualified || got[2].PositionDrop != 0 {
+		t.Fatalf("after clearing: %s %+v", order(got), got)
+	}
+}
+
+func TestApplyWithoutTimes(t *testing.T) {
+	results := []dynamo.Result{
+		{UID: "a", Position: 1},
+		{UID: "b", Position: 2},
+	}
+	pens := []dynamo.Penalty{{UID: "a", Type: dynamo.PenaltyTime, Value: 10000}}
+
+	// No race times to re-sort with; the entered order stands.
+	got := Apply(results, pens, true)
+	if order(got) != "ab" || got[0].TimePenaltyMs != 10000 {
+		t.Fatalf("got %+v", got)
+	}
+}
@@ -0,0 +1,191 @@
+// Package pricing works out what a driver pays to register: the standard
+// price, or the best of the current early-bird price and the member price,
+// less any promo code. A season pass enters its holder free.
+package pricing
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+)
+
+// Rules that can set a registration's price
+const (
+	RuleStandard   = "standard"
+	RuleEarlyBird  = "early_bird"
+	RuleMember     = "member"
+	RuleSeasonPass = "season_pass"
+)
+
+var (
+	ErrUnknownCode = errors.New("unknown promo code")
+	ErrCodeExpired = errors.New("promo code has expired")
+)
+
+// Buyer is what a driver brings to the price.
+type Buyer struct {
+	Member     bool   // holds a current membership of the track
+	SeasonPass bool   // confirmed for a series whose season pass covers the event
+	PromoCode  string // as typed
+}
+
+// Quote is the price a driver registers at and the rule that set it.
+type Quote struct {
+	PriceCents int    `json:"price_cents"`
+	Rule       string `json:"rule"`
+	PromoCode  string `json:"promo_code,omitempty"`
+	// MaxUses is the promo code's limit, for redeeming it; 0 is unlimited.
+	MaxUses int `json:"-"`
+}
+
+// NormalizeCode puts a promo code in the form it's stored in.
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Price quotes a driver registering now. A promo code comes off whichever
+// price applies, down to free; codes are ignored with a season pass, which
+// is free already. An unknown or expired code is an error, so the driver
+// can fix it rather than pay full price.
+func Price(s dynamo.RegistrationSettings, b Buyer, now time.Time) (Quote, error) {
+	if b.SeasonPass {
+		return Quote{Rule: RuleSeasonPass}, nil
+	}
+
+	q := Quote{PriceCents: s.PriceCents, Rule: RuleStandard}
+	if tier := currentTier(s.EarlyBird, now); tier != nil && tier.PriceCents < q.PriceCents {
+		q = Quote{PriceCents: tier.PriceCents, Rule: RuleEarlyBird}
+	}
+	if b.Member && s.MemberPriceCents > 0 && s.MemberPriceCents < q.PriceCents {
+		q = Quote{PriceCents: s.MemberPriceCents, Rule: RuleMember}
+	}
+
+	code := NormalizeCode(b.PromoCode)
+	if code == "" {
+		return q, nil
+	}
+	var promo *dynamo.PromoCode
+	for i := range s.PromoCodes {
+		if s.PromoCodes[i].Code == code {
+			promo = &s.PromoCodes[i]
+		}
+	}
+	if promo == nil {
+		return Quote{}, ErrUnknownCode
+	}
+	if expired(promo.ExpiresAt, now) {
+		return Quote{}, ErrCodeExpired
+	}
+	off := promo.AmountOffCents + q.PriceCents*promo.PercentOff/100
+	q.PriceCents = max(0, q.PriceCents-off)
+	q.PromoCode = code
+	q.MaxUses = promo.MaxUses
+	return q, nil
+}
+
+// currentTier is the early-bird tier in force at now: the first one whose
+// date hasn't passed.
+func currentTier(tiers []dynamo.PriceTier, now time.Time) *dynamo.PriceTier {
+	var current *dynamo.PriceTier
+	var currentUntil time.Time
+	for i := range tiers {
+		until, err := time.Parse(time.RFC3339, tiers[i].Until)
+		if err != nil || !now.Before(until) {
+			continue
+		}
+		if current == nil || until.Before(currentUntil) {
+			current, currentUntil = &tiers[i], until
+		}
+	}
+	return current
+}
+
+func expired(expiresAt string, now time.Time) bool {
+	if expiresAt == "" {
+		return false
+	}
+	t, err := time.Parse(time.RFC3339, expiresAt)
+	return err == nil && !now.Before(t)
+}
+
+// Member reports whether a track membership is current at now.
+func Member(m *dynamo.Membership, now time.Time) bool {
+	return m != nil && !expired(m.ExpiresAt, now)
+}
+
+// Due is what a registration owes: the price it was quoted when the driver
+// registered. Registrations from before pricing rules owe the price an
+// admin set for them, or else the parent's.
+func Due(parentPriceCents int, reg dynamo.Registration) int {
+	if reg.PriceRule != "" || reg.PriceCents > 0 {
+		return reg.PriceCents
+	}
+	return parentPriceCents
+}
+
+// ValidateTiers checks early-bird tiers set up by an admin and returns them
+// in date order.
+func ValidateTiers(tiers []dynamo.PriceTier) ([]dynamo.PriceTier, error) {
+	seen := map[string]bool{}
+	for _, t := range tiers {
+		if _, err := time.Parse(time.RFC3339, t.Until); err != nil {
+			return nil, fmt.Errorf("early-bird date %q must be RFC 3339", t.Until)
+		}
+		if seen[t.Until] {
+			return nil, fmt.Errorf("two early-bird prices end at %s", t.Until)
+		}
+		seen[t.Until] = true
+		if t.PriceCents < 0 {
+			return nil, fmt.Errorf("early-bird price can't be negative")
+		}
+	}
+	out := append([]dynamo.PriceTier(nil), tiers...)
+	sort.Slice(out, func(i, j int) bool {
+		a, _ := time.Parse(time.RFC3339, out[i].Until)
+		b, _ := time.Parse(time.RFC3339, out[j].Until)
+		return a.Before(b)
+	})
+	return out, nil
+}
+
+var codePattern = regexp.MustCompile(`^[A-Z0-9_-]{3,32}$`)
+
+// ValidateCodes checks promo codes set up by an admin and returns them
+// normalised: each is 3-32 letters, digits, dashes or underscores, unique,
+// and takes either a percentage or an amount off.
+func ValidateCodes(codes []dynamo.PromoCode) ([]dynamo.PromoCode, error) {
+	seen := map[string]bool{}
+	out := make([]dynamo.PromoCode, 0, len(codes))
+	for _, pc := range codes {
+		pc.Code = NormalizeCode(pc.Code)
+		if !codePattern.MatchString(pc.Code) {
+			return nil, fmt.Errorf("promo code %q must be 3-32 letters, digits, dashes or underscores", pc.Code)
+		}
+		if seen[pc.Code] {
+			return nil, fmt.Errorf("duplicate promo code %q", pc.Code)
+		}
+		seen[pc.Code] = true
+		switch {
+		case pc.PercentOff < 0 || pc.PercentOff > 100:
+			return nil, fmt.Errorf("promo code %s: percent off must be 0-100", pc.Code)
+		case pc.AmountOffCents < 0:
+			return nil, fmt.Errorf("promo code %s: amount off can't be negative", pc.Code)
+		case (pc.PercentOff > 0) == (pc.AmountOffCents > 0):
+			return nil, fmt.Errorf("promo code %s: give either a percent or an amount off", pc.Code)
+		case pc.MaxUses < 0:
+			return nil, fmt.Errorf("promo code %s: max uses can't be negative", pc.Code)
+		}
+		if pc.ExpiresAt != "" {
+			if _, err := time.Parse(time.RFC3339, pc.ExpiresAt); err != nil {
+				return nil, fmt.Errorf("promo code %s: expiry must be RFC 3339", pc.Code)
+			}
+		}
+		out = append(out, pc)
+	}
+	return out, nil
+}
@@ -0,0 +1,153 @@
+package pricing
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+)
+
+var now = time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func TestPrice(t *testing.T) {
+	s := dynamo.RegistrationSettings{
+		PriceCents: 6000,
+		EarlyBird: []dynamo.PriceTier{
+			{Until: "2026-04-01T00:00:00Z", PriceCents: 5000},
+			{Until: "2026-03-15T00:00:00Z", PriceCents: 4000},
+			{Until: "2026-03-01T00:00:00Z", PriceCents: 3000}, // passed
+		},
+		MemberPriceCents: 4500,
+		PromoCodes: []dynamo.PromoCode{
+			{Code: "TENOFF", AmountOffCents: 1000, MaxUses: 20},
+			{Code: "HALF", PercentOff: 50},
+			{Code: "BIG", AmountOffCents: 10000},
+			{Code: "OLD", PercentOff: 10, ExpiresAt: "2026-03-01T00:00:00Z"},
+		},
+	}
+	late := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		b     Buyer
+		at    time.Time
+		cents int
+		rule  string
+		code  string
+	}{
+		{"current early-bird tier", Buyer{}, now, 4000, RuleEarlyBird, ""},
+		{"early bird beats member", Buyer{Member: true}, now, 4000, RuleEarlyBird, ""},
+		{"standard after early bird", Buyer{}, late, 6000, RuleStandard, ""},
+		{"member after early bird", Buyer{Member: true}, late, 4500, RuleMember, ""},
+		{"amount off", Buyer{PromoCode: " tenoff "}, now, 3000, RuleEarlyBird, "TENOFF"},
+		{"percent off member price", Buyer{Member: true, PromoCode: "half"}, late, 2250, RuleMember, "HALF"},
+		{"never below free", Buyer{PromoCode: "BIG"}, late, 0, RuleStandard, "BIG"},
+		{"season pass", Buyer{SeasonPass: true, Member: true, PromoCode: "nonsense"}, now, 0, RuleSeasonPass, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := Price(s, tt.b, tt.at)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if q.PriceCents != tt.cents || q.Rule != tt.rule || q.PromoCode != tt.code {
+				t.Errorf("Price = %+v, want %d %s %q", q, tt.cents, tt.rule, tt.code)
+			}
+		})
+	}
+
+	if q, _ := Price(s, Buyer{PromoCode: "TENOFF"}, now); q.MaxUses != 20 {
+		t.Errorf("MaxUses = %d, want 20", q.MaxUses)
+	}
+	if _, err := Price(s, Buyer{PromoCode: "NOPE"}, now); !errors.Is(err, ErrUnknownCode) {
+		t.Errorf("unknown code err = %v", err)
+	}
+	if _, err := Price(s, Buyer{PromoCode: "old"}, now); !errors.Is(err, ErrCodeExpired) {
+		t.Errorf("expired code err = %v", err)
+	}
+
+	// Free entries stay free; a higher "early-bird" price never applies
+	free := dynamo.RegistrationSettings{EarlyBird: []dynamo.PriceTier{{Until: "2026-04-01T00:00:00Z", PriceCents: 500}}}
+	if q, _ := Price(free, Buyer{}, now); q.PriceCents != 0 || q.Rule != RuleStandard {
+		t.Errorf("free Price = %+v", q)
+	}
+}
+
+func TestMember(t *testing.T) {
+	if Member(nil, now) {
+		t.Error("no membership counts as a member")
+	}
+	if !Member(&dynamo.Membership{}, now) || !Member(&dynamo.Membership{ExpiresAt: "2026-12-31T00:00:00Z"}, now) {
+		t.Error("current membership doesn't count")
+	}
+	if Member(&dynamo.Membership{ExpiresAt: "2026-03-10T12:00:00Z"}, now) {
+		t.Error("membership counts at the moment it expires")
+	}
+}
+
+func TestDue(t *testing.T) {
+	tests := []struct {
+		name string
+		reg  dynamo.Registration
+		want int
+	}{
+		{"legacy registration pays the parent's price", dynamo.Registration{}, 5000},
+		{"admin-set price", dynamo.Registration{PriceCents: 2500}, 2500},
+		{"quoted price", dynamo.Registration{PriceCents: 4000, PriceRule: RuleEarlyBird}, 4000},
+		{"quoted free", dynamo.Registration{PriceRule: RuleSeasonPass}, 0},
+	}
+	for _, tt := range tests {
+		if got := Due(5000, tt.reg); got != tt.want {
+			t.Errorf("%s: Due = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTiers(t *testing.T) {
+	got, err := ValidateTiers([]dynamo.PriceTier{
+		{Until: "2026-04-01T00:00:00Z", PriceCents: 5000},
+		{Until: "2026-03-01T00:00:00-05:00", PriceCents: 4000},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0].PriceCents != 4000 {
+		t.Errorf("tiers not in date order: %+v", got)
+	}
+
+	for _, bad := range [][]dynamo.PriceTier{
+		{{Until: "March 1", PriceCents: 100}},
+		{{Until: "2026-03-01T00:00:00Z", PriceCents: -1}},
+		{{Until: "2026-03-01T00:00:00Z"}, {Until: "2026-03-01T00:00:00Z"}},
+	} {
+		if _, err := ValidateTiers(bad); err == nil {
+			t.Errorf("ValidateTiers(%+v) = nil, want an error", bad)
+		}
+	}
+}
+
+func TestValidateCodes(t *testing.T) {
+	got, err := ValidateCodes([]dynamo.PromoCode{{Code: " spring-26 ", PercentOff: 15, MaxUses: 10}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0].Code != "SPRING-26" {
+		t.Errorf("code = %q, want SPRING-26", got[0].Code)
+	}
+
+	for _, bad := range [][]dynamo.PromoCode{
+		{{Code: "AB", PercentOff: 10}},
+		{{Code: "HAS SPACE", PercentOff: 10}},
+		{{Code: "X10", PercentOff: 10}, {Code: "x10", AmountOffCents: 100}},
+		{{Code: "NONE"}},
+		{{Code: "BOTH", PercentOff: 10, AmountOffCents: 100}},
+		{{Code: "TOOMUCH", PercentOff: 101}},
+		{{Code: "NEGUSES", PercentOff: 5, MaxUses: -1}},
+		{{Code: "BADDATE", PercentOff: 5, ExpiresAt: "soon"}},
+	} {
+		if _, err := ValidateCodes(bad); err == nil {
+			t.Errorf("ValidateCodes(%+v) = nil, want an error", bad)
+		}
+	}
+}
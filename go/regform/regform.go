@@ -0,0 +1,199 @@
+// Package regform checks registration form questions set up by admins and
+// the answers drivers give to them.
+package regform
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+	"github.com/nyaruka/phonenumbers"
+)
+
+var validTypes = map[string]bool{
+	dynamo.QuestionText: true, dynamo.QuestionNumber: true, dynamo.QuestionEmail: true,
+	dynamo.QuestionPhone: true, dynamo.QuestionDate: true, dynamo.QuestionSelect: true,
+	dynamo.QuestionCheckbox: true,
+}
+
+var idPattern = regexp.MustCompile(`^[a-z0-9_]{1,40}$`)
+
+// ValidateSchema checks a form's questions: IDs are short, lowercase and
+// unique, every question has a label and a known type, selects have options
+// and patterns compile.
+func ValidateSchema(questions []dynamo.RegistrationQuestion) error {
+	seen := map[string]bool{}
+	for _, q := range questions {
+		if !idPattern.MatchString(q.ID) {
+			return fmt.Errorf("question id %q must be 1-40 lowercase letters, digits or underscores", q.ID)
+		}
+		if seen[q.ID] {
+			return fmt.Errorf("duplicate question id %q", q.ID)
+		}
+		seen[q.ID] = true
+		if strings.TrimSpace(q.Label) == "" {
+			return fmt.Errorf("question %q needs a label", q.ID)
+		}
+		if !validTypes[q.Type] {
+			return fmt.Errorf("question %q: type must be text, number, email, phone, date, select or checkbox", q.ID)
+		}
+		if q.Type == dynamo.QuestionSelect && len(q.Options) == 0 {
+			return fmt.Errorf("question %q: select needs options", q.ID)
+		}
+		if q.MaxLength < 0 {
+			return fmt.Errorf("question %q: max length can't be negative", q.ID)
+		}
+		if q.Pattern != "" {
+			if _, err := regexp.Compile(q.Pattern); err != nil {
+				return fmt.Errorf("question %q: invalid pattern: %w", q.ID, err)
+			}
+		}
+		if q.Min != nil && q.Max != nil && *q.Min > *q.Max {
+			return fmt.Errorf("question %q: min is above max", q.ID)
+		}
+	}
+	return nil
+}
+
+// Validate checks answers against a form and returns them normalised for
+// storage: trimmed, numbers and phone numbers in canonical form, and ticked
+// checkboxes as "true". Blank answers and unticked checkboxes are left out.
+// Required questions are only enforced when requireAll is set, so an admin
+// entering a driver can leave them for the driver to fill in. Every problem
+// is reported in the one error.
+func Validate(questions []dynamo.RegistrationQuestion, answers map[string]any, requireAll bool) (map[string]string, error) {
+	byID := map[string]dynamo.RegistrationQuestion{}
+	for _, q := range questions {
+		byID[q.ID] = q
+	}
+	var problems []string
+	for id := range answers {
+		if _, ok := byID[id]; !ok {
+			problems = append(problems, fmt.Sprintf("unknown question %q", id))
+		}
+	}
+	slices.Sort(problems)
+
+	out := map[string]string{}
+	for _, q := range questions {
+		val, err := normalize(q, answers[q.ID])
+		switch {
+		case err != nil:
+			problems = append(problems, q.Label+" "+err.Error())
+		case val == "" && q.Required && requireAll:
+			if q.Type == dynamo.QuestionCheckbox {
+				problems = append(problems, q.Label+" must be accepted")
+			} else {
+				problems = append(problems, q.Label+" is required")
+			}
+		case val != "":
+			out[q.ID] = val
+		}
+	}
+	if len(problems) > 0 {
+		return nil, errors.New(strings.Join(problems, "; "))
+	}
+	return out, nil
+}
+
+// normalize returns an answer's stored form, or "" when it's blank.
+func normalize(q dynamo.RegistrationQuestion, raw any) (string, error) {
+	if q.Type == dynamo.QuestionCheckbox {
+		switch v := raw.(type) {
+		case nil:
+			return "", nil
+		case bool:
+			if v {
+				return "true", nil
+			}
+			return "", nil
+		case string:
+			v = strings.TrimSpace(v)
+			if v == "" {
+				return "", nil
+			}
+			ticked, err := strconv.ParseBool(v)
+			if err != nil {
+				return "", errors.New("must be true or false")
+			}
+			if ticked {
+				return "true", nil
+			}
+			return "", nil
+		}
+		return "", errors.New("must be true or false")
+	}
+
+	var s string
+	switch v := raw.(type) {
+	case nil:
+		return "", nil
+	case string:
+		s = strings.TrimSpace(v)
+	case float64:
+		if q.Type != dynamo.QuestionNumber {
+			return "", errors.New("must be text")
+		}
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return "", errors.New("must be text")
+	}
+	if s == "" {
+		return "", nil
+	}
+
+	switch q.Type {
+	case dynamo.QuestionText:
+		if q.MaxLength > 0 && len([]rune(s)) > q.MaxLength {
+			return "", fmt.Errorf("must be at most %d characters", q.MaxLength)
+		}
+		if q.Pattern != "" {
+			re, err := regexp.Compile("^(?:" + q.Pattern + ")$")
+			if err != nil || !re.MatchString(s) {
+				return "", errors.New("is not in the expected format")
+			}
+		}
+		return s, nil
+	case dynamo.QuestionNumber:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return "", errors.New("must be a number")
+		}
+		if q.Min != nil && f < *q.Min {
+			return "", fmt.Errorf("must be at least %s", strconv.FormatFloat(*q.Min, 'f', -1, 64))
+		}
+		if q.Max != nil && f > *q.Max {
+			return "", fmt.Errorf("must be at most %s", strconv.FormatFloat(*q.Max, 'f', -1, 64))
+		}
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	case dynamo.QuestionEmail:
+		addr, err := mail.ParseAddress(s)
+		if err != nil || addr.Address != s {
+			return "", errors.New("must be an email address")
+		}
+		return addr.Address, nil
+	case dynamo.QuestionPhone:
+		parsed, err := phonenumbers.Parse(s, "US")
+		if err != nil || !phonenumbers.IsValidNumber(parsed) {
+			return "", errors.New("must be a phone number")
+		}
+		return phonenumbers.Format(parsed, phonenumbers.E164), nil
+	case dynamo.QuestionDate:
+		if _, err := time.Parse(time.DateOnly, s); err != nil {
+			return "", errors.New("must be a date (YYYY-MM-DD)")
+		}
+		return s, nil
+	case dynamo.QuestionSelect:
+		if !slices.Contains(q.Options, s) {
+			return "", fmt.Errorf("must be one of %s", strings.Join(q.Options, ", "))
+		}
+		return s, nil
+	}
+	return "", fmt.Errorf("has unknown type %q", q.Type)
+}
@@ -0,0 +1,103 @@
+package regform
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+)
+
+func ptr(f float64) *float64 { return &f }
+
+var form = []dynamo.RegistrationQuestion{
+	{ID: "transponder", Label: "Transponder", Type: dynamo.QuestionText, Required: true, Pattern: `\d{7}`},
+	{ID: "kart_number", Label: "Kart number", Type: dynamo.QuestionNumber, Min: ptr(1), Max: ptr(999)},
+	{ID: "emergency_phone", Label: "Emergency contact", Type: dynamo.QuestionPhone, Required: true},
+	{ID: "shirt", Label: "T-shirt size", Type: dynamo.QuestionSelect, Options: []string{"S", "M", "L"}},
+	{ID: "waiver", Label: "Waiver", Type: dynamo.QuestionCheckbox, Required: true},
+}
+
+func TestValidate(t *testing.T) {
+	got, err := Validate(form, map[string]any{
+		"transponder":     " 1234567 ",
+		"kart_number":     float64(42),
+		"emergency_phone": "(312) 555-0142",
+		"shirt":           "",
+		"waiver":          true,
+	}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"transponder":     "1234567",
+		"kart_number":     "42",
+		"emergency_phone": "+13125550142",
+		"waiver":          "true",
+	}
+	if len(got) != len(want) {
+		t.Errorf("answers = %v, want %v", got, want)
+	}
+	for id, v := range want {
+		if got[id] != v {
+			t.Errorf("%s = %q, want %q", id, got[id], v)
+		}
+	}
+}
+
+func TestValidateProblems(t *testing.T) {
+	_, err := Validate(form, map[string]any{
+		"transponder": "12ab",
+		"kart_number": "1000",
+		"shirt":       "XL",
+		"waiver":      false,
+		"nickname":    "Speedy",
+	}, true)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{
+		`unknown question "nickname"`,
+		"Transponder is not in the expected format",
+		"Kart number must be at most 999",
+		"Emergency contact is required",
+		"T-shirt size must be one of S, M, L",
+		"Waiver must be accepted",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q missing %q", err, want)
+		}
+	}
+}
+
+func TestValidateRequiredOptional(t *testing.T) {
+	got, err := Validate(form, map[string]any{"shirt": "M"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got["shirt"] != "M" {
+		t.Errorf("answers = %v, want only shirt", got)
+	}
+	if _, err := Validate(form, map[string]any{"shirt": "XXL"}, false); err == nil {
+		t.Error("format is still checked without requireAll")
+	}
+}
+
+func TestValidateSchema(t *testing.T) {
+	if err := ValidateSchema(form); err != nil {
+		t.Fatal(err)
+	}
+	bad := [][]dynamo.RegistrationQuestion{
+		{{ID: "Bad ID", Label: "x", Type: dynamo.QuestionText}},
+		{{ID: "a", Label: "x", Type: dynamo.QuestionText}, {ID: "a", Label: "y", Type: dynamo.QuestionText}},
+		{{ID: "a", Type: dynamo.QuestionText}},
+		{{ID: "a", Label: "x", Type: "color"}},
+		{{ID: "a", Label: "x", Type: dynamo.QuestionSelect}},
+		{{ID: "a", Label: "x", Type: dynamo.QuestionText, Pattern: "("}},
+		{{ID: "a", Label: "x", Type: dynamo.QuestionNumber, Min: ptr(5), Max: ptr(1)}},
+	}
+	for i, qs := range bad {
+		if err := ValidateSchema(qs); err == nil {
+			t.Errorf("case %d: expected an error", i)
+		}
+	}
+}
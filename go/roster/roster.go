@@ -0,0 +1,127 @@
+// Package roster reads driver rosters that admins upload to register a
+// batch of drivers at once.
+package roster
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"strings"
+)
+
+// Row is one driver to register. Line is the row's line in the file, for
+// reporting problems back.
+type Row struct {
+	Line       int    `json:"row"`
+	Name       string `json:"name,omitempty"`
+	Email      string `json:"email"`
+	Class      string `json:"class,omitempty"`
+	KartNumber string `json:"kart_number,omitempty"`
+}
+
+// RowError is a row that can't be imported.
+type RowError struct {
+	Line  int    `json:"row"`
+	Email string `json:"email,omitempty"`
+	Error string `json:"error"`
+}
+
+// columns maps accepted header names to fields. The export's headings are
+// accepted too, so an exported roster can be edited and imported again.
+var columns = map[string]string{
+	"name":        "name",
+	"driver":      "name",
+	"driver name": "name",
+	"email":       "email",
+	"e-mail":      "email",
+	"class":       "class",
+	"kart class":  "class",
+	"kart number": "kart",
+	"kart_number": "kart",
+	"kart #":      "kart",
+	"kart":        "kart",
+	"number":      "kart",
+}
+
+// MaxRows is the most drivers one import takes.
+const MaxRows = 500
+
+// Parse reads a CSV roster with a header row naming its columns: name,
+// email, class and kart number, in any order, of which only email is
+// required. Blank lines are skipped. Rows with a problem are returned as
+// errors and left out; the error return is for a file that can't be read
+// at all.
+func Parse(r io.Reader) ([]Row, []RowError, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, errors.New("the file is empty")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("read header: %w", err)
+	}
+	index := map[string]int{}
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		if field, ok := columns[h]; ok {
+			if _, dup := index[field]; !dup {
+				index[field] = i
+			}
+		}
+	}
+	if _, ok := index["email"]; !ok {
+		return nil, nil, errors.New("the header row needs an email column")
+	}
+	get := func(rec []string, field string) string {
+		i, ok := index[field]
+		if !ok || i >= len(rec) {
+			return ""
+		}
+		return strings.TrimSpace(rec[i])
+	}
+
+	var rows []Row
+	var rowErrs []RowError
+	seen := map[string]int{}
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("read roster: %w", err) // csv errors carry the line
+		}
+		line, _ := cr.FieldPos(0)
+		if strings.TrimSpace(strings.Join(rec, "")) == "" {
+			continue
+		}
+		if len(rows)+len(rowErrs) == MaxRows {
+			return nil, nil, fmt.Errorf("at most %d drivers can be imported at once", MaxRows)
+		}
+
+		row := Row{
+			Line:       line,
+			Name:       get(rec, "name"),
+			Email:      strings.ToLower(get(rec, "email")),
+			Class:      get(rec, "class"),
+			KartNumber: get(rec, "kart"),
+		}
+		switch addr, err := mail.ParseAddress(row.Email); {
+		case row.Email == "":
+			rowErrs = append(rowErrs, RowError{Line: line, Error: "email is required"})
+		case err != nil || addr.Address != row.Email:
+			rowErrs = append(rowErrs, RowError{Line: line, Email: row.Email, Error: "invalid email"})
+		case seen[row.Email] > 0:
+			rowErrs = append(rowErrs, RowError{Line: line, Email: row.Email, Error: fmt.Sprintf("same email as row %d", seen[row.Email])})
+		default:
+			seen[row.Email] = line
+			rows = append(rows, row)
+		}
+	}
+	return rows, rowErrs, nil
+}
@@ -0,0 +1,66 @@
+package roster
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	in := "\ufeffKart Number,Email,Name,Class,Notes\n" +
+		"12, Ada@Example.com ,Ada Driver,Senior,fast\n" +
+		"\n" +
+		",bob@example.com,,,\n" +
+		"7,not-an-email,Cy,,\n" +
+		"8,,Di,,\n" +
+		"9,ada@example.com,Ada Again,,\n" +
+		"3,\"Eve <eve@example.com>\",Eve,,\n" +
+		"4,fay@example.com\n"
+
+	rows, rowErrs, err := Parse(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	wantRows := []Row{
+		{Line: 2, Name: "Ada Driver", Email: "ada@example.com", Class: "Senior", KartNumber: "12"},
+		{Line: 4, Email: "bob@example.com"},
+		{Line: 9, Email: "fay@example.com", KartNumber: "4"},
+	}
+	if !reflect.DeepEqual(rows, wantRows) {
+		t.Errorf("rows = %+v\nwant %+v", rows, wantRows)
+	}
+	wantErrs := []RowError{
+		{Line: 5, Email: "not-an-email", Error: "invalid email"},
+		{Line: 6, Error: "email is required"},
+		{Line: 7, Email: "ada@example.com", Error: "same email as row 2"},
+		{Line: 8, Email: "eve <eve@example.com>", Error: "invalid email"},
+	}
+	if !reflect.DeepEqual(rowErrs, wantErrs) {
+		t.Errorf("errors = %+v\nwant %+v", rowErrs, wantErrs)
+	}
+}
+
+func TestParseExportHeadings(t *testing.T) {
+	rows, _, err := Parse(strings.NewReader("Driver,Email,Status,Class,Kart Number\nAda,ada@example.com,confirmed,Senior,5\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Row{{Line: 2, Name: "Ada", Email: "ada@example.com", Class: "Senior", KartNumber: "5"}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %+v, want %+v", rows, want)
+	}
+}
+
+func TestParseBadFiles(t *testing.T) {
+	for name, in := range map[string]string{
+		"empty":     "",
+		"no email":  "name,class\nAda,Senior\n",
+		"bad quote": "email\n\"ada@example.com\n",
+		"too many":  "email\n" + strings.Repeat("x@example.com\n", MaxRows+1),
+	} {
+		if _, _, err := Parse(strings.NewReader(in)); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package standings
+
+import (
+	"sort"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+)
+
+// Championship methods
+const (
+	ChampSum      = "sum"      // series points totals added up
+	ChampPosition = "position" // points by finishing position in each series
+)
+
+// SeriesTable is one series' standings as input to Aggregate.
+type SeriesTable struct {
+	SeriesID  string
+	ClassID   string
+	Timed     bool
+	Standings []Standing
+	Teams     map[string]TeamRef // uid -> team
+	Chassis   map[string]string  // uid -> chassis manufacturer
+}
+
+// Entry is a row in a championship table: a driver, team or manufacturer.
+type Entry struct {
+	Position int              `json:"position"`
+	Key      string           `json:"key"` // uid, team or chassis name
+	Name     string           `json:"name"`
+	Total    int64            `json:"total"`
+	Series   map[string]int64 `json:"series"` // series ID -> points scored there
+}
+
+// ClassTable is the driver table for one class. Series without a class are
+// grouped under ClassID "".
+type ClassTable struct {
+	ClassID string  `json:"class_id"`
+	Drivers []Entry `json:"drivers"`
+}
+
+// ChampionshipTables is every championship-level table.
+type ChampionshipTables struct {
+	Classes       []ClassTable `json:"classes"`
+	Teams         []Entry      `json:"teams"`
+	Manufacturers []Entry      `json:"manufacturers"`
+}
+
+// Aggregate builds the championship tables from its series standings.
+func Aggregate(cfg dynamo.ChampionshipScoring, tables []SeriesTable) ChampionshipTables {
+	classes := map[string]map[string]*Entry{}
+	var classOrder []string
+	teams := map[string]*Entry{}
+	makers := map[string]*Entry{}
+
+	for _, t := range tables {
+		drivers := classes[t.ClassID]
+		if drivers == nil {
+			drivers = map[string]*Entry{}
+			classes[t.ClassID] = drivers
+			classOrder = append(classOrder, t.ClassID)
+		}
+
+		var teamScores, makerScores []scored
+		for _, s := range t.Standings {
+			pts := seriesPoints(cfg, t, s)
+			add(drivers, s.UID, s.DriverName, t.SeriesID, pts)
+			if team := t.Teams[s.UID]; team.ID != "" {
+				teamScores = append(teamScores, scored{team.ID, team.Name, pts})
+			}
+			if chassis := t.Chassis[s.UID]; chassis != "" {
+				makerScores = append(makerScores, scored{chassis, chassis, pts})
+			}
+		}
+		addBest(teams, t.SeriesID, teamScores, cfg.TeamCountBest)
+		addBest(makers, t.SeriesID, makerScores, cfg.ChassisCountBest)
+	}
+
+	sort.SliceStable(classOrder, func(i, j int) bool {
+		if (classOrder[i] == "") != (classOrder[j] == "") {
+			return classOrder[j] == ""
+		}
+		return classOrder[i] < classOrder[j]
+	})
+	out := ChampionshipTables{Classes: []ClassTable{}}
+	for _, c := range classOrder {
+		out.Classes = append(out.Classes, ClassTable{ClassID: c, Drivers: rank(classes[c])})
+	}
+	out.Teams = rank(teams)
+	out.Manufacturers = rank(makers)
+	return out
+}
+
+// seriesPoints is what a series standing is worth in the championship.
+func seriesPoints(cfg dynamo.ChampionshipScoring, t SeriesTable, s Standing) int64 {
+	if cfg.ChampMethod == ChampPosition || t.Timed {
+		if s.Position >= 1 && s.Position <= len(cfg.ChampPointsScheme) {
+			return int64(cfg.ChampPointsScheme[s.Position-1])
+		}
+		return 0
+	}
+	return s.Total
+}
+
+type scored struct {
+	key    string
+	name   string
+	points int64
+}
+
+func add(entries map[string]*Entry, key, name, seriesID string, pts int64) {
+	e := entries[key]
+	if e == nil {
+		e = &Entry{Key: key, Name: name, Series: map[string]int64{}}
+		entries[key] = e
+	}
+	if name != "" {
+		e.Name = name
+	}
+	e.Total += pts
+	e.Series[seriesID] += pts
+}
+
+// addBest credits each team (or manufacturer) with its best count scores in
+// a series; count 0 credits every score.
+func addBest(entries map[string]*Entry, seriesID string, scores []scored, count int) {
+	sort.SliceStable(scores, func(i, j int) bool { return scores[i].points > scores[j].points })
+	used := map[string]int{}
+	for _, s := range scores {
+		if count > 0 && used[s.key] >= count {
+			continue
+		}
+		used[s.key]++
+		add(entries, s.key, s.name, seriesID, s.points)
+	}
+}
+
+// rank sorts by total, highest first. Entries on the same total share a
+// position.
+func rank(entries map[string]*Entry) []Entry {
+	out := make([]Entry, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, *e)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Total != out[j].Total {
+			return out[i].Total > out[j].Total
+		}
+		if out[i].Name != out[j].Name {
+			return out[i].Name < out[j].Name
+		}
+		return out[i].Key < out[j].Key
+	})
+	for i := range out {
+		out[i].Position = i + 1
+		if i > 0 && out[i].Total == out[i-1].Total {
+			out[i].Position = out[i-1].Position
+		}
+	}
+	return out
+}
@@ -0,0 +1,65 @@
+package standings
+
+import (
+	"testing"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+)
+
+func TestAggregate(t *testing.T) {
+	tables := []SeriesTable{
+		{
+			SeriesID: "sat", ClassID: "senior",
+			Standings: []Standing{
+				{Position: 1, UID: "a", DriverName: "A", Total: 50},
+				{Position: 2, UID: "b", DriverName: "B", Total: 40},
+				{Position: 3, UID: "c", DriverName: "C", Total: 30},
+			},
+			Teams:   map[string]TeamRef{"a": {"red", "Red"}, "b": {"red", "Red"}, "c": {"red", "Red"}},
+			Chassis: map[string]string{"a": "OTK", "b": "CRG", "c": "OTK"},
+		},
+		{
+			SeriesID: "sun", ClassID: "senior",
+			Standings: []Standing{
+				{Position: 1, UID: "b", DriverName: "B", Total: 60},
+				{Position: 2, UID: "d", DriverName: "D", Total: 20},
+			},
+			Teams:   map[string]TeamRef{"d": {"blue", "Blue"}},
+			Chassis: map[string]string{"b": "CRG", "d": "OTK"},
+		},
+		{
+			SeriesID: "kids", ClassID: "junior", Timed: true,
+			Standings: []Standing{{Position: 1, UID: "e", DriverName: "E", Total: 123456}},
+			Teams:     map[string]TeamRef{"e": {"blue", "Blue"}},
+		},
+	}
+	cfg := dynamo.ChampionshipScoring{ChampMethod: ChampSum, ChampPointsScheme: []int{25, 18, 15}, TeamCountBest: 2}
+
+	got := Aggregate(cfg, tables)
+
+	if len(got.Classes) != 2 || got.Classes[0].ClassID != "junior" || got.Classes[1].ClassID != "senior" {
+		t.Fatalf("classes = %+v", got.Classes)
+	}
+	senior := got.Classes[1].Drivers
+	if senior[0].Key != "b" || senior[0].Total != 100 || senior[0].Series["sat"] != 40 {
+		t.Errorf("senior leader = %+v", senior[0])
+	}
+	// Timed series score by position from the championship scheme
+	if junior := got.Classes[0].Drivers; junior[0].Total != 25 {
+		t.Errorf("junior = %+v", junior)
+	}
+
+	// Red counts its best two in "sat" (50+40) plus nothing elsewhere
+	teams := map[string]int64{}
+	for _, e := range got.Teams {
+		teams[e.Name] = e.Total
+	}
+	if teams["Red"] != 90 || teams["Blue"] != 45 {
+		t.Errorf("teams = %v", teams)
+	}
+
+	// Manufacturers count everyone: CRG 40+60, OTK 50+30+20, level on points
+	if m := got.Manufacturers; len(m) != 2 || m[0].Key != "CRG" || m[0].Total != 100 || m[1].Total != 100 || m[1].Position != 1 {
+		t.Errorf("manufacturers = %+v", m)
+	}
+}
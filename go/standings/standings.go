@@ -0,0 +1,374 @@
+// Package standings computes series championship tables from event results
+// according to a ScoringConfig.
+package standings
+
+import (
+	"cmp"
+	"math"
+	"sort"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+	"github.com/BrianLeishman/karttrackpark.com/go/timing"
+)
+
+// Tiebreaker rules understood by Compute.
+const (
+	TiebreakMostWins   = "most_wins"
+	TiebreakBestFinish = "best_finish"
+	TiebreakCountback  = "countback"
+	TiebreakLastResult = "last_result"
+)
+
+// Round is one scored event of a series: every result recorded across the
+// event's sessions, plus the laps driven in them (only needed for total_time).
+type Round struct {
+	Number  int
+	Results []dynamo.Result
+	Laps    []dynamo.Lap
+}
+
+// RoundScore is a driver's score for a single round. Score is points for the
+// points method and milliseconds for the time methods.
+type RoundScore struct {
+	Round    int   `json:"round"`
+	Played   bool  `json:"played"`
+	Score    int64 `json:"score"`
+	Position int   `json:"position,omitempty"`
+	Dropped  bool  `json:"dropped,omitempty"`
+}
+
+// Standing is one driver's row in the series table.
+type Standing struct {
+	Position   int          `json:"position"`
+	UID        string       `json:"uid"`
+	DriverName string       `json:"driver_name"`
+	Total      int64        `json:"total"`
+	Counted    int          `json:"counted"`
+	Wins       int          `json:"wins"`
+	BestFinish int          `json:"best_finish,omitempty"`
+	Rounds     []RoundScore `json:"rounds"`
+}
+
+// IsTimed reports whether a scoring method ranks by time (lower is better)
+// rather than points.
+func IsTimed(method string) bool {
+	return method == "best_time" || method == "total_time"
+}
+
+// ScoringSessions picks the sessions of an event whose results make up its
+// round: those of cfg.ScoringSession's type if set, otherwise the finals, or
+// the heats and races when there is no final. Practice and qualifying only
+// score when asked for, so a round pays out once per driver.
+func ScoringSessions(cfg dynamo.ScoringConfig, sessions []dynamo.EventSession) []dynamo.EventSession {
+	want := func(es dynamo.EventSession) bool { return es.SessionType == cfg.ScoringSession }
+	if cfg.ScoringSession == "" {
+		want = func(es dynamo.EventSession) bool { return timing.IsRace(es.SessionType) }
+		for _, es := range sessions {
+			if es.SessionType == "final" {
+				want = func(es dynamo.EventSession) bool { return es.SessionType == "final" }
+				break
+			}
+		}
+	}
+
+	var out []dynamo.EventSession
+	for _, es := range sessions {
+		if want(es) {
+			out = append(out, es)
+		}
+	}
+	return out
+}
+
+// Compute builds the standings table. Rounds with no results are ignored.
+// The worst cfg.DropRounds rounds are dropped for each driver (missed rounds
+// first), always keeping at least one. Drivers still level after the
+// configured tiebreaker share a position.
+func Compute(cfg dynamo.ScoringConfig, rounds []Round) []Standing {
+	timed := IsTimed(cfg.Method)
+
+	played := make([]Round, 0, len(rounds))
+	for _, r := range rounds {
+		if len(r.Results) > 0 {
+			played = append(played, r)
+		}
+	}
+	sort.SliceStable(played, func(i, j int) bool { return played[i].Number < played[j].Number })
+
+	byUID := map[string]*Standing{}
+	var order []*Standing
+	for i, r := range played {
+		scores := roundScores(cfg, r)
+		for _, rs := range scores {
+			s := byUID[rs.uid]
+			if s == nil {
+				s = &Standing{UID: rs.uid, Rounds: make([]RoundScore, len(played))}
+				for k, p := range played {
+					s.Rounds[k].Round = p.Number
+				}
+				byUID[rs.uid] = s
+				order = append(order, s)
+			}
+			if rs.name != "" {
+				s.DriverName = rs.name
+			}
+			s.Rounds[i].Played = true
+			s.Rounds[i].Score = rs.score
+			s.Rounds[i].Position = rs.pos
+		}
+	}
+
+	for _, s := range order {
+		dropWorst(s, cfg.DropRounds, timed)
+		for _, rs := range s.Rounds {
+			if rs.Played && rs.Position == 1 {
+				s.Wins++
+			}
+			if rs.Played && (s.BestFinish == 0 || rs.Position < s.BestFinish) {
+				s.BestFinish = rs.Position
+			}
+			if rs.Played && !rs.Dropped {
+				s.Total += rs.Score
+				s.Counted++
+			}
+		}
+	}
+
+	compare := func(a, b *Standing) int {
+		if c := compareTotal(a, b, timed); c != 0 {
+			return c
+		}
+		return compareTiebreak(a, b, cfg.Tiebreaker)
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		if c := compare(order[i], order[j]); c != 0 {
+			return c < 0
+		}
+		return order[i].DriverName < order[j].DriverName
+	})
+
+	out := make([]Standing, len(order))
+	for i, s := range order {
+		s.Position = i + 1
+		if i > 0 && compare(order[i-1], s) == 0 {
+			s.Position = out[i-1].Position
+		}
+		out[i] = *s
+	}
+	return out
+}
+
+type driverScore struct {
+	uid   string
+	name  string
+	score int64
+	pos   int
+}
+
+// roundScores scores every driver with a result in the round and ranks them.
+func roundScores(cfg dynamo.ScoringConfig, r Round) []driverScore {
+	byUID := map[string]*driverScore{}
+	var order []*driverScore
+	get := func(uid, name string) *driverScore {
+		d := byUID[uid]
+		if d == nil {
+			d = &driverScore{uid: uid}
+			byUID[uid] = d
+			order = append(order, d)
+		}
+		if name != "" {
+			d.name = name
+		}
+		return d
+	}
+
+	switch cfg.Method {
+	case "best_time":
+		best := lapBests(r.Laps)
+		for _, res := range r.Results {
+			d := get(res.UID, res.DriverName)
+			ms := res.FastestLapMs
+			if ms <= 0 {
+				ms = best[res.UID]
+			}
+			if ms > 0 && (d.score == 0 || ms < d.score) {
+				d.score = ms
+			}
+		}
+	case "total_time":
+		totals := map[string]int64{}
+		for _, l := range r.Laps {
+			totals[l.UID] += l.LapTimeMs
+		}
+		// A driver's laps span all of the round's sessions, so they count
+		// once; each session's result adds its own time penalty.
+		for _, res := range r.Results {
+			d := get(res.UID, res.DriverName)
+			if totals[res.UID] > 0 {
+				d.score += res.TimePenaltyMs
+			}
+		}
+		for uid, ms := range totals {
+			if d := byUID[uid]; d != nil && ms > 0 {
+				d.score += ms
+			}
+		}
+	default:
+		for _, res := range r.Results {
+			get(res.UID, res.DriverName).score += int64(resultPoints(cfg.PointsScheme, res))
+		}
+	}
+
+	timed := IsTimed(cfg.Method)
+	ranked := make([]*driverScore, len(order))
+	copy(ranked, order)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return betterScore(ranked[i].score, ranked[j].score, timed)
+	})
+	for i, d := range ranked {
+		d.pos = i + 1
+		if i > 0 && ranked[i-1].score == d.score {
+			d.pos = ranked[i-1].pos
+		}
+	}
+
+	out := make([]driverScore, len(order))
+	for i, d := range order {
+		out[i] = *d
+	}
+	return out
+}
+
+// resultPoints is a result's points: explicitly awarded points win, otherwise
+// the scheme's value for the finishing position. Non-starters score nothing
+// from the scheme, disqualified drivers nothing at all, and points deductions
+// are taken off whatever is left.
+func resultPoints(scheme []int, r dynamo.Result) int {
+	points := 0
+	switch {
+	case r.Disqualified:
+	case r.Points != 0 || r.Status == "dns":
+		points = r.Points
+	case r.Position >= 1 && r.Position <= len(scheme):
+		points = scheme[r.Position-1]
+	}
+	return points - r.PointsDeduction
+}
+
+func lapBests(laps []dynamo.Lap) map[string]int64 {
+	best := map[string]int64{}
+	for _, l := range laps {
+		if l.LapTimeMs > 0 && (best[l.UID] == 0 || l.LapTimeMs < best[l.UID]) {
+			best[l.UID] = l.LapTimeMs
+		}
+	}
+	return best
+}
+
+// betterScore reports whether a beats b. A time of zero means no time was
+// set and loses to any real time.
+func betterScore(a, b int64, timed bool) bool {
+	if !timed {
+		return a > b
+	}
+	if a == 0 || b == 0 {
+		return a != 0 && b == 0
+	}
+	return a < b
+}
+
+// dropWorst marks a driver's n worst rounds as dropped, missed rounds first.
+func dropWorst(s *Standing, n int, timed bool) {
+	n = min(n, len(s.Rounds)-1)
+	if n <= 0 {
+		return
+	}
+	idx := make([]int, len(s.Rounds))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		a, b := s.Rounds[idx[i]], s.Rounds[idx[j]]
+		if a.Played != b.Played {
+			return !a.Played
+		}
+		return betterScore(b.Score, a.Score, timed)
+	})
+	for _, i := range idx[:n] {
+		s.Rounds[i].Dropped = true
+	}
+}
+
+// compareTotal orders by total. For timed series a driver with more counted
+// rounds ranks ahead, since a missing round has no time to add.
+func compareTotal(a, b *Standing, timed bool) int {
+	if timed {
+		if a.Counted != b.Counted {
+			return b.Counted - a.Counted
+		}
+		return cmp.Compare(a.Total, b.Total)
+	}
+	return cmp.Compare(b.Total, a.Total)
+}
+
+// compareTiebreak returns <0 if a wins the tie, >0 if b does, 0 if still level.
+func compareTiebreak(a, b *Standing, rule string) int {
+	switch rule {
+	case TiebreakMostWins:
+		return b.Wins - a.Wins
+	case TiebreakBestFinish:
+		return finishRank(a.BestFinish) - finishRank(b.BestFinish)
+	case TiebreakCountback:
+		return countback(a, b)
+	case TiebreakLastResult:
+		for i := len(a.Rounds) - 1; i >= 0; i-- {
+			pa, pb := roundRank(a.Rounds[i]), roundRank(b.Rounds[i])
+			if pa != pb {
+				return pa - pb
+			}
+		}
+	}
+	return 0
+}
+
+// countback compares the number of wins, then second places, and so on.
+func countback(a, b *Standing) int {
+	counts := func(s *Standing) map[int]int {
+		m := map[int]int{}
+		for _, rs := range s.Rounds {
+			if rs.Played {
+				m[rs.Position]++
+			}
+		}
+		return m
+	}
+	ca, cb := counts(a), counts(b)
+	worst := 0
+	for p := range ca {
+		worst = max(worst, p)
+	}
+	for p := range cb {
+		worst = max(worst, p)
+	}
+	for p := 1; p <= worst; p++ {
+		if ca[p] != cb[p] {
+			return cb[p] - ca[p]
+		}
+	}
+	return 0
+}
+
+// roundRank is a round position for comparison; not taking part ranks last.
+func roundRank(rs RoundScore) int {
+	if !rs.Played {
+		return math.MaxInt
+	}
+	return finishRank(rs.Position)
+}
+
+func finishRank(pos int) int {
+	if pos <= 0 {
+		return math.MaxInt
+	}
+	return pos
+}
@@ -0,0 +1,175 @@
+package standings
+
+import (
+	"testing"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+)
+
+func res(uid string, pos int) dynamo.Result {
+	return dynamo.Result{UID: uid, DriverName: uid, Position: pos}
+}
+
+func positions(table []Standing) map[string]int {
+	m := map[string]int{}
+	for _, s := range table {
+		m[s.UID] = s.Position
+	}
+	return m
+}
+
+func TestComputePointsAndDrops(t *testing.T) {
+	cfg := dynamo.ScoringConfig{Method: "points", PointsScheme: []int{25, 18, 15}, DropRounds: 1}
+	rounds := []Round{
+		{Number: 1, Results: []dynamo.Result{res("a", 1), res("b", 2), res("c", 3)}},
+		{Number: 2, Results: []dynamo.Result{res("b", 1), res("a", 3)}},
+		{Number: 3, Results: []dynamo.Result{res("a", 2), res("b", 3), res("c", 1)}},
+		{Number: 4}, // not run yet
+	}
+
+	table := Compute(cfg, rounds)
+	if len(table) != 3 {
+		t.Fatalf("got %d rows, want 3", len(table))
+	}
+
+	// a: 25+15+18, drops 15 -> 43. b: 18+25+15, drops 15 -> 43. c: 15+0+25, drops the missed round -> 40.
+	a, b, c := table[0], table[1], table[2]
+	if a.Total != 43 || b.Total != 43 || c.UID != "c" || c.Total != 40 {
+		t.Fatalf("totals = %+v", table)
+	}
+	if !c.Rounds[1].Dropped || c.Rounds[1].Played {
+		t.Errorf("c should drop the missed round 2: %+v", c.Rounds)
+	}
+	if len(a.Rounds) != 3 {
+		t.Errorf("unplayed round should be skipped, got %d rounds", len(a.Rounds))
+	}
+	// No tiebreaker configured: a and b share first.
+	if a.Position != 1 || b.Position != 1 || c.Position != 3 {
+		t.Errorf("positions = %v", positions(table))
+	}
+}
+
+func TestComputeExplicitPoints(t *testing.T) {
+	cfg := dynamo.ScoringConfig{Method: "points", PointsScheme: []int{10, 5, 3}}
+	r := res("a", 2)
+	r.Points = 12 // e.g. bonus for pole and fastest lap
+	dns := res("c", 3)
+	dns.Status = "dns"
+	dsq := res("d", 4)
+	dsq.Points, dsq.Disqualified, dsq.PointsDeduction = 8, true, 2
+	table := Compute(cfg, []Round{{Number: 1, Results: []dynamo.Result{res("b", 1), r, dns, dsq}}})
+	if table[0].UID != "a" || table[0].Total != 12 || table[1].Total != 10 || table[2].Total != 0 || table[3].Total != -2 {
+		t.Fatalf("table = %+v", table)
+	}
+	// Round position follows the round score, not the session finish.
+	if table[0].Rounds[0].Position != 1 || table[0].Wins != 1 {
+		t.Errorf("a should be credited with the round win: %+v", table[0])
+	}
+}
+
+func TestComputeTiebreakers(t *testing.T) {
+	// c leads on 32; a and b both score 30, a with a win and a fourth, b with two seconds.
+	rounds := []Round{
+		{Number: 1, Results: []dynamo.Result{res("a", 1), res("b", 2), res("c", 3), res("d", 4)}},
+		{Number: 2, Results: []dynamo.Result{res("c", 1), res("b", 2), res("d", 3), res("a", 4)}},
+	}
+	scheme := []int{20, 15, 12, 10}
+
+	tests := []struct {
+		rule   string
+		second string
+	}{
+		{TiebreakMostWins, "a"},
+		{TiebreakBestFinish, "a"},
+		{TiebreakCountback, "a"},
+		{TiebreakLastResult, "b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			cfg := dynamo.ScoringConfig{Method: "points", PointsScheme: scheme, Tiebreaker: tt.rule}
+			table := Compute(cfg, rounds)
+			if table[0].UID != "c" || table[1].UID != tt.second {
+				t.Fatalf("table = %+v", table)
+			}
+			if table[1].Total != 30 || table[1].Position != 2 || table[2].Position != 3 {
+				t.Fatalf("tie not broken: %v", positions(table))
+			}
+		})
+	}
+}
+
+func TestComputeBestTime(t *testing.T) {
+	cfg := dynamo.ScoringConfig{Method: "best_time"}
+	r1 := []dynamo.Result{
+		{UID: "a", Position: 1, FastestLapMs: 42000},
+		{UID: "b", Position: 2}, // falls back to laps
+	}
+	laps := []dynamo.Lap{{UID: "b", LapTimeMs: 41900}, {UID: "b", LapTimeMs: 43000}}
+	r2 := []dynamo.Result{{UID: "a", Position: 1, FastestLapMs: 41000}}
+
+	table := Compute(cfg, []Round{{Number: 1, Results: r1, Laps: laps}, {Number: 2, Results: r2}})
+	if table[0].UID != "a" || table[0].Total != 83000 || table[0].Counted != 2 {
+		t.Fatalf("a = %+v", table[0])
+	}
+	// b has the faster round 1 but missed round 2, so ranks behind.
+	if table[1].UID != "b" || table[1].Total != 41900 || table[1].Rounds[0].Position != 1 {
+		t.Fatalf("b = %+v", table[1])
+	}
+}
+
+func TestComputeTotalTimeHeats(t *testing.T) {
+	cfg := dynamo.ScoringConfig{Method: "total_time"}
+	// Two heats in the round: a is quicker overall, b picks up a penalty in
+	// heat 2.
+	results := []dynamo.Result{
+		{SessionID: "h1", UID: "a", Position: 1}, {SessionID: "h1", UID: "b", Position: 2},
+		{SessionID: "h2", UID: "a", Position: 2}, {SessionID: "h2", UID: "b", Position: 1, TimePenaltyMs: 5000},
+	}
+	laps := []dynamo.Lap{
+		{SessionID: "h1", UID: "a", LapTimeMs: 40000}, {SessionID: "h1", UID: "b", LapTimeMs: 41000},
+		{SessionID: "h2", UID: "a", LapTimeMs: 42000}, {SessionID: "h2", UID: "b", LapTimeMs: 40000},
+	}
+
+	table := Compute(cfg, []Round{{Number: 1, Results: results, Laps: laps}})
+	if table[0].UID != "a" || table[0].Total != 82000 {
+		t.Fatalf("a = %+v, want first on 82000", table[0])
+	}
+	if table[1].UID != "b" || table[1].Total != 86000 {
+		t.Fatalf("b = %+v, want second on 81000 plus the 5000 penalty", table[1])
+	}
+}
+
+func TestScoringSessions(t *testing.T) {
+	cfg := dynamo.ScoringConfig{Method: "points", PointsScheme: []int{25, 18}}
+	sessions := []dynamo.EventSession{
+		{SessionID: "p", SessionType: "practice"},
+		{SessionID: "r", SessionType: "race"},
+	}
+	results := map[string][]dynamo.Result{
+		"p": {res("b", 1), res("a", 2)},
+		"r": {res("a", 1), res("b", 2)},
+	}
+
+	round := Round{Number: 1}
+	for _, es := range ScoringSessions(cfg, sessions) {
+		round.Results = append(round.Results, results[es.SessionID]...)
+	}
+	table := Compute(cfg, []Round{round})
+	if len(table) != 2 || table[0].UID != "a" || table[0].Total != 25 || table[1].Total != 18 {
+		t.Errorf("table = %+v, want only the race scored: a 25, b 18", table)
+	}
+
+	// Heats feed the final, which is the only one that scores
+	got := ScoringSessions(cfg, append(sessions,
+		dynamo.EventSession{SessionID: "h1", SessionType: "heat"},
+		dynamo.EventSession{SessionID: "f", SessionType: "final"},
+	))
+	if len(got) != 1 || got[0].SessionID != "f" {
+		t.Errorf("with a final, scoring sessions = %+v, want just the final", got)
+	}
+
+	cfg.ScoringSession = "quali"
+	if got := ScoringSessions(cfg, append(sessions, dynamo.EventSession{SessionID: "q", SessionType: "quali"})); len(got) != 1 || got[0].SessionID != "q" {
+		t.Errorf("configured quali, scoring sessions = %+v, want just qualifying", got)
+	}
+}
@@ -0,0 +1,98 @@
+package standings
+
+import (
+	"sort"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+)
+
+// TeamRef is the team a driver races for.
+type TeamRef struct {
+	ID   string
+	Name string
+}
+
+// TeamRound is a team's score for one round and the drivers who made it.
+type TeamRound struct {
+	Round   int      `json:"round"`
+	Score   int64    `json:"score"`
+	Drivers []string `json:"drivers,omitempty"`
+}
+
+// TeamStanding is one team's row in a series team table.
+type TeamStanding struct {
+	Position int         `json:"position"`
+	TeamID   string      `json:"team_id"`
+	TeamName string      `json:"team_name"`
+	Total    int64       `json:"total"`
+	Rounds   []TeamRound `json:"rounds"`
+}
+
+// ComputeTeams builds the team table from a driver table: each round a team
+// scores the points of its best cfg.TeamCountBest drivers (all of them when
+// 0). Dropped rounds still count for the team. Only points methods have a
+// team table; timed methods return nil. Teams on the same total share a
+// position.
+func ComputeTeams(cfg dynamo.ScoringConfig, table []Standing, teams map[string]TeamRef) []TeamStanding {
+	if IsTimed(cfg.Method) || len(table) == 0 {
+		return nil
+	}
+	nRounds := len(table[0].Rounds)
+
+	type member struct {
+		uid   string
+		score int64
+	}
+	byTeam := map[string]*TeamStanding{}
+	perRound := map[string][][]member{}
+	var order []*TeamStanding
+	for _, s := range table {
+		ref, ok := teams[s.UID]
+		if !ok || ref.ID == "" {
+			continue
+		}
+		ts := byTeam[ref.ID]
+		if ts == nil {
+			ts = &TeamStanding{TeamID: ref.ID, TeamName: ref.Name, Rounds: make([]TeamRound, nRounds)}
+			byTeam[ref.ID] = ts
+			perRound[ref.ID] = make([][]member, nRounds)
+			order = append(order, ts)
+		}
+		for k, rs := range s.Rounds {
+			ts.Rounds[k].Round = rs.Round
+			if rs.Played {
+				perRound[ref.ID][k] = append(perRound[ref.ID][k], member{s.UID, rs.Score})
+			}
+		}
+	}
+
+	for _, ts := range order {
+		for k, members := range perRound[ts.TeamID] {
+			sort.SliceStable(members, func(i, j int) bool { return members[i].score > members[j].score })
+			if cfg.TeamCountBest > 0 && len(members) > cfg.TeamCountBest {
+				members = members[:cfg.TeamCountBest]
+			}
+			for _, m := range members {
+				ts.Rounds[k].Score += m.score
+				ts.Rounds[k].Drivers = append(ts.Rounds[k].Drivers, m.uid)
+			}
+			ts.Total += ts.Rounds[k].Score
+		}
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		if order[i].Total != order[j].Total {
+			return order[i].Total > order[j].Total
+		}
+		return order[i].TeamName < order[j].TeamName
+	})
+	out := make([]TeamStanding, len(order))
+	for i, ts := range order {
+		ts.Position = i + 1
+		if i > 0 && out[i-1].Total == ts.Total {
+			ts.Position = out[i-1].Position
+		}
+		out[i] = *ts
+	}
+	return out
+}
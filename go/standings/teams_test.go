@@ -0,0 +1,44 @@
+package standings
+
+import (
+	"testing"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+)
+
+func TestComputeTeams(t *testing.T) {
+	rounds := func(scores ...int64) []RoundScore {
+		out := make([]RoundScore, len(scores))
+		for i, s := range scores {
+			out[i] = RoundScore{Round: i + 1, Played: s >= 0, Score: max(s, 0)}
+		}
+		return out
+	}
+	table := []Standing{
+		{UID: "a", Rounds: rounds(25, 18)},
+		{UID: "b", Rounds: rounds(18, 25)},
+		{UID: "c", Rounds: rounds(15, -1)},
+		{UID: "d", Rounds: rounds(12, 15)},
+		{UID: "e", Rounds: rounds(10, 12)},
+	}
+	teams := map[string]TeamRef{
+		"a": {"red", "Red"}, "c": {"red", "Red"}, "d": {"red", "Red"},
+		"b": {"blue", "Blue"}, "e": {"blue", "Blue"},
+	}
+
+	got := ComputeTeams(dynamo.ScoringConfig{Method: "points", TeamCountBest: 2}, table, teams)
+	if len(got) != 2 {
+		t.Fatalf("got %d teams", len(got))
+	}
+	// Red: (25+15) + (18+15) = 73; Blue: (18+10) + (25+12) = 65
+	if got[0].TeamID != "red" || got[0].Total != 73 || got[1].Total != 65 {
+		t.Errorf("teams = %+v", got)
+	}
+	if d := got[0].Rounds[0].Drivers; len(d) != 2 || d[0] != "a" || d[1] != "c" {
+		t.Errorf("red round 1 drivers = %v", d)
+	}
+
+	if ComputeTeams(dynamo.ScoringConfig{Method: "best_time"}, table, teams) != nil {
+		t.Error("timed methods should have no team table")
+	}
+}
@@ -0,0 +1,141 @@
+package timing
+
+import (
+	"sort"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+)
+
+// IsRace reports whether a session type is ordered by laps completed rather
+// than best lap (mirrors isRaceType in the frontend).
+func IsRace(sessionType string) bool {
+	return sessionType == "heat" || sessionType == "race" || sessionType == "final"
+}
+
+// Position is one driver's row on the timing board.
+type Position struct {
+	Pos           int    `json:"pos"`
+	UID           string `json:"uid"`
+	DriverName    string `json:"driver_name,omitempty"`
+	KartID        string `json:"kart_id,omitempty"`
+	Laps          int    `json:"laps"`
+	LastLapMs     int64  `json:"last_lap_ms,omitempty"`
+	BestLapMs     int64  `json:"best_lap_ms,omitempty"`
+	TotalMs       int64  `json:"total_ms,omitempty"`
+	GapMs         int64  `json:"gap_ms,omitempty"`
+	GapLaps       int    `json:"gap_laps,omitempty"`
+	IntervalMs    int64  `json:"interval_ms,omitempty"`
+	IntervalLaps  int    `json:"interval_laps,omitempty"`
+	LastCrossedAt int64  `json:"last_crossed_at_ms,omitempty"`
+}
+
+// BestLap is the fastest lap of the session so far.
+type BestLap struct {
+	UID        string `json:"uid"`
+	DriverName string `json:"driver_name,omitempty"`
+	LapNo      int    `json:"lap_no"`
+	LapTimeMs  int64  `json:"lap_time_ms"`
+}
+
+type driverLaps struct {
+	pos Position
+	// at[n] is when lap n+1 ended: decoder time when known, otherwise the
+	// running total of lap times.
+	at []int64
+}
+
+// Board orders drivers for a timing screen. Races rank by laps completed and
+// then by who crossed the line first; other sessions rank by best lap. Gaps
+// are to the leader and intervals to the car ahead; when a driver is laps
+// down, the time gap is measured at the same lap count.
+func Board(laps []dynamo.Lap, race bool, names map[string]string) ([]Position, *BestLap) {
+	sorted := make([]dynamo.Lap, len(laps))
+	copy(sorted, laps)
+	SortLaps(sorted)
+
+	byUID := map[string]*driverLaps{}
+	var order []*driverLaps
+	var best *BestLap
+	for _, l := range sorted {
+		d := byUID[l.UID]
+		if d == nil {
+			d = &driverLaps{pos: Position{UID: l.UID, DriverName: names[l.UID]}}
+			byUID[l.UID] = d
+			order = append(order, d)
+		}
+		p := &d.pos
+		p.Laps++
+		p.LastLapMs = l.LapTimeMs
+		p.TotalMs += l.LapTimeMs
+		if l.KartID != "" {
+			p.KartID = l.KartID
+		}
+		if p.BestLapMs == 0 || l.LapTimeMs < p.BestLapMs {
+			p.BestLapMs = l.LapTimeMs
+		}
+		if l.CrossedAtMs > 0 {
+			p.LastCrossedAt = l.CrossedAtMs
+			d.at = append(d.at, l.CrossedAtMs)
+		} else {
+			d.at = append(d.at, p.TotalMs)
+		}
+		if l.LapTimeMs > 0 && (best == nil || l.LapTimeMs < best.LapTimeMs) {
+			best = &BestLap{UID: l.UID, DriverName: names[l.UID], LapNo: l.LapNo, LapTimeMs: l.LapTimeMs}
+		}
+	}
+
+	if race {
+		sort.SliceStable(order, func(i, j int) bool {
+			a, b := order[i], order[j]
+			if a.pos.Laps != b.pos.Laps {
+				return a.pos.Laps > b.pos.Laps
+			}
+			return a.at[len(a.at)-1] < b.at[len(b.at)-1]
+		})
+	} else {
+		sort.SliceStable(order, func(i, j int) bool {
+			return order[i].pos.BestLapMs < order[j].pos.BestLapMs
+		})
+	}
+
+	out := make([]Position, len(order))
+	for i, d := range order {
+		p := d.pos
+		p.Pos = i + 1
+		if i > 0 {
+			if race {
+				p.GapMs, p.GapLaps = raceGap(order[0], d)
+				p.IntervalMs, p.IntervalLaps = raceGap(order[i-1], d)
+			} else {
+				p.GapMs = p.BestLapMs - order[0].pos.BestLapMs
+				p.IntervalMs = p.BestLapMs - order[i-1].pos.BestLapMs
+			}
+		}
+		out[i] = p
+	}
+	return out, best
+}
+
+// raceGap is how far d is behind ahead, compared at d's lap count.
+func raceGap(ahead, d *driverLaps) (int64, int) {
+	n := len(d.at)
+	return d.at[n-1] - ahead.at[n-1], len(ahead.at) - n
+}
+
+// SortLaps orders laps as they were completed: by decoder crossing time when
+// known, then creation time, then driver and lap number.
+func SortLaps(laps []dynamo.Lap) {
+	sort.SliceStable(laps, func(i, j int) bool {
+		a, b := laps[i], laps[j]
+		if a.CrossedAtMs != b.CrossedAtMs {
+			return a.CrossedAtMs < b.CrossedAtMs
+		}
+		if a.CreatedAt != b.CreatedAt {
+			return a.CreatedAt < b.CreatedAt
+		}
+		if a.UID != b.UID {
+			return a.UID < b.UID
+		}
+		return a.LapNo < b.LapNo
+	})
+}
@@ -0,0 +1,64 @@
+package timing
+
+import (
+	"testing"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+)
+
+func crossing(uid string, lapNo int, lapMs, atMs int64) dynamo.Lap {
+	return dynamo.Lap{UID: uid, LapNo: lapNo, LapTimeMs: lapMs, CrossedAtMs: atMs}
+}
+
+func TestBoardRace(t *testing.T) {
+	laps := []dynamo.Lap{
+		crossing("a", 1, 40000, 40000),
+		crossing("b", 1, 41000, 41000),
+		crossing("c", 1, 45000, 45000),
+		crossing("a", 2, 40000, 80000),
+		crossing("b", 2, 40500, 81500),
+		crossing("a", 3, 39000, 119000),
+	}
+
+	board, best := Board(laps, true, map[string]string{"a": "Alice"})
+	if len(board) != 3 {
+		t.Fatalf("got %d positions", len(board))
+	}
+	if board[0].UID != "a" || board[1].UID != "b" || board[2].UID != "c" {
+		t.Fatalf("order = %s %s %s", board[0].UID, board[1].UID, board[2].UID)
+	}
+	if board[0].DriverName != "Alice" || board[0].Laps != 3 {
+		t.Errorf("leader = %+v", board[0])
+	}
+	// b is a lap down; at lap 2 b crossed 1.5s after a
+	if board[1].GapMs != 1500 || board[1].GapLaps != 1 {
+		t.Errorf("b gap = %d ms, %d laps", board[1].GapMs, board[1].GapLaps)
+	}
+	// c is two laps down on a, one on b; at lap 1 c was 4s behind b
+	if board[2].IntervalMs != 4000 || board[2].IntervalLaps != 1 || board[2].GapLaps != 2 {
+		t.Errorf("c = %+v", board[2])
+	}
+	if best == nil || best.UID != "a" || best.LapNo != 3 || best.LapTimeMs != 39000 {
+		t.Errorf("best = %+v", best)
+	}
+}
+
+func TestBoardBestLap(t *testing.T) {
+	laps := []dynamo.Lap{
+		{UID: "a", LapNo: 1, LapTimeMs: 41000},
+		{UID: "a", LapNo: 2, LapTimeMs: 40200},
+		{UID: "b", LapNo: 1, LapTimeMs: 40000},
+		{UID: "c", LapNo: 1, LapTimeMs: 40900},
+	}
+
+	board, _ := Board(laps, false, nil)
+	if board[0].UID != "b" || board[1].UID != "a" || board[2].UID != "c" {
+		t.Fatalf("order = %s %s %s", board[0].UID, board[1].UID, board[2].UID)
+	}
+	if board[1].GapMs != 200 || board[2].GapMs != 900 || board[2].IntervalMs != 700 {
+		t.Errorf("gaps = %+v", board)
+	}
+	if board[1].TotalMs != 81200 {
+		t.Errorf("total = %d", board[1].TotalMs)
+	}
+}
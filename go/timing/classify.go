@@ -0,0 +1,89 @@
+package timing
+
+import "sort"
+
+// Finishing statuses for drivers who are not classified as finishers.
+const (
+	StatusDNF = "dnf"
+	StatusDNS = "dns"
+)
+
+// DefaultMinLapPct is the share of the winner's laps a driver must complete
+// in a race to be classified as a finisher.
+const DefaultMinLapPct = 75
+
+// Classified is a driver's provisional finishing position.
+type Classified struct {
+	Pos        int    `json:"position"`
+	UID        string `json:"uid"`
+	DriverName string `json:"driver_name,omitempty"`
+	KartID     string `json:"kart_id,omitempty"`
+	Laps       int    `json:"laps"`
+	TotalMs    int64  `json:"total_ms,omitempty"`
+	BestLapMs  int64  `json:"best_lap_ms,omitempty"`
+	GapMs      int64  `json:"gap_ms,omitempty"`
+	GapLaps    int    `json:"gap_laps,omitempty"`
+	Status     string `json:"status,omitempty"`
+}
+
+// Classify turns a timing board into a finishing order. In a race, drivers who
+// completed fewer than minLapPct percent of the winner's laps are DNF and
+// placed after the finishers, keeping board order. Entrants (uid -> name) who
+// never completed a lap are DNS and placed last, by name.
+func Classify(board []Position, race bool, entrants map[string]string, minLapPct int) []Classified {
+	if minLapPct <= 0 {
+		minLapPct = DefaultMinLapPct
+	}
+
+	minLaps := 0
+	if race && len(board) > 0 {
+		// Round up: 75% of 10 laps is 8 laps, not 7
+		minLaps = (board[0].Laps*minLapPct + 99) / 100
+	}
+
+	var finished, dnf, dns []Classified
+	seen := map[string]bool{}
+	for _, p := range board {
+		seen[p.UID] = true
+		c := Classified{
+			UID:        p.UID,
+			DriverName: p.DriverName,
+			KartID:     p.KartID,
+			Laps:       p.Laps,
+			TotalMs:    p.TotalMs,
+			BestLapMs:  p.BestLapMs,
+			GapMs:      p.GapMs,
+			GapLaps:    p.GapLaps,
+		}
+		if c.DriverName == "" {
+			c.DriverName = entrants[p.UID]
+		}
+		if race && p.Laps < minLaps {
+			c.Status = StatusDNF
+			dnf = append(dnf, c)
+			continue
+		}
+		finished = append(finished, c)
+	}
+
+	for uid, name := range entrants {
+		if !seen[uid] {
+			dns = append(dns, Classified{UID: uid, DriverName: name, Status: StatusDNS})
+		}
+	}
+	sort.Slice(dns, func(i, j int) bool {
+		if dns[i].DriverName != dns[j].DriverName {
+			return dns[i].DriverName < dns[j].DriverName
+		}
+		return dns[i].UID < dns[j].UID
+	})
+
+	out := make([]Classified, 0, len(finished)+len(dnf)+len(dns))
+	out = append(out, finished...)
+	out = append(out, dnf...)
+	out = append(out, dns...)
+	for i := range out {
+		out[i].Pos = i + 1
+	}
+	return out
+}
@@ -0,0 +1,47 @@
+package timing
+
+import "testing"
+
+func TestClassifyRace(t *testing.T) {
+	board := []Position{
+		{UID: "a", Laps: 10, TotalMs: 400000},
+		{UID: "b", Laps: 10, TotalMs: 401000, GapMs: 1000},
+		{UID: "c", Laps: 8, TotalMs: 330000},
+		{UID: "d", Laps: 7, TotalMs: 290000},
+	}
+	entrants := map[string]string{"a": "Alice", "b": "Bob", "c": "Cy", "d": "Dee", "z": "Zed", "e": "Eve"}
+
+	got := Classify(board, true, entrants, 0)
+	want := []struct {
+		uid    string
+		status string
+	}{
+		{"a", ""}, {"b", ""}, {"c", ""}, // 8 laps is exactly 75% of 10
+		{"d", StatusDNF},
+		{"e", StatusDNS}, {"z", StatusDNS},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		if got[i].UID != w.uid || got[i].Status != w.status || got[i].Pos != i+1 {
+			t.Errorf("row %d = %+v, want %s %q", i, got[i], w.uid, w.status)
+		}
+	}
+	if got[0].DriverName != "Alice" || got[1].GapMs != 1000 {
+		t.Errorf("board fields not carried over: %+v", got[:2])
+	}
+}
+
+func TestClassifyBestLap(t *testing.T) {
+	board := []Position{
+		{UID: "a", Laps: 6, BestLapMs: 40000},
+		{UID: "b", Laps: 1, BestLapMs: 40500},
+	}
+
+	// Lap counts don't matter outside races
+	got := Classify(board, false, nil, 0)
+	if len(got) != 2 || got[1].UID != "b" || got[1].Status != "" {
+		t.Fatalf("got %+v", got)
+	}
+}
@@ -0,0 +1,74 @@
+// Package timing turns raw loop crossings into laps.
+package timing
+
+import "time"
+
+// DefaultMinLap is the shortest plausible lap. Crossings closer together are
+// treated as duplicate reads of the same pass.
+const DefaultMinLap = 10 * time.Second
+
+// Lap is a completed lap derived from two consecutive crossings.
+type Lap struct {
+	Key       string
+	LapNo     int
+	LapTimeMs int64
+	CrossedAt time.Time
+}
+
+// Tracker remembers each competitor's last crossing. The first crossing only
+// starts the clock; every later one completes a lap.
+type Tracker struct {
+	MinLap time.Duration
+	// MaxLap, if set, restarts the clock instead of recording a lap when the
+	// gap is longer (driver sat in the pits, bridge restarted between runs).
+	MaxLap time.Duration
+	last   map[string]time.Time
+	laps   map[string]int
+}
+
+func NewTracker(minLap time.Duration) *Tracker {
+	if minLap <= 0 {
+		minLap = DefaultMinLap
+	}
+	return &Tracker{
+		MinLap: minLap,
+		last:   make(map[string]time.Time),
+		laps:   make(map[string]int),
+	}
+}
+
+// Resume seeds a competitor's state, e.g. after the bridge restarts mid-session.
+func (t *Tracker) Resume(key string, lapNo int, lastCrossing time.Time) {
+	t.laps[key] = lapNo
+	if !lastCrossing.IsZero() {
+		t.last[key] = lastCrossing
+	}
+}
+
+// Pass records a crossing. ok is false when no lap was completed: the first
+// crossing, a duplicate within MinLap, a crossing older than the last one, or
+// a gap beyond MaxLap.
+func (t *Tracker) Pass(key string, at time.Time) (Lap, bool) {
+	prev, seen := t.last[key]
+	if !seen {
+		t.last[key] = at
+		return Lap{}, false
+	}
+
+	d := at.Sub(prev)
+	if d < t.MinLap {
+		return Lap{}, false
+	}
+
+	t.last[key] = at
+	if t.MaxLap > 0 && d > t.MaxLap {
+		return Lap{}, false
+	}
+	t.laps[key]++
+	return Lap{
+		Key:       key,
+		LapNo:     t.laps[key],
+		LapTimeMs: d.Milliseconds(),
+		CrossedAt: at,
+	}, true
+}
@@ -0,0 +1,52 @@
+package timing
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTracker(t *testing.T) {
+	base := time.Date(2026, 5, 1, 14, 0, 0, 0, time.UTC)
+	tr := NewTracker(0)
+
+	if _, ok := tr.Pass("a", base); ok {
+		t.Fatal("first crossing should not complete a lap")
+	}
+	if _, ok := tr.Pass("a", base.Add(200*time.Millisecond)); ok {
+		t.Fatal("duplicate read should be ignored")
+	}
+
+	lap, ok := tr.Pass("a", base.Add(42500*time.Millisecond))
+	if !ok || lap.LapNo != 1 || lap.LapTimeMs != 42500 {
+		t.Fatalf("lap 1 = %+v, %v", lap, ok)
+	}
+
+	lap, ok = tr.Pass("a", base.Add(84*time.Second))
+	if !ok || lap.LapNo != 2 || lap.LapTimeMs != 41500 {
+		t.Fatalf("lap 2 = %+v, %v", lap, ok)
+	}
+
+	if _, ok := tr.Pass("a", base.Add(30*time.Second)); ok {
+		t.Fatal("out-of-order crossing should be ignored")
+	}
+}
+
+func TestTrackerResume(t *testing.T) {
+	base := time.Date(2026, 5, 1, 14, 0, 0, 0, time.UTC)
+	tr := NewTracker(time.Second)
+	tr.Resume("a", 7, base)
+
+	lap, ok := tr.Pass("a", base.Add(40*time.Second))
+	if !ok || lap.LapNo != 8 {
+		t.Fatalf("lap = %+v, %v", lap, ok)
+	}
+
+	tr.MaxLap = 5 * time.Minute
+	if _, ok := tr.Pass("a", base.Add(20*time.Minute)); ok {
+		t.Fatal("gap beyond MaxLap should restart the clock")
+	}
+	lap, ok = tr.Pass("a", base.Add(20*time.Minute+41*time.Second))
+	if !ok || lap.LapNo != 9 || lap.LapTimeMs != 41000 {
+		t.Fatalf("lap after restart = %+v, %v", lap, ok)
+	}
+}
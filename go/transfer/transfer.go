@@ -0,0 +1,70 @@
+// Package transfer works out how a registration changes hands: handed to
+// another driver, or swapped with another driver's between two sessions of
+// the same event.
+package transfer
+
+import (
+	"errors"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+)
+
+var (
+	ErrNotTransferable = errors.New("only confirmed or pending registrations can be transferred")
+	ErrSameDriver      = errors.New("can't transfer a registration to its own driver")
+	ErrSameSession     = errors.New("both registrations are for the same session")
+	ErrOtherEvent      = errors.New("swaps are only between sessions of the same event")
+)
+
+// Transferable reports whether a registration in this status holds a spot
+// its driver can hand on. Waitlist places and spot offers stay with the
+// driver they were given to.
+func Transferable(status string) bool {
+	return status == "confirmed" || status == "pending"
+}
+
+// Moved is reg handed to another driver. The spot, its price and whether it
+// was paid carry over; what belongs to the old driver, like their answers,
+// weight, team and kart number, doesn't. An unpaid checkout was opened for
+// the old driver, so the new one starts a fresh one.
+func Moved(reg dynamo.Registration, uid, driverName, email string) dynamo.Registration {
+	reg.UID = uid
+	reg.DriverName = driverName
+	reg.Email = email
+	reg.Answers = nil
+	reg.WeightKg = 0
+	reg.KartNumber = ""
+	reg.TeamID = ""
+	reg.TeamName = ""
+	reg.InvitedBy = ""
+	reg.ViaSeries = ""
+	reg.Standings = nil
+	if !reg.Paid {
+		reg.CheckoutID = ""
+		reg.CheckoutURL = ""
+	}
+	return reg
+}
+
+// Swapped is the pair of session registrations after a and b trade
+// sessions. Each driver keeps everything else about their registration.
+func Swapped(a, b dynamo.Registration) (dynamo.Registration, dynamo.Registration) {
+	a.ParentID, b.ParentID = b.ParentID, a.ParentID
+	return a, b
+}
+
+// CheckSwap reports why two session registrations can't be swapped, given
+// the event each session belongs to, or nil if they can.
+func CheckSwap(a, b dynamo.Registration, eventA, eventB string) error {
+	switch {
+	case a.UID == b.UID:
+		return ErrSameDriver
+	case a.ParentID == b.ParentID:
+		return ErrSameSession
+	case eventA == "" || eventA != eventB:
+		return ErrOtherEvent
+	case !Transferable(a.Status) || !Transferable(b.Status):
+		return ErrNotTransferable
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package transfer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+)
+
+func TestTransferable(t *testing.T) {
+	for status, want := range map[string]bool{
+		"confirmed":  true,
+		"pending":    true,
+		"offered":    false,
+		"waitlisted": false,
+		"invited":    false,
+	} {
+		if got := Transferable(status); got != want {
+			t.Errorf("Transferable(%q) = %v, want %v", status, got, want)
+		}
+	}
+}
+
+func TestMoved(t *testing.T) {
+	reg := dynamo.Registration{
+		ParentType: "event", ParentID: "e1", TrackID: "t1",
+		UID: "a", DriverName: "Ada", Email: "ada@example.com",
+		ClassID: "c1", TeamID: "tm1", TeamName: "Fast", WeightKg: 80, KartNumber: "7",
+		Answers: map[string]string{"transponder": "123"},
+		Status:  "confirmed", Paid: true, PriceCents: 4000, PriceRule: "early_bird", PaymentID: "pay_1",
+		CheckoutID: "co_1", ViaSeries: "s1", RegisteredAt: "2026-04-01T00:00:00Z",
+	}
+	got := Moved(reg, "b", "Bo", "bo@example.com")
+	if got.UID != "b" || got.DriverName != "Bo" || got.Email != "bo@example.com" {
+		t.Errorf("driver = %s %s %s, want b Bo bo@example.com", got.UID, got.DriverName, got.Email)
+	}
+	if !got.Paid || got.PriceCents != 4000 || got.PriceRule != "early_bird" || got.PaymentID != "pay_1" || got.CheckoutID != "co_1" {
+		t.Errorf("payment not carried over: %+v", got)
+	}
+	if got.Status != "confirmed" || got.ClassID != "c1" || got.RegisteredAt != reg.RegisteredAt {
+		t.Errorf("spot not carried over: %+v", got)
+	}
+	if got.Answers != nil || got.WeightKg != 0 || got.KartNumber != "" || got.TeamID != "" || got.ViaSeries != "" {
+		t.Errorf("old driver's details kept: %+v", got)
+	}
+	if reg.UID != "a" || reg.Answers == nil {
+		t.Error("Moved changed the original")
+	}
+
+	reg.Paid = false
+	reg.CheckoutURL = "https://pay.example.com/co_1"
+	if got := Moved(reg, "b", "Bo", ""); got.CheckoutID != "" || got.CheckoutURL != "" {
+		t.Errorf("unpaid checkout kept: %s %s", got.CheckoutID, got.CheckoutURL)
+	}
+}
+
+func TestSwap(t *testing.T) {
+	a := dynamo.Registration{ParentType: "session", ParentID: "s1", UID: "a", Status: "confirmed", Paid: true, KartNumber: "7"}
+	b := dynamo.Registration{ParentType: "session", ParentID: "s2", UID: "b", Status: "pending"}
+
+	gotA, gotB := Swapped(a, b)
+	if gotA.ParentID != "s2" || gotA.UID != "a" || !gotA.Paid || gotA.KartNumber != "7" {
+		t.Errorf("a after swap = %+v, want a in s2, still paid", gotA)
+	}
+	if gotB.ParentID != "s1" || gotB.UID != "b" || gotB.Status != "pending" {
+		t.Errorf("b after swap = %+v, want b in s1", gotB)
+	}
+
+	tests := []struct {
+		name           string
+		a, b           dynamo.Registration
+		eventA, eventB string
+		want           error
+	}{
+		{"ok", a, b, "e1", "e1", nil},
+		{"same driver", a, dynamo.Registration{ParentID: "s2", UID: "a", Status: "confirmed"}, "e1", "e1", ErrSameDriver},
+		{"same session", a, dynamo.Registration{ParentID: "s1", UID: "b", Status: "confirmed"}, "e1", "e1", ErrSameSession},
+		{"other event", a, b, "e1", "e2", ErrOtherEvent},
+		{"no event", a, b, "", "", ErrOtherEvent},
+		{"waitlisted", a, dynamo.Registration{ParentID: "s2", UID: "b", Status: "waitlisted"}, "e1", "e1", ErrNotTransferable},
+	}
+	for _, tt := range tests {
+		if err := CheckSwap(tt.a, tt.b, tt.eventA, tt.eventB); !errors.Is(err, tt.want) {
+			t.Errorf("%s: CheckSwap = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+// Package waitlist decides who comes off a full registration list's
+// waitlist when spots open up.
+package waitlist
+
+import (
+	"sort"
+	"time"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+)
+
+// Queue returns the waitlisted registrations in the order they are offered
+// spots: first registered first.
+func Queue(regs []dynamo.Registration) []dynamo.Registration {
+	var queue []dynamo.Registration
+	for _, reg := range regs {
+		if reg.Status == "waitlisted" {
+			queue = append(queue, reg)
+		}
+	}
+	sort.SliceStable(queue, func(i, j int) bool {
+		if queue[i].RegisteredAt != queue[j].RegisteredAt {
+			return queue[i].RegisteredAt < queue[j].RegisteredAt
+		}
+		return queue[i].UID < queue[j].UID
+	})
+	return queue
+}
+
+// Expired returns the offered registrations whose time to accept ran out
+// before now.
+func Expired(regs []dynamo.Registration, now time.Time) []dynamo.Registration {
+	var expired []dynamo.Registration
+	for _, reg := range regs {
+		if reg.Status != "offered" || reg.OfferExpiresAt == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, reg.OfferExpiresAt)
+		if err == nil && now.After(t) {
+			expired = append(expired, reg)
+		}
+	}
+	return expired
+}
+
+// Open is how many spots are free. maxSpots of 0 means no limit, so every
+// waitlisted driver can come off.
+func Open(regs []dynamo.Registration, maxSpots int) int {
+	if maxSpots <= 0 {
+		return len(Queue(regs))
+	}
+	held := 0
+	for _, reg := range regs {
+		if dynamo.HoldsSpot(reg.Status) {
+			held++
+		}
+	}
+	return max(0, maxSpots-held)
+}
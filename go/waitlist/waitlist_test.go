@@ -0,0 +1,61 @@
+package waitlist
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+)
+
+func TestQueue(t *testing.T) {
+	regs := []dynamo.Registration{
+		{UID: "c", Status: "waitlisted", RegisteredAt: "2026-05-02T10:00:00Z"},
+		{UID: "a", Status: "confirmed", RegisteredAt: "2026-05-01T09:00:00Z"},
+		{UID: "d", Status: "waitlisted", RegisteredAt: "2026-05-01T12:00:00Z"},
+		{UID: "b", Status: "waitlisted", RegisteredAt: "2026-05-01T12:00:00Z"},
+	}
+
+	var got []string
+	for _, reg := range Queue(regs) {
+		got = append(got, reg.UID)
+	}
+	if got, want := strings.Join(got, ","), "b,d,c"; got != want {
+		t.Errorf("queue = %s, want %s", got, want)
+	}
+}
+
+func TestExpired(t *testing.T) {
+	now := time.Date(2026, 5, 3, 12, 0, 0, 0, time.UTC)
+	regs := []dynamo.Registration{
+		{UID: "a", Status: "offered", OfferExpiresAt: "2026-05-03T11:00:00Z"},
+		{UID: "b", Status: "offered", OfferExpiresAt: "2026-05-03T13:00:00Z"},
+		{UID: "c", Status: "confirmed", OfferExpiresAt: "2026-05-01T00:00:00Z"},
+	}
+
+	got := Expired(regs, now)
+	if len(got) != 1 || got[0].UID != "a" {
+		t.Errorf("expired = %+v, want only a", got)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	regs := []dynamo.Registration{
+		{UID: "a", Status: "confirmed"},
+		{UID: "b", Status: "pending"},
+		{UID: "c", Status: "offered"},
+		{UID: "d", Status: "invited"},
+		{UID: "e", Status: "waitlisted"},
+		{UID: "f", Status: "waitlisted"},
+	}
+
+	if got := Open(regs, 5); got != 2 {
+		t.Errorf("open(5) = %d, want 2", got)
+	}
+	if got := Open(regs, 2); got != 0 {
+		t.Errorf("open(2) = %d, want 0", got)
+	}
+	if got := Open(regs, 0); got != 2 {
+		t.Errorf("open(unlimited) = %d, want 2", got)
+	}
+}
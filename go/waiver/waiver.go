@@ -0,0 +1,112 @@
+// Package waiver checks liability waiver signatures and works out which
+// entrants still need to sign before they can drive.
+package waiver
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+)
+
+// AdultAge is the age from which drivers sign for themselves.
+const AdultAge = 18
+
+// Age is how old someone born on dob (YYYY-MM-DD) is on now's date.
+func Age(dob string, now time.Time) (int, error) {
+	born, err := time.Parse(time.DateOnly, dob)
+	if err != nil {
+		return 0, errors.New("date of birth must be YYYY-MM-DD")
+	}
+	if born.After(now) {
+		return 0, errors.New("date of birth is in the future")
+	}
+	age := now.Year() - born.Year()
+	if now.Month() < born.Month() || (now.Month() == born.Month() && now.Day() < born.Day()) {
+		age--
+	}
+	return age, nil
+}
+
+// Check tidies a signature and checks it's complete. The signer must type
+// their name. A date of birth under AdultAge marks the driver a minor, and
+// a minor's waiver also needs a parent or guardian's name, relationship and
+// typed signature.
+func Check(sig *dynamo.WaiverSignature, now time.Time) error {
+	sig.SignedName = strings.TrimSpace(sig.SignedName)
+	sig.DateOfBirth = strings.TrimSpace(sig.DateOfBirth)
+	sig.GuardianName = strings.TrimSpace(sig.GuardianName)
+	sig.GuardianRelationship = strings.TrimSpace(sig.GuardianRelationship)
+	sig.GuardianSignedName = strings.TrimSpace(sig.GuardianSignedName)
+
+	if sig.SignedName == "" {
+		return errors.New("type your full name to sign")
+	}
+	if sig.DateOfBirth != "" {
+		age, err := Age(sig.DateOfBirth, now)
+		if err != nil {
+			return err
+		}
+		if age < AdultAge {
+			sig.Minor = true
+		}
+	}
+	if !sig.Minor {
+		sig.GuardianName, sig.GuardianRelationship, sig.GuardianSignedName = "", "", ""
+		return nil
+	}
+	switch {
+	case sig.GuardianName == "":
+		return errors.New("a parent or guardian must sign for a minor")
+	case sig.GuardianRelationship == "":
+		return errors.New("guardian relationship is required")
+	case sig.GuardianSignedName == "":
+		return errors.New("the guardian must type their name to sign")
+	}
+	return nil
+}
+
+// Entrant is a registration at check-in with whether its driver has signed
+// the current waiver.
+type Entrant struct {
+	UID        string `json:"uid"`
+	DriverName string `json:"driver_name"`
+	Status     string `json:"status"`
+	Signed     bool   `json:"signed"`
+	SignedAt   string `json:"signed_at,omitempty"`
+	Minor      bool   `json:"minor,omitempty"`
+}
+
+// CheckIn lists who is expected at the track, i.e. confirmed and pending
+// registrations, against the signatures of the current waiver: drivers still
+// to sign first, then by name.
+func CheckIn(regs []dynamo.Registration, sigs []dynamo.WaiverSignature) []Entrant {
+	byUID := map[string]dynamo.WaiverSignature{}
+	for _, s := range sigs {
+		byUID[s.UID] = s
+	}
+
+	out := []Entrant{}
+	for _, reg := range regs {
+		if reg.Status != "confirmed" && reg.Status != "pending" {
+			continue
+		}
+		e := Entrant{UID: reg.UID, DriverName: reg.DriverName, Status: reg.Status}
+		if s, ok := byUID[reg.UID]; ok {
+			e.Signed, e.SignedAt, e.Minor = true, s.SignedAt, s.Minor
+		}
+		out = append(out, e)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Signed != out[j].Signed {
+			return !out[i].Signed
+		}
+		if out[i].DriverName != out[j].DriverName {
+			return out[i].DriverName < out[j].DriverName
+		}
+		return out[i].UID < out[j].UID
+	})
+	return out
+}
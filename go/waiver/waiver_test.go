@@ -0,0 +1,81 @@
+package waiver
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BrianLeishman/karttrackpark.com/go/dynamo"
+)
+
+func TestAge(t *testing.T) {
+	now := time.Date(2026, 6, 15, 12, 0, 0, 0, time.UTC)
+	cases := map[string]int{
+		"2008-06-15": 18,
+		"2008-06-16": 17,
+		"2010-01-01": 16,
+	}
+	for dob, want := range cases {
+		got, err := Age(dob, now)
+		if err != nil || got != want {
+			t.Errorf("Age(%s) = %d, %v; want %d", dob, got, err, want)
+		}
+	}
+	if _, err := Age("2027-01-01", now); err == nil {
+		t.Error("future date of birth accepted")
+	}
+	if _, err := Age("15/06/2008", now); err == nil {
+		t.Error("malformed date of birth accepted")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	now := time.Date(2026, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	adult := dynamo.WaiverSignature{SignedName: " Ada Driver ", DateOfBirth: "1990-02-03", GuardianName: "Nobody"}
+	if err := Check(&adult, now); err != nil {
+		t.Fatalf("adult: %v", err)
+	}
+	if adult.Minor || adult.GuardianName != "" || adult.SignedName != "Ada Driver" {
+		t.Errorf("adult = %+v", adult)
+	}
+
+	minor := dynamo.WaiverSignature{SignedName: "Kid Racer", DateOfBirth: "2014-09-01"}
+	err := Check(&minor, now)
+	if err == nil || !strings.Contains(err.Error(), "guardian") {
+		t.Fatalf("minor without guardian err = %v", err)
+	}
+	if !minor.Minor {
+		t.Error("under 18 not marked a minor")
+	}
+	minor.GuardianName, minor.GuardianRelationship, minor.GuardianSignedName = "Pat Racer", "Parent", "Pat Racer"
+	if err := Check(&minor, now); err != nil {
+		t.Errorf("minor with guardian: %v", err)
+	}
+
+	if err := Check(&dynamo.WaiverSignature{SignedName: "  "}, now); err == nil {
+		t.Error("blank signature accepted")
+	}
+}
+
+func TestCheckIn(t *testing.T) {
+	regs := []dynamo.Registration{
+		{UID: "a", DriverName: "Alice", Status: "confirmed"},
+		{UID: "b", DriverName: "Bob", Status: "confirmed"},
+		{UID: "c", DriverName: "Cara", Status: "pending"},
+		{UID: "d", DriverName: "Dan", Status: "waitlisted"},
+	}
+	sigs := []dynamo.WaiverSignature{{UID: "a", SignedAt: "2026-06-01T10:00:00Z"}}
+
+	got := CheckIn(regs, sigs)
+	var order []string
+	for _, e := range got {
+		order = append(order, e.UID)
+	}
+	if strings.Join(order, ",") != "b,c,a" {
+		t.Errorf("order = %v, want b,c,a", order)
+	}
+	if !got[2].Signed || got[2].SignedAt == "" || got[0].Signed {
+		t.Errorf("signed flags wrong: %+v", got)
+	}
+}
@@ -0,0 +1,97 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestColumn(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		if got := Column(i); got != want {
+			t.Errorf("Column(%d) = %s, want %s", i, got, want)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	rows := [][]string{
+		{"Driver", "Email", "Paid"},
+		{"Ada <Fast> & Co", "ada@example.com", "true"},
+		{"  padded  ", "", "bell\a"},
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, "Round 1: Spring/Summer [Seniors] extra long name", rows); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("not a zip: %v", err)
+	}
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name], _ = io.ReadAll(rc)
+		rc.Close()
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml"} {
+		body, ok := files[name]
+		if !ok {
+			t.Errorf("missing %s", name)
+			continue
+		}
+		if err := xml.Unmarshal(body, new(struct{})); err != nil {
+			t.Errorf("%s isn't well-formed: %v", name, err)
+		}
+	}
+
+	var wb struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := xml.Unmarshal(files["xl/workbook.xml"], &wb); err != nil {
+		t.Fatal(err)
+	}
+	if len(wb.Sheets) != 1 || wb.Sheets[0].Name != "Round 1  Spring Summer (Seniors" {
+		t.Errorf("sheets = %+v", wb.Sheets)
+	}
+
+	var ws struct {
+		Rows []struct {
+			Cells []struct {
+				Ref   string `xml:"r,attr"`
+				Style string `xml:"s,attr"`
+				Text  string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal(files["xl/worksheets/sheet1.xml"], &ws); err != nil {
+		t.Fatal(err)
+	}
+	var got [][]string
+	for _, r := range ws.Rows {
+		var vals []string
+		for _, c := range r.Cells {
+			vals = append(vals, c.Text)
+		}
+		got = append(got, vals)
+	}
+	want := [][]string{rows[0], rows[1], {"  padded  ", "", "bell�"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("cells = %q, want %q", got, want)
+	}
+	if c := ws.Rows[1].Cells[2]; c.Ref != "C2" || c.Style != "" {
+		t.Errorf("cell = %+v, want unstyled C2", c)
+	}
+	if ws.Rows[0].Cells[0].Style != "1" {
+		t.Error("header row isn't bold")
+	}
+}
@@ -29,6 +29,20 @@ interface RacingSeries {
     created_at: string;
 }
 
+interface ChampEntry {
+    position: number;
+    key: string;
+    name: string;
+    total: number;
+}
+
+interface ChampStandings {
+    classes: { class_id: string; drivers: ChampEntry[] }[];
+    class_names: Record<string, string>;
+    teams: ChampEntry[];
+    manufacturers: ChampEntry[];
+}
+
 interface TrackAuth {
     role: string;
 }
@@ -44,15 +58,18 @@ export async function renderChampionshipDetail(container: HTMLElement): Promise<
 
     let champ: Championship;
     let seriesList: RacingSeries[];
+    let standings: ChampStandings | null;
 
-    // Fetch championship + series in parallel (both only need champId)
+    // Fetch championship + series + standings in parallel (all only need champId)
     try {
-        const [champResp, seriesResp] = await Promise.all([
+        const [champResp, seriesResp, standingsResp] = await Promise.all([
             api.get<Championship>(`/api/championships/${champId}`),
             api.get<RacingSeries[]>(`/api/championships/${champId}/series`),
+            api.get<ChampStandings>(`/api/championships/${champId}/standings`).catch(() => null),
         ]);
         champ = champResp.data;
         seriesList = seriesResp.data;
+        standings = standingsResp?.data ?? null;
     } catch (err) {
         if (axios.isAxiosError(err) && err.response?.status === 404) {
             container.innerHTML = '<div class="alert alert-warning">Championship not found.</div>';
@@ -143,6 +160,7 @@ export async function renderChampionshipDetail(container: HTMLElement): Promise<
             ${canManage ? '<button class="btn btn-sm btn-primary ms-auto" id="new-series-btn"><i class="fa-solid fa-plus me-1"></i>New Series</button>' : ''}
         </div>
         <div class="row g-3">${seriesCards}</div>
+        ${standings ? buildChampStandings(standings) : ''}
     `;
 
     // Delete championship handler
@@ -164,6 +182,38 @@ export async function renderChampionshipDetail(container: HTMLElement): Promise<
     });
 }
 
+function buildEntryTable(title: string, rows: ChampEntry[]): string {
+    if (rows.length === 0) {
+        return '';
+    }
+    return `
+        <div class="col-lg-6">
+            <h5>${esc(title)}</h5>
+            <div class="table-responsive">
+                <table class="table table-sm table-striped mb-0">
+                    <thead><tr><th>Pos</th><th>Name</th><th class="text-end">Points</th></tr></thead>
+                    <tbody>
+                        ${rows.map(r => `<tr><td>P${r.position}</td><td>${esc(r.name || r.key)}</td><td class="text-end">${r.total}</td></tr>`).join('')}
+                    </tbody>
+                </table>
+            </div>
+        </div>`;
+}
+
+function buildChampStandings(st: ChampStandings): string {
+    const tables = [
+        ...st.classes.map(c => buildEntryTable(c.class_id ? st.class_names[c.class_id] ?? 'Class' : 'Drivers', c.drivers)),
+        buildEntryTable('Teams', st.teams),
+        buildEntryTable('Manufacturers', st.manufacturers),
+    ].filter(Boolean);
+    if (tables.length === 0) {
+        return '';
+    }
+    return `
+        <h2 class="mt-5 mb-3">Championship Standings</h2>
+        <div class="row g-4">${tables.join('')}</div>`;
+}
+
 function showNewSeriesModal(champId: string, onSave: () => Promise<void>): void {
     document.getElementById('modal-container')?.remove();
     document.body.insertAdjacentHTML('beforeend', `
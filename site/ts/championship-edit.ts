@@ -11,6 +11,10 @@ interface Championship {
     name: string;
     description?: string;
     logo_key?: string;
+    champ_method?: string;
+    champ_points_scheme?: number[];
+    team_count_best?: number;
+    chassis_count_best?: number;
 }
 
 export async function renderChampionshipEdit(container: HTMLElement): Promise<void> {
@@ -35,6 +39,30 @@ export async function renderChampionshipEdit(container: HTMLElement): Promise<vo
         <div class="mx-auto" style="max-width:600px">
             <h4 class="mb-4">Edit Championship</h4>
             ${championshipFormHtml({ prefix: 'edit', values: champ })}
+            <hr>
+            <h5 class="mb-3">Championship Scoring</h5>
+            <div class="mb-3">
+                <label class="form-label" for="edit-champ-method">Driver Points</label>
+                <select class="form-select" id="edit-champ-method">
+                    <option value="sum"${(champ.champ_method ?? 'sum') === 'sum' ? ' selected' : ''}>Sum of series points</option>
+                    <option value="position"${champ.champ_method === 'position' ? ' selected' : ''}>Points by series finishing position</option>
+                </select>
+            </div>
+            <div class="mb-3">
+                <label class="form-label" for="edit-champ-scheme">Position Points Scheme</label>
+                <input type="text" class="form-control" id="edit-champ-scheme" placeholder="25,18,15,12,10" value="${(champ.champ_points_scheme ?? []).join(',')}">
+                <div class="form-text">Also used for series scored by time.</div>
+            </div>
+            <div class="row">
+                <div class="col-sm-6 mb-3">
+                    <label class="form-label" for="edit-team-count">Team: best N drivers per series</label>
+                    <input type="number" class="form-control" id="edit-team-count" min="0" value="${champ.team_count_best ?? ''}">
+                </div>
+                <div class="col-sm-6 mb-3">
+                    <label class="form-label" for="edit-chassis-count">Manufacturer: best N drivers per series</label>
+                    <input type="number" class="form-control" id="edit-chassis-count" min="0" value="${champ.chassis_count_best ?? ''}">
+                </div>
+            </div>
             <div class="d-flex gap-2">
                 <button type="button" class="btn btn-primary" id="save-champ-btn">Save</button>
                 <a href="${championshipDetailUrl(champ.championship_id, champ.name)}" class="btn btn-secondary">Cancel</a>
@@ -64,9 +92,20 @@ export async function renderChampionshipEdit(container: HTMLElement): Promise<vo
 
         try {
             const descEl = document.getElementById('edit-desc');
-            const body: Record<string, string> = {
+            const methodEl = document.getElementById('edit-champ-method');
+            const schemeEl = document.getElementById('edit-champ-scheme');
+            const teamCountEl = document.getElementById('edit-team-count');
+            const chassisCountEl = document.getElementById('edit-chassis-count');
+            const scheme = schemeEl instanceof HTMLInputElement && schemeEl.value.trim() ?
+                schemeEl.value.split(',').map(v => parseInt(v.trim(), 10)).filter(n => !isNaN(n)) :
+                [];
+            const body: Record<string, unknown> = {
                 name,
                 description: descEl instanceof HTMLTextAreaElement ? descEl.value.trim() : '',
+                champMethod: methodEl instanceof HTMLSelectElement ? methodEl.value : 'sum',
+                champPointsScheme: scheme,
+                teamCountBest: teamCountEl instanceof HTMLInputElement ? parseInt(teamCountEl.value, 10) || 0 : 0,
+                chassisCountBest: chassisCountEl instanceof HTMLInputElement ? parseInt(chassisCountEl.value, 10) || 0 : 0,
             };
 
             const logo = bindings.croppedBlob ?? bindings.logoInput.files?.[0];
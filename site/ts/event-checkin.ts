@@ -0,0 +1,287 @@
+import axios from 'axios';
+import { Modal } from 'bootstrap';
+import { api, apiBase } from './api';
+import { getAccessToken } from './auth';
+import { esc } from './html';
+
+interface Entrant {
+    uid: string;
+    driver_name: string;
+    parent_type: string;
+    parent_id: string;
+    paid: boolean;
+    waiver_signed: boolean;
+    age?: number;
+    problems?: string[];
+    arrived_at?: string;
+    transponder?: string;
+    kart_number?: string;
+}
+
+interface EventCheckIn {
+    waiver_version?: number;
+    min_age?: number;
+    expected: number;
+    arrived: number;
+    problems: number;
+    entrants: Entrant[];
+}
+
+const timeFmt = new Intl.DateTimeFormat(undefined, { hour: 'numeric', minute: '2-digit' });
+
+function credentialBadges(e: Entrant): string {
+    const badge = (ok: boolean, label: string): string =>
+        `<span class="badge ${ok ? 'text-bg-success' : 'text-bg-danger'} me-1">${label}</span>`;
+    return [
+        badge(e.paid, e.paid ? 'Paid' : 'Unpaid'),
+        badge(e.waiver_signed, e.waiver_signed ? 'Waiver' : 'No waiver'),
+        e.age !== undefined ? `<span class="badge text-bg-secondary me-1">Age ${e.age}</span>` : '',
+    ].join('');
+}
+
+function checkInFormHtml(e: Entrant): string {
+    const problems = e.problems ?? [];
+    return `
+        <form class="checkin-form" data-uid="${esc(e.uid)}">
+            ${problems.length > 0 ? `
+                <div class="alert alert-warning py-2 small mb-2">${problems.map(p => esc(p)).join('<br>')}</div>
+            ` : ''}
+            <div class="row g-2 align-items-end">
+                <div class="col-sm-4">
+                    <label class="form-label small mb-1">Transponder</label>
+                    <input type="text" class="form-control form-control-sm" name="transponder" inputmode="numeric" value="${esc(e.transponder ?? '')}">
+                </div>
+                <div class="col-sm-3">
+                    <label class="form-label small mb-1">Kart #</label>
+                    <input type="text" class="form-control form-control-sm" name="kart_number" value="${esc(e.kart_number ?? '')}">
+                </div>
+                <div class="col-sm-5 d-flex gap-2 align-items-center">
+                    ${problems.length > 0 ? `
+                        <div class="form-check small mb-0">
+                            <input type="checkbox" class="form-check-input" name="override" id="override-${esc(e.uid)}">
+                            <label class="form-check-label" for="override-${esc(e.uid)}">Override</label>
+                        </div>
+                    ` : ''}
+                    <button type="submit" class="btn btn-sm btn-success ms-auto">${e.arrived_at ? 'Update' : 'Check In'}</button>
+                </div>
+            </div>
+        </form>
+    `;
+}
+
+/**
+ * Wires a check-in form to the API. onDone gets the updated entrant once the
+ * driver is checked in.
+ */
+function bindCheckInForm(form: HTMLFormElement, eventId: string, code: string, onDone: (e: Entrant) => void): void {
+    form.addEventListener('submit', async ev => {
+        ev.preventDefault();
+        const data = new FormData(form);
+        const btn = form.querySelector('button[type="submit"]');
+        if (btn instanceof HTMLButtonElement) {
+            btn.disabled = true;
+        }
+        try {
+            const { data: entrant } = await api.post<Entrant>(`/api/events/${eventId}/checkin/${form.dataset.uid ?? ''}`, {
+                code,
+                transponder: String(data.get('transponder') ?? ''),
+                kart_number: String(data.get('kart_number') ?? ''),
+                override: data.get('override') === 'on',
+            });
+            onDone(entrant);
+        } catch {
+            /* api interceptor shows toast */
+        } finally {
+            if (btn instanceof HTMLButtonElement) {
+                btn.disabled = false;
+            }
+        }
+    });
+}
+
+function entrantRowHtml(e: Entrant): string {
+    return `
+        <li class="list-group-item" data-uid="${esc(e.uid)}">
+            <div class="d-flex align-items-center gap-2">
+                <div class="fw-semibold">${esc(e.driver_name)}</div>
+                <div class="small">${credentialBadges(e)}</div>
+                <div class="ms-auto small text-body-secondary">
+                    ${e.arrived_at ? `<i class="fa-solid fa-check text-success me-1"></i>${timeFmt.format(new Date(e.arrived_at))}` : ''}
+                    ${e.transponder ? ` &middot; T${esc(e.transponder)}` : ''}
+                    ${e.kart_number ? ` &middot; Kart ${esc(e.kart_number)}` : ''}
+                </div>
+                <button class="btn btn-sm btn-outline-secondary checkin-toggle">${e.arrived_at ? 'Edit' : 'Check In'}</button>
+                ${e.arrived_at ? '<button class="btn btn-sm btn-link text-danger checkin-undo">Undo</button>' : ''}
+            </div>
+            <div class="checkin-form-wrap mt-2 d-none">${checkInFormHtml(e)}</div>
+        </li>
+    `;
+}
+
+/** Shows the gate list for an event: who is expected, their credentials and who has arrived. */
+export async function showCheckInModal(eventId: string, eventName: string): Promise<void> {
+    document.getElementById('checkin-modal')?.remove();
+    document.body.insertAdjacentHTML('beforeend', `
+        <div class="modal fade" id="checkin-modal" tabindex="-1">
+            <div class="modal-dialog modal-lg modal-dialog-scrollable">
+                <div class="modal-content">
+                    <div class="modal-header">
+                        <h5 class="modal-title">Check-In &mdash; ${esc(eventName)}</h5>
+                        <button type="button" class="btn-close" data-bs-dismiss="modal"></button>
+                    </div>
+                    <div class="modal-body" id="checkin-body">
+                        <div class="text-center py-4"><div class="spinner-border" role="status"></div></div>
+                    </div>
+                </div>
+            </div>
+        </div>
+    `);
+    const modalEl = document.getElementById('checkin-modal');
+    const body = document.getElementById('checkin-body');
+    if (!modalEl || !body) {
+        return;
+    }
+    const bsModal = new Modal(modalEl);
+    bsModal.show();
+    modalEl.addEventListener('hidden.bs.modal', () => modalEl.remove(), { once: true });
+
+    const load = async (): Promise<void> => {
+        let list: EventCheckIn;
+        try {
+            list = (await api.get<EventCheckIn>(`/api/events/${eventId}/checkin`)).data;
+        } catch {
+            body.innerHTML = '<div class="alert alert-danger">Failed to load check-in.</div>';
+            return;
+        }
+
+        body.innerHTML = `
+            <p class="small text-body-secondary">
+                ${list.arrived} of ${list.expected} arrived${list.problems > 0 ? ` &middot; <span class="text-danger">${list.problems} with problems</span>` : ''}
+                ${list.waiver_version ? ` &middot; waiver v${list.waiver_version}` : ''}
+                ${list.min_age ? ` &middot; minimum age ${list.min_age}` : ''}
+            </p>
+            ${list.entrants.length === 0 ?
+                '<p class="text-body-secondary">No confirmed drivers.</p>' :
+                `<ul class="list-group">${list.entrants.map(entrantRowHtml).join('')}</ul>`}
+        `;
+
+        body.querySelectorAll<HTMLElement>('li[data-uid]').forEach(li => {
+            li.querySelector('.checkin-toggle')?.addEventListener('click', () => {
+                li.querySelector('.checkin-form-wrap')?.classList.toggle('d-none');
+            });
+            li.querySelector('.checkin-undo')?.addEventListener('click', async () => {
+                try {
+                    await api.delete(`/api/events/${eventId}/checkin/${li.dataset.uid ?? ''}`);
+                    void load();
+                } catch {
+                    /* api interceptor shows toast */
+                }
+            });
+            const form = li.querySelector('form');
+            if (form instanceof HTMLFormElement) {
+                bindCheckInForm(form, eventId, '', () => void load());
+            }
+        });
+    };
+    await load();
+}
+
+/** Shows a driver their pass for an event: the QR code staff scan at the gate. */
+export async function showMyPass(eventId: string, uid: string): Promise<void> {
+    let src: string;
+    try {
+        const { data } = await axios.get<Blob>(`${apiBase}/api/events/${eventId}/checkin/${uid}/qr`, {
+            headers: { Authorization: `Bearer ${getAccessToken() ?? ''}` },
+            responseType: 'blob',
+        });
+        src = URL.createObjectURL(data);
+    } catch (err) {
+        if (axios.isAxiosError(err) && err.response?.status === 404) {
+            alert('You need a confirmed registration for this event to get a pass.');
+        }
+        return;
+    }
+
+    document.getElementById('pass-modal')?.remove();
+    document.body.insertAdjacentHTML('beforeend', `
+        <div class="modal fade" id="pass-modal" tabindex="-1">
+            <div class="modal-dialog modal-dialog-centered modal-sm">
+                <div class="modal-content">
+                    <div class="modal-header">
+                        <h5 class="modal-title">Your Pass</h5>
+                        <button type="button" class="btn-close" data-bs-dismiss="modal"></button>
+                    </div>
+                    <div class="modal-body text-center">
+                        <img src="${src}" alt="Check-in QR code" class="img-fluid mx-auto d-block" style="max-width:260px">
+                        <p class="small text-body-secondary mt-2 mb-0">Show this at the gate to check in.</p>
+                    </div>
+                    <div class="modal-footer">
+                        <button type="button" class="btn btn-outline-secondary" id="pass-print"><i class="fa-solid fa-print me-1"></i>Print</button>
+                    </div>
+                </div>
+            </div>
+        </div>
+    `);
+    const modalEl = document.getElementById('pass-modal');
+    if (!modalEl) {
+        URL.revokeObjectURL(src);
+        return;
+    }
+    new Modal(modalEl).show();
+    modalEl.addEventListener('hidden.bs.modal', () => {
+        modalEl.remove();
+        URL.revokeObjectURL(src);
+    }, { once: true });
+    document.getElementById('pass-print')?.addEventListener('click', () => window.print());
+}
+
+/** Renders the page a scanned pass opens: the driver's credentials and the check-in form. */
+export async function renderCheckInScan(container: HTMLElement): Promise<void> {
+    const params = new URLSearchParams(window.location.search);
+    const eventId = params.get('e') ?? '';
+    const uid = params.get('u') ?? '';
+    const code = params.get('c') ?? '';
+    if (!eventId || !uid || !code) {
+        container.innerHTML = '<div class="alert alert-warning">This isn’t a valid check-in pass.</div>';
+        return;
+    }
+
+    container.innerHTML = '<div class="text-center py-5"><div class="spinner-border" role="status"></div></div>';
+
+    let list: EventCheckIn;
+    try {
+        list = (await api.get<EventCheckIn>(`/api/events/${eventId}/checkin`)).data;
+    } catch {
+        container.innerHTML = '<div class="alert alert-danger">Failed to load check-in. Only track staff can check drivers in.</div>';
+        return;
+    }
+    const entrant = list.entrants.find(e => e.uid === uid);
+    if (!entrant) {
+        container.innerHTML = '<div class="alert alert-danger">This driver isn’t confirmed for the event.</div>';
+        return;
+    }
+
+    const render = (e: Entrant): void => {
+        container.innerHTML = `
+            <div class="card mx-auto" style="max-width:560px">
+                <div class="card-body">
+                    <h4 class="card-title mb-1">${esc(e.driver_name)}</h4>
+                    <div class="mb-3">${credentialBadges(e)}</div>
+                    ${e.arrived_at ? `
+                        <div class="alert alert-success py-2">
+                            <i class="fa-solid fa-check me-1"></i>Checked in at ${timeFmt.format(new Date(e.arrived_at))}
+                            ${e.transponder ? ` &middot; transponder ${esc(e.transponder)}` : ''}
+                            ${e.kart_number ? ` &middot; kart ${esc(e.kart_number)}` : ''}
+                        </div>
+                    ` : ''}
+                    ${checkInFormHtml(e)}
+                </div>
+            </div>
+        `;
+        const form = container.querySelector('form');
+        if (form instanceof HTMLFormElement) {
+            bindCheckInForm(form, eventId, code, render);
+        }
+    };
+    render(entrant);
+}
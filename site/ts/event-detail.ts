@@ -1,9 +1,11 @@
 import axios from 'axios';
 import { api, apiBase, assetsBase } from './api';
-import { getAccessToken } from './auth';
+import { getAccessToken, getUser } from './auth';
+import { showCheckInModal, showMyPass } from './event-checkin';
 import { esc, dateFmt, typeLabel, formatLapTime, SESSION_TYPES, START_TYPES, startTypeLabel } from './html';
 import { getEntityId, ensureCorrectEventUrl, trackDetailUrl, championshipDetailUrl, seriesDetailUrl, sessionDetailUrl } from './url-utils';
 import { openUploadManager } from './upload-manager';
+import { bindRosterMenu, rosterMenuHtml } from './roster';
 
 interface SeriesContext {
     series_id: string;
@@ -37,6 +39,18 @@ interface TrackAuth {
     role: string;
 }
 
+interface Penalty {
+    penalty_id: string;
+    session_id: string;
+    uid: string;
+    driver_name?: string;
+    type: string;
+    value?: number;
+    reason: string;
+    issued_by_name?: string;
+    created_at: string;
+}
+
 interface FullSession {
     session_id: string;
     track_id: string;
@@ -122,6 +136,42 @@ function sessionCardHtml(s: FullSession): string {
         </a>`;
 }
 
+function penaltyLabel(p: Penalty): string {
+    const v = p.value ?? 0;
+    switch (p.type) {
+        case 'time': return `+${(v / 1000).toFixed(v % 1000 === 0 ? 0 : 1)}s`;
+        case 'position': return `-${v} place${v === 1 ? '' : 's'}`;
+        case 'laps': return `-${v} lap${v === 1 ? '' : 's'}`;
+        case 'points': return `-${v} pts`;
+        case 'grid': return `${v}-place grid drop`;
+        case 'dsq': return 'Disqualified';
+        default: return p.type;
+    }
+}
+
+function buildPenaltyLog(penalties: Penalty[], sessions: FullSession[]): string {
+    if (penalties.length === 0) {
+        return '';
+    }
+    const sessionNames = new Map(sessions.map(s => [s.session_id, s.session_name ?? '']));
+    return `
+        <h2 class="h6 mt-4 mb-2">Penalties</h2>
+        <div class="table-responsive">
+            <table class="table table-sm align-middle">
+                <thead><tr><th>Driver</th><th>Session</th><th>Penalty</th><th>Reason</th><th>Steward</th></tr></thead>
+                <tbody>
+                    ${penalties.map(p => `<tr>
+                        <td>${esc(p.driver_name ?? '')}</td>
+                        <td class="text-body-secondary">${esc(sessionNames.get(p.session_id) ?? '')}</td>
+                        <td class="fw-semibold text-nowrap">${esc(penaltyLabel(p))}</td>
+                        <td>${esc(p.reason)}</td>
+                        <td class="text-body-secondary small">${esc(p.issued_by_name ?? '')}</td>
+                    </tr>`).join('')}
+                </tbody>
+            </table>
+        </div>`;
+}
+
 function buildSessionGroups(sessions: FullSession[]): string {
     if (sessions.length === 0) {
         return '<p class="text-body-secondary">No sessions.</p>';
@@ -226,15 +276,19 @@ export async function renderEventDetail(container: HTMLElement): Promise<void> {
 
     let event: EventDetail;
     let fullSessions: FullSession[];
+    let penalties: Penalty[];
 
     try {
-        const [eventResp, sessionsResp] = await Promise.all([
+        const [eventResp, sessionsResp, penaltiesResp] = await Promise.all([
             api.get<EventDetail>(`/api/events/${eventId}`),
             axios.get<FullSession[]>(`${apiBase}/api/events/${eventId}/sessions?full=true`).
                 catch((): { data: FullSession[] } => ({ data: [] })),
+            axios.get<Penalty[]>(`${apiBase}/api/events/${eventId}/penalties`).
+                catch((): { data: Penalty[] } => ({ data: [] })),
         ]);
         event = eventResp.data;
         fullSessions = sessionsResp.data;
+        penalties = penaltiesResp.data;
     } catch (err) {
         if (axios.isAxiosError(err) && err.response?.status === 404) {
             container.innerHTML = '<div class="alert alert-warning">Event not found.</div>';
@@ -283,6 +337,8 @@ export async function renderEventDetail(container: HTMLElement): Promise<void> {
     const classMap = new Map(classes.map(c => [c.class_id, c.name]));
 
     const canManage = role === 'owner' || role === 'admin';
+    const canCheckIn = canManage || role === 'operator';
+    const user = getUser();
 
     document.title = `${event.name} \u2014 Kart Track Park`;
 
@@ -378,13 +434,16 @@ export async function renderEventDetail(container: HTMLElement): Promise<void> {
                 ${seriesTag}
                 ${event.description ? `<p class="text-body-secondary mt-2 mb-0" style="font-size:13px">${esc(event.description)}</p>` : ''}
             </div>
-            ${canManage ? `
-                <div class="ms-auto d-flex gap-2 flex-shrink-0">
+            <div class="ms-auto d-flex gap-2 flex-shrink-0">
+                ${user ? '<button class="btn btn-sm btn-outline-secondary" id="my-pass-btn"><i class="fa-solid fa-qrcode me-1"></i>My Pass</button>' : ''}
+                ${canCheckIn ? '<button class="btn btn-sm btn-outline-success" id="checkin-btn"><i class="fa-solid fa-clipboard-check me-1"></i>Check-In</button>' : ''}
+                ${canManage ? `
                     <button class="btn btn-sm btn-primary" id="upload-laps-btn"><i class="fa-solid fa-upload me-1"></i>Upload Laps</button>
                     <button class="btn btn-sm btn-outline-secondary" id="edit-event-btn"><i class="fa-solid fa-pen me-1"></i>Edit</button>
                     <button class="btn-ghost-danger" id="delete-event-btn"><i class="fa-solid fa-trash me-1"></i>Delete</button>
-                </div>
-            ` : ''}
+                    ${rosterMenuHtml()}
+                ` : ''}
+            </div>
         </div>
         ${fastestLapMs > 0 ? `
         <div class="stats-row">
@@ -405,9 +464,24 @@ export async function renderEventDetail(container: HTMLElement): Promise<void> {
             </div>
         </div>` : ''}
         ${sessionsHtml}
+        ${buildPenaltyLog(penalties, fullSessions)}
         ${canManage && fullSessions.some(s => s.lap_count && s.lap_count > 0) ? '<div class="text-end mt-2"><button class="btn btn-sm btn-outline-secondary" id="reprocess-all-btn"><i class="fa-solid fa-arrows-rotate me-1"></i>Reprocess All Laps</button></div>' : ''}
     `;
 
+    document.getElementById('my-pass-btn')?.addEventListener('click', () => {
+        if (user) {
+            void showMyPass(event.event_id, user.uid);
+        }
+    });
+
+    document.getElementById('checkin-btn')?.addEventListener('click', () => {
+        void showCheckInModal(event.event_id, event.name);
+    });
+
+    if (canManage) {
+        bindRosterMenu(`/api/events/${event.event_id}`, event.name, () => void renderEventDetail(container));
+    }
+
     document.getElementById('delete-event-btn')?.addEventListener('click', async () => {
         if (!confirm(`Delete "${event.name}"? This cannot be undone.`)) {
             return;
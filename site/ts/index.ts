@@ -3,6 +3,7 @@ import { initAnalyzeTray } from './analyze-tray';
 import { handleCallback, getUser, login, logout } from './auth';
 import { renderChampionshipDetail } from './championship-detail';
 import { renderChampionshipEdit } from './championship-edit';
+import { renderCheckInScan } from './event-checkin';
 import { renderEventDetail } from './event-detail';
 import { renderEvents } from './events';
 import { renderKeys } from './keys';
@@ -22,6 +23,7 @@ const pages: Record<string, (el: HTMLElement) => Promise<void>> = {
     'track-edit': renderTrackEdit,
     'championship-edit': renderChampionshipEdit,
     'series-edit': renderSeriesEdit,
+    'checkin-scan': renderCheckInScan,
 };
 
 async function init(): Promise<void> {
@@ -125,7 +127,7 @@ async function init(): Promise<void> {
         for (const id of Object.keys(pages)) {
             const el = document.getElementById(id);
             if (el) {
-                const labels: Record<string, string> = { 'keys': 'API keys', 'my-tracks': 'tracks', 'track-edit': 'track settings', 'championship-edit': 'championship settings', 'series-edit': 'series settings' };
+                const labels: Record<string, string> = { 'keys': 'API keys', 'my-tracks': 'tracks', 'track-edit': 'track settings', 'championship-edit': 'championship settings', 'series-edit': 'series settings', 'checkin-scan': 'check-in' };
                 const label = labels[id] ?? id;
                 el.innerHTML = `
                     <div class="text-center py-5">
@@ -0,0 +1,277 @@
+import { Modal } from 'bootstrap';
+import { api } from './api';
+import { esc } from './html';
+
+export interface PriceTier {
+    until: string;
+    price_cents: number;
+}
+
+export interface PricingSettings {
+    early_bird?: PriceTier[];
+    member_price_cents?: number;
+    season_pass?: boolean;
+}
+
+interface PromoCode {
+    code: string;
+    percent_off?: number;
+    amount_off_cents?: number;
+    max_uses?: number;
+    expires_at?: string;
+    uses?: number;
+}
+
+interface Quote {
+    price_cents: number;
+    rule: string;
+    promo_code?: string;
+    standard_price_cents: number;
+    currency?: string;
+}
+
+const RULE_LABELS: Record<string, string> = {
+    early_bird: 'Early bird',
+    member: 'Member price',
+    season_pass: 'Season pass',
+};
+
+export function formatPrice(cents: number, currency?: string): string {
+    return `$${(cents / 100).toFixed(2)}${currency ? ' ' + esc(currency) : ''}`;
+}
+
+function tierRowHtml(t: PriceTier): string {
+    return `
+        <div class="row g-2 mb-2 align-items-center tier-row">
+            <div class="col-md-6">
+                <input type="datetime-local" class="form-control form-control-sm tier-until" value="${t.until ? t.until.slice(0, 16) : ''}">
+            </div>
+            <div class="col-md-5">
+                <input type="number" class="form-control form-control-sm tier-price" min="0" placeholder="Price (cents)" value="${t.until ? t.price_cents : ''}">
+            </div>
+            <div class="col-md-1 text-end">
+                <button type="button" class="btn btn-sm btn-outline-danger tier-remove" title="Remove"><i class="fa-solid fa-xmark"></i></button>
+            </div>
+        </div>
+    `;
+}
+
+/**
+ * Editor for early-bird tiers and member pricing, bound with
+ * bindPricingEditor. withSeasonPass adds the series' season pass option.
+ */
+export function pricingEditorHtml(settings: PricingSettings, withSeasonPass: boolean): string {
+    return `
+        <div class="mb-3">
+            <label class="form-label">Early-Bird Prices</label>
+            <div id="tier-rows">${(settings.early_bird ?? []).map(tierRowHtml).join('')}</div>
+            <button type="button" class="btn btn-sm btn-outline-secondary" id="add-tier-btn"><i class="fa-solid fa-plus me-1"></i>Add Tier</button>
+            <div class="form-text">Each price applies until its date; after the last one the regular price does.</div>
+        </div>
+        <div class="row g-3 mb-3">
+            <div class="col-md-4">
+                <label class="form-label" for="member-price">Member Price (cents) <span class="text-body-secondary">(0 = none)</span></label>
+                <input type="number" class="form-control" id="member-price" min="0" value="${settings.member_price_cents ?? 0}">
+            </div>
+        </div>
+        ${withSeasonPass ? `
+        <div class="form-check mb-3">
+            <input class="form-check-input" type="checkbox" id="season-pass"${settings.season_pass ? ' checked' : ''}>
+            <label class="form-check-label" for="season-pass">Season pass: confirmed drivers enter every round free</label>
+        </div>` : ''}
+    `;
+}
+
+export function bindPricingEditor(): void {
+    const rows = document.getElementById('tier-rows');
+    if (!rows) {
+        return;
+    }
+    document.getElementById('add-tier-btn')?.addEventListener('click', () => {
+        rows.insertAdjacentHTML('beforeend', tierRowHtml({ until: '', price_cents: 0 }));
+    });
+    rows.addEventListener('click', e => {
+        const btn = e.target instanceof Element ? e.target.closest('.tier-remove') : null;
+        btn?.closest('.tier-row')?.remove();
+    });
+}
+
+/** Reads the editor's settings as series or event update fields. */
+export function readPricing(): Record<string, unknown> {
+    const tiers: PriceTier[] = [];
+    document.querySelectorAll<HTMLElement>('#tier-rows .tier-row').forEach(row => {
+        const until = row.querySelector<HTMLInputElement>('.tier-until')?.value ?? '';
+        if (!until) {
+            return;
+        }
+        tiers.push({
+            until: new Date(until).toISOString(),
+            price_cents: parseInt(row.querySelector<HTMLInputElement>('.tier-price')?.value ?? '', 10) || 0,
+        });
+    });
+    const memberEl = document.getElementById('member-price');
+    const seasonPassEl = document.getElementById('season-pass');
+    return {
+        earlyBird: tiers,
+        memberPriceCents: memberEl instanceof HTMLInputElement ? parseInt(memberEl.value, 10) || 0 : 0,
+        ...seasonPassEl instanceof HTMLInputElement && { seasonPass: seasonPassEl.checked },
+    };
+}
+
+/** Price and promo code field for a registration form, bound with bindPromoCodeField. */
+export function promoCodeFieldHtml(): string {
+    return `
+        <div class="mb-3">
+            <div class="d-flex align-items-center gap-2 mb-2">
+                <i class="fa-solid fa-tag text-body-secondary"></i>
+                <span id="reg-price"><span class="spinner-border spinner-border-sm"></span></span>
+            </div>
+            <div class="input-group input-group-sm">
+                <input type="text" class="form-control text-uppercase" id="promo-code" placeholder="Promo code" maxlength="32">
+                <button type="button" class="btn btn-outline-secondary" id="promo-apply">Apply</button>
+            </div>
+        </div>
+    `;
+}
+
+/**
+ * Shows what the driver would pay to register with base (e.g.
+ * /api/series/{id}) and rechecks it when they apply a promo code. Returns
+ * the code to register with.
+ */
+export function bindPromoCodeField(base: string): () => string {
+    const priceEl = document.getElementById('reg-price');
+    const codeEl = document.getElementById('promo-code');
+    const show = async (code: string): Promise<void> => {
+        if (!priceEl) {
+            return;
+        }
+        try {
+            const { data } = await api.get<Quote>(`${base}/price`, { params: code ? { code } : {} });
+            const label = RULE_LABELS[data.rule];
+            const discounted = data.price_cents < data.standard_price_cents;
+            priceEl.innerHTML = `
+                Entry fee: <strong>${data.price_cents > 0 ? formatPrice(data.price_cents, data.currency) : 'Free'}</strong>
+                ${discounted ? `<s class="text-body-secondary small ms-1">${formatPrice(data.standard_price_cents, data.currency)}</s>` : ''}
+                ${label ? `<span class="badge text-bg-success ms-1">${label}</span>` : ''}
+                ${data.promo_code ? `<span class="badge text-bg-info ms-1">${esc(data.promo_code)}</span>` : ''}
+            `;
+        } catch {
+            if (codeEl instanceof HTMLInputElement) {
+                codeEl.value = '';
+            }
+        }
+    };
+    void show('');
+    document.getElementById('promo-apply')?.addEventListener('click', () => {
+        void show(codeEl instanceof HTMLInputElement ? codeEl.value.trim() : '');
+    });
+    return () => codeEl instanceof HTMLInputElement ? codeEl.value.trim() : '';
+}
+
+function promoRowHtml(pc: PromoCode): string {
+    return `
+        <tr class="promo-row">
+            <td><input type="text" class="form-control form-control-sm text-uppercase promo-code" value="${esc(pc.code)}" maxlength="32"></td>
+            <td><input type="number" class="form-control form-control-sm promo-percent" min="0" max="100" value="${pc.percent_off ?? ''}"></td>
+            <td><input type="number" class="form-control form-control-sm promo-amount" min="0" value="${pc.amount_off_cents ?? ''}"></td>
+            <td><input type="number" class="form-control form-control-sm promo-max" min="0" value="${pc.max_uses ?? ''}" placeholder="&infin;"></td>
+            <td><input type="date" class="form-control form-control-sm promo-expires" value="${pc.expires_at ? pc.expires_at.slice(0, 10) : ''}"></td>
+            <td class="text-body-secondary small align-middle">${pc.uses ?? 0}</td>
+            <td class="text-end"><button type="button" class="btn btn-sm btn-outline-danger promo-remove" title="Remove"><i class="fa-solid fa-xmark"></i></button></td>
+        </tr>
+    `;
+}
+
+function readPromoCodes(): PromoCode[] {
+    const out: PromoCode[] = [];
+    const num = (row: HTMLElement, cls: string): number => parseInt(row.querySelector<HTMLInputElement>(cls)?.value ?? '', 10) || 0;
+    document.querySelectorAll<HTMLElement>('#promo-rows .promo-row').forEach(row => {
+        const code = row.querySelector<HTMLInputElement>('.promo-code')?.value.trim() ?? '';
+        if (!code) {
+            return;
+        }
+        const expires = row.querySelector<HTMLInputElement>('.promo-expires')?.value ?? '';
+        out.push({
+            code,
+            percent_off: num(row, '.promo-percent'),
+            amount_off_cents: num(row, '.promo-amount'),
+            max_uses: num(row, '.promo-max'),
+            // Good through the end of the day it expires
+            ...expires && { expires_at: `${expires}T23:59:59Z` },
+        });
+    });
+    return out;
+}
+
+/** Admin modal listing the promo codes of a series, event or session at base. */
+export async function showPromoCodesModal(base: string): Promise<void> {
+    let codes: PromoCode[];
+    try {
+        const { data } = await api.get<PromoCode[]>(`${base}/promo-codes`);
+        codes = data;
+    } catch {
+        return;
+    }
+
+    document.getElementById('promo-codes-modal')?.remove();
+    document.body.insertAdjacentHTML('beforeend', `
+        <div class="modal fade" id="promo-codes-modal" tabindex="-1">
+            <div class="modal-dialog modal-lg">
+                <div class="modal-content">
+                    <div class="modal-header">
+                        <h5 class="modal-title">Promo Codes</h5>
+                        <button type="button" class="btn-close" data-bs-dismiss="modal"></button>
+                    </div>
+                    <form id="promo-codes-form">
+                    <div class="modal-body">
+                        <table class="table table-sm small">
+                            <thead><tr><th>Code</th><th>% Off</th><th>Cents Off</th><th>Max Uses</th><th>Expires</th><th>Used</th><th></th></tr></thead>
+                            <tbody id="promo-rows">${codes.map(promoRowHtml).join('')}</tbody>
+                        </table>
+                        <button type="button" class="btn btn-sm btn-outline-secondary" id="add-promo-btn"><i class="fa-solid fa-plus me-1"></i>Add Code</button>
+                        <div class="form-text">Give each code either a percentage or an amount off. Codes are case-insensitive.</div>
+                    </div>
+                    <div class="modal-footer">
+                        <button type="button" class="btn btn-secondary" data-bs-dismiss="modal">Cancel</button>
+                        <button type="submit" class="btn btn-primary" id="promo-save">Save</button>
+                    </div>
+                    </form>
+                </div>
+            </div>
+        </div>
+    `);
+
+    const modalEl = document.getElementById('promo-codes-modal');
+    const form = document.getElementById('promo-codes-form');
+    const rows = document.getElementById('promo-rows');
+    if (!modalEl || !form || !rows) {
+        return;
+    }
+    const bsModal = new Modal(modalEl);
+    bsModal.show();
+    modalEl.addEventListener('hidden.bs.modal', () => modalEl.remove(), { once: true });
+
+    document.getElementById('add-promo-btn')?.addEventListener('click', () => {
+        rows.insertAdjacentHTML('beforeend', promoRowHtml({ code: '' }));
+    });
+    rows.addEventListener('click', e => {
+        const btn = e.target instanceof Element ? e.target.closest('.promo-remove') : null;
+        btn?.closest('.promo-row')?.remove();
+    });
+
+    form.addEventListener('submit', async e => {
+        e.preventDefault();
+        const btn = document.getElementById('promo-save');
+        if (!(btn instanceof HTMLButtonElement)) {
+            return;
+        }
+        btn.disabled = true;
+        try {
+            await api.put(`${base}/promo-codes`, readPromoCodes());
+            bsModal.hide();
+        } catch {
+            btn.disabled = false;
+        }
+    });
+}
@@ -0,0 +1,240 @@
+import { Modal } from 'bootstrap';
+import { esc } from './html';
+import { bindPromoCodeField, promoCodeFieldHtml } from './pricing';
+
+export interface RegistrationQuestion {
+    id: string;
+    label: string;
+    type: string;
+    required?: boolean;
+    help?: string;
+    options?: string[];
+    max_length?: number;
+    pattern?: string;
+    min?: number;
+    max?: number;
+}
+
+const QUESTION_TYPES: Record<string, string> = {
+    text: 'Text',
+    number: 'Number',
+    email: 'Email',
+    phone: 'Phone',
+    date: 'Date',
+    select: 'Choice',
+    checkbox: 'Checkbox (e.g. waiver)',
+};
+
+const INPUT_TYPES: Record<string, string> = { number: 'number', email: 'email', phone: 'tel', date: 'date' };
+
+function questionRowHtml(q: RegistrationQuestion): string {
+    const typeOptions = Object.entries(QUESTION_TYPES)
+        .map(([value, label]) => `<option value="${value}"${q.type === value ? ' selected' : ''}>${label}</option>`)
+        .join('');
+    return `
+        <div class="row g-2 mb-2 align-items-center question-row" data-id="${esc(q.id)}"
+            data-pattern="${esc(q.pattern ?? '')}" data-max-length="${q.max_length ?? ''}"
+            data-min="${q.min ?? ''}" data-max="${q.max ?? ''}" data-help="${esc(q.help ?? '')}">
+            <div class="col-md-4">
+                <input type="text" class="form-control form-control-sm question-label" placeholder="Label, e.g. Transponder number" value="${esc(q.label)}">
+            </div>
+            <div class="col-md-3">
+                <select class="form-select form-select-sm question-type">${typeOptions}</select>
+            </div>
+            <div class="col-md-3">
+                <input type="text" class="form-control form-control-sm question-options" placeholder="Choices, comma-separated" value="${esc((q.options ?? []).join(', '))}">
+            </div>
+            <div class="col-md-1 form-check">
+                <input type="checkbox" class="form-check-input question-required"${q.required ? ' checked' : ''} title="Required">
+            </div>
+            <div class="col-md-1 text-end">
+                <button type="button" class="btn btn-sm btn-outline-danger question-remove" title="Remove"><i class="fa-solid fa-xmark"></i></button>
+            </div>
+        </div>
+    `;
+}
+
+/** Editor for a registration form's questions, bound with bindQuestionsEditor. */
+export function questionsEditorHtml(questions: RegistrationQuestion[]): string {
+    return `
+        <div id="questions-editor">
+            <div id="question-rows">${questions.map(questionRowHtml).join('')}</div>
+            <button type="button" class="btn btn-sm btn-outline-secondary" id="add-question-btn"><i class="fa-solid fa-plus me-1"></i>Add Question</button>
+            <div class="form-text">Tick a question to make it required. A required checkbox must be ticked to register, e.g. to accept a waiver.</div>
+        </div>
+    `;
+}
+
+export function bindQuestionsEditor(): void {
+    const rows = document.getElementById('question-rows');
+    if (!rows) {
+        return;
+    }
+    document.getElementById('add-question-btn')?.addEventListener('click', () => {
+        rows.insertAdjacentHTML('beforeend', questionRowHtml({ id: '', label: '', type: 'text' }));
+    });
+    rows.addEventListener('click', e => {
+        const btn = e.target instanceof Element ? e.target.closest('.question-remove') : null;
+        btn?.closest('.question-row')?.remove();
+    });
+}
+
+function slug(label: string): string {
+    return label.toLowerCase().replace(/[^a-z0-9]+/g, '_').replace(/^_+|_+$/g, '').slice(0, 40);
+}
+
+/** Reads the editor's questions. New questions get an ID from their label. */
+export function readQuestions(): RegistrationQuestion[] {
+    const out: RegistrationQuestion[] = [];
+    const used = new Set<string>();
+    document.querySelectorAll<HTMLElement>('#question-rows .question-row').forEach(row => {
+        const label = row.querySelector<HTMLInputElement>('.question-label')?.value.trim() ?? '';
+        if (!label) {
+            return;
+        }
+        let id = row.dataset.id || slug(label) || 'question';
+        for (let n = 2; used.has(id); n++) {
+            id = `${slug(label) || 'question'}_${n}`;
+        }
+        used.add(id);
+
+        const q: RegistrationQuestion = {
+            id,
+            label,
+            type: row.querySelector<HTMLSelectElement>('.question-type')?.value ?? 'text',
+            required: row.querySelector<HTMLInputElement>('.question-required')?.checked ?? false,
+        };
+        if (q.type === 'select') {
+            q.options = (row.querySelector<HTMLInputElement>('.question-options')?.value ?? '')
+                .split(',').map(s => s.trim()).filter(Boolean);
+        }
+        // Keep settings made through the API that the editor doesn't show
+        if (row.dataset.help) {
+            q.help = row.dataset.help;
+        }
+        if (row.dataset.pattern) {
+            q.pattern = row.dataset.pattern;
+        }
+        if (row.dataset.maxLength) {
+            q.max_length = Number(row.dataset.maxLength);
+        }
+        if (row.dataset.min) {
+            q.min = Number(row.dataset.min);
+        }
+        if (row.dataset.max) {
+            q.max = Number(row.dataset.max);
+        }
+        out.push(q);
+    });
+    return out;
+}
+
+function answerInputHtml(q: RegistrationQuestion, value: string): string {
+    const id = `answer-${esc(q.id)}`;
+    const req = q.required ? ' required' : '';
+    const help = q.help ? `<div class="form-text">${esc(q.help)}</div>` : '';
+    if (q.type === 'checkbox') {
+        return `
+            <div class="form-check mb-3">
+                <input type="checkbox" class="form-check-input" id="${id}" data-question="${esc(q.id)}"${value === 'true' ? ' checked' : ''}${req}>
+                <label class="form-check-label" for="${id}">${esc(q.label)}</label>
+                ${help}
+            </div>
+        `;
+    }
+    let input: string;
+    if (q.type === 'select') {
+        const options = (q.options ?? [])
+            .map(o => `<option value="${esc(o)}"${o === value ? ' selected' : ''}>${esc(o)}</option>`)
+            .join('');
+        input = `<select class="form-select" id="${id}" data-question="${esc(q.id)}"${req}><option value=""></option>${options}</select>`;
+    } else {
+        const type = INPUT_TYPES[q.type] ?? 'text';
+        const attrs = [
+            q.max_length ? ` maxlength="${q.max_length}"` : '',
+            q.pattern ? ` pattern="${esc(q.pattern)}"` : '',
+            q.min !== undefined ? ` min="${q.min}"` : '',
+            q.max !== undefined ? ` max="${q.max}"` : '',
+            q.type === 'number' ? ' step="any"' : '',
+        ].join('');
+        input = `<input type="${type}" class="form-control" id="${id}" data-question="${esc(q.id)}" value="${esc(value)}"${attrs}${req}>`;
+    }
+    return `
+        <div class="mb-3">
+            <label class="form-label" for="${id}">${esc(q.label)}${q.required ? ' <span class="text-danger">*</span>' : ''}</label>
+            ${input}
+            ${help}
+        </div>
+    `;
+}
+
+/**
+ * Shows the registration form, prefilled with answers, and hands what the
+ * driver enters to submit. When submit throws the modal stays open so they
+ * can correct it. With priceBase (e.g. /api/series/{id}) the form shows the
+ * entry fee and takes a promo code.
+ */
+export function askRegistrationQuestions(
+    questions: RegistrationQuestion[],
+    answers: Record<string, string>,
+    submit: (answers: Record<string, unknown>, promoCode: string) => Promise<void>,
+    priceBase?: string,
+): void {
+    document.getElementById('reg-questions-modal')?.remove();
+    document.body.insertAdjacentHTML('beforeend', `
+        <div class="modal fade" id="reg-questions-modal" tabindex="-1">
+            <div class="modal-dialog">
+                <div class="modal-content">
+                    <div class="modal-header">
+                        <h5 class="modal-title">Registration</h5>
+                        <button type="button" class="btn-close" data-bs-dismiss="modal"></button>
+                    </div>
+                    <form id="reg-questions-form">
+                    <div class="modal-body">
+                        ${priceBase ? promoCodeFieldHtml() : ''}
+                        ${questions.map(q => answerInputHtml(q, answers[q.id] ?? '')).join('')}
+                    </div>
+                    <div class="modal-footer">
+                        <button type="button" class="btn btn-secondary" data-bs-dismiss="modal">Cancel</button>
+                        <button type="submit" class="btn btn-success" id="reg-questions-submit">Register</button>
+                    </div>
+                    </form>
+                </div>
+            </div>
+        </div>
+    `);
+
+    const modalEl = document.getElementById('reg-questions-modal');
+    const form = document.getElementById('reg-questions-form');
+    if (!modalEl || !(form instanceof HTMLFormElement)) {
+        return;
+    }
+    const bsModal = new Modal(modalEl);
+    bsModal.show();
+    modalEl.addEventListener('hidden.bs.modal', () => modalEl.remove(), { once: true });
+    const promoCode = priceBase ? bindPromoCodeField(priceBase) : () => '';
+
+    form.addEventListener('submit', async e => {
+        e.preventDefault();
+        const given: Record<string, unknown> = {};
+        form.querySelectorAll<HTMLInputElement | HTMLSelectElement>('[data-question]').forEach(el => {
+            const id = el.dataset.question ?? '';
+            given[id] = el instanceof HTMLInputElement && el.type === 'checkbox' ? el.checked : el.value;
+        });
+
+        const btn = document.getElementById('reg-questions-submit');
+        if (btn instanceof HTMLButtonElement) {
+            btn.disabled = true;
+        }
+        try {
+            await submit(given, promoCode());
+            bsModal.hide();
+        } catch {
+            /* api interceptor shows toast */
+        } finally {
+            if (btn instanceof HTMLButtonElement) {
+                btn.disabled = false;
+            }
+        }
+    });
+}
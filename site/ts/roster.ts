@@ -0,0 +1,126 @@
+import { Modal } from 'bootstrap';
+import { api } from './api';
+import { esc } from './html';
+
+interface ImportResult {
+    imported: number;
+    invited: number;
+    waitlisted: number;
+    errors: { row: number; email?: string; error: string }[];
+}
+
+/** Admin dropdown for a registration roster: import a CSV, export CSV or Excel. */
+export function rosterMenuHtml(): string {
+    return `
+        <div class="dropdown ms-2">
+            <button class="btn btn-sm btn-outline-secondary dropdown-toggle" data-bs-toggle="dropdown" title="Import or export the roster">
+                <i class="fa-solid fa-file-csv"></i>
+            </button>
+            <ul class="dropdown-menu dropdown-menu-end">
+                <li><button class="dropdown-item" id="roster-import-btn"><i class="fa-solid fa-upload me-2"></i>Import CSV&hellip;</button></li>
+                <li><hr class="dropdown-divider"></li>
+                <li><button class="dropdown-item" data-roster-export="csv"><i class="fa-solid fa-file-csv me-2"></i>Export CSV</button></li>
+                <li><button class="dropdown-item" data-roster-export="xlsx"><i class="fa-solid fa-file-excel me-2"></i>Export Excel</button></li>
+            </ul>
+        </div>
+    `;
+}
+
+/**
+ * Wires the roster menu for a series, event or session. base is its API path,
+ * e.g. /api/events/{id}; onImported runs after an import added drivers.
+ */
+export function bindRosterMenu(base: string, name: string, onImported: () => void): void {
+    document.querySelectorAll<HTMLElement>('[data-roster-export]').forEach(btn => {
+        btn.addEventListener('click', async () => {
+            const format = btn.dataset.rosterExport ?? 'csv';
+            try {
+                const { data } = await api.get<Blob>(`${base}/registrations/export?format=${format}`, { responseType: 'blob' });
+                const link = document.createElement('a');
+                link.href = URL.createObjectURL(data);
+                link.download = `${name} registrations.${format}`;
+                link.click();
+                URL.revokeObjectURL(link.href);
+            } catch { /* api interceptor shows toast */ }
+        });
+    });
+
+    document.getElementById('roster-import-btn')?.addEventListener('click', () => {
+        showImportModal(base, onImported);
+    });
+}
+
+function showImportModal(base: string, onImported: () => void): void {
+    let imported = false;
+    document.getElementById('roster-import-modal')?.remove();
+    document.body.insertAdjacentHTML('beforeend', `
+        <div class="modal fade" id="roster-import-modal" tabindex="-1">
+            <div class="modal-dialog modal-lg">
+                <div class="modal-content">
+                    <div class="modal-header">
+                        <h5 class="modal-title">Import Drivers</h5>
+                        <button type="button" class="btn-close" data-bs-dismiss="modal"></button>
+                    </div>
+                    <div class="modal-body">
+                        <p class="small text-body-secondary">
+                            Upload a CSV with a header row. Columns: <code>name</code>, <code>email</code> (required),
+                            <code>class</code> and <code>kart number</code>. Drivers are matched to accounts by email;
+                            anyone without one is invited to sign up.
+                        </p>
+                        <input type="file" class="form-control" id="roster-file" accept=".csv,text/csv">
+                        <div id="roster-result" class="mt-3"></div>
+                    </div>
+                    <div class="modal-footer">
+                        <button type="button" class="btn btn-secondary" data-bs-dismiss="modal">Close</button>
+                        <button type="button" class="btn btn-primary" id="roster-upload">Import</button>
+                    </div>
+                </div>
+            </div>
+        </div>
+    `);
+
+    const modalEl = document.getElementById('roster-import-modal');
+    if (!modalEl) {
+        return;
+    }
+    new Modal(modalEl).show();
+    modalEl.addEventListener('hidden.bs.modal', () => {
+        modalEl.remove();
+        if (imported) {
+            onImported();
+        }
+    }, { once: true });
+
+    const btn = document.getElementById('roster-upload');
+    btn?.addEventListener('click', async () => {
+        const fileEl = document.getElementById('roster-file');
+        const file = fileEl instanceof HTMLInputElement ? fileEl.files?.[0] : undefined;
+        const resultEl = document.getElementById('roster-result');
+        if (!file || !resultEl || !(btn instanceof HTMLButtonElement)) {
+            return;
+        }
+        btn.disabled = true;
+        try {
+            const { data } = await api.post<ImportResult>(`${base}/registrations/import`, await file.text(), {
+                headers: { 'Content-Type': 'text/csv' },
+            });
+            imported = imported || data.imported > 0;
+            resultEl.innerHTML = `
+                <div class="alert ${data.errors.length > 0 ? 'alert-warning' : 'alert-success'} py-2">
+                    Imported ${data.imported} driver${data.imported === 1 ? '' : 's'}${data.invited > 0 ? `, ${data.invited} invited by email` : ''}${data.waitlisted > 0 ? `, ${data.waitlisted} waitlisted` : ''}.
+                    ${data.errors.length > 0 ? `${data.errors.length} row${data.errors.length === 1 ? '' : 's'} skipped:` : ''}
+                </div>
+                ${data.errors.length > 0 ? `
+                    <table class="table table-sm small">
+                        <thead><tr><th>Row</th><th>Email</th><th>Problem</th></tr></thead>
+                        <tbody>${data.errors.map(e => `<tr><td>${e.row}</td><td>${esc(e.email ?? '')}</td><td>${esc(e.error)}</td></tr>`).join('')}</tbody>
+                    </table>
+                ` : ''}
+            `;
+        } catch {
+            /* api interceptor shows toast */
+        } finally {
+            btn.disabled = false;
+        }
+    });
+}
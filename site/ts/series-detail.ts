@@ -1,9 +1,14 @@
 import axios from 'axios';
 import { Modal } from 'bootstrap';
 import { api, apiBase, assetsBase } from './api';
-import { getAccessToken, isLoggedIn } from './auth';
+import { getAccessToken, getUser, isLoggedIn } from './auth';
 import { esc, typeLabel } from './html';
+import { showPromoCodesModal } from './pricing';
+import { askRegistrationQuestions, type RegistrationQuestion } from './reg-questions';
+import { bindTransfers, transferButtonHtml } from './transfer';
+import { ensureWaiverSigned } from './waiver-sign';
 import { getEntityId, ensureCorrectSlug, trackDetailUrl, championshipDetailUrl, eventDetailUrl } from './url-utils';
+import { bindRosterMenu, rosterMenuHtml } from './roster';
 
 interface Series {
     series_id: string;
@@ -17,6 +22,7 @@ interface Series {
     price_cents?: number;
     currency?: string;
     registration_deadline?: string;
+    questions?: RegistrationQuestion[];
     method?: string;
     points_scheme?: number[];
     drop_rounds?: number;
@@ -52,9 +58,17 @@ interface SeriesDriver {
     driver_name: string;
     seeded: boolean;
     total_points?: number;
+    position?: number;
     created_at: string;
 }
 
+interface TeamStanding {
+    position: number;
+    team_id: string;
+    team_name: string;
+    total: number;
+}
+
 interface Registration {
     parent_type: string;
     parent_id: string;
@@ -64,6 +78,9 @@ interface Registration {
     status: string;
     paid?: boolean;
     price_cents?: number;
+    checkout_url?: string;
+    offer_expires_at?: string;
+    answers?: Record<string, string>;
     standings?: Record<string, unknown>;
     registered_at: string;
     created_at: string;
@@ -116,19 +133,22 @@ export async function renderSeriesDetail(container: HTMLElement): Promise<void>
     let events: SeriesEvent[];
     let drivers: SeriesDriver[];
     let registrations: Registration[];
+    let teamStandings: TeamStanding[];
 
-    // Fetch series + events + drivers + registrations in parallel
+    // Fetch series + events + drivers + registrations + team table in parallel
     try {
-        const [seriesResp, eventsResp, driversResp, regsResp] = await Promise.all([
+        const [seriesResp, eventsResp, driversResp, regsResp, teamsResp] = await Promise.all([
             api.get<Series>(`/api/series/${seriesId}`),
             api.get<SeriesEvent[]>(`/api/series/${seriesId}/events`),
             api.get<SeriesDriver[]>(`/api/series/${seriesId}/drivers`),
             api.get<Registration[]>(`/api/series/${seriesId}/registrations`).catch((): { data: Registration[] } => ({ data: [] })),
+            api.get<TeamStanding[]>(`/api/series/${seriesId}/team-standings`).catch((): { data: TeamStanding[] } => ({ data: [] })),
         ]);
         series = seriesResp.data;
         events = eventsResp.data;
         drivers = driversResp.data;
         registrations = regsResp.data;
+        teamStandings = teamsResp.data;
     } catch (err) {
         if (axios.isAxiosError(err) && err.response?.status === 404) {
             container.innerHTML = '<div class="alert alert-warning">Series not found.</div>';
@@ -177,7 +197,10 @@ export async function renderSeriesDetail(container: HTMLElement): Promise<void>
 
     // Sort events by round number, drivers by points descending
     events.sort((a, b) => a.round_number - b.round_number);
-    drivers.sort((a, b) => (b.total_points ?? 0) - (a.total_points ?? 0));
+    // Standings positions come from the server (drop rounds and tiebreakers applied)
+    const rank = (pos: unknown) => typeof pos === 'number' && pos > 0 ? pos : Number.MAX_SAFE_INTEGER;
+    drivers.sort((a, b) => rank(a.position) - rank(b.position) || (b.total_points ?? 0) - (a.total_points ?? 0));
+    registrations.sort((a, b) => rank(a.standings?.position) - rank(b.standings?.position));
 
     const eventsHtml = events.length > 0 ?
         events.map(ev => `
@@ -194,6 +217,8 @@ export async function renderSeriesDetail(container: HTMLElement): Promise<void>
             pending: 'text-bg-warning',
             invited: 'text-bg-info',
             waitlisted: 'text-bg-secondary',
+            offered: 'text-bg-primary',
+            expired: 'text-bg-danger',
             cancelled: 'text-bg-danger',
         };
         return `<span class="badge ${colors[status] ?? 'text-bg-secondary'}">${status}</span>`;
@@ -203,6 +228,16 @@ export async function renderSeriesDetail(container: HTMLElement): Promise<void>
     const regMode = series.registration_mode ?? 'closed';
     const canSelfRegister = isLoggedIn() && (regMode === 'open' || regMode === 'approval_required' || regMode === 'invite_only');
 
+    // A spot offered off the waitlist, waiting on the driver to accept
+    const myUid = getUser()?.uid;
+    const myOffer = registrations.find(r => r.uid === myUid && r.status === 'offered');
+    const offerHtml = myOffer ? `
+        <div class="alert alert-primary d-flex align-items-center gap-2 py-2">
+            <span class="flex-grow-1">A spot opened up for you${myOffer.offer_expires_at ? ` — accept by ${shortDate.format(new Date(myOffer.offer_expires_at))}` : ''}.</span>
+            <button class="btn btn-sm btn-primary" id="accept-offer-btn">Accept Spot</button>
+        </div>
+    ` : '';
+
     const spotsInfo = series.max_spots ?
         `<span class="text-body-secondary small">${registrations.filter(r => r.status === 'confirmed').length}/${series.max_spots} spots filled</span>` :
         '';
@@ -224,10 +259,16 @@ export async function renderSeriesDetail(container: HTMLElement): Promise<void>
             const ptsHtml = typeof pts === 'number' ?
                 `<span class="fw-semibold">${String(pts)} pts</span>` :
                 '';
+            const pos = r.standings?.position;
+            const posHtml = typeof pos === 'number' && pos > 0 ?
+                `<span class="text-body-secondary font-monospace">P${String(pos)}</span>` :
+                '';
             return `<div class="d-flex align-items-center gap-2 py-2 border-bottom" data-reg-uid="${r.uid}">
+                ${posHtml}
                 <span class="flex-grow-1">${esc(r.driver_name)}</span>
                 ${statusBadge(r.status)}
                 ${ptsHtml}
+                ${r.uid === myUid && (r.status === 'confirmed' || r.status === 'pending') ? transferButtonHtml(r.uid) : ''}
                 ${canManage ? `<button class="btn btn-sm btn-outline-danger reg-remove-btn" data-uid="${r.uid}" title="Remove"><i class="fa-solid fa-xmark"></i></button>` : ''}
             </div>`;
         }).join('') :
@@ -236,12 +277,22 @@ export async function renderSeriesDetail(container: HTMLElement): Promise<void>
     // Legacy drivers list (only show if registrations are empty and drivers exist)
     const driversHtml = registrations.length === 0 && drivers.length > 0 ?
         drivers.map(d => `<div class="d-flex align-items-center gap-2 py-2 border-bottom">
+                ${d.position ? `<span class="text-body-secondary font-monospace">P${d.position}</span>` : ''}
                 <span class="flex-grow-1">${esc(d.driver_name)}</span>
                 ${d.seeded ? '<span class="badge text-bg-info">Seeded</span>' : ''}
                 <span class="fw-semibold">${d.total_points ?? 0} pts</span>
             </div>`).join('') :
         '';
 
+    const teamsHtml = teamStandings.length > 0 ? `
+        <h3 class="mt-4 mb-3">Teams</h3>
+        ${teamStandings.map(t => `<div class="d-flex align-items-center gap-2 py-2 border-bottom">
+                <span class="text-body-secondary font-monospace">P${t.position}</span>
+                <span class="flex-grow-1">${esc(t.team_name)}</span>
+                <span class="fw-semibold">${t.total} pts</span>
+            </div>`).join('')}` :
+        '';
+
     const noDriversMsg = registrations.length === 0 && drivers.length === 0 ?
         '<p class="text-body-secondary">No drivers enrolled yet.</p>' :
         '';
@@ -288,9 +339,14 @@ export async function renderSeriesDetail(container: HTMLElement): Promise<void>
                     <h3 class="mb-0">Drivers</h3>
                     ${canSelfRegister ? `<button class="btn btn-sm btn-success ms-auto" id="self-register-btn"><i class="fa-solid fa-user-plus me-1"></i>${regMode === 'invite_only' ? 'Accept Invite' : 'Register'}</button>` : ''}
                     ${canManage ? `<button class="btn btn-sm btn-primary ms-2" id="admin-register-btn"><i class="fa-solid fa-plus me-1"></i>${regMode === 'invite_only' ? 'Invite Driver' : 'Add Driver'}</button>` : ''}
+                    ${canManage ? rosterMenuHtml() : ''}
+                    ${canManage ? '<button class="btn btn-sm btn-outline-secondary ms-2" id="promo-codes-btn" title="Promo codes"><i class="fa-solid fa-ticket"></i></button>' : ''}
                 </div>
                 ${regInfoHtml}
+                ${offerHtml}
+                <div id="transfer-offer"></div>
                 <div>${regsHtml}${driversHtml}${noDriversMsg}</div>
+                ${teamsHtml}
             </div>
         </div>
     `;
@@ -307,23 +363,64 @@ export async function renderSeriesDetail(container: HTMLElement): Promise<void>
     });
 
     // Self-register button
+    const register = async (answers?: Record<string, unknown>, promoCode?: string): Promise<void> => {
+        const { data: reg } = await api.post<Registration>(`/api/series/${series.series_id}/registrations`, {
+            return_url: window.location.href,
+            ...answers && Object.keys(answers).length > 0 && { answers },
+            ...promoCode && { promo_code: promoCode },
+        });
+        if (reg.checkout_url) {
+            window.location.href = reg.checkout_url;
+            return;
+        }
+        await renderSeriesDetail(container);
+    };
     document.getElementById('self-register-btn')?.addEventListener('click', async () => {
         const btn = document.getElementById('self-register-btn');
         if (!(btn instanceof HTMLButtonElement)) {
             return;
         }
+        if (!await ensureWaiverSigned(series.track_id)) {
+            return;
+        }
+        if (series.questions?.length || series.price_cents) {
+            const invite = registrations.find(r => r.uid === myUid);
+            askRegistrationQuestions(series.questions ?? [], invite?.answers ?? {}, register,
+                series.price_cents ? `/api/series/${series.series_id}` : undefined);
+            return;
+        }
         btn.disabled = true;
         btn.innerHTML = '<span class="spinner-border spinner-border-sm me-1"></span>Registering\u2026';
         const label = regMode === 'invite_only' ? 'Accept Invite' : 'Register';
         try {
-            await api.post(`/api/series/${series.series_id}/registrations`, {});
-            await renderSeriesDetail(container);
+            await register();
         } catch {
             btn.disabled = false;
             btn.innerHTML = `<i class="fa-solid fa-user-plus me-1"></i>${label}`;
         }
     });
 
+    // Accept a waitlist offer
+    document.getElementById('accept-offer-btn')?.addEventListener('click', async () => {
+        const btn = document.getElementById('accept-offer-btn');
+        if (!(btn instanceof HTMLButtonElement) || !myUid) {
+            return;
+        }
+        btn.disabled = true;
+        try {
+            const { data: reg } = await api.post<Registration>(`/api/series/${series.series_id}/registrations/${myUid}/accept`, {
+                return_url: window.location.href,
+            });
+            if (reg.checkout_url) {
+                window.location.href = reg.checkout_url;
+                return;
+            }
+            await renderSeriesDetail(container);
+        } catch {
+            btn.disabled = false;
+        }
+    });
+
     // Admin register / invite button
     document.getElementById('admin-register-btn')?.addEventListener('click', () => {
         showInviteDriverModal(series, regMode, async () => {
@@ -331,6 +428,17 @@ export async function renderSeriesDetail(container: HTMLElement): Promise<void>
         });
     });
 
+    // Roster import, and CSV / Excel export with answers to the registration questions
+    bindRosterMenu(`/api/series/${series.series_id}`, series.name, () => void renderSeriesDetail(container));
+
+    // Handing a registration to another driver, and accepting one
+    bindTransfers(`/api/series/${series.series_id}`, 'series', series.series_id, () => void renderSeriesDetail(container));
+
+    // Promo codes
+    document.getElementById('promo-codes-btn')?.addEventListener('click', () => {
+        void showPromoCodesModal(`/api/series/${series.series_id}`);
+    });
+
     // Registration remove buttons
     container.querySelectorAll<HTMLElement>('.reg-remove-btn').forEach(btn => {
         btn.addEventListener('click', async () => {
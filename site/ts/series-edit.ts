@@ -1,7 +1,9 @@
 import axios from 'axios';
 import { api, apiBase, assetsBase } from './api';
 import { getUser } from './auth';
-import { esc } from './html';
+import { esc, SESSION_TYPES, typeLabel } from './html';
+import { bindPricingEditor, pricingEditorHtml, readPricing, type PriceTier } from './pricing';
+import { bindQuestionsEditor, questionsEditorHtml, readQuestions, type RegistrationQuestion } from './reg-questions';
 import { seriesDetailUrl, trackDetailUrl, championshipDetailUrl } from './url-utils';
 
 interface Series {
@@ -11,15 +13,25 @@ interface Series {
     name: string;
     description?: string;
     rules?: string;
+    tier?: number;
     registration_mode?: string;
     max_spots?: number;
     price_cents?: number;
     currency?: string;
     registration_deadline?: string;
+    waitlist_offer_hours?: number;
+    min_age?: number;
+    auto_register_events?: boolean;
+    early_bird?: PriceTier[];
+    member_price_cents?: number;
+    season_pass?: boolean;
+    questions?: RegistrationQuestion[];
     method?: string;
     points_scheme?: number[];
     drop_rounds?: number;
     tiebreaker?: string;
+    team_count_best?: number;
+    scoring_session?: string;
 }
 
 interface TrackPublic {
@@ -117,6 +129,11 @@ export async function renderSeriesEdit(container: HTMLElement): Promise<void> {
                 <label class="form-label" for="series-rules">Rules</label>
                 <textarea class="form-control" id="series-rules" rows="3">${esc(series.rules ?? '')}</textarea>
             </div>
+            <div class="mb-3">
+                <label class="form-label" for="series-tier">Division Tier</label>
+                <input type="number" class="form-control" id="series-tier" min="0" value="${series.tier ?? ''}">
+                <div class="form-text">1 is the top division. Used for promotion and relegation within the championship; leave blank if this series isn't a division.</div>
+            </div>
             <hr>
             <h5 class="mb-3">Registration Settings</h5>
             <div class="mb-3">
@@ -142,9 +159,28 @@ export async function renderSeriesEdit(container: HTMLElement): Promise<void> {
                     <input type="text" class="form-control" id="series-currency" value="${esc(series.currency ?? 'USD')}" maxlength="3">
                 </div>
             </div>
+            <div class="row g-3 mb-3">
+                <div class="col-md-5">
+                    <label class="form-label" for="series-deadline">Registration Deadline</label>
+                    <input type="datetime-local" class="form-control" id="series-deadline" value="${series.registration_deadline ? series.registration_deadline.slice(0, 16) : ''}">
+                </div>
+                <div class="col-md-4">
+                    <label class="form-label" for="series-offer-hours">Waitlist Offer Hours <span class="text-body-secondary">(0 = confirm at once)</span></label>
+                    <input type="number" class="form-control" id="series-offer-hours" min="0" value="${series.waitlist_offer_hours ?? 0}">
+                </div>
+                <div class="col-md-3">
+                    <label class="form-label" for="series-min-age">Minimum Age <span class="text-body-secondary">(0 = none)</span></label>
+                    <input type="number" class="form-control" id="series-min-age" min="0" value="${series.min_age ?? 0}">
+                </div>
+            </div>
+            <div class="form-check mb-3">
+                <input class="form-check-input" type="checkbox" id="series-auto-register"${series.auto_register_events ? ' checked' : ''}>
+                <label class="form-check-label" for="series-auto-register">Register confirmed drivers for every upcoming round</label>
+            </div>
+            ${pricingEditorHtml(series, true)}
             <div class="mb-3">
-                <label class="form-label" for="series-deadline">Registration Deadline</label>
-                <input type="datetime-local" class="form-control" id="series-deadline" value="${series.registration_deadline ? series.registration_deadline.slice(0, 16) : ''}">
+                <label class="form-label">Registration Questions</label>
+                ${questionsEditorHtml(series.questions ?? [])}
             </div>
             <hr>
             <h5 class="mb-3">Scoring</h5>
@@ -172,9 +208,24 @@ export async function renderSeriesEdit(container: HTMLElement): Promise<void> {
                         <option value=""${!series.tiebreaker ? ' selected' : ''}>None</option>
                         <option value="most_wins"${series.tiebreaker === 'most_wins' ? ' selected' : ''}>Most Wins</option>
                         <option value="best_finish"${series.tiebreaker === 'best_finish' ? ' selected' : ''}>Best Finish</option>
+                        <option value="countback"${series.tiebreaker === 'countback' ? ' selected' : ''}>Countback</option>
+                        <option value="last_result"${series.tiebreaker === 'last_result' ? ' selected' : ''}>Last Result</option>
                     </select>
                 </div>
             </div>
+            <div class="mb-3">
+                <label class="form-label" for="series-scoring-session">Scoring Session</label>
+                <select class="form-select" id="series-scoring-session">
+                    <option value=""${!series.scoring_session ? ' selected' : ''}>Final (or heats and races without one)</option>
+                    ${SESSION_TYPES.filter(t => t !== 'driver_meeting').map(t => `<option value="${t}"${series.scoring_session === t ? ' selected' : ''}>${typeLabel(t)}</option>`).join('')}
+                </select>
+                <div class="form-text">Only results from these sessions count towards a round.</div>
+            </div>
+            <div class="mb-3">
+                <label class="form-label" for="series-team-count">Team Scoring: best N drivers per round</label>
+                <input type="number" class="form-control" id="series-team-count" min="0" value="${series.team_count_best ?? 0}">
+                <div class="form-text">0 counts every driver on the team.</div>
+            </div>
             <div class="d-flex gap-2">
                 <button type="button" class="btn btn-primary" id="save-series-btn">Save</button>
                 <a href="${seriesDetailUrl(series.series_id, series.name)}" class="btn btn-secondary">Cancel</a>
@@ -182,6 +233,9 @@ export async function renderSeriesEdit(container: HTMLElement): Promise<void> {
         </div>
     `;
 
+    bindQuestionsEditor();
+    bindPricingEditor();
+
     document.getElementById('save-series-btn')?.addEventListener('click', async () => {
         const nameInput = document.getElementById('series-name');
         if (!(nameInput instanceof HTMLInputElement)) {
@@ -204,6 +258,7 @@ export async function renderSeriesEdit(container: HTMLElement): Promise<void> {
             const descEl = document.getElementById('series-desc');
             const rulesEl = document.getElementById('series-rules');
             const champEl = document.getElementById('series-champ');
+            const tierEl = document.getElementById('series-tier');
             const champId = champEl instanceof HTMLSelectElement ? champEl.value : series.championship_id;
 
             const regModeEl = document.getElementById('series-reg-mode');
@@ -211,10 +266,15 @@ export async function renderSeriesEdit(container: HTMLElement): Promise<void> {
             const priceEl = document.getElementById('series-price');
             const currencyEl = document.getElementById('series-currency');
             const deadlineEl = document.getElementById('series-deadline');
+            const offerHoursEl = document.getElementById('series-offer-hours');
+            const minAgeEl = document.getElementById('series-min-age');
+            const autoRegisterEl = document.getElementById('series-auto-register');
             const methodEl = document.getElementById('series-scoring-method');
             const pointsSchemeEl = document.getElementById('series-points-scheme');
             const dropRoundsEl = document.getElementById('series-drop-rounds');
             const tiebreakerEl = document.getElementById('series-tiebreaker');
+            const scoringSessionEl = document.getElementById('series-scoring-session');
+            const teamCountEl = document.getElementById('series-team-count');
 
             const deadlineVal = deadlineEl instanceof HTMLInputElement && deadlineEl.value ?
                 new Date(deadlineEl.value).toISOString() :
@@ -232,15 +292,23 @@ export async function renderSeriesEdit(container: HTMLElement): Promise<void> {
                 description: descEl instanceof HTMLTextAreaElement ? descEl.value.trim() : '',
                 rules: rulesEl instanceof HTMLTextAreaElement ? rulesEl.value.trim() : '',
                 ...champId !== series.championship_id && { championship_id: champId },
+                tier: tierEl instanceof HTMLInputElement ? parseInt(tierEl.value, 10) || 0 : 0,
                 registrationMode: regModeEl instanceof HTMLSelectElement ? regModeEl.value : 'closed',
                 maxSpots: maxSpotsEl instanceof HTMLInputElement ? parseInt(maxSpotsEl.value, 10) || 0 : 0,
                 priceCents: priceEl instanceof HTMLInputElement ? parseInt(priceEl.value, 10) || 0 : 0,
                 currency: currencyEl instanceof HTMLInputElement ? currencyEl.value.trim() : 'USD',
                 registrationDeadline: deadlineVal,
+                waitlistOfferHours: offerHoursEl instanceof HTMLInputElement ? parseInt(offerHoursEl.value, 10) || 0 : 0,
+                minAge: minAgeEl instanceof HTMLInputElement ? parseInt(minAgeEl.value, 10) || 0 : 0,
+                autoRegisterEvents: autoRegisterEl instanceof HTMLInputElement && autoRegisterEl.checked,
+                ...readPricing(),
+                questions: readQuestions(),
                 method: methodEl instanceof HTMLSelectElement ? methodEl.value : '',
                 pointsScheme: pointsScheme.length > 0 ? pointsScheme : [],
                 dropRounds: dropRoundsEl instanceof HTMLInputElement ? parseInt(dropRoundsEl.value, 10) || 0 : 0,
                 tiebreaker: tiebreakerEl instanceof HTMLSelectElement ? tiebreakerEl.value : '',
+                scoringSession: scoringSessionEl instanceof HTMLSelectElement ? scoringSessionEl.value : '',
+                teamCountBest: teamCountEl instanceof HTMLInputElement ? parseInt(teamCountEl.value, 10) || 0 : 0,
             });
             window.location.href = seriesDetailUrl(seriesId, name);
         } finally {
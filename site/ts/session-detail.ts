@@ -2,6 +2,7 @@ import axios from 'axios';
 import { api, apiBase, assetsBase } from './api';
 import { getAccessToken, isLoggedIn, login } from './auth';
 import { esc, dateFmt, formatLapTime, typeLabel, SESSION_TYPES, START_TYPES, startTypeLabel, buildSessionInfoPills, initTooltips, scoringUsesTotalTime, SESSION_TYPE_BADGE_COLORS, sectorBlocksHtml, positionHtml } from './html';
+import { bindPromoCodeField, promoCodeFieldHtml } from './pricing';
 import { openUploadManager } from './upload-manager';
 import { getEntityId, trackDetailUrl, championshipDetailUrl, seriesDetailUrl, eventDetailUrl, driverDetailUrl } from './url-utils';
 
@@ -65,6 +66,7 @@ interface Registration {
     parent_id: string;
     status: string;
     driver_name: string;
+    checkout_url?: string;
 }
 
 interface TrackPublic {
@@ -492,10 +494,7 @@ export async function renderSessionDetail(container: HTMLElement): Promise<void>
                     </div>
                     <div class="modal-body">
                         <p>You are registering for <strong>${seriesCtx ? esc(seriesCtx.series_name) : 'this series'}</strong>.</p>
-                        ${series?.price_cents ? `<div class="d-flex align-items-center gap-2 mb-3">
-                            <i class="fa-solid fa-tag text-body-secondary"></i>
-                            <span>Entry fee: <strong>$${(series.price_cents / 100).toFixed(2)}${series.currency ? ' ' + esc(series.currency) : ''}</strong></span>
-                        </div>` : ''}
+                        ${series?.price_cents ? promoCodeFieldHtml() : ''}
                         ${series?.max_spots ? `<div class="d-flex align-items-center gap-2 mb-3">
                             <i class="fa-solid fa-users text-body-secondary"></i>
                             <span>${regCount}/${series.max_spots} spots filled</span>
@@ -554,7 +553,14 @@ export async function renderSessionDetail(container: HTMLElement): Promise<void>
             if (joinModalEl && joinCheck && joinSubmit) {
                 const joinModal = new bs.Modal(joinModalEl);
 
-                document.getElementById('join-series-btn')?.addEventListener('click', () => joinModal.show());
+                // The price is quoted when the modal first opens
+                let promoCode: (() => string) | undefined;
+                document.getElementById('join-series-btn')?.addEventListener('click', () => {
+                    if (!promoCode && series?.price_cents) {
+                        promoCode = bindPromoCodeField(`/api/series/${seriesCtx.series_id}`);
+                    }
+                    joinModal.show();
+                });
 
                 joinCheck.addEventListener('change', () => {
                     joinSubmit.disabled = !joinCheck.checked;
@@ -565,7 +571,15 @@ export async function renderSessionDetail(container: HTMLElement): Promise<void>
                     joinSubmit.innerHTML = '<span class="spinner-border spinner-border-sm me-1"></span>Registering\u2026';
 
                     try {
-                        await api.post(`/api/series/${seriesCtx.series_id}/registrations`, {});
+                        const code = promoCode?.() ?? '';
+                        const { data: reg } = await api.post<Registration>(`/api/series/${seriesCtx.series_id}/registrations`, {
+                            return_url: window.location.href,
+                            ...code && { promo_code: code },
+                        });
+                        if (reg.checkout_url) {
+                            window.location.href = reg.checkout_url;
+                            return;
+                        }
                         joinModal.hide();
                         await renderSessionDetail(container);
                     } catch (err: unknown) {
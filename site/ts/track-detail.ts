@@ -85,6 +85,10 @@ export interface KartClass {
     engine?: string;
     description?: string;
     is_default?: boolean;
+    min_driver_weight_kg?: number;
+    win_ballast_kg?: number;
+    podium_ballast_kg?: number;
+    max_ballast_kg?: number;
     created_at: string;
 }
 
@@ -409,7 +413,9 @@ export async function renderTrackDetail(container: HTMLElement): Promise<void> {
             </div>
             <div class="tab-pane fade" id="champs-pane" role="tabpanel">
                 <div class="d-flex align-items-center mb-3">
-                    ${canManage ? '<button class="btn btn-sm btn-primary ms-auto" id="new-champ-btn"><i class="fa-solid fa-plus me-1"></i>New Championship</button>' : ''}
+                    ${canManage ? '<button class="btn btn-sm btn-outline-secondary ms-auto" id="waiver-btn"><i class="fa-solid fa-file-signature me-1"></i>Waiver</button>' : ''}
+                    ${canManage ? '<button class="btn btn-sm btn-outline-secondary ms-2" id="memberships-btn"><i class="fa-solid fa-id-card me-1"></i>Members</button>' : ''}
+                    ${canManage ? '<button class="btn btn-sm btn-primary ms-2" id="new-champ-btn"><i class="fa-solid fa-plus me-1"></i>New Championship</button>' : ''}
                 </div>
                 <div class="row g-3">${champCards}</div>
             </div>
@@ -426,10 +432,206 @@ export async function renderTrackDetail(container: HTMLElement): Promise<void> {
         });
     });
 
+    // Waiver publishing
+    document.getElementById('waiver-btn')?.addEventListener('click', () => {
+        void showWaiverModal(trackId);
+    });
+
+    // Driver memberships, for member pricing
+    document.getElementById('memberships-btn')?.addEventListener('click', () => {
+        void showMembershipsModal(trackId);
+    });
+
     // Leaderboard
     bindLeaderboard(trackId);
 }
 
+interface Membership {
+    uid: string;
+    driver_name?: string;
+    expires_at?: string;
+}
+
+function membershipRowsHtml(memberships: Membership[]): string {
+    if (memberships.length === 0) {
+        return '<tr><td colspan="3" class="text-body-secondary">No members yet.</td></tr>';
+    }
+    return memberships.map(m => `
+        <tr>
+            <td>${esc(m.driver_name || m.uid)}</td>
+            <td class="text-body-secondary">${m.expires_at ? formatDate(m.expires_at) : 'Never'}</td>
+            <td class="text-end"><button type="button" class="btn btn-sm btn-outline-danger membership-remove" data-uid="${esc(m.uid)}" title="Remove"><i class="fa-solid fa-xmark"></i></button></td>
+        </tr>
+    `).join('');
+}
+
+// showMembershipsModal manages the track's member drivers, who register at
+// the member price.
+async function showMembershipsModal(trackId: string): Promise<void> {
+    const load = async (): Promise<Membership[]> => {
+        const { data } = await api.get<Membership[]>(`/api/tracks/${trackId}/memberships`);
+        return data;
+    };
+    let memberships: Membership[];
+    try {
+        memberships = await load();
+    } catch {
+        return;
+    }
+
+    document.getElementById('modal-container')?.remove();
+    document.body.insertAdjacentHTML('beforeend', `
+        <div class="modal fade" id="modal-container" tabindex="-1">
+            <div class="modal-dialog">
+                <div class="modal-content">
+                    <div class="modal-header">
+                        <h5 class="modal-title">Members</h5>
+                        <button type="button" class="btn-close" data-bs-dismiss="modal"></button>
+                    </div>
+                    <div class="modal-body">
+                        <table class="table table-sm">
+                            <thead><tr><th>Driver</th><th>Expires</th><th></th></tr></thead>
+                            <tbody id="membership-rows">${membershipRowsHtml(memberships)}</tbody>
+                        </table>
+                        <form id="membership-add-form" class="row g-2">
+                            <div class="col-7">
+                                <input type="email" class="form-control form-control-sm" id="membership-email" placeholder="Driver email" required>
+                            </div>
+                            <div class="col-3">
+                                <input type="date" class="form-control form-control-sm" id="membership-expires" title="Expires (optional)">
+                            </div>
+                            <div class="col-2">
+                                <button type="submit" class="btn btn-sm btn-primary w-100" id="membership-add">Add</button>
+                            </div>
+                        </form>
+                        <div class="form-text">Members register at the member price set on a series or event.</div>
+                    </div>
+                </div>
+            </div>
+        </div>
+    `);
+
+    const modalEl = document.getElementById('modal-container');
+    const form = document.getElementById('membership-add-form');
+    const rows = document.getElementById('membership-rows');
+    if (!modalEl || !form || !rows) {
+        return;
+    }
+    new Modal(modalEl).show();
+    modalEl.addEventListener('hidden.bs.modal', () => modalEl.remove(), { once: true });
+
+    const refresh = async (): Promise<void> => {
+        rows.innerHTML = membershipRowsHtml(await load());
+    };
+
+    rows.addEventListener('click', async e => {
+        const btn = e.target instanceof Element ? e.target.closest<HTMLElement>('.membership-remove') : null;
+        const uid = btn?.dataset.uid;
+        if (!uid || !confirm('Remove this member?')) {
+            return;
+        }
+        try {
+            await api.delete(`/api/tracks/${trackId}/memberships/${uid}`);
+            await refresh();
+        } catch { /* api interceptor shows toast */ }
+    });
+
+    form.addEventListener('submit', async e => {
+        e.preventDefault();
+        const btn = document.getElementById('membership-add');
+        const emailEl = document.getElementById('membership-email');
+        const expiresEl = document.getElementById('membership-expires');
+        if (!(btn instanceof HTMLButtonElement) || !(emailEl instanceof HTMLInputElement)) {
+            return;
+        }
+        const expires = expiresEl instanceof HTMLInputElement ? expiresEl.value : '';
+        btn.disabled = true;
+        try {
+            await api.post(`/api/tracks/${trackId}/memberships`, {
+                email: emailEl.value.trim(),
+                // Good through the end of the day it expires
+                ...expires && { expires_at: `${expires}T23:59:59Z` },
+            });
+            emailEl.value = '';
+            await refresh();
+        } catch {
+            /* api interceptor shows toast */
+        } finally {
+            btn.disabled = false;
+        }
+    });
+}
+
+// showWaiverModal edits the track's liability waiver. Saving publishes a new
+// version, which every driver has to sign again.
+async function showWaiverModal(trackId: string): Promise<void> {
+    let current: { version: number; title: string; body: string } | undefined;
+    try {
+        const { data } = await axios.get<{ version: number; title: string; body: string }>(`${apiBase}/api/tracks/${trackId}/waiver`);
+        current = data;
+    } catch { /* no waiver yet */ }
+
+    document.getElementById('modal-container')?.remove();
+    document.body.insertAdjacentHTML('beforeend', `
+        <div class="modal fade" id="modal-container" tabindex="-1">
+            <div class="modal-dialog modal-lg">
+                <div class="modal-content">
+                    <div class="modal-header">
+                        <h5 class="modal-title">Liability Waiver${current ? ` <span class="text-body-secondary small">v${current.version}</span>` : ''}</h5>
+                        <button type="button" class="btn-close" data-bs-dismiss="modal"></button>
+                    </div>
+                    <form id="waiver-edit-form">
+                    <div class="modal-body">
+                        <div class="mb-3">
+                            <label class="form-label" for="waiver-title">Title</label>
+                            <input type="text" class="form-control" id="waiver-title" value="${esc(current?.title ?? 'Liability Waiver')}">
+                        </div>
+                        <div class="mb-3">
+                            <label class="form-label" for="waiver-body">Text</label>
+                            <textarea class="form-control" id="waiver-body" rows="12" required>${esc(current?.body ?? '')}</textarea>
+                        </div>
+                        <div class="form-text">Publishing saves a new version. Drivers must sign it before their registrations are confirmed.</div>
+                    </div>
+                    <div class="modal-footer">
+                        <button type="button" class="btn btn-secondary" data-bs-dismiss="modal">Cancel</button>
+                        <button type="submit" class="btn btn-primary" id="waiver-publish">Publish</button>
+                    </div>
+                    </form>
+                </div>
+            </div>
+        </div>
+    `);
+
+    const modalEl = document.getElementById('modal-container');
+    const form = document.getElementById('waiver-edit-form');
+    if (!modalEl || !form) {
+        return;
+    }
+    const bsModal = new Modal(modalEl);
+    bsModal.show();
+    modalEl.addEventListener('hidden.bs.modal', () => modalEl.remove(), { once: true });
+
+    form.addEventListener('submit', async e => {
+        e.preventDefault();
+        const btn = document.getElementById('waiver-publish');
+        const titleEl = document.getElementById('waiver-title');
+        const bodyEl = document.getElementById('waiver-body');
+        if (!(btn instanceof HTMLButtonElement) || !(bodyEl instanceof HTMLTextAreaElement)) {
+            return;
+        }
+        btn.disabled = true;
+        try {
+            await api.post(`/api/tracks/${trackId}/waivers`, {
+                title: titleEl instanceof HTMLInputElement ? titleEl.value.trim() : '',
+                body: bodyEl.value.trim(),
+            });
+            bsModal.hide();
+        } catch {
+            btn.disabled = false;
+        }
+    });
+}
+
 function bindLeaderboard(trackId: string): void {
     const layoutSelect = document.querySelector<HTMLSelectElement>('#lb-layout');
     const classSelect = document.querySelector<HTMLSelectElement>('#lb-class');
@@ -820,6 +1022,25 @@ export function showClassModal(trackId: string, allClasses: KartClass[], kc: Kar
                             <label class="form-label" for="class-desc">Description</label>
                             <textarea class="form-control" id="class-desc" rows="2" placeholder="Any additional details about this class">${esc(kc?.description ?? '')}</textarea>
                         </div>
+                        <div class="row g-2 mb-3">
+                            <div class="col-6">
+                                <label class="form-label" for="class-min-weight">Min Driver Weight (kg)</label>
+                                <input type="number" class="form-control" id="class-min-weight" min="0" step="0.1" value="${kc?.min_driver_weight_kg ?? ''}">
+                            </div>
+                            <div class="col-6">
+                                <label class="form-label" for="class-max-ballast">Max Success Ballast (kg)</label>
+                                <input type="number" class="form-control" id="class-max-ballast" min="0" step="0.1" value="${kc?.max_ballast_kg ?? ''}" placeholder="No cap">
+                            </div>
+                            <div class="col-6">
+                                <label class="form-label" for="class-win-ballast">Ballast per Win (kg)</label>
+                                <input type="number" class="form-control" id="class-win-ballast" min="0" step="0.1" value="${kc?.win_ballast_kg ?? ''}">
+                            </div>
+                            <div class="col-6">
+                                <label class="form-label" for="class-podium-ballast">Ballast per Podium (kg)</label>
+                                <input type="number" class="form-control" id="class-podium-ballast" min="0" step="0.1" value="${kc?.podium_ballast_kg ?? ''}">
+                            </div>
+                            <div class="col-12 form-text">Drivers under the minimum weight carry the difference. Race wins and 2nd/3rd places add success ballast for later rounds.</div>
+                        </div>
                         <div class="form-check mb-3">
                             <input type="checkbox" class="form-check-input" id="class-default" ${shouldDefault ? 'checked' : ''}>
                             <label class="form-check-label" for="class-default">Default Class</label>
@@ -886,6 +1107,14 @@ export function showClassModal(trackId: string, allClasses: KartClass[], kc: Kar
         const description = descEl instanceof HTMLTextAreaElement ? descEl.value.trim() : '';
         const defaultCheck = document.getElementById('class-default');
         const isDefault = defaultCheck instanceof HTMLInputElement ? defaultCheck.checked : false;
+        const kg = (id: string): number => {
+            const el = document.getElementById(id);
+            return el instanceof HTMLInputElement ? parseFloat(el.value) || 0 : 0;
+        };
+        const minWeight = kg('class-min-weight');
+        const winBallast = kg('class-win-ballast');
+        const podiumBallast = kg('class-podium-ballast');
+        const maxBallast = kg('class-max-ballast');
 
         const btn = document.getElementById('class-submit');
         if (!(btn instanceof HTMLButtonElement)) {
@@ -902,6 +1131,10 @@ export function showClassModal(trackId: string, allClasses: KartClass[], kc: Kar
                     engine,
                     description,
                     isDefault: isDefault,
+                    minDriverWeightKg: minWeight,
+                    winBallastKg: winBallast,
+                    podiumBallastKg: podiumBallast,
+                    maxBallastKg: maxBallast,
                 });
             } else {
                 await api.post(`/api/tracks/${trackId}/classes`, {
@@ -910,6 +1143,10 @@ export function showClassModal(trackId: string, allClasses: KartClass[], kc: Kar
                     engine,
                     description,
                     is_default: isDefault,
+                    min_driver_weight_kg: minWeight,
+                    win_ballast_kg: winBallast,
+                    podium_ballast_kg: podiumBallast,
+                    max_ballast_kg: maxBallast,
                 });
             }
             bsModal.hide();
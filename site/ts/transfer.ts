@@ -0,0 +1,69 @@
+import { api } from './api';
+import { isLoggedIn } from './auth';
+import { esc } from './html';
+
+interface RegistrationTransfer {
+    parent_type: string;
+    parent_id: string;
+    parent_name?: string;
+    from_uid: string;
+    from_name?: string;
+    swap_session_id?: string;
+}
+
+/** Button on the driver's own registration row to hand it to someone else. */
+export function transferButtonHtml(uid: string): string {
+    return `<button class="btn btn-sm btn-outline-secondary reg-transfer-btn" data-uid="${esc(uid)}" title="Transfer to another driver"><i class="fa-solid fa-right-left"></i></button>`;
+}
+
+/**
+ * Wires the transfer buttons for a series, event or session at base (e.g.
+ * /api/series/{id}), and fills #transfer-offer with any transfer offered to
+ * the caller there. onChange runs after one is accepted.
+ */
+export function bindTransfers(base: string, parentType: string, parentId: string, onChange: () => void): void {
+    document.querySelectorAll<HTMLElement>('.reg-transfer-btn').forEach(btn => {
+        btn.addEventListener('click', async () => {
+            const to = prompt('Email of the driver to hand your registration to. They get an email to accept it; anything you paid carries over.');
+            if (!to?.trim()) {
+                return;
+            }
+            try {
+                await api.post(`${base}/registrations/${btn.dataset.uid}/transfer`, { email: to.trim() });
+                btn.classList.replace('btn-outline-secondary', 'btn-secondary');
+                btn.title = `Transfer offered to ${to.trim()}`;
+            } catch { /* api interceptor shows toast */ }
+        });
+    });
+
+    const slot = document.getElementById('transfer-offer');
+    if (!slot || !isLoggedIn()) {
+        return;
+    }
+    void (async () => {
+        let offers: RegistrationTransfer[];
+        try {
+            const { data } = await api.get<RegistrationTransfer[]>('/api/my/transfers');
+            offers = data.filter(t => t.parent_type === parentType && t.parent_id === parentId);
+        } catch {
+            return;
+        }
+        slot.innerHTML = offers.map(t => `
+            <div class="alert alert-info d-flex align-items-center gap-2 py-2">
+                <span class="flex-grow-1">${esc(t.from_name ?? 'A driver')} ${t.swap_session_id ? 'wants to swap sessions with you' : 'offered you their registration'}.</span>
+                <button class="btn btn-sm btn-primary transfer-accept-btn" data-uid="${esc(t.from_uid)}">Accept</button>
+            </div>
+        `).join('');
+        slot.querySelectorAll<HTMLButtonElement>('.transfer-accept-btn').forEach(btn => {
+            btn.addEventListener('click', async () => {
+                btn.disabled = true;
+                try {
+                    await api.post(`${base}/registrations/${btn.dataset.uid}/transfer/accept`);
+                    onChange();
+                } catch {
+                    btn.disabled = false;
+                }
+            });
+        });
+    })();
+}
@@ -0,0 +1,145 @@
+import axios from 'axios';
+import { Modal } from 'bootstrap';
+import { api, apiBase } from './api';
+import { getAccessToken } from './auth';
+import { esc } from './html';
+
+interface Waiver {
+    track_id: string;
+    version: number;
+    title: string;
+    body: string;
+    signature?: { signed_at: string };
+}
+
+/**
+ * Makes sure the driver has signed the track's current waiver, showing it
+ * for them to sign when they haven't. Resolves false if they close it
+ * unsigned; tracks without a waiver resolve true straight away.
+ */
+export async function ensureWaiverSigned(trackId: string): Promise<boolean> {
+    let waiver: Waiver;
+    try {
+        const token = getAccessToken();
+        const { data } = await axios.get<Waiver>(`${apiBase}/api/tracks/${trackId}/waiver`, {
+            headers: token ? { Authorization: `Bearer ${token}` } : {},
+        });
+        waiver = data;
+    } catch (err) {
+        return axios.isAxiosError(err) && err.response?.status === 404;
+    }
+    if (waiver.signature) {
+        return true;
+    }
+
+    return new Promise(resolve => {
+        let signed = false;
+        document.getElementById('waiver-modal')?.remove();
+        document.body.insertAdjacentHTML('beforeend', `
+            <div class="modal fade" id="waiver-modal" tabindex="-1">
+                <div class="modal-dialog modal-lg modal-dialog-scrollable">
+                    <div class="modal-content">
+                        <div class="modal-header">
+                            <h5 class="modal-title">${esc(waiver.title)} <span class="text-body-secondary small">v${waiver.version}</span></h5>
+                            <button type="button" class="btn-close" data-bs-dismiss="modal"></button>
+                        </div>
+                        <form id="waiver-form">
+                        <div class="modal-body">
+                            <div class="border rounded p-3 mb-3 bg-body-tertiary" style="white-space:pre-wrap">${esc(waiver.body)}</div>
+                            <div class="row g-3 mb-3">
+                                <div class="col-md-8">
+                                    <label class="form-label" for="waiver-name">Type your full name to sign</label>
+                                    <input type="text" class="form-control" id="waiver-name" required>
+                                </div>
+                                <div class="col-md-4">
+                                    <label class="form-label" for="waiver-dob">Date of birth</label>
+                                    <input type="date" class="form-control" id="waiver-dob" required>
+                                </div>
+                            </div>
+                            <div class="form-check mb-3">
+                                <input type="checkbox" class="form-check-input" id="waiver-minor">
+                                <label class="form-check-label" for="waiver-minor">The driver is under 18</label>
+                            </div>
+                            <div class="d-none" id="waiver-guardian">
+                                <p class="small text-body-secondary">A parent or legal guardian must also sign for a minor.</p>
+                                <div class="row g-3 mb-3">
+                                    <div class="col-md-6">
+                                        <label class="form-label" for="waiver-guardian-name">Guardian name</label>
+                                        <input type="text" class="form-control" id="waiver-guardian-name">
+                                    </div>
+                                    <div class="col-md-6">
+                                        <label class="form-label" for="waiver-guardian-rel">Relationship</label>
+                                        <input type="text" class="form-control" id="waiver-guardian-rel" placeholder="e.g. Parent">
+                                    </div>
+                                </div>
+                                <div class="mb-3">
+                                    <label class="form-label" for="waiver-guardian-sig">Guardian: type your full name to sign</label>
+                                    <input type="text" class="form-control" id="waiver-guardian-sig">
+                                </div>
+                            </div>
+                        </div>
+                        <div class="modal-footer">
+                            <button type="button" class="btn btn-secondary" data-bs-dismiss="modal">Cancel</button>
+                            <button type="submit" class="btn btn-primary" id="waiver-submit">Sign Waiver</button>
+                        </div>
+                        </form>
+                    </div>
+                </div>
+            </div>
+        `);
+
+        const modalEl = document.getElementById('waiver-modal');
+        const form = document.getElementById('waiver-form');
+        if (!modalEl || !(form instanceof HTMLFormElement)) {
+            resolve(false);
+            return;
+        }
+        const bsModal = new Modal(modalEl);
+        bsModal.show();
+        modalEl.addEventListener('hidden.bs.modal', () => {
+            modalEl.remove();
+            resolve(signed);
+        }, { once: true });
+
+        const value = (id: string): string => {
+            const el = document.getElementById(id);
+            return el instanceof HTMLInputElement ? el.value.trim() : '';
+        };
+        const minorEl = document.getElementById('waiver-minor');
+        const guardianEl = document.getElementById('waiver-guardian');
+        minorEl?.addEventListener('change', () => {
+            const minor = minorEl instanceof HTMLInputElement && minorEl.checked;
+            guardianEl?.classList.toggle('d-none', !minor);
+            guardianEl?.querySelectorAll('input').forEach(input => {
+                input.required = minor;
+            });
+        });
+
+        form.addEventListener('submit', async e => {
+            e.preventDefault();
+            const btn = document.getElementById('waiver-submit');
+            if (btn instanceof HTMLButtonElement) {
+                btn.disabled = true;
+            }
+            try {
+                await api.post(`/api/tracks/${trackId}/waiver/sign`, {
+                    version: waiver.version,
+                    signed_name: value('waiver-name'),
+                    date_of_birth: value('waiver-dob'),
+                    minor: minorEl instanceof HTMLInputElement && minorEl.checked,
+                    guardian_name: value('waiver-guardian-name'),
+                    guardian_relationship: value('waiver-guardian-rel'),
+                    guardian_signed_name: value('waiver-guardian-sig'),
+                });
+                signed = true;
+                bsModal.hide();
+            } catch {
+                /* api interceptor shows toast */
+            } finally {
+                if (btn instanceof HTMLButtonElement) {
+                    btn.disabled = false;
+                }
+            }
+        });
+    });
+}